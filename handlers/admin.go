@@ -0,0 +1,1181 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"movie-api-go/middleware"
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// imdbIDPattern matches IMDb title IDs such as tt0133093
+var imdbIDPattern = regexp.MustCompile(`^tt\d{7,}$`)
+
+type AdminHandler struct {
+	aliases     *services.AliasStore
+	shadow      *services.Shadow
+	drift       *services.SchemaDrift
+	quarantine  *services.Quarantine
+	canary      *services.RecommendationCanary
+	recommended *services.RecommendationCache
+	audit       *services.AuditLog
+	users       *services.UserStore
+	tags        *services.TagStore
+	maintenance *services.MaintenanceMode
+	traces      *services.TraceStore
+	reviews     *services.ReviewStore
+	spoilers    *services.SpoilerStore
+	reports     *services.ReportStore
+	filter      *services.ContentFilter
+	genreLists  *services.GenreListStore
+	genres      *services.GenreTaxonomy
+	staffPicks  *services.StaffPicks
+	collections *services.CollectionStore
+	profiles    *services.FieldProfileStore
+	weights     *services.ScoreWeightStore
+	seeder      *services.Seeder
+	permissions func() models.PermissionsMatrix
+}
+
+func NewAdminHandler(aliases *services.AliasStore, shadow *services.Shadow, drift *services.SchemaDrift, quarantine *services.Quarantine, canary *services.RecommendationCanary, recommended *services.RecommendationCache, audit *services.AuditLog, users *services.UserStore, tags *services.TagStore, maintenance *services.MaintenanceMode, traces *services.TraceStore, reviews *services.ReviewStore, spoilers *services.SpoilerStore, reports *services.ReportStore, filter *services.ContentFilter, genreLists *services.GenreListStore, genres *services.GenreTaxonomy, staffPicks *services.StaffPicks, collections *services.CollectionStore, profiles *services.FieldProfileStore, weights *services.ScoreWeightStore, seeder *services.Seeder, permissions func() models.PermissionsMatrix) *AdminHandler {
+	return &AdminHandler{
+		aliases:     aliases,
+		shadow:      shadow,
+		drift:       drift,
+		quarantine:  quarantine,
+		canary:      canary,
+		recommended: recommended,
+		audit:       audit,
+		users:       users,
+		tags:        tags,
+		maintenance: maintenance,
+		traces:      traces,
+		reviews:     reviews,
+		spoilers:    spoilers,
+		reports:     reports,
+		filter:      filter,
+		genreLists:  genreLists,
+		genres:      genres,
+		staffPicks:  staffPicks,
+		collections: collections,
+		profiles:    profiles,
+		weights:     weights,
+		seeder:      seeder,
+		permissions: permissions,
+	}
+}
+
+// record adds a mutation to the audit log. The change has already been applied, so a
+// failure to persist the entry is logged rather than reported to the caller.
+func (h *AdminHandler) record(c *gin.Context, action, target string, before, after interface{}) {
+	entry, err := h.audit.Record(middleware.AdminActor(c), c.ClientIP(), action, target, before, after)
+	if err != nil {
+		log.Printf("audit: failed to persist entry %d (%s %s by %s): %v", entry.ID, action, target, entry.Actor, err)
+	}
+}
+
+// ListAliases handles GET /admin/aliases, or the soft-deleted ones with deleted=true
+func (h *AdminHandler) ListAliases(c *gin.Context) {
+	aliases := h.aliases.List()
+	if c.Query("deleted") == "true" {
+		aliases = h.aliases.Deleted()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"aliases": aliases,
+		"total":   len(aliases),
+	})
+}
+
+// PutAlias handles PUT /admin/aliases/:alias with body {"imdb_id": "tt0133093"}
+func (h *AdminHandler) PutAlias(c *gin.Context) {
+	var req models.AliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !imdbIDPattern.MatchString(req.ImdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with a valid imdb_id (e.g. tt0133093)",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	alias, previous, err := h.aliases.Set(c.Param("alias"), req.ImdbID)
+	if errors.Is(err, services.ErrInvalidAlias) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save alias",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	h.record(c, "alias.set", c.Param("alias"), previous, alias)
+
+	c.JSON(http.StatusOK, alias)
+}
+
+// DeleteAlias handles DELETE /admin/aliases/:alias. The alias is soft-deleted and can be
+// restored by setting it again.
+func (h *AdminHandler) DeleteAlias(c *gin.Context) {
+	previous, err := h.aliases.Delete(c.Param("alias"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to delete alias",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	if previous == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Alias not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	h.record(c, "alias.delete", c.Param("alias"), previous, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// ShadowReport handles GET /admin/shadow/report
+func (h *AdminHandler) ShadowReport(c *gin.Context) {
+	report := h.shadow.Report()
+	if !report.Enabled {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Shadow mode is disabled; set SHADOW_BASE_URL to enable it",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// SchemaDrift handles GET /admin/schema-drift
+func (h *AdminHandler) SchemaDrift(c *gin.Context) {
+	c.JSON(http.StatusOK, h.drift.Report())
+}
+
+// ResetSchemaDrift handles DELETE /admin/schema-drift, clearing the findings once a change
+// has been dealt with
+func (h *AdminHandler) ResetSchemaDrift(c *gin.Context) {
+	cleared := h.drift.Reset()
+	h.record(c, "schema_drift.reset", "schema_drift", gin.H{"fields": cleared}, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// Quarantine handles GET /admin/quarantine, listing the titles background jobs skip and
+// those on their way there
+func (h *AdminHandler) Quarantine(c *gin.Context) {
+	c.JSON(http.StatusOK, h.quarantine.List())
+}
+
+// ReleaseQuarantine handles DELETE /admin/quarantine/:imdbID, letting background jobs look
+// a title up again once its record has been fixed
+func (h *AdminHandler) ReleaseQuarantine(c *gin.Context) {
+	entry, err := h.quarantine.Release(c.Param("imdbID"))
+	if errors.Is(err, services.ErrNotQuarantined) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Title is not quarantined",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to release title",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "quarantine.release", entry.ImdbID, entry, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// Maintenance handles GET /admin/maintenance
+func (h *AdminHandler) Maintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, h.maintenance.State())
+}
+
+// EnableMaintenance handles PUT /admin/maintenance with an optional body
+// {"message": "Rotating provider keys", "retry_after_seconds": 600, "until": "2024-01-01T12:00:00Z"}.
+// It takes the data routes offline, or updates the message of a window in progress.
+func (h *AdminHandler) EnableMaintenance(c *gin.Context) {
+	var req models.MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with an optional message, retry_after_seconds and until (RFC 3339)",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	state, previous, err := h.maintenance.Enable(req, middleware.AdminActor(c))
+	if errors.Is(err, services.ErrInvalidMaintenance) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save maintenance mode",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "maintenance.enable", "maintenance", previous, state)
+
+	c.JSON(http.StatusOK, state)
+}
+
+// DisableMaintenance handles DELETE /admin/maintenance, bringing the data routes back
+func (h *AdminHandler) DisableMaintenance(c *gin.Context) {
+	previous, err := h.maintenance.Disable()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save maintenance mode",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "maintenance.disable", "maintenance", previous, models.MaintenanceState{})
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListTraces handles GET /admin/traces, listing the kept debug traces newest first
+func (h *AdminHandler) ListTraces(c *gin.Context) {
+	traces := h.traces.List()
+	c.JSON(http.StatusOK, gin.H{
+		"traces": traces,
+		"total":  len(traces),
+	})
+}
+
+// GetTrace handles GET /admin/traces/:id
+func (h *AdminHandler) GetTrace(c *gin.Context) {
+	trace, ok := h.traces.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Trace not found; only the most recent traces are kept",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, trace)
+}
+
+// RecommendationCanary handles GET /admin/recommendations/canary
+func (h *AdminHandler) RecommendationCanary(c *gin.Context) {
+	c.JSON(http.StatusOK, h.canary.Report())
+}
+
+// InvalidateRecommendations handles POST /admin/recommendations/invalidate, retiring every
+// cached recommendation after the algorithm changed
+func (h *AdminHandler) InvalidateRecommendations(c *gin.Context) {
+	before := h.recommended.CurrentVersion()
+	version, purged := h.recommended.Invalidate()
+	h.record(c, "recommendations.invalidate", "recommendation_cache",
+		gin.H{"version": before}, gin.H{"version": version, "purged": purged})
+
+	c.JSON(http.StatusOK, gin.H{
+		"version": version,
+		"purged":  purged,
+	})
+}
+
+// GenreLists handles GET /admin/genre-lists
+func (h *AdminHandler) GenreLists(c *gin.Context) {
+	lists := h.genreLists.List()
+
+	c.JSON(http.StatusOK, models.GenreListsResponse{
+		Lists: lists,
+		Total: len(lists),
+	})
+}
+
+// RefreshGenreList handles POST /admin/genre-lists/:genre/refresh, searching a genre's top
+// list now instead of waiting for the scheduled refresh
+func (h *AdminHandler) RefreshGenreList(c *gin.Context) {
+	genre, _, err := h.genres.Resolve(c.Param("genre"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	before, _ := h.genreLists.Get(genre)
+	list, err := h.genreLists.Refresh(c.Request.Context(), genre)
+	if err != nil {
+		upstreamFailure(c, err, "Failed to refresh genre list")
+		return
+	}
+	h.record(c, "genre_list.refresh", genre,
+		gin.H{"total": len(before.Movies), "refreshed_at": before.RefreshedAt},
+		gin.H{"total": len(list.Movies), "refreshed_at": list.RefreshedAt})
+
+	c.JSON(http.StatusOK, models.GenreListInfo{
+		Genre:       list.Genre,
+		Total:       len(list.Movies),
+		RefreshedAt: list.RefreshedAt,
+	})
+}
+
+// StaffPicks handles GET /admin/staff-picks, listing the picks with who added them
+func (h *AdminHandler) StaffPicks(c *gin.Context) {
+	picks := h.staffPicks.List()
+
+	c.JSON(http.StatusOK, gin.H{
+		"picks": picks,
+		"total": len(picks),
+	})
+}
+
+// PutStaffPick handles PUT /admin/staff-picks/:imdbID with body
+// {"note": "A landmark of the genre", "position": 1}, adding the title or updating its pick
+func (h *AdminHandler) PutStaffPick(c *gin.Context) {
+	imdbID := c.Param("imdbID")
+	var req models.StaffPickRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !imdbIDPattern.MatchString(imdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Path must hold a valid IMDb ID (e.g. tt0133093) and the body JSON with an optional note and position",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	pick, previous, err := h.staffPicks.Put(imdbID, middleware.AdminActor(c), req)
+	if errors.Is(err, services.ErrInvalidStaffPick) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save staff pick",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "staff_pick.set", imdbID, previous, pick)
+
+	c.JSON(http.StatusOK, pick)
+}
+
+// DeleteStaffPick handles DELETE /admin/staff-picks/:imdbID
+func (h *AdminHandler) DeleteStaffPick(c *gin.Context) {
+	pick, err := h.staffPicks.Delete(c.Param("imdbID"))
+	if errors.Is(err, services.ErrStaffPickNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Staff pick not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to delete staff pick",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "staff_pick.delete", pick.ImdbID, pick, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// Collections handles GET /admin/collections, listing every collection with whether it is
+// active now
+func (h *AdminHandler) Collections(c *gin.Context) {
+	collections := h.collections.List()
+
+	c.JSON(http.StatusOK, models.CollectionsResponse{
+		Collections: collections,
+		Total:       len(collections),
+	})
+}
+
+// PutCollection handles PUT /admin/collections/:slug with body
+// {"title": "Halloween", "imdb_ids": ["tt0077651"], "active_from": "10-01", "active_until": "10-31"}
+func (h *AdminHandler) PutCollection(c *gin.Context) {
+	var req models.CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with a title, a list of IMDb IDs and an optional activation window",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	collection, previous, err := h.collections.Put(c.Param("slug"), req)
+	if errors.Is(err, services.ErrInvalidCollection) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save collection",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "collection.set", collection.Slug, previous, collection)
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection handles DELETE /admin/collections/:slug
+func (h *AdminHandler) DeleteCollection(c *gin.Context) {
+	collection, err := h.collections.Delete(c.Param("slug"))
+	if errors.Is(err, services.ErrCollectionNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Collection not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to delete collection",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "collection.delete", collection.Slug, collection, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// Audit handles GET /admin/audit?action=alias.set&actor=alice&target=Amelie&since=2024-01-01T00:00:00Z&limit=100
+func (h *AdminHandler) Audit(c *gin.Context) {
+	query := services.AuditQuery{
+		Action: c.Query("action"),
+		Actor:  c.Query("actor"),
+		Target: c.Query("target"),
+		Limit:  100,
+	}
+	if since := c.Query("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "since must be an RFC 3339 timestamp (e.g. 2024-01-01T00:00:00Z)",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		query.Since = parsed
+	}
+	if limit := c.Query("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "limit must be a number between 1 and 1000",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		query.Limit = parsed
+	}
+
+	entries := h.audit.Query(query)
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   len(entries),
+	})
+}
+
+// ListUsers handles GET /admin/users
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	users := h.users.List()
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"total": len(users),
+	})
+}
+
+// SetUserRole handles PUT /admin/users/:id/role with body {"role": "readonly"}
+func (h *AdminHandler) SetUserRole(c *gin.Context) {
+	var req models.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !services.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with a role of admin, user, readonly or service",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	before, after, err := h.users.SetRole(c.Param("id"), req.Role)
+	if errors.Is(err, services.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save user",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "user.role", after.ID, gin.H{"role": before.Role}, gin.H{"role": after.Role})
+
+	c.JSON(http.StatusOK, after)
+}
+
+// Permissions handles GET /admin/permissions, the roles' permissions and what each route requires
+func (h *AdminHandler) Permissions(c *gin.Context) {
+	c.JSON(http.StatusOK, h.permissions())
+}
+
+// PutTag handles PUT /admin/tags/:tag with body
+// {"name": "Heist", "description": "...", "keywords": ["heist", "robbery"]}
+func (h *AdminHandler) PutTag(c *gin.Context) {
+	var req models.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with a name and optional description and keywords",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	tag, previous, err := h.tags.Put(c.Param("tag"), req)
+	if errors.Is(err, services.ErrInvalidTag) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save tag",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "tag.set", tag.Slug, previous, tag)
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag handles DELETE /admin/tags/:tag, which also removes it from every title
+func (h *AdminHandler) DeleteTag(c *gin.Context) {
+	tag, err := h.tags.Delete(c.Param("tag"))
+	if errors.Is(err, services.ErrTagNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Tag not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to delete tag",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "tag.delete", tag.Slug, tag, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// PutTitleTags handles PUT /admin/titles/:imdbID/tags with body {"tags": ["heist", "revenge"]}.
+// An empty list removes the title's tags.
+func (h *AdminHandler) PutTitleTags(c *gin.Context) {
+	imdbID := c.Param("imdbID")
+	var req models.TitleTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !imdbIDPattern.MatchString(imdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Path must hold a valid IMDb ID (e.g. tt0133093) and the body a JSON list of tags",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	title, previous, err := h.tags.SetTitle(imdbID, req.Tags)
+	if errors.Is(err, services.ErrInvalidTag) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save tags",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "title.tags", imdbID, previous, title)
+
+	c.JSON(http.StatusOK, title)
+}
+
+// PutTitleSpoilers handles PUT /admin/titles/:imdbID/spoilers with body
+// {"spans": ["Neo is the One"]}: passages of the title's plot that hide_spoilers=true
+// redacts. An empty list removes them.
+func (h *AdminHandler) PutTitleSpoilers(c *gin.Context) {
+	var req models.TitleSpoilersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with a list of spans",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	before, after, err := h.spoilers.Set(c.Param("imdbID"), req.Spans)
+	if errors.Is(err, services.ErrInvalidSpoilers) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save spoilers",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "title.spoilers", after.ImdbID, before, after)
+
+	c.JSON(http.StatusOK, after)
+}
+
+// ReviewQueue handles GET /admin/reviews, the pending reviews oldest first, or those in
+// another status with status=published|rejected
+func (h *AdminHandler) ReviewQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", services.ReviewPending)
+	if !services.ValidReviewStatus(status) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "status must be pending, published or rejected",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	reviews := h.reviews.Queue(status)
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"total":   len(reviews),
+	})
+}
+
+// ModerateReview handles PUT /admin/reviews/:id/status with body {"status": "published"}
+func (h *AdminHandler) ModerateReview(c *gin.Context) {
+	var req models.ReviewStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with a status of published or rejected",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	before, after, err := h.reviews.Moderate(c.Param("id"), req.Status)
+	switch {
+	case errors.Is(err, services.ErrInvalidReview):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	case errors.Is(err, services.ErrReviewNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Review not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save review",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "review.status", after.ID, gin.H{"status": before.Status}, gin.H{"status": after.Status})
+
+	c.JSON(http.StatusOK, after)
+}
+
+// ReportQueue handles GET /admin/reports, the open reports oldest first, or the resolved
+// ones with status=resolved
+func (h *AdminHandler) ReportQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", services.ReportOpen)
+	if status != services.ReportOpen && status != services.ReportResolved {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "status must be open or resolved",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	reports := h.reports.Queue(status)
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+		"total":   len(reports),
+	})
+}
+
+// ResolveReport handles POST /admin/reports/:id/resolve with body {"action": "remove"}.
+// The action applies to every open report of the same content: dismiss shows the
+// content again, remove rejects a review or keeps a watchlist hidden, and ban also
+// demotes the owner to the readonly role and revokes the tokens they hold, logging them out.
+func (h *AdminHandler) ResolveReport(c *gin.Context) {
+	var req models.ReportResolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with an action of dismiss, remove or ban",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	resolved, err := h.reports.Resolve(c.Param("id"), req.Action)
+	switch {
+	case errors.Is(err, services.ErrInvalidReport):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	case errors.Is(err, services.ErrReportNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Report not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save reports",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	target := resolved[0]
+	h.record(c, "report.resolve", target.Kind+":"+target.TargetID, gin.H{"status": services.ReportOpen}, gin.H{"status": services.ReportResolved, "resolution": req.Action, "reports": len(resolved)})
+
+	if req.Action != services.ResolveDismiss && target.Kind == services.ContentReview {
+		before, after, err := h.reviews.Moderate(target.TargetID, services.ReviewRejected)
+		if err != nil && !errors.Is(err, services.ErrReviewNotFound) {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Internal Server Error",
+				Message: "Reports resolved, but failed to reject the review",
+				Code:    http.StatusInternalServerError,
+			})
+			return
+		}
+		if err == nil {
+			h.record(c, "review.status", after.ID, gin.H{"status": before.Status}, gin.H{"status": after.Status})
+		}
+	}
+	if req.Action == services.ResolveBan {
+		before, after, err := h.users.SetRole(target.OwnerID, services.RoleReadonly)
+		if err != nil && !errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Internal Server Error",
+				Message: "Reports resolved, but failed to ban the owner",
+				Code:    http.StatusInternalServerError,
+			})
+			return
+		}
+		if err == nil {
+			h.record(c, "user.role", after.ID, gin.H{"role": before.Role}, gin.H{"role": after.Role})
+
+			before, after, err := h.users.RevokeTokens(target.OwnerID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error:   "Internal Server Error",
+					Message: "Reports resolved, but failed to revoke the owner's tokens",
+					Code:    http.StatusInternalServerError,
+				})
+				return
+			}
+			h.record(c, "user.tokens", after.ID, gin.H{"tokens_revoked_at": before.TokensRevokedAt}, gin.H{"tokens_revoked_at": after.TokensRevokedAt})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": resolved,
+		"total":   len(resolved),
+	})
+}
+
+// ContentViolations handles GET /admin/violations?limit=100, the profanity and personal
+// data the content filter found in users' text, newest first, with counts per kind
+func (h *AdminHandler) ContentViolations(c *gin.Context) {
+	limit := 100
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "limit must be a number between 1 and 1000",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	violations, counts := h.filter.Violations(limit)
+	c.JSON(http.StatusOK, gin.H{
+		"mode":       h.filter.Mode,
+		"violations": violations,
+		"counts":     counts,
+		"total":      len(violations),
+	})
+}
+
+// FieldProfiles handles GET /admin/field-profiles
+func (h *AdminHandler) FieldProfiles(c *gin.Context) {
+	profiles := h.profiles.List()
+
+	c.JSON(http.StatusOK, models.FieldProfilesResponse{
+		Profiles: profiles,
+		Total:    len(profiles),
+	})
+}
+
+// PutFieldProfile handles PUT /admin/field-profiles/:tenant with body
+// {"mappings": [{"from": "imdb_rating", "to": "rating.imdb"}]}
+func (h *AdminHandler) PutFieldProfile(c *gin.Context) {
+	var req models.FieldProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with a list of mappings, each with from and to",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	profile, previous, err := h.profiles.Put(c.Param("tenant"), middleware.AdminActor(c), req)
+	if errors.Is(err, services.ErrInvalidFieldProfile) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save field profile",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "field_profile.set", profile.Tenant, previous, profile)
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// DeleteFieldProfile handles DELETE /admin/field-profiles/:tenant
+func (h *AdminHandler) DeleteFieldProfile(c *gin.Context) {
+	profile, err := h.profiles.Delete(c.Param("tenant"))
+	if errors.Is(err, services.ErrFieldProfileNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Field profile not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to delete field profile",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "field_profile.delete", profile.Tenant, profile, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// ScoreWeights handles GET /admin/score-weights, listing the default blended score weights
+// and those of tenants
+func (h *AdminHandler) ScoreWeights(c *gin.Context) {
+	tenants := h.weights.List()
+
+	c.JSON(http.StatusOK, models.ScoreWeightsResponse{
+		Default: h.weights.Default,
+		Tenants: tenants,
+		Total:   len(tenants),
+	})
+}
+
+// PutScoreWeights handles PUT /admin/score-weights/:tenant with body
+// {"imdb": 1, "metascore": 2, "rotten_tomatoes": 1}
+func (h *AdminHandler) PutScoreWeights(c *gin.Context) {
+	var req models.ScoreWeights
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with the weights of imdb, metascore and rotten_tomatoes",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	weights, previous, err := h.weights.Put(c.Param("tenant"), middleware.AdminActor(c), req)
+	if errors.Is(err, services.ErrInvalidScoreWeights) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save score weights",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "score_weights.set", weights.Tenant, previous, weights)
+
+	c.JSON(http.StatusOK, weights)
+}
+
+// DeleteScoreWeights handles DELETE /admin/score-weights/:tenant; the tenant gets the
+// default weights again
+func (h *AdminHandler) DeleteScoreWeights(c *gin.Context) {
+	weights, err := h.weights.Delete(c.Param("tenant"))
+	if errors.Is(err, services.ErrScoreWeightsNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Score weights not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to delete score weights",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "score_weights.delete", weights.Tenant, weights, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// SeedJobs handles GET /admin/seed, listing the seed jobs kept, newest first
+func (h *AdminHandler) SeedJobs(c *gin.Context) {
+	jobs := h.seeder.List()
+
+	c.JSON(http.StatusOK, models.SeedJobsResponse{Jobs: jobs, Total: len(jobs)})
+}
+
+// maxSeedBodyBytes bounds the body of a seed request
+const maxSeedBodyBytes = 10 << 20
+
+// Seed handles POST /admin/seed, starting a job that seeds the local corpus with titles
+// in the background. The body is JSON, {"imdb_ids": ["tt0133093", ...]}, or with
+// Content-Type text/csv, a CSV file with the IMDb IDs in its first column and an optional
+// header row. The job's progress is reported at /admin/seed/:jobID.
+func (h *AdminHandler) Seed(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSeedBodyBytes)
+
+	var imdbIDs []string
+	if c.ContentType() == "text/csv" {
+		var err error
+		imdbIDs, err = readSeedCSV(c.Request.Body, h.seeder.MaxTitles)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			seedBodyTooLarge(c)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Body must be a CSV file with IMDb IDs in its first column",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+	} else {
+		var req models.SeedRequest
+		err := c.ShouldBindJSON(&req)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			seedBodyTooLarge(c)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Body must be JSON with the imdb_ids to seed, or a CSV file sent as text/csv",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		imdbIDs = req.ImdbIDs
+	}
+
+	job, err := h.seeder.Start(middleware.AdminActor(c), imdbIDs)
+	if errors.Is(err, services.ErrInvalidSeed) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to start seed job",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	h.record(c, "seed.start", job.ID, nil, job)
+
+	c.Header("Location", "/admin/seed/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// SeedJob handles GET /admin/seed/:jobID, reporting a seed job's progress
+func (h *AdminHandler) SeedJob(c *gin.Context) {
+	job, err := h.seeder.Get(c.Param("jobID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Seed job not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func seedBodyTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+		Error:   "Request Entity Too Large",
+		Message: "Body must be at most 10 MB",
+		Code:    http.StatusRequestEntityTooLarge,
+	})
+}
+
+// readSeedCSV reads the first column of a CSV file, skipping blank rows and a header row.
+// It stops after one ID more than maxTitles, which is enough for the job to be refused.
+func readSeedCSV(r io.Reader, maxTitles int) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var imdbIDs []string
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return imdbIDs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := strings.TrimSpace(record[0])
+		if value == "" || (row == 0 && !imdbIDPattern.MatchString(value)) {
+			continue
+		}
+		imdbIDs = append(imdbIDs, value)
+		if len(imdbIDs) > maxTitles {
+			return imdbIDs, nil
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie carries the login state between the redirect to the provider and the
+// callback, so a callback can't be forged from another browser
+const oidcStateCookie = "oidc_state"
+
+// AuthHandler logs users in with an external provider and issues API tokens
+type AuthHandler struct {
+	oidc   *services.OIDCLogin
+	users  *services.UserStore
+	tokens *services.TokenIssuer
+	links  *LinkBuilder
+}
+
+func NewAuthHandler(oidc *services.OIDCLogin, users *services.UserStore, tokens *services.TokenIssuer, links *LinkBuilder) *AuthHandler {
+	return &AuthHandler{
+		oidc:   oidc,
+		users:  users,
+		tokens: tokens,
+		links:  links,
+	}
+}
+
+// Providers handles GET /auth/providers
+func (h *AuthHandler) Providers(c *gin.Context) {
+	providers := []string{}
+	if h.tokens != nil {
+		providers = h.oidc.Providers()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"providers": providers})
+}
+
+// Login handles GET /auth/:provider/login by redirecting to the provider's login page
+func (h *AuthHandler) Login(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+
+	stateBytes := make([]byte, 16)
+	if _, err := rand.Read(stateBytes); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to start login",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	state := hex.EncodeToString(stateBytes)
+
+	authURL, err := h.oidc.AuthCodeURL(c.Request.Context(), c.Param("provider"), state, h.redirectURI(c))
+	if err != nil {
+		h.loginFailure(c, err)
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, 600, "/auth/", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback handles GET /auth/:provider/callback?code=...&state=..., mapping the external
+// identity to a local user and returning an API token
+func (h *AuthHandler) Callback(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+
+	state, _ := c.Cookie(oidcStateCookie)
+	c.SetCookie(oidcStateCookie, "", -1, "/auth/", "", c.Request.TLS != nil, true)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Login state is missing or does not match; start the login again",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if reason := c.Query("error"); reason != "" || c.Query("code") == "" {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Message: "The provider did not complete the login: " + reason,
+			Code:    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	external, err := h.oidc.Exchange(c.Request.Context(), c.Param("provider"), c.Query("code"), h.redirectURI(c))
+	if err != nil {
+		h.loginFailure(c, err)
+		return
+	}
+
+	user, err := h.users.LoginExternal(external.Identity, external.Name, external.Email)
+	if err != nil {
+		log.Printf("login: failed to save user for %s identity %s: %v", external.Identity.Provider, external.Identity.Subject, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save the user",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	token, expires, err := h.tokens.Issue(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to issue a token",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LoginResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresAt: expires.UTC(),
+		User:      user,
+	})
+}
+
+// Me handles GET /api/me, returning the logged-in user
+func (h *AuthHandler) Me(c *gin.Context) {
+	claims, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	user, ok := h.users.Get(claims.Subject)
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// enabled writes a 404 when login isn't configured
+func (h *AuthHandler) enabled(c *gin.Context) bool {
+	if h.tokens != nil && h.oidc != nil {
+		return true
+	}
+	c.JSON(http.StatusNotFound, models.ErrorResponse{
+		Error:   "Not Found",
+		Message: "Login is disabled. Set JWT_SECRET and OIDC_PROVIDERS to enable it",
+		Code:    http.StatusNotFound,
+	})
+	return false
+}
+
+func (h *AuthHandler) redirectURI(c *gin.Context) string {
+	return h.links.baseURL(c) + "/auth/" + c.Param("provider") + "/callback"
+}
+
+func (h *AuthHandler) loginFailure(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Unknown login provider",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	log.Printf("login: %s: %v", c.Param("provider"), err)
+	c.JSON(http.StatusBadGateway, models.ErrorResponse{
+		Error:     "Bad Gateway",
+		Message:   "The login provider could not be reached or rejected the login",
+		Code:      http.StatusBadGateway,
+		Retryable: true,
+	})
+}
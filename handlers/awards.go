@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AwardsHandler serves the Academy Awards nominees and winners by ceremony year
+type AwardsHandler struct {
+	awards  *services.AwardsService
+	posters *services.PosterService
+	links   *LinkBuilder
+}
+
+func NewAwardsHandler(awards *services.AwardsService, posters *services.PosterService, links *LinkBuilder) *AwardsHandler {
+	return &AwardsHandler{awards: awards, posters: posters, links: links}
+}
+
+// Oscars handles GET /api/awards/oscars/:year?category=best-picture. The year is the
+// year of the ceremony, so 2020 lists the films of 2019.
+func (h *AwardsHandler) Oscars(c *gin.Context) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil || year < 1929 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "year must be the year of a ceremony, e.g. 2020",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	category := c.Query("category")
+	response, err := h.awards.Oscars(c.Request.Context(), year, category)
+	if errors.Is(err, services.ErrNoAwardsData) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if errors.Is(err, services.ErrUnknownAwardCategory) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	for _, awardCategory := range response.Categories {
+		for _, nominee := range awardCategory.Nominees {
+			if nominee.Movie == nil {
+				continue
+			}
+			if hash, ok := h.posters.Blurhashes.Get(nominee.ImdbID); ok {
+				nominee.Movie.Blurhash = hash
+			}
+			h.links.LinkBrief(c, nominee.Movie)
+		}
+	}
+	response.Links = h.links.OscarsLinks(c, year, category, h.awards.Years())
+	response.Errors = services.ScopeFrom(c.Request.Context()).FailedItems()
+	response.Meta = services.ScopeFrom(c.Request.Context()).Meta()
+
+	streamJSON(c, http.StatusOK, response)
+}
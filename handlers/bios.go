@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PersonHandler serves biographies of actors, directors and writers
+type PersonHandler struct {
+	bios  *services.PersonBios
+	links *LinkBuilder
+}
+
+func NewPersonHandler(bios *services.PersonBios, links *LinkBuilder) *PersonHandler {
+	return &PersonHandler{bios: bios, links: links}
+}
+
+// Bio handles GET /api/person/:name/bio, e.g. /api/person/Quentin%20Tarantino/bio
+func (h *PersonHandler) Bio(c *gin.Context) {
+	name := strings.TrimSpace(c.Param("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Path must hold a person's name",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	bio, err := h.bios.Bio(c.Request.Context(), name)
+	if errors.Is(err, services.ErrPersonNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "No actor, director or writer by that name was found on Wikidata",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		upstreamFailure(c, err, "Failed to look up person")
+		return
+	}
+
+	for i := range bio.Filmography {
+		bio.Filmography[i].Links = h.links.TitleLinks(c, bio.Filmography[i].ImdbID)
+	}
+	bio.Meta = services.ScopeFrom(c.Request.Context()).Meta()
+
+	c.JSON(http.StatusOK, bio)
+}
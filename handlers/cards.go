@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CardHandler serves share cards of titles for social embeds
+type CardHandler struct {
+	cards *services.CardService
+}
+
+func NewCardHandler(cards *services.CardService) *CardHandler {
+	return &CardHandler{cards: cards}
+}
+
+// GetCard handles GET /api/movie/:imdbID/card.png?theme=dark, a PNG with the title's
+// poster, title, year and rating to use as its Open Graph image
+func (h *CardHandler) GetCard(c *gin.Context) {
+	imdbID := c.Param("imdbID")
+	if !imdbIDPattern.MatchString(imdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "imdbID must be a valid IMDb ID (e.g. tt0133093)",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	theme := strings.ToLower(c.DefaultQuery("theme", services.CardDark))
+	supported := false
+	for _, known := range h.cards.Themes() {
+		supported = supported || theme == known
+	}
+	if !supported {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Unsupported theme '" + theme + "'. Supported values: " + strings.Join(h.cards.Themes(), ", "),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	card, err := h.cards.Render(c.Request.Context(), imdbID, theme)
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Title not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	case err != nil:
+		upstreamFailure(c, err, "Failed to render share card")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, "image/png", card)
+}
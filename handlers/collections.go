@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CollectionHandler serves the themed collections that are active now; admins schedule
+// them under /admin/collections
+type CollectionHandler struct {
+	collections *services.CollectionStore
+	posters     *services.PosterService
+	links       *LinkBuilder
+}
+
+func NewCollectionHandler(collections *services.CollectionStore, posters *services.PosterService, links *LinkBuilder) *CollectionHandler {
+	return &CollectionHandler{collections: collections, posters: posters, links: links}
+}
+
+// List handles GET /api/collections, listing the collections active now
+func (h *CollectionHandler) List(c *gin.Context) {
+	collections := h.collections.Active()
+
+	c.JSON(http.StatusOK, models.CollectionsResponse{
+		Collections: collections,
+		Total:       len(collections),
+	})
+}
+
+// Get handles GET /api/collections/:slug. A collection outside its activation window is
+// 404 like an unknown one, so clients can't show a rail before it is scheduled.
+func (h *CollectionHandler) Get(c *gin.Context) {
+	collection, err := h.collections.Get(c.Param("slug"))
+	if errors.Is(err, services.ErrCollectionNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Collection not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if errors.Is(err, services.ErrCollectionInactive) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Collection is not active",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	movies, missing := h.collections.Movies(c.Request.Context(), collection)
+	for i := range movies {
+		if hash, ok := h.posters.Blurhashes.Get(movies[i].ImdbID); ok {
+			movies[i].Blurhash = hash
+		}
+	}
+	h.links.AddBriefLinks(c, movies)
+
+	streamJSON(c, http.StatusOK, models.CollectionResponse{
+		Slug:        collection.Slug,
+		Title:       collection.Title,
+		Description: collection.Description,
+		ActiveFrom:  collection.ActiveFrom,
+		ActiveUntil: collection.ActiveUntil,
+		Movies:      movies,
+		Total:       len(movies),
+		Missing:     missing,
+		Errors:      services.ScopeFrom(c.Request.Context()).FailedItems(),
+		Links:       h.links.CollectionLinks(c, collection.Slug),
+		Meta:        services.ScopeFrom(c.Request.Context()).Meta(),
+	})
+}
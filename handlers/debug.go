@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pprof handles GET /admin/debug/pprof/ and /admin/debug/pprof/:profile, the net/http/pprof
+// index and profiles (heap, goroutine, profile, trace, ...). The handlers are called
+// directly since pprof.Index only resolves profiles under /debug/pprof/.
+func Pprof(c *gin.Context) {
+	switch profile := strings.TrimPrefix(c.Param("profile"), "/"); profile {
+	case "":
+		pprof.Index(c.Writer, c.Request)
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Handler(profile).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// Vars handles GET /admin/debug/vars, the expvar variables: memstats and cmdline
+var Vars = gin.WrapH(expvar.Handler())
+
+// Goroutines handles GET /admin/debug/goroutines, a summary of the running goroutines
+// grouped by state, function and creator
+func Goroutines(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, services.Goroutines())
+}
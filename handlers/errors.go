@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// upstreamFailure writes the error response for a request that failed talking to OMDb.
+// The status and retry hints follow the error class: an exhausted quota is 503, a timeout
+// 504 and anything else 500, and transient failures carry retry_after_ms and Retry-After.
+func upstreamFailure(c *gin.Context, err error, message string) {
+	status, title := http.StatusInternalServerError, "Internal Server Error"
+	switch {
+	case errors.Is(err, services.ErrUpstreamQuota):
+		status, title = http.StatusServiceUnavailable, "Service Unavailable"
+	case services.IsTimeout(err):
+		status, title = http.StatusGatewayTimeout, "Gateway Timeout"
+	}
+
+	hint := services.RetryHintFor(err)
+	response := models.ErrorResponse{
+		Error:     title,
+		Message:   message,
+		Code:      status,
+		Retryable: hint.Retryable,
+	}
+	if hint.RetryAfter > 0 {
+		response.RetryAfterMs = hint.RetryAfter.Milliseconds()
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(hint.RetryAfter.Seconds()))))
+	}
+
+	c.JSON(status, response)
+}
@@ -1,69 +1,209 @@
 package handlers
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"movie-api-go/middleware"
 	"movie-api-go/models"
 	"movie-api-go/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxEpisodeRange is the largest number of episodes a single range request may fetch
+const maxEpisodeRange = 30
+
+// maxRecommendationsPerLevel is the number of movies each recommendation level holds
+const maxRecommendationsPerLevel = 20
+
 type MovieHandler struct {
-	omdbService *services.OMDbService
+	omdbService    *services.OMDbService
+	resolver       *services.Resolver
+	expansions     *services.ExpansionService
+	certifications *services.CertificationMapper
+	canary         *services.RecommendationCanary
+	recommended    *services.RecommendationCache
+	preferences    *services.PreferenceStore
+	tags           *services.TagStore
+	spoilers       *services.SpoilerStore
+	posters        *services.PosterService
+	genreLists     *services.GenreListStore
+	links          *LinkBuilder
 }
 
-func NewMovieHandler(omdbService *services.OMDbService) *MovieHandler {
+func NewMovieHandler(omdbService *services.OMDbService, resolver *services.Resolver, expansions *services.ExpansionService, certifications *services.CertificationMapper, canary *services.RecommendationCanary, recommended *services.RecommendationCache, preferences *services.PreferenceStore, tags *services.TagStore, spoilers *services.SpoilerStore, posters *services.PosterService, genreLists *services.GenreListStore, links *LinkBuilder) *MovieHandler {
 	return &MovieHandler{
-		omdbService: omdbService,
+		omdbService:    omdbService,
+		resolver:       resolver,
+		expansions:     expansions,
+		certifications: certifications,
+		canary:         canary,
+		recommended:    recommended,
+		preferences:    preferences,
+		tags:           tags,
+		spoilers:       spoilers,
+		posters:        posters,
+		genreLists:     genreLists,
+		links:          links,
 	}
 }
 
-// GetMovieDetails handles GET /api/movie?title=MovieTitle
+// GetMovieDetails handles GET /api/movie?title=MovieTitle&year=1999&include=ratings,wikipedia&cert_country=GB
+// The movie may alternatively be referenced by imdb_id=tt0133093 or a fuzzy q=query.
+// Other versions of the title are listed unless versions=false.
 func (h *MovieHandler) GetMovieDetails(c *gin.Context) {
-	title := c.Query("title")
-	if title == "" {
+	query := services.ResolveQuery{
+		ImdbID: c.Query("imdb_id"),
+		Title:  c.Query("title"),
+		Year:   c.Query("year"),
+		Query:  c.Query("q"),
+		Type:   "movie",
+	}
+	if query.ImdbID == "" && query.Title == "" && query.Query == "" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Bad Request",
-			Message: "Title parameter is required",
+			Message: "Title parameter is required (or imdb_id, or q for a fuzzy lookup)",
 			Code:    http.StatusBadRequest,
 		})
 		return
 	}
 
-	movie, err := h.omdbService.GetMovieByTitle(title)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Internal Server Error",
-			Message: "Failed to fetch movie details",
-			Code:    http.StatusInternalServerError,
+	include := parseList(c.Query("include"))
+	if unknown, ok := h.expansions.Validate(include); !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Unsupported include value '" + unknown + "'. Supported values: " + strings.Join(h.expansions.Supported(), ", "),
+			Code:    http.StatusBadRequest,
 		})
 		return
 	}
 
-	if movie.Response == "False" {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "Not Found",
-			Message: movie.Error,
-			Code:    http.StatusNotFound,
-		})
+	certCountry, hasCertCountry := c.GetQuery("cert_country")
+	if hasCertCountry {
+		country, ok := h.certifications.Country(certCountry)
+		if !ok {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "Unsupported cert_country '" + certCountry + "'. Supported values: " + strings.Join(h.certifications.Countries(), ", "),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		certCountry = country
+	}
+
+	resolution, movie, ok := h.resolveTitle(c, query, "Movie not found!", "Failed to fetch movie details")
+	if !ok {
 		return
 	}
 
+	presented := h.omdbService.NAPolicy.Present(movie)
 	response := models.MovieDetailsResponse{
-		Title:    movie.Title,
-		Year:     movie.Year,
-		Plot:     movie.Plot,
-		Country:  movie.Country,
-		Awards:   movie.Awards,
-		Director: movie.Director,
-		Ratings:  movie.Ratings,
+		Title:      presented.Title,
+		Year:       presented.Year,
+		Plot:       presented.Plot,
+		Country:    presented.Country,
+		Awards:     presented.Awards,
+		Director:   presented.Director,
+		Credits:    services.ParseCredits(movie.Director, movie.Writer),
+		Ratings:    presented.Ratings,
+		ImdbID:     movie.ImdbID,
+		Resolution: resolution,
+		Rated:      presented.Rated,
+		Links:      h.links.MovieLinks(c, movie.Title, movie.Poster),
+
+		Released:      presented.Released,
+		ReleasedDate:  services.ISODate(movie.Released),
+		ReleaseStatus: h.omdbService.Releases.Status(movie, time.Now().UTC()),
+		Completeness:  services.Completeness(movie),
+	}
+	if hideSpoilers(c) {
+		response.Plot, response.SpoilersHidden = h.spoilers.RedactPlot(movie.ImdbID, movie.Plot)
+	}
+	response.PosterColors = h.posterColors(movie.ImdbID, movie.Poster)
+	response.Blurhash = h.blurhash(movie.ImdbID, movie.Poster)
+	if hasCertCountry {
+		response.Certification = h.certifications.Map(movie.Rated, certCountry)
+	}
+
+	// Remakes and editions for a version picker; they are left out if the search fails
+	if c.Query("versions") != "false" {
+		if versions, err := h.omdbService.OtherVersions(c.Request.Context(), movie); err == nil && len(versions) > 0 {
+			for i := range versions {
+				versions[i].Links = h.links.VersionLinks(c, versions[i])
+			}
+			response.OtherVersions = versions
+		}
+	}
+
+	if len(include) > 0 {
+		expansions, failures := h.expansions.Expand(c.Request.Context(), movie, include)
+		if len(expansions) > 0 {
+			response.Expansions = expansions
+		}
+		if len(failures) > 0 {
+			response.ExpansionErrors = failures
+		}
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// GetGameDetails handles GET /api/game?title=GameTitle (or imdb_id=ID)
+func (h *MovieHandler) GetGameDetails(c *gin.Context) {
+	query := services.ResolveQuery{
+		ImdbID: c.Query("imdb_id"),
+		Title:  c.Query("title"),
+		Year:   c.Query("year"),
+		Type:   "game",
+	}
+	if query.ImdbID == "" && query.Title == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Title parameter is required",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	_, game, ok := h.resolveTitle(c, query, "Game not found!", "Failed to fetch game details")
+	if !ok {
+		return
+	}
+
+	presented := h.omdbService.NAPolicy.Present(game)
+	response := models.GameDetailsResponse{
+		Title:        presented.Title,
+		Year:         presented.Year,
+		Released:     presented.Released,
+		ReleasedDate: services.ISODate(game.Released),
+		Genre:        presented.Genre,
+		Plot:         presented.Plot,
+		Writer:       presented.Writer,
+		Actors:       presented.Actors,
+		Credits:      services.ParseCredits(game.Director, game.Writer),
+		ImdbID:       game.ImdbID,
+		ImdbRating:   presented.ImdbRating,
+		Ratings:      presented.Ratings,
+		Links:        h.links.GameLinks(c, game.Title, game.Poster),
+	}
+	if hideSpoilers(c) {
+		response.Plot, response.SpoilersHidden = h.spoilers.RedactPlot(game.ImdbID, game.Plot)
+	}
+	response.PosterColors = h.posterColors(game.ImdbID, game.Poster)
+	response.Blurhash = h.blurhash(game.ImdbID, game.Poster)
+
+	c.JSON(http.StatusOK, response)
+}
+
 // GetEpisodeDetails handles GET /api/episode?series_title=SeriesTitle&season=1&episode_number=1
 func (h *MovieHandler) GetEpisodeDetails(c *gin.Context) {
 	seriesTitle := c.Query("series_title")
@@ -99,13 +239,19 @@ func (h *MovieHandler) GetEpisodeDetails(c *gin.Context) {
 		return
 	}
 
-	episodeDetails, err := h.omdbService.GetEpisodeDetails(seriesTitle, season, episode)
+	tz, ok := h.airDateZone(c)
+	if !ok {
+		return
+	}
+
+	_, series, ok := h.resolveTitle(c, services.ResolveQuery{Title: seriesTitle, Type: "series"}, "Series not found!", "Failed to fetch episode details")
+	if !ok {
+		return
+	}
+
+	episodeDetails, err := h.omdbService.GetEpisodeDetails(c.Request.Context(), series.ImdbID, season, episode)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Internal Server Error",
-			Message: "Failed to fetch episode details",
-			Code:    http.StatusInternalServerError,
-		})
+		upstreamFailure(c, err, "Failed to fetch episode details")
 		return
 	}
 
@@ -118,26 +264,307 @@ func (h *MovieHandler) GetEpisodeDetails(c *gin.Context) {
 		return
 	}
 
-	response := models.EpisodeDetailsResponse{
-		Title:       episodeDetails.Title,
-		SeriesTitle: seriesTitle,
-		Season:      episodeDetails.Season,
-		Episode:     episodeDetails.Episode,
-		Year:        episodeDetails.Year,
-		Plot:        episodeDetails.Plot,
-		Director:    episodeDetails.Director,
-		Actors:      episodeDetails.Actors,
-		ImdbRating:  episodeDetails.ImdbRating,
-		Ratings:     episodeDetails.Ratings,
+	response := h.episodeResponse(c, seriesTitle, season, episode, episodeDetails, tz)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetEpisodeByID handles GET /api/episode/id/:imdbID, for episodes that titles and
+// season and episode numbers can't address reliably, such as specials
+func (h *MovieHandler) GetEpisodeByID(c *gin.Context) {
+	imdbID := c.Param("imdbID")
+	if !imdbIDPattern.MatchString(imdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "imdbID must be an IMDb title ID such as tt0959621",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	tz, ok := h.airDateZone(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	episodeDetails, err := h.omdbService.GetTitleByID(ctx, imdbID)
+	if err != nil {
+		upstreamFailure(c, err, "Failed to fetch episode details")
+		return
+	}
+	if episodeDetails.Response == "False" || episodeDetails.Type != "episode" {
+		message := episodeDetails.Error
+		if episodeDetails.Response != "False" {
+			message = imdbID + " is not an episode"
+		}
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: message,
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	// The episode record only has the series' ID; its title comes from the series record
+	var seriesTitle string
+	if series, err := h.omdbService.GetTitleByID(services.WithPriority(ctx, services.PriorityEnrichment), episodeDetails.SeriesID); err == nil && series.Response == "True" {
+		seriesTitle = series.Title
+	}
+
+	season, _ := strconv.Atoi(episodeDetails.Season)
+	episode, _ := strconv.Atoi(episodeDetails.Episode)
+	response := h.episodeResponse(c, seriesTitle, season, episode, episodeDetails, tz)
+	if seriesTitle == "" || season == 0 || episode == 0 {
+		// Without a series title or numbers there are no neighbours to link to
+		response.Links = models.Links{"self": response.Links["by_id"]}
+	} else {
+		response.Links["self"] = response.Links["by_id"]
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetMovieChanges handles GET /api/movie/:imdbID/changes?field=imdbRating&since=2024-01-01T00:00:00Z
+func (h *MovieHandler) GetMovieChanges(c *gin.Context) {
+	imdbID := c.Param("imdbID")
+	if !imdbIDPattern.MatchString(imdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "imdbID must be an IMDb title ID such as tt0133093",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	since, ok := queryTime(c, "since")
+	if !ok {
+		return
 	}
 
+	response, err := h.omdbService.TitleChanges(c.Request.Context(), imdbID)
+	if errors.Is(err, services.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "No title found with IMDb ID " + imdbID,
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		upstreamFailure(c, err, "Failed to fetch title changes")
+		return
+	}
+
+	// A field filter matches the field itself, or every rating with field=Ratings
+	field := c.Query("field")
+	changes := []models.TitleChange{}
+	for _, change := range response.Changes {
+		if change.At.Before(since) {
+			break
+		}
+		if field != "" {
+			var matched []models.FieldChange
+			for _, fieldChange := range change.Changes {
+				if fieldChange.Field == field || strings.HasPrefix(fieldChange.Field, field+".") {
+					matched = append(matched, fieldChange)
+				}
+			}
+			if len(matched) == 0 {
+				continue
+			}
+			change.Changes = matched
+		}
+		changes = append(changes, change)
+	}
+	response.Changes = changes
+	response.Total = len(changes)
+	response.Links = h.links.ChangesLinks(c, imdbID)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetRatingHistory handles GET /api/movie/:imdbID/rating-history?since=2024-01-01T00:00:00Z&until=2024-07-01T00:00:00Z
+func (h *MovieHandler) GetRatingHistory(c *gin.Context) {
+	imdbID := c.Param("imdbID")
+	if !imdbIDPattern.MatchString(imdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "imdbID must be an IMDb title ID such as tt0133093",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	since, ok := queryTime(c, "since")
+	if !ok {
+		return
+	}
+	until, ok := queryTime(c, "until")
+	if !ok {
+		return
+	}
+
+	response, err := h.omdbService.RatingHistory(c.Request.Context(), imdbID)
+	if errors.Is(err, services.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "No title found with IMDb ID " + imdbID,
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		upstreamFailure(c, err, "Failed to fetch rating history")
+		return
+	}
+
+	points := []models.RatingPoint{}
+	for _, point := range response.Points {
+		if point.At.Before(since) || (!until.IsZero() && point.At.After(until)) {
+			continue
+		}
+		points = append(points, point)
+	}
+	response.Points = points
+	response.Total = len(points)
+	response.Links = h.links.RatingHistoryLinks(c, imdbID)
+
 	c.JSON(http.StatusOK, response)
 }
 
-// GetMoviesByGenre handles GET /api/movies/genre?genre=Action
+// GetEpisodeRange handles GET /api/episodes?series_title=SeriesTitle&season=1&from=1&to=5
+func (h *MovieHandler) GetEpisodeRange(c *gin.Context) {
+	seriesTitle := c.Query("series_title")
+	seasonStr := c.Query("season")
+	fromStr := c.Query("from")
+	toStr := c.Query("to")
+
+	if seriesTitle == "" || seasonStr == "" || fromStr == "" || toStr == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "series_title, season, from, and to parameters are required",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	season, err := strconv.Atoi(seasonStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Season must be a valid number",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	from, errFrom := strconv.Atoi(fromStr)
+	to, errTo := strconv.Atoi(toStr)
+	if errFrom != nil || errTo != nil || from < 1 || to < from {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "from and to must be valid episode numbers with from <= to",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if to-from+1 > maxEpisodeRange {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "A range may contain at most " + strconv.Itoa(maxEpisodeRange) + " episodes",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	tz, ok := h.airDateZone(c)
+	if !ok {
+		return
+	}
+
+	_, series, ok := h.resolveTitle(c, services.ResolveQuery{Title: seriesTitle, Type: "series"}, "Series not found!", "Failed to fetch episode details")
+	if !ok {
+		return
+	}
+
+	episodes, err := h.omdbService.GetEpisodeRange(c.Request.Context(), series.ImdbID, season, from, to)
+
+	response := models.EpisodeRangeResponse{
+		SeriesTitle:  seriesTitle,
+		SeriesImdbID: series.ImdbID,
+		Season:       season,
+		From:         from,
+		To:           to,
+		Episodes:     []models.EpisodeDetailsResponse{},
+		Links:        h.links.EpisodeRangeLinks(c, seriesTitle, season, from, to),
+	}
+	for i, episodeDetails := range episodes {
+		if episodeDetails == nil {
+			response.Missing = append(response.Missing, from+i)
+			continue
+		}
+		response.Episodes = append(response.Episodes, h.episodeResponse(c, seriesTitle, season, from+i, episodeDetails, tz))
+	}
+	response.Total = len(response.Episodes)
+	response.Errors = services.ScopeFrom(c.Request.Context()).FailedItems()
+	response.Meta = services.ScopeFrom(c.Request.Context()).Meta()
+
+	if response.Total == 0 {
+		if err != nil {
+			upstreamFailure(c, err, "Failed to fetch episode details")
+			return
+		}
+
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "No episodes found in the specified range",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	streamJSON(c, http.StatusOK, response)
+}
+
+func (h *MovieHandler) episodeResponse(c *gin.Context, seriesTitle string, season, episode int, episodeDetails *models.OMDbResponse, tz *time.Location) models.EpisodeDetailsResponse {
+	presented := h.omdbService.NAPolicy.Present(episodeDetails)
+	response := models.EpisodeDetailsResponse{
+		ImdbID:       episodeDetails.ImdbID,
+		Title:        presented.Title,
+		SeriesTitle:  seriesTitle,
+		SeriesImdbID: episodeDetails.SeriesID,
+		Season:       presented.Season,
+		Episode:      presented.Episode,
+		Year:         presented.Year,
+		Plot:         presented.Plot,
+		Director:     presented.Director,
+		Actors:       presented.Actors,
+		Credits:      services.ParseCredits(episodeDetails.Director, episodeDetails.Writer),
+		ImdbRating:   presented.ImdbRating,
+		Ratings:      presented.Ratings,
+		Links:        h.links.EpisodeLinks(c, seriesTitle, season, episode, episodeDetails.ImdbID, episodeDetails.Poster),
+		Released:     presented.Released,
+		ReleasedDate: services.ISODate(episodeDetails.Released),
+	}
+	if aired, ok := h.omdbService.AirDates.AirTime(episodeDetails.Released, tz); ok {
+		response.AirDate = &aired
+		response.AirTimezone = tz.String()
+	}
+	if hideSpoilers(c) {
+		response.Plot, response.SpoilersHidden = h.spoilers.RedactPlot(episodeDetails.ImdbID, episodeDetails.Plot)
+	}
+	response.PosterColors = h.posterColors(episodeDetails.ImdbID, episodeDetails.Poster)
+	response.Blurhash = h.blurhash(episodeDetails.ImdbID, episodeDetails.Poster)
+	return response
+}
+
+// GetMoviesByGenre handles GET /api/movies/genre?genre=Action&max_runtime=120
+// The genre is matched against the taxonomy; close misspellings and aliases are corrected
+// unless strict=true. The logged-in user's preferences apply unless overridden (see
+// discoveryFilter). explain=true adds the stages that produced the list. The list comes from
+// the materialized top lists, refreshed_at telling how fresh it is.
 func (h *MovieHandler) GetMoviesByGenre(c *gin.Context) {
-	genre := c.Query("genre")
-	if genre == "" {
+	requested := c.Query("genre")
+	if requested == "" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Bad Request",
 			Message: "Genre parameter is required",
@@ -146,16 +573,87 @@ func (h *MovieHandler) GetMoviesByGenre(c *gin.Context) {
 		return
 	}
 
-	movies, err := h.omdbService.SearchMoviesByGenre(genre)
+	genre, corrected, err := h.omdbService.Genres.Resolve(requested, c.Query("strict") == "true")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Internal Server Error",
-			Message: "Failed to fetch movies by genre",
-			Code:    http.StatusInternalServerError,
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if !corrected {
+		requested = ""
+	}
+
+	order := c.DefaultQuery("sort", services.SortRating)
+	if !services.ValidBriefSort(order) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "sort must be rating, popularity, metascore or blended",
+			Code:    http.StatusBadRequest,
 		})
 		return
 	}
 
+	filter, ok := discoveryFilter(c, h.preferences, h.tags)
+	if !ok {
+		return
+	}
+	explainer, ok := explainRequest(c)
+	if !ok {
+		return
+	}
+
+	// The materialized list is served when there is one; a genre the scheduled refresh
+	// hasn't reached yet is searched live
+	var movies []models.MovieBrief
+	var refreshedAt *time.Time
+	var failed []models.FailedItem
+	if list, ok := h.genreLists.Get(genre); ok {
+		movies = list.Movies
+		refreshedAt = &list.RefreshedAt
+		failed = list.Errors
+		explainer.Add(models.ExplainStage{
+			Stage:      "materialized",
+			Strategy:   "top list refreshed at " + list.RefreshedAt.Format(time.RFC3339),
+			Candidates: len(movies),
+		})
+	} else {
+		var err error
+		movies, err = h.omdbService.SearchMoviesByGenre(c.Request.Context(), genre)
+		if err != nil {
+			upstreamFailure(c, err, "Failed to fetch movies by genre")
+			return
+		}
+		failed = services.ScopeFrom(c.Request.Context()).FailedItems()
+	}
+	candidates := len(movies)
+	movies = filter.Apply(movies)
+	if filter.Active() {
+		explainer.Add(models.ExplainStage{Stage: "discovery_filter", Filters: filter.Describe(), Input: candidates, Candidates: len(movies)})
+	}
+
+	ranked := len(movies)
+	strategy := "-imdb_rating"
+	switch order {
+	case services.SortPopularity:
+		strategy = "-vote_count, -imdb_rating"
+	case services.SortMetascore:
+		strategy = "-metascore, -imdb_rating"
+	case services.SortBlended:
+		strategy = "-blended_score, -imdb_rating"
+	}
+	services.SortBriefs(movies, order)
+	if len(filter.PreferredGenres) > 0 {
+		filter.Prefer(movies)
+		strategy = "preferred genres first, " + strategy
+	}
+	if len(movies) > services.GenreListSize {
+		movies = movies[:services.GenreListSize]
+	}
+	explainer.Add(models.ExplainStage{Stage: "rank", Strategy: fmt.Sprintf("%s, top %d", strategy, services.GenreListSize), Input: ranked, Candidates: len(movies)})
+
 	if len(movies) == 0 {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error:   "Not Found",
@@ -165,16 +663,161 @@ func (h *MovieHandler) GetMoviesByGenre(c *gin.Context) {
 		return
 	}
 
+	h.briefBlurhashes(movies)
+	h.links.AddBriefLinks(c, movies)
+
 	response := models.GenreMoviesResponse{
-		Genre:  genre,
-		Movies: movies,
-		Total:  len(movies),
+		Genre:          genre,
+		RequestedGenre: requested,
+		Movies:         movies,
+		Total:          len(movies),
+		RefreshedAt:    refreshedAt,
+		Errors:         failed,
+		Links:          h.links.GenreLinks(c, genre),
+		Meta:           services.ScopeFrom(c.Request.Context()).Meta(),
+		Explain:        explainer.Explanation(),
 	}
 
-	c.JSON(http.StatusOK, response)
+	streamJSON(c, http.StatusOK, response)
+}
+
+// ListTags handles GET /api/tags
+func (h *MovieHandler) ListTags(c *gin.Context) {
+	tags := h.tags.List()
+
+	c.JSON(http.StatusOK, models.TagsResponse{
+		Tags:       tags,
+		Total:      len(tags),
+		Extraction: h.tags.Extraction,
+	})
+}
+
+// ListGenres handles GET /api/genres
+func (h *MovieHandler) ListGenres(c *gin.Context) {
+	genres := h.omdbService.Genres.List()
+
+	c.JSON(http.StatusOK, models.GenresResponse{
+		Genres: genres,
+		Total:  len(genres),
+	})
+}
+
+// levelPage selects one recommendation level and a page within it
+type levelPage struct {
+	level  int
+	limit  int
+	offset int
+}
+
+// levelCursor is the position within a level; it is bound to the level it was issued for
+type levelCursor struct {
+	Level  int `json:"l"`
+	Offset int `json:"o"`
+}
+
+// parseLevelPage reads level=, limit= and cursor=. Without level the whole response is
+// returned and limit and cursor are not accepted. If the parameters are invalid, the
+// error response has been written and ok is false.
+func parseLevelPage(c *gin.Context) (page levelPage, ok bool) {
+	badRequest := func(message string) (levelPage, bool) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: message,
+			Code:    http.StatusBadRequest,
+		})
+		return levelPage{}, false
+	}
+
+	levelStr := c.Query("level")
+	if levelStr == "" {
+		if c.Query("limit") != "" || c.Query("cursor") != "" {
+			return badRequest("limit and cursor require the level parameter")
+		}
+		return levelPage{}, true
+	}
+
+	level, err := strconv.Atoi(levelStr)
+	if err != nil || level < 1 || level > 3 {
+		return badRequest("level must be 1, 2 or 3")
+	}
+	page = levelPage{level: level, limit: maxRecommendationsPerLevel}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxRecommendationsPerLevel {
+			return badRequest("limit must be a number between 1 and 20")
+		}
+		page.limit = limit
+	}
+
+	if token := c.Query("cursor"); token != "" {
+		var cursor levelCursor
+		data, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil || json.Unmarshal(data, &cursor) != nil || cursor.Level != level || cursor.Offset < 0 {
+			return badRequest("cursor is invalid")
+		}
+		page.offset = cursor.Offset
+	}
+	return page, true
+}
+
+// apply narrows the response to the selected level and page. If the level has no
+// recommendations, a 404 has been written and it returns false.
+func (p levelPage) apply(c *gin.Context, recommendations *models.RecommendationResponse) bool {
+	for _, level := range recommendations.Recommendations {
+		if level.Level != p.level {
+			continue
+		}
+
+		level.Total = len(level.Movies)
+		start := min(p.offset, len(level.Movies))
+		end := min(start+p.limit, len(level.Movies))
+		level.Movies = level.Movies[start:end]
+		if end < level.Total {
+			data, _ := json.Marshal(levelCursor{Level: p.level, Offset: end})
+			level.NextCursor = base64.RawURLEncoding.EncodeToString(data)
+		}
+		recommendations.Recommendations = []models.MovieLevel{level}
+		return true
+	}
+
+	c.JSON(http.StatusNotFound, models.ErrorResponse{
+		Error:   "Not Found",
+		Message: "No recommendations at the requested level",
+		Code:    http.StatusNotFound,
+	})
+	return false
+}
+
+// ResolvePerson handles GET /api/person?name=Quintin Tarentino
+func (h *MovieHandler) ResolvePerson(c *gin.Context) {
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "name parameter is required",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	match, ok := h.omdbService.People.Resolve(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "No known actor, director or writer matches the specified name",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, match)
 }
 
-// GetMovieRecommendations handles GET /api/recommendations?favorite_movie=MovieTitle
+// GetMovieRecommendations handles GET /api/recommendations?favorite_movie=MovieTitle&engine=v1&level=2&limit=10&cursor=Cursor
+// The logged-in user's preferences apply unless overridden (see discoveryFilter).
+// A movie is only listed in its first level unless dedupe_levels=false.
+// explain=true adds the stages that produced the recommendations.
 func (h *MovieHandler) GetMovieRecommendations(c *gin.Context) {
 	favoriteMovie := c.Query("favorite_movie")
 	if favoriteMovie == "" {
@@ -186,26 +829,116 @@ func (h *MovieHandler) GetMovieRecommendations(c *gin.Context) {
 		return
 	}
 
-	recommendations, err := h.omdbService.GetMovieRecommendations(favoriteMovie)
+	page, ok := parseLevelPage(c)
+	if !ok {
+		return
+	}
+	filter, ok := discoveryFilter(c, h.preferences, h.tags)
+	if !ok {
+		return
+	}
+	explainer, ok := explainRequest(c)
+	if !ok {
+		return
+	}
+	dedupe, err := strconv.ParseBool(c.DefaultQuery("dedupe_levels", "true"))
 	if err != nil {
-		if err.Error() == "movie not found: "+favoriteMovie {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Error:   "Not Found",
-				Message: "Favorite movie not found",
-				Code:    http.StatusNotFound,
-			})
-			return
-		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "dedupe_levels must be true or false",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
 
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Internal Server Error",
-			Message: "Failed to generate recommendations",
-			Code:    http.StatusInternalServerError,
+	// engine= pins a variant, e.g. to compare both for one title; otherwise the canary picks
+	variant := c.DefaultQuery("engine", h.canary.Variant(favoriteMovie))
+	if variant != services.RecommendationsV1 && variant != services.RecommendationsV2 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "engine must be v1 or v2",
+			Code:    http.StatusBadRequest,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, recommendations)
+	step := explainer.Begin(c.Request.Context())
+	resolution, favorite, ok := h.resolveTitle(c, services.ResolveQuery{Title: favoriteMovie, Year: c.Query("favorite_year"), Type: "movie"}, "Favorite movie not found", "Failed to generate recommendations")
+	if !ok {
+		return
+	}
+	step.End(models.ExplainStage{Stage: "resolve", Strategy: resolution.Method, Terms: []string{favoriteMovie}, Candidates: 1})
+
+	recommend := h.omdbService.GetMovieRecommendations
+	if variant == services.RecommendationsV2 {
+		recommend = h.omdbService.GetMovieRecommendationsV2
+	}
+	recommendations, err := h.recommended.Recommend(c.Request.Context(), favorite.ImdbID, variant, func(ctx context.Context) (*models.RecommendationResponse, error) {
+		return recommend(ctx, favorite)
+	})
+	if err != nil {
+		upstreamFailure(c, err, "Failed to generate recommendations")
+		return
+	}
+
+	if dedupe {
+		repeated := briefCount(recommendations.Recommendations)
+		recommendations.Recommendations = services.DedupeLevels(recommendations.Recommendations)
+		explainer.Add(models.ExplainStage{Stage: "dedupe", Strategy: "across levels, first level kept", Input: repeated, Candidates: briefCount(recommendations.Recommendations)})
+	}
+
+	candidates, kept := 0, 0
+	levels := recommendations.Recommendations[:0]
+	for _, level := range recommendations.Recommendations {
+		candidates += len(level.Movies)
+		level.Movies = filter.Apply(level.Movies)
+		kept += len(level.Movies)
+		if len(level.Movies) > 0 {
+			levels = append(levels, level)
+		}
+	}
+	recommendations.Recommendations = levels
+	if filter.Active() {
+		explainer.Add(models.ExplainStage{Stage: "discovery_filter", Filters: filter.Describe(), Input: candidates, Candidates: kept})
+	}
+
+	recommendations.Links = h.links.RecommendationLinks(c, recommendations.FavoriteMovie.Title)
+	for i := range recommendations.Recommendations {
+		level := &recommendations.Recommendations[i]
+		level.Description = middleware.Localized(c, level.Description)
+		level.Links = h.links.LevelLinks(c, recommendations.FavoriteMovie.Title, level.Level)
+	}
+	if page.level > 0 {
+		if !page.apply(c, recommendations) {
+			return
+		}
+		recommendations.Links = h.links.PageLinks(c, recommendations.Recommendations[0].NextCursor)
+	}
+	scope := services.ScopeFrom(c.Request.Context())
+	recommendations.Errors = scope.FailedItems()
+	recommendations.Meta = scope.Meta()
+	if recommendations.Meta == nil {
+		recommendations.Meta = &models.ResponseMeta{UpstreamCalls: scope.Calls()}
+	}
+	recommendations.Meta.Variant = variant
+	recommendations.Explain = explainer.Explanation()
+	h.canary.Record(variant)
+	h.links.LinkBrief(c, &recommendations.FavoriteMovie)
+	for i := range recommendations.Recommendations {
+		h.briefBlurhashes(recommendations.Recommendations[i].Movies)
+		h.links.AddBriefLinks(c, recommendations.Recommendations[i].Movies)
+	}
+
+	streamJSON(c, http.StatusOK, recommendations)
+}
+
+// briefCount is the number of movies in all levels
+func briefCount(levels []models.MovieLevel) int {
+	count := 0
+	for _, level := range levels {
+		count += len(level.Movies)
+	}
+	return count
 }
 
 // HealthCheck handles GET /health
@@ -215,3 +948,116 @@ func (h *MovieHandler) HealthCheck(c *gin.Context) {
 		"message": "Movie API is running",
 	})
 }
+
+// queryTime parses an optional RFC 3339 query parameter, answering 400 if it is invalid.
+// A missing parameter is the zero time.
+func queryTime(c *gin.Context, name string) (time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, true
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: name + " must be an RFC 3339 timestamp (e.g. 2024-01-01T00:00:00Z)",
+			Code:    http.StatusBadRequest,
+		})
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+// parseList splits a comma-separated query value, dropping blanks and duplicates
+func parseList(value string) []string {
+	var items []string
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		items = append(items, item)
+	}
+	return items
+}
+
+// posterColors returns the palette of a title's poster once it is known; titles without
+// a poster have none
+func (h *MovieHandler) posterColors(imdbID, poster string) []models.PosterColor {
+	if imdbID == "" || poster == "" {
+		return nil
+	}
+	return h.posters.Palette(imdbID)
+}
+
+// blurhash returns the placeholder of a title's poster once it is known; titles without
+// a poster have none
+func (h *MovieHandler) blurhash(imdbID, poster string) string {
+	if imdbID == "" || poster == "" {
+		return ""
+	}
+	return h.posters.Blurhash(imdbID)
+}
+
+// briefBlurhashes sets the placeholders already computed for the movies. Briefs don't
+// carry their poster, so missing ones are left to the details of each title.
+func (h *MovieHandler) briefBlurhashes(movies []models.MovieBrief) {
+	for i := range movies {
+		if movies[i].ImdbID == "" {
+			continue
+		}
+		if hash, ok := h.posters.Blurhashes.Get(movies[i].ImdbID); ok {
+			movies[i].Blurhash = hash
+		}
+	}
+}
+
+// airDateZone reads the tz parameter (an IANA zone such as Europe/Berlin) that episode air
+// dates are converted to; they stay in the source market's zone without it. If the zone is
+// unknown, the error response has been written and ok is false.
+func (h *MovieHandler) airDateZone(c *gin.Context) (*time.Location, bool) {
+	name := c.Query("tz")
+	if name == "" {
+		return h.omdbService.AirDates.Source, true
+	}
+	tz, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "tz must be an IANA time zone such as Europe/Berlin",
+			Code:    http.StatusBadRequest,
+		})
+		return nil, false
+	}
+	return tz, true
+}
+
+// explainRequest starts collecting the stages of a discovery request that asks for them
+// with explain=true; the explainer is nil otherwise. If the parameter is invalid, the
+// error response has been written and ok is false.
+func explainRequest(c *gin.Context) (explainer *services.Explainer, ok bool) {
+	explain, err := strconv.ParseBool(c.DefaultQuery("explain", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "explain must be true or false",
+			Code:    http.StatusBadRequest,
+		})
+		return nil, false
+	}
+	if !explain {
+		return nil, true
+	}
+
+	explainer = services.NewExplainer()
+	c.Request = c.Request.WithContext(services.WithExplainer(c.Request.Context(), explainer))
+	return explainer, true
+}
+
+// hideSpoilers reports whether the request asks for spoilers to be redacted with
+// hide_spoilers=true
+func hideSpoilers(c *gin.Context) bool {
+	return c.Query("hide_spoilers") == "true"
+}
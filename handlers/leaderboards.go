@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultLeaderboardLimit is the number of entries returned without a limit parameter
+const defaultLeaderboardLimit = 20
+
+// LeaderboardHandler serves the leaderboards aggregated from user data
+type LeaderboardHandler struct {
+	leaderboards *services.LeaderboardService
+	links        *LinkBuilder
+}
+
+func NewLeaderboardHandler(leaderboards *services.LeaderboardService, links *LinkBuilder) *LeaderboardHandler {
+	return &LeaderboardHandler{leaderboards: leaderboards, links: links}
+}
+
+// Watchlisted handles GET /api/leaderboards/watchlisted?window=week&limit=20
+func (h *LeaderboardHandler) Watchlisted(c *gin.Context) {
+	h.serve(c, services.LeaderboardWatchlisted)
+}
+
+// UserRated handles GET /api/leaderboards/user-rated?window=month&limit=20
+func (h *LeaderboardHandler) UserRated(c *gin.Context) {
+	h.serve(c, services.LeaderboardUserRated)
+}
+
+func (h *LeaderboardHandler) serve(c *gin.Context, board string) {
+	window := c.DefaultQuery("window", "all")
+	if !services.ValidWindow(window) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "window must be week, month, year or all",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	limit := defaultLeaderboardLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > h.leaderboards.Size {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "limit must be a number between 1 and " + strconv.Itoa(h.leaderboards.Size),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	leaderboard := h.leaderboards.Get(c.Request.Context(), board, window)
+	if len(leaderboard.Entries) > limit {
+		leaderboard.Entries = leaderboard.Entries[:limit]
+	}
+	for i := range leaderboard.Entries {
+		leaderboard.Entries[i].Links = h.links.TitleLinks(c, leaderboard.Entries[i].ImdbID)
+	}
+	leaderboard.Total = len(leaderboard.Entries)
+	leaderboard.Links = h.links.LeaderboardLinks(c, board, window)
+
+	c.JSON(http.StatusOK, leaderboard)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeLike likes (or, with like false, unlikes) a target owned by owner on behalf of
+// userID and writes the new count. Users can't like their own reviews and lists, and
+// their likes and unlikes are rate limited.
+func writeLike(c *gin.Context, likes *services.LikeStore, userID, owner, kind, id string, like bool) {
+	if userID == owner {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "You can't like your own " + kind,
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if status := likes.Allow(userID); !status.Allowed {
+		retryAfter := int(time.Until(status.Reset).Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+			Error:        "Too Many Requests",
+			Message:      "Like limit exceeded, retry after " + strconv.Itoa(retryAfter) + " seconds",
+			Code:         http.StatusTooManyRequests,
+			Retryable:    true,
+			RetryAfterMs: time.Until(status.Reset).Milliseconds(),
+		})
+		return
+	}
+
+	update := likes.Like
+	if !like {
+		update = likes.Unlike
+	}
+	result, err := update(userID, kind, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save like",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
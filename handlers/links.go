@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"movie-api-go/middleware"
+	"movie-api-go/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LinkBuilder generates hypermedia links for API responses
+type LinkBuilder struct {
+	// BaseURL is the externally visible base URL of the API (e.g. https://movies.example.com).
+	// When empty, links are built from the scheme and host of the incoming request (see
+	// middleware.RequestOrigin).
+	BaseURL string
+	// TrustedProxies are the proxies whose X-Forwarded-Proto is honoured without BaseURL
+	TrustedProxies []*net.IPNet
+}
+
+func NewLinkBuilder(baseURL string, trustedProxies []*net.IPNet) *LinkBuilder {
+	return &LinkBuilder{
+		BaseURL:        strings.TrimRight(baseURL, "/"),
+		TrustedProxies: trustedProxies,
+	}
+}
+
+// MovieLinks returns links for a movie detail response
+func (b *LinkBuilder) MovieLinks(c *gin.Context, title, poster string) models.Links {
+	links := models.Links{
+		"self":    b.link(c, "/api/movie", url.Values{"title": {title}}),
+		"similar": b.link(c, "/api/recommendations", url.Values{"favorite_movie": {title}}),
+	}
+	if poster != "" {
+		links["poster"] = models.Link{Href: poster}
+	}
+	return links
+}
+
+// GameLinks returns links for a video game detail response
+func (b *LinkBuilder) GameLinks(c *gin.Context, title, poster string) models.Links {
+	links := models.Links{
+		"self": b.link(c, "/api/game", url.Values{"title": {title}}),
+	}
+	if poster != "" {
+		links["poster"] = models.Link{Href: poster}
+	}
+	return links
+}
+
+// VersionLinks links another version of a title to its details by IMDb ID
+func (b *LinkBuilder) VersionLinks(c *gin.Context, version models.TitleVersion) models.Links {
+	return models.Links{
+		"self": b.link(c, "/api/movie", url.Values{"imdb_id": {version.ImdbID}}),
+	}
+}
+
+// ChangesLinks returns links for the change history of a title
+func (b *LinkBuilder) ChangesLinks(c *gin.Context, imdbID string) models.Links {
+	return models.Links{
+		"self":  b.link(c, "/api/movie/"+imdbID+"/changes", nil),
+		"title": b.link(c, "/api/movie", url.Values{"imdb_id": {imdbID}}),
+	}
+}
+
+// RatingHistoryLinks returns links for the rating time series of a title
+func (b *LinkBuilder) RatingHistoryLinks(c *gin.Context, imdbID string) models.Links {
+	return models.Links{
+		"self":    b.link(c, "/api/movie/"+imdbID+"/rating-history", nil),
+		"title":   b.link(c, "/api/movie", url.Values{"imdb_id": {imdbID}}),
+		"changes": b.link(c, "/api/movie/"+imdbID+"/changes", nil),
+	}
+}
+
+// LeaderboardLinks returns links for a leaderboard and its other time windows
+func (b *LinkBuilder) LeaderboardLinks(c *gin.Context, board, window string) models.Links {
+	links := models.Links{
+		"self": b.link(c, "/api/leaderboards/"+board, url.Values{"window": {window}}),
+	}
+	for _, other := range []string{"week", "month", "year", "all"} {
+		if other != window {
+			links[other] = b.link(c, "/api/leaderboards/"+board, url.Values{"window": {other}})
+		}
+	}
+	return links
+}
+
+// CollectionLinks returns links for a themed collection
+func (b *LinkBuilder) CollectionLinks(c *gin.Context, slug string) models.Links {
+	return models.Links{
+		"self": b.link(c, "/api/collections/"+url.PathEscape(slug), nil),
+	}
+}
+
+// OscarsLinks returns links for a ceremony's nominees and the neighbouring ceremonies
+// covered by the dataset
+func (b *LinkBuilder) OscarsLinks(c *gin.Context, year int, category string, years []int) models.Links {
+	params := url.Values{}
+	if category != "" {
+		params.Set("category", category)
+	}
+	path := func(year int) string {
+		return "/api/awards/oscars/" + strconv.Itoa(year)
+	}
+
+	links := models.Links{
+		"self": b.link(c, path(year), params),
+	}
+	for i, covered := range years {
+		if covered != year {
+			continue
+		}
+		if i > 0 {
+			links["previous"] = b.link(c, path(years[i-1]), params)
+		}
+		if i < len(years)-1 {
+			links["next"] = b.link(c, path(years[i+1]), params)
+		}
+	}
+	return links
+}
+
+// TitleLinks links a title in a leaderboard or feed to its details
+func (b *LinkBuilder) TitleLinks(c *gin.Context, imdbID string) models.Links {
+	return models.Links{
+		"self": b.link(c, "/api/movie", url.Values{"imdb_id": {imdbID}}),
+	}
+}
+
+// SharePage returns the public URL of a page of the demo UI, such as a title's details
+// at /?imdb_id=tt0133093, for people to open from a shared link or QR code
+func (b *LinkBuilder) SharePage(c *gin.Context, params url.Values) string {
+	return b.baseURL(c) + "/?" + params.Encode()
+}
+
+// ReviewLinks links a review to the reviewed title and its other reviews
+func (b *LinkBuilder) ReviewLinks(c *gin.Context, imdbID string) models.Links {
+	return models.Links{
+		"title":   b.link(c, "/api/movie", url.Values{"imdb_id": {imdbID}}),
+		"reviews": b.link(c, "/api/movie/"+imdbID+"/reviews", nil),
+	}
+}
+
+// ReviewPageLinks returns links for a page of a title's reviews
+func (b *LinkBuilder) ReviewPageLinks(c *gin.Context, imdbID, nextCursor string) models.Links {
+	links := b.PageLinks(c, nextCursor)
+	links["title"] = b.link(c, "/api/movie", url.Values{"imdb_id": {imdbID}})
+	return links
+}
+
+// BriefLinks returns links for a movie listed in a genre or recommendation response
+func (b *LinkBuilder) BriefLinks(c *gin.Context, movie models.MovieBrief) models.Links {
+	links := models.Links{
+		"self":    b.link(c, "/api/movie", url.Values{"title": {movie.Title}}),
+		"similar": b.link(c, "/api/recommendations", url.Values{"favorite_movie": {movie.Title}}),
+	}
+	if movie.Poster != "" {
+		links["poster"] = models.Link{Href: movie.Poster}
+	}
+	return links
+}
+
+// LinkBrief routes a movie's poster as the request asks (see PosterHref) and attaches
+// its links
+func (b *LinkBuilder) LinkBrief(c *gin.Context, movie *models.MovieBrief) {
+	movie.Poster = b.PosterHref(c, movie.ImdbID, movie.Poster)
+	movie.Links = b.BriefLinks(c, *movie)
+}
+
+// PosterHref returns the poster URL of a list entry. With poster=proxy it is the poster
+// proxy's, so clients load thumbnails from the API rather than the image host, and
+// poster_width= asks the proxy for a smaller copy; otherwise it is OMDb's. Titles without
+// a poster keep none.
+func (b *LinkBuilder) PosterHref(c *gin.Context, imdbID, poster string) string {
+	if poster == "" || imdbID == "" || c.Query("poster") != "proxy" {
+		return poster
+	}
+	var params url.Values
+	if width, err := strconv.Atoi(c.Query("poster_width")); err == nil && width > 0 {
+		params = url.Values{"w": {strconv.Itoa(width)}}
+	}
+	return b.link(c, "/api/poster/"+url.PathEscape(imdbID), params).Href
+}
+
+// EpisodeLinks returns links for an episode, including navigation to neighbouring episodes
+// and, when the IMDb ID is known, the episode by ID
+func (b *LinkBuilder) EpisodeLinks(c *gin.Context, seriesTitle string, season, episode int, imdbID, poster string) models.Links {
+	links := models.Links{
+		"self": b.episodeLink(c, seriesTitle, season, episode),
+		"next": b.episodeLink(c, seriesTitle, season, episode+1),
+	}
+	if imdbID != "" {
+		links["by_id"] = b.link(c, "/api/episode/id/"+url.PathEscape(imdbID), nil)
+	}
+	if episode > 1 {
+		links["previous"] = b.episodeLink(c, seriesTitle, season, episode-1)
+	}
+	if season > 1 {
+		links["previous_season"] = b.episodeLink(c, seriesTitle, season-1, 1)
+	}
+	links["next_season"] = b.episodeLink(c, seriesTitle, season+1, 1)
+	if poster != "" {
+		links["poster"] = models.Link{Href: poster}
+	}
+	return links
+}
+
+// EpisodeRangeLinks returns links for an episode range, including the adjacent ranges
+func (b *LinkBuilder) EpisodeRangeLinks(c *gin.Context, seriesTitle string, season, from, to int) models.Links {
+	size := to - from + 1
+	links := models.Links{
+		"self": b.episodeRangeLink(c, seriesTitle, season, from, to),
+		"next": b.episodeRangeLink(c, seriesTitle, season, to+1, to+size),
+	}
+	if from > 1 {
+		start := from - size
+		if start < 1 {
+			start = 1
+		}
+		links["previous"] = b.episodeRangeLink(c, seriesTitle, season, start, from-1)
+	}
+	return links
+}
+
+// GenreLinks returns links for a genre list response
+func (b *LinkBuilder) GenreLinks(c *gin.Context, genre string) models.Links {
+	return models.Links{
+		"self": b.link(c, "/api/movies/genre", url.Values{"genre": {genre}}),
+	}
+}
+
+// RecommendationLinks returns links for a recommendation response
+func (b *LinkBuilder) RecommendationLinks(c *gin.Context, favoriteTitle string) models.Links {
+	return models.Links{
+		"self":     b.link(c, "/api/recommendations", url.Values{"favorite_movie": {favoriteTitle}}),
+		"favorite": b.link(c, "/api/movie", url.Values{"title": {favoriteTitle}}),
+	}
+}
+
+// PageLinks returns links for a page of results, including the next page when available
+func (b *LinkBuilder) PageLinks(c *gin.Context, nextCursor string) models.Links {
+	params := c.Request.URL.Query()
+	links := models.Links{
+		"self": b.link(c, c.Request.URL.Path, params),
+	}
+	if nextCursor != "" {
+		params.Set("cursor", nextCursor)
+		links["next"] = b.link(c, c.Request.URL.Path, params)
+	}
+	return links
+}
+
+// LevelLinks returns the link to load one recommendation level on its own
+func (b *LinkBuilder) LevelLinks(c *gin.Context, favoriteTitle string, level int) models.Links {
+	return models.Links{
+		"self": b.link(c, "/api/recommendations", url.Values{"favorite_movie": {favoriteTitle}, "level": {strconv.Itoa(level)}}),
+	}
+}
+
+// ItemLinks returns links for a single search result
+func (b *LinkBuilder) ItemLinks(c *gin.Context, item models.SearchItem) models.Links {
+	links := models.Links{}
+	switch item.Type {
+	case "movie":
+		links["self"] = b.link(c, "/api/movie", url.Values{"title": {item.Title}})
+	case "game":
+		links["self"] = b.link(c, "/api/game", url.Values{"title": {item.Title}})
+	}
+	if item.Poster != "" {
+		links["poster"] = models.Link{Href: item.Poster}
+	}
+	return links
+}
+
+// AddBriefLinks routes the poster of every movie in the slice and attaches its links
+func (b *LinkBuilder) AddBriefLinks(c *gin.Context, movies []models.MovieBrief) {
+	for i := range movies {
+		b.LinkBrief(c, &movies[i])
+	}
+}
+
+func (b *LinkBuilder) episodeLink(c *gin.Context, seriesTitle string, season, episode int) models.Link {
+	return b.link(c, "/api/episode", url.Values{
+		"series_title":   {seriesTitle},
+		"season":         {strconv.Itoa(season)},
+		"episode_number": {strconv.Itoa(episode)},
+	})
+}
+
+func (b *LinkBuilder) episodeRangeLink(c *gin.Context, seriesTitle string, season, from, to int) models.Link {
+	return b.link(c, "/api/episodes", url.Values{
+		"series_title": {seriesTitle},
+		"season":       {strconv.Itoa(season)},
+		"from":         {strconv.Itoa(from)},
+		"to":           {strconv.Itoa(to)},
+	})
+}
+
+func (b *LinkBuilder) link(c *gin.Context, path string, params url.Values) models.Link {
+	href := b.baseURL(c) + path
+	if len(params) > 0 {
+		href += "?" + params.Encode()
+	}
+	return models.Link{Href: href}
+}
+
+func (b *LinkBuilder) baseURL(c *gin.Context) string {
+	if b.BaseURL != "" {
+		return b.BaseURL
+	}
+	return middleware.RequestOrigin(c, b.TrustedProxies)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"movie-api-go/middleware"
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MonitorHandler lets clients register rating alerts. Monitors belong to the client that
+// created them, identified like for rate limiting (API key, else IP address).
+type MonitorHandler struct {
+	monitors *services.MonitorService
+	policy   *services.AccessPolicy
+}
+
+func NewMonitorHandler(monitors *services.MonitorService, policy *services.AccessPolicy) *MonitorHandler {
+	return &MonitorHandler{monitors: monitors, policy: policy}
+}
+
+// CreateMonitor handles POST /api/monitors with body
+// {"imdb_id": "tt0133093", "field": "imdb_rating", "condition": "below", "threshold": 7, "webhook": "https://..."}
+func (h *MonitorHandler) CreateMonitor(c *gin.Context) {
+	// Monitors call out to webhooks; anonymous callers, whom ANONYMOUS_ROLE may grant
+	// monitors:write, can't create them
+	if middleware.CurrentCaller(c, h.policy).Credential == "" {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Message: "Creating a monitor requires a user token or an API key",
+			Code:    http.StatusUnauthorized,
+		})
+		return
+	}
+
+	var req models.MonitorRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !imdbIDPattern.MatchString(req.ImdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with a valid imdb_id (e.g. tt0133093), field and condition",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	monitor, err := h.monitors.Create(c.Request.Context(), middleware.ClientID(c, h.policy), req)
+	switch {
+	case errors.Is(err, services.ErrInvalidMonitor):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	case errors.Is(err, services.ErrMonitorLimit):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Conflict",
+			Message: "Monitor limit reached; delete a monitor first",
+			Code:    http.StatusConflict,
+		})
+		return
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Title not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	case err != nil:
+		upstreamFailure(c, err, "Failed to create monitor")
+		return
+	}
+
+	c.JSON(http.StatusCreated, monitor)
+}
+
+// ListMonitors handles GET /api/monitors
+func (h *MonitorHandler) ListMonitors(c *gin.Context) {
+	monitors := h.monitors.List(middleware.ClientID(c, h.policy))
+
+	c.JSON(http.StatusOK, gin.H{
+		"monitors": monitors,
+		"total":    len(monitors),
+	})
+}
+
+// DeleteMonitor handles DELETE /api/monitors/:id
+func (h *MonitorHandler) DeleteMonitor(c *gin.Context) {
+	deleted, err := h.monitors.Delete(middleware.ClientID(c, h.policy), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to delete monitor",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Monitor not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultOnboardingTitles is the size of the onboarding sample unless count= is given
+const defaultOnboardingTitles = 12
+
+// maxOnboardingRatings bounds the ratings of one submission
+const maxOnboardingRatings = 50
+
+// OnboardingHandler serves the cold-start flow: rate a sample, get recommendations
+type OnboardingHandler struct {
+	onboarding  *services.Onboarding
+	ratings     *services.RatingStore
+	preferences *services.PreferenceStore
+	tags        *services.TagStore
+	links       *LinkBuilder
+}
+
+func NewOnboardingHandler(onboarding *services.Onboarding, ratings *services.RatingStore, preferences *services.PreferenceStore, tags *services.TagStore, links *LinkBuilder) *OnboardingHandler {
+	return &OnboardingHandler{
+		onboarding:  onboarding,
+		ratings:     ratings,
+		preferences: preferences,
+		tags:        tags,
+		links:       links,
+	}
+}
+
+// GetTitles handles GET /api/onboarding/titles?count=12
+func (h *OnboardingHandler) GetTitles(c *gin.Context) {
+	count := defaultOnboardingTitles
+	if raw := c.Query("count"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > h.onboarding.Size() {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "count must be a number between 1 and " + strconv.Itoa(h.onboarding.Size()),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		count = parsed
+	}
+
+	titles := h.onboarding.Titles(c.Request.Context(), count)
+	for i := range titles {
+		titles[i].Poster = h.links.PosterHref(c, titles[i].ImdbID, titles[i].Poster)
+	}
+	c.JSON(http.StatusOK, models.OnboardingTitlesResponse{
+		Titles: titles,
+		Total:  len(titles),
+		Errors: services.ScopeFrom(c.Request.Context()).FailedItems(),
+		Meta:   services.ScopeFrom(c.Request.Context()).Meta(),
+	})
+}
+
+// PostRatings handles POST /api/onboarding/ratings with body
+// {"ratings": [{"imdb_id": "tt0133093", "rating": 9}, {"imdb_id": "tt0081505", "rating": 3}]}
+// The logged-in user's preferences apply to the recommendations (see discoveryFilter).
+func (h *OnboardingHandler) PostRatings(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	var req models.OnboardingRatingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Ratings) == 0 || len(req.Ratings) > maxOnboardingRatings {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with 1 to " + strconv.Itoa(maxOnboardingRatings) + " ratings",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	filter, ok := discoveryFilter(c, h.preferences, h.tags)
+	if !ok {
+		return
+	}
+
+	response, err := h.onboarding.Rate(c.Request.Context(), user.Subject, req.Ratings)
+	if errors.Is(err, services.ErrInvalidRating) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		upstreamFailure(c, err, "Failed to generate recommendations")
+		return
+	}
+
+	response.Recommendations = filter.Apply(response.Recommendations)
+	response.Meta = services.ScopeFrom(c.Request.Context()).Meta()
+	h.links.AddBriefLinks(c, response.Seeds)
+	h.links.AddBriefLinks(c, response.Recommendations)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetRatings handles GET /api/me/ratings
+func (h *OnboardingHandler) GetRatings(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	ratings := h.ratings.List(user.Subject)
+	c.JSON(http.StatusOK, gin.H{
+		"ratings": ratings,
+		"total":   len(ratings),
+	})
+}
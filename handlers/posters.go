@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPosterWidth bounds the width of resized posters
+const maxPosterWidth = 2000
+
+// PosterHandler serves poster images through the API and hands out signed links to them
+type PosterHandler struct {
+	posters *services.PosterService
+	signer  *services.URLSigner
+	links   *LinkBuilder
+}
+
+func NewPosterHandler(posters *services.PosterService, signer *services.URLSigner, links *LinkBuilder) *PosterHandler {
+	return &PosterHandler{
+		posters: posters,
+		signer:  signer,
+		links:   links,
+	}
+}
+
+// GetPoster handles GET /api/poster/:imdbID, or a smaller or converted copy of the poster
+// with w=300&format=jpeg
+func (h *PosterHandler) GetPoster(c *gin.Context) {
+	imdbID := c.Param("imdbID")
+	if !imdbIDPattern.MatchString(imdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "imdbID must be a valid IMDb ID (e.g. tt0133093)",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	width, format, ok := posterVariant(c)
+	if !ok {
+		return
+	}
+
+	var poster *services.Poster
+	var err error
+	if width == 0 && format == "" {
+		poster, err = h.posters.Get(c.Request.Context(), imdbID)
+	} else {
+		poster, err = h.posters.Variant(c.Request.Context(), imdbID, width, format)
+	}
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Title not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	case errors.Is(err, services.ErrNoPoster):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "This title has no poster",
+			Code:    http.StatusNotFound,
+		})
+		return
+	case err != nil:
+		upstreamFailure(c, err, "Failed to fetch poster")
+		return
+	}
+
+	c.Header("Cache-Control", posterCacheControl(c))
+	c.Data(http.StatusOK, poster.ContentType, poster.Data)
+}
+
+// posterCacheControl lets shared caches keep a poster for a day. A poster opened with a
+// signed URL may only be kept by the browser, and not past the URL's expiry, so that a
+// cache doesn't go on serving it once the link is no longer valid.
+func posterCacheControl(c *gin.Context) string {
+	const maxAge = 86400
+	if _, signed := c.GetQuery("signature"); !signed {
+		return "public, max-age=" + strconv.Itoa(maxAge)
+	}
+
+	expires, _ := strconv.ParseInt(c.Query("expires"), 10, 64)
+	remaining := expires - time.Now().Unix()
+	if remaining < 0 {
+		remaining = 0
+	}
+	if remaining > maxAge {
+		remaining = maxAge
+	}
+	return "private, max-age=" + strconv.FormatInt(remaining, 10)
+}
+
+// SignPoster handles GET /api/poster/:imdbID/signed?ttl=3600&w=300&format=jpeg, returning
+// a link to the poster that works without credentials until it expires
+func (h *PosterHandler) SignPoster(c *gin.Context) {
+	if h.signer == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Signed URLs are disabled. Set URL_SIGNING_SECRET to enable them",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	imdbID := c.Param("imdbID")
+	if !imdbIDPattern.MatchString(imdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "imdbID must be a valid IMDb ID (e.g. tt0133093)",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var ttl time.Duration
+	if raw := c.Query("ttl"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 1 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "ttl must be a positive number of seconds",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+
+	width, format, ok := posterVariant(c)
+	if !ok {
+		return
+	}
+	params := url.Values{}
+	if width > 0 {
+		params.Set("w", strconv.Itoa(width))
+	}
+	if format != "" {
+		params.Set("format", format)
+	}
+
+	signed, expires := h.signer.Sign("/api/poster/"+imdbID, params, ttl)
+	c.JSON(http.StatusOK, models.SignedURL{
+		URL:       h.links.baseURL(c) + signed,
+		ExpiresAt: expires.UTC(),
+	})
+}
+
+// posterVariant parses the w (16 to maxPosterWidth pixels) and format (jpeg or png)
+// parameters; zero and "" leave the poster as it is. If they are invalid, the error
+// response has been written and ok is false.
+func posterVariant(c *gin.Context) (width int, format string, ok bool) {
+	if raw := c.Query("w"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 16 || parsed > maxPosterWidth {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "w must be a width from 16 to " + strconv.Itoa(maxPosterWidth) + " pixels",
+				Code:    http.StatusBadRequest,
+			})
+			return 0, "", false
+		}
+		width = parsed
+	}
+
+	switch format = strings.ToLower(c.Query("format")); format {
+	case "", services.FormatJPEG, services.FormatPNG:
+	case "jpg":
+		format = services.FormatJPEG
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "format must be jpeg or png; this server has no " + format + " encoder (use jpeg for the smallest posters)",
+			Code:    http.StatusBadRequest,
+		})
+		return 0, "", false
+	}
+	return width, format, true
+}
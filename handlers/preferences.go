@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"movie-api-go/middleware"
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreferencesHandler lets users keep the defaults applied to the discovery endpoints
+type PreferencesHandler struct {
+	preferences *services.PreferenceStore
+}
+
+func NewPreferencesHandler(preferences *services.PreferenceStore) *PreferencesHandler {
+	return &PreferencesHandler{preferences: preferences}
+}
+
+// GetPreferences handles GET /api/me/preferences
+func (h *PreferencesHandler) GetPreferences(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.preferences.Get(user.Subject))
+}
+
+// PutPreferences handles PUT /api/me/preferences with body
+// {"preferred_genres": ["Sci-Fi"], "disliked_genres": ["Horror"], "max_runtime_minutes": 150, "language": "English", "max_content_rating": "PG-13"}
+func (h *PreferencesHandler) PutPreferences(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	var req models.Preferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be a JSON preference profile",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	prefs, err := h.preferences.Set(user.Subject, req)
+	if errors.Is(err, services.ErrInvalidPreferences) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save preferences",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// loggedIn returns the logged-in user, writing a 401 for anonymous callers
+func loggedIn(c *gin.Context) (services.Claims, bool) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Message: "Log in and send the token as Authorization: Bearer <token>",
+			Code:    http.StatusUnauthorized,
+		})
+	}
+	return user, ok
+}
+
+// discoveryFilter combines the logged-in user's preferences with the request's explicit
+// settings, which take precedence: prefer_genres, exclude_genres, max_runtime, language
+// and max_rating. preferences=false ignores the stored profile. tags= keeps the movies
+// carrying all the listed tags, min_metascore= those critics rate at least that and
+// min_completeness= those whose records are at least that complete.
+// weights= replaces the caller's blended score weights, e.g. imdb:2,metascore:1. If a parameter is invalid, the error response has been
+// written and ok is false.
+func discoveryFilter(c *gin.Context, preferences *services.PreferenceStore, tags *services.TagStore) (filter services.DiscoveryFilter, ok bool) {
+	var prefs models.Preferences
+	if user, loggedIn := middleware.CurrentUser(c); loggedIn && c.Query("preferences") != "false" {
+		prefs = preferences.Get(user.Subject)
+	}
+
+	if genres, set := c.GetQuery("prefer_genres"); set {
+		prefs.PreferredGenres = strings.Split(genres, ",")
+	}
+	if genres, set := c.GetQuery("exclude_genres"); set {
+		prefs.DislikedGenres = strings.Split(genres, ",")
+	}
+	if runtime, set := c.GetQuery("max_runtime"); set {
+		minutes, err := strconv.Atoi(runtime)
+		if runtime != "" && (err != nil || minutes < 0) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "max_runtime must be a number of minutes",
+				Code:    http.StatusBadRequest,
+			})
+			return filter, false
+		}
+		prefs.MaxRuntime = minutes
+	}
+	if language, set := c.GetQuery("language"); set {
+		prefs.Language = language
+	}
+	if rating, set := c.GetQuery("max_rating"); set {
+		prefs.MaxContentRating = rating
+	}
+
+	filter, err := services.NewDiscoveryFilter(prefs)
+	if err == nil {
+		var required []string
+		if list := c.Query("tags"); list != "" {
+			required = strings.Split(list, ",")
+		}
+		filter, err = filter.WithTags(tags, required)
+	}
+	if score := c.Query("min_metascore"); err == nil && score != "" {
+		filter.MinMetascore, err = strconv.Atoi(score)
+		if err != nil || filter.MinMetascore < 0 || filter.MinMetascore > 100 {
+			err = errors.New("min_metascore must be a number from 0 to 100")
+		}
+	}
+	if score := c.Query("min_completeness"); err == nil && score != "" {
+		filter.MinCompleteness, err = strconv.Atoi(score)
+		if err != nil || filter.MinCompleteness < 0 || filter.MinCompleteness > 100 {
+			err = errors.New("min_completeness must be a number from 0 to 100")
+		}
+	}
+	filter.Weights = services.ScoreWeightsFrom(c.Request.Context())
+	if spec := c.Query("weights"); err == nil && spec != "" {
+		filter.Weights, err = services.ParseScoreWeights(spec)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return filter, false
+	}
+	return filter, true
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QR code sizes in pixels
+const (
+	defaultQRSize = 512
+	minQRSize     = 64
+	maxQRSize     = 2048
+)
+
+// QRHandler serves QR codes of the public pages of titles and watchlists, for printed
+// flyers and for sharing from a TV screen
+type QRHandler struct {
+	omdb    *services.OMDbService
+	users   *services.UserStore
+	privacy *services.PrivacyStore
+	reports *services.ReportStore
+	links   *LinkBuilder
+}
+
+func NewQRHandler(omdb *services.OMDbService, users *services.UserStore, privacy *services.PrivacyStore, reports *services.ReportStore, links *LinkBuilder) *QRHandler {
+	return &QRHandler{
+		omdb:    omdb,
+		users:   users,
+		privacy: privacy,
+		reports: reports,
+		links:   links,
+	}
+}
+
+// MovieQR handles GET /api/movie/:imdbID/qr.png?size=512&ec=M, a QR code of the title's
+// page in the UI
+func (h *QRHandler) MovieQR(c *gin.Context) {
+	imdbID := c.Param("imdbID")
+	if !imdbIDPattern.MatchString(imdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "imdbID must be a valid IMDb ID (e.g. tt0133093)",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	size, level, ok := qrOptions(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.omdb.GetTitleByID(c.Request.Context(), imdbID)
+	if err != nil {
+		upstreamFailure(c, err, "Failed to fetch title")
+		return
+	}
+	if record.Response == "False" {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Title not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	writeQR(c, h.links.SharePage(c, url.Values{"imdb_id": {imdbID}}), level, size)
+}
+
+// WatchlistQR handles GET /api/users/:userID/watchlist/qr.png?size=512&ec=M, a QR code of
+// the watchlist's page in the UI. Whoever scans it isn't logged in, so only watchlists
+// shared with everyone have one.
+func (h *QRHandler) WatchlistQR(c *gin.Context) {
+	userID := c.Param("userID")
+	if _, exists := h.users.Get(userID); !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if !h.privacy.CanView("", userID, services.PrivacyLists) || h.reports.Hidden(services.ContentList, userID) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Forbidden",
+			Message: "Only watchlists shared with everyone have a QR code",
+			Code:    http.StatusForbidden,
+		})
+		return
+	}
+	size, level, ok := qrOptions(c)
+	if !ok {
+		return
+	}
+
+	writeQR(c, h.links.SharePage(c, url.Values{"watchlist": {userID}}), level, size)
+}
+
+// qrOptions parses the size (minQRSize to maxQRSize pixels) and ec (L, M, Q or H)
+// parameters. If they are invalid, the error response has been written and ok is false.
+func qrOptions(c *gin.Context) (size int, level string, ok bool) {
+	size = defaultQRSize
+	if raw := c.Query("size"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < minQRSize || parsed > maxQRSize {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "size must be from " + strconv.Itoa(minQRSize) + " to " + strconv.Itoa(maxQRSize) + " pixels",
+				Code:    http.StatusBadRequest,
+			})
+			return 0, "", false
+		}
+		size = parsed
+	}
+
+	switch level = strings.ToUpper(c.DefaultQuery("ec", services.QRMedium)); level {
+	case services.QRLow, services.QRMedium, services.QRQuartile, services.QRHigh:
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "ec must be L, M, Q or H",
+			Code:    http.StatusBadRequest,
+		})
+		return 0, "", false
+	}
+	return size, level, true
+}
+
+// writeQR responds with a QR code holding text
+func writeQR(c *gin.Context, text, level string, size int) {
+	code, err := services.QRCode(text, level, size)
+	if errors.Is(err, services.ErrQRTooLong) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "The link is too long for a QR code",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to draw QR code",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "image/png", code)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueryHandler serves discovery queries that combine several filters in one call
+type QueryHandler struct {
+	queries *services.QueryService
+	posters *services.PosterService
+	links   *LinkBuilder
+}
+
+func NewQueryHandler(queries *services.QueryService, posters *services.PosterService, links *LinkBuilder) *QueryHandler {
+	return &QueryHandler{
+		queries: queries,
+		posters: posters,
+		links:   links,
+	}
+}
+
+// Query handles POST /api/query?explain=true with a JSON query such as
+// {"filters": {"genres": ["Sci-Fi"], "year": {"min": 1990}, "people": ["Keanu Reeves"]}, "sort": "-rating", "limit": 10}
+func (h *QueryHandler) Query(c *gin.Context) {
+	var req models.QueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be a JSON query with filters, sort and limit",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	query, err := h.queries.Plan(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	explainer, ok := explainRequest(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.queries.Run(c.Request.Context(), query)
+	if err != nil {
+		upstreamFailure(c, err, "Failed to run query")
+		return
+	}
+
+	movies := result.Movies
+	if len(movies) == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "No titles match the query",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	for i := range movies {
+		if hash, ok := h.posters.Blurhashes.Get(movies[i].ImdbID); ok {
+			movies[i].Blurhash = hash
+		}
+	}
+	h.links.AddBriefLinks(c, movies)
+
+	streamJSON(c, http.StatusOK, models.QueryResponse{
+		Movies:           movies,
+		Total:            len(movies),
+		ProviderFallback: result.ProviderFallback,
+		Errors:           services.ScopeFrom(c.Request.Context()).FailedItems(),
+		Meta:             services.ScopeFrom(c.Request.Context()).Meta(),
+		Explain:          explainer.Explanation(),
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReportHandler lets logged-in users report reviews and watchlists to moderators
+type ReportHandler struct {
+	reports *services.ReportStore
+	reviews *services.ReviewStore
+	users   *services.UserStore
+	privacy *services.PrivacyStore
+}
+
+func NewReportHandler(reports *services.ReportStore, reviews *services.ReviewStore, users *services.UserStore, privacy *services.PrivacyStore) *ReportHandler {
+	return &ReportHandler{reports: reports, reviews: reviews, users: users, privacy: privacy}
+}
+
+// PostReport handles POST /api/reports with body
+// {"kind": "review", "id": "r_1c581678e2a52ce8", "reason": "spam", "comment": "..."}
+func (h *ReportHandler) PostReport(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with the kind and id of the reported content and a reason",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	// Users can only report content they can see
+	owner, found := h.owner(req, user.Subject)
+	if !found {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "No " + req.Kind + " found with ID " + req.ID,
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	report, created, err := h.reports.File(user.Subject, owner, req)
+	if errors.Is(err, services.ErrInvalidReport) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save report",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+	c.JSON(status, report)
+}
+
+// owner returns the owner of the reported content if the reporter can see it. Unknown
+// kinds are left for the report store to reject.
+func (h *ReportHandler) owner(req models.ReportRequest, reporter string) (string, bool) {
+	switch req.Kind {
+	case services.ContentReview:
+		review, ok := h.reviews.Get(req.ID)
+		if !ok || !h.reviews.Visible(review, reporter) {
+			return "", false
+		}
+		return review.UserID, true
+	case services.ContentList:
+		if _, ok := h.users.Get(req.ID); !ok || !h.privacy.CanView(reporter, req.ID, services.PrivacyLists) {
+			return "", false
+		}
+		return req.ID, true
+	}
+	return "", true
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resolveTitle resolves a title reference through the shared resolver. If it can't be
+// resolved, the error response has been written and ok is false.
+func (h *MovieHandler) resolveTitle(c *gin.Context, query services.ResolveQuery, notFoundMessage, failureMessage string) (*models.Resolution, *models.OMDbResponse, bool) {
+	resolution, record, err := h.resolver.Resolve(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Not Found",
+				Message: notFoundMessage,
+				Code:    http.StatusNotFound,
+			})
+			return nil, nil, false
+		}
+
+		upstreamFailure(c, err, failureMessage)
+		return nil, nil, false
+	}
+
+	return resolution, record, true
+}
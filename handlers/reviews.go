@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultReviewLimit and maxReviewLimit bound the reviews of one page
+const (
+	defaultReviewLimit = 20
+	maxReviewLimit     = 100
+)
+
+// ReviewHandler lets logged-in users review titles and everyone read the reviews
+type ReviewHandler struct {
+	omdb    *services.OMDbService
+	reviews *services.ReviewStore
+	likes   *services.LikeStore
+	links   *LinkBuilder
+}
+
+func NewReviewHandler(omdb *services.OMDbService, reviews *services.ReviewStore, likes *services.LikeStore, links *LinkBuilder) *ReviewHandler {
+	return &ReviewHandler{omdb: omdb, reviews: reviews, likes: likes, links: links}
+}
+
+// PostReview handles POST /api/movie/:imdbID/reviews with body
+// {"rating": 9, "body": "Still the best action film of its decade.", "spoiler": false}
+func (h *ReviewHandler) PostReview(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+	imdbID, ok := reviewedTitle(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON with a rating from 1 to 10 and a body",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	// Only titles OMDb knows can be reviewed
+	title, err := h.omdb.GetTitleByID(c.Request.Context(), imdbID)
+	if err != nil {
+		upstreamFailure(c, err, "Failed to look up the reviewed title")
+		return
+	}
+	if title.Response == "False" {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "No title found with IMDb ID " + imdbID,
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	review, err := h.reviews.Post(user.Subject, imdbID, req)
+	if errors.Is(err, services.ErrInvalidReview) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save review",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	if hideSpoilers(c) {
+		review = services.HideSpoilers(review)
+	}
+	review.Links = h.links.ReviewLinks(c, review.ImdbID)
+
+	status := http.StatusCreated
+	if review.UpdatedAt != nil {
+		status = http.StatusOK
+	}
+	c.JSON(status, review)
+}
+
+// GetReviews handles GET /api/movie/:imdbID/reviews?sort=top&limit=20&cursor=Cursor&hide_spoilers=true
+func (h *ReviewHandler) GetReviews(c *gin.Context) {
+	imdbID, ok := reviewedTitle(c)
+	if !ok {
+		return
+	}
+	order := c.DefaultQuery("sort", services.ReviewSortNewest)
+	if order != services.ReviewSortNewest && order != services.ReviewSortTop {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "sort must be newest or top",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	cursor, limit, ok := cursorPage(c, defaultReviewLimit, maxReviewLimit)
+	if !ok {
+		return
+	}
+
+	page := h.reviews.Page(imdbID, viewer(c), order, cursor, limit)
+	for i := range page.Reviews {
+		if hideSpoilers(c) {
+			page.Reviews[i] = services.HideSpoilers(page.Reviews[i])
+		}
+		page.Reviews[i].Links = h.links.ReviewLinks(c, imdbID)
+	}
+	c.JSON(http.StatusOK, models.ReviewsResponse{
+		ImdbID:     imdbID,
+		Reviews:    page.Reviews,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		Links:      h.links.ReviewPageLinks(c, imdbID, page.NextCursor),
+	})
+}
+
+// LikeReview handles POST /api/reviews/:reviewID/like
+func (h *ReviewHandler) LikeReview(c *gin.Context) {
+	h.like(c, true)
+}
+
+// UnlikeReview handles DELETE /api/reviews/:reviewID/like
+func (h *ReviewHandler) UnlikeReview(c *gin.Context) {
+	h.like(c, false)
+}
+
+func (h *ReviewHandler) like(c *gin.Context, like bool) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	// Only published reviews that reports don't hide can be liked
+	review, found := h.reviews.Get(c.Param("reviewID"))
+	if !found || !h.reviews.Visible(review, "") {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "No published review found with ID " + c.Param("reviewID"),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	writeLike(c, h.likes, user.Subject, review.UserID, services.ContentReview, review.ID, like)
+}
+
+// reviewedTitle returns the imdbID parameter. If it isn't an IMDb ID, the error response
+// has been written and ok is false.
+func reviewedTitle(c *gin.Context) (string, bool) {
+	imdbID := c.Param("imdbID")
+	if !imdbIDPattern.MatchString(imdbID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "imdbID must be an IMDb title ID such as tt0133093",
+			Code:    http.StatusBadRequest,
+		})
+		return imdbID, false
+	}
+	return imdbID, true
+}
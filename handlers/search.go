@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+
+	// seriesEnrichmentLimit is how many series results get their totalSeasons looked up
+	seriesEnrichmentLimit = 5
+)
+
+// allowedSearchTypes lists the OMDb result types accepted by the search endpoint
+var allowedSearchTypes = map[string]bool{
+	"movie":   true,
+	"series":  true,
+	"episode": true,
+	"game":    true,
+}
+
+// SearchTitles handles GET /api/search?q=Query&type=movie&year=1999&limit=10&enrich=false&spellcheck=true&cursor=Cursor
+func (h *MovieHandler) SearchTitles(c *gin.Context) {
+	titleType := c.DefaultQuery("type", "movie")
+	if !allowedSearchTypes[titleType] {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "type must be one of movie, series, episode, game",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	response, ok := h.searchTitles(c, titleType)
+	if !ok {
+		return
+	}
+
+	streamJSON(c, http.StatusOK, response)
+}
+
+// SearchSeries handles GET /api/search/series?q=Query&year=2008&limit=10&cursor=Cursor
+func (h *MovieHandler) SearchSeries(c *gin.Context) {
+	response, ok := h.searchTitles(c, "series")
+	if !ok {
+		return
+	}
+
+	// Enrich the top results with their season count
+	var imdbIDs []string
+	for i := 0; i < len(response.Results) && i < seriesEnrichmentLimit; i++ {
+		imdbIDs = append(imdbIDs, response.Results[i].ImdbID)
+	}
+	totalSeasons := h.omdbService.GetTotalSeasons(c.Request.Context(), imdbIDs)
+	for i := range response.Results {
+		response.Results[i].TotalSeasons = totalSeasons[response.Results[i].ImdbID]
+	}
+	response.Errors = services.ScopeFrom(c.Request.Context()).FailedItems()
+	response.Meta = services.ScopeFrom(c.Request.Context()).Meta()
+
+	streamJSON(c, http.StatusOK, response)
+}
+
+// searchTitles runs a paginated search restricted to titleType. If the request is invalid
+// or the search fails, the error response has been written and ok is false.
+func (h *MovieHandler) searchTitles(c *gin.Context, titleType string) (response *models.SearchTitlesResponse, ok bool) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "q parameter is required",
+			Code:    http.StatusBadRequest,
+		})
+		return nil, false
+	}
+
+	limit := defaultSearchLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "limit must be a number between 1 and 50",
+				Code:    http.StatusBadRequest,
+			})
+			return nil, false
+		}
+		limit = parsed
+	}
+
+	enrich, err := strconv.ParseBool(c.DefaultQuery("enrich", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "enrich must be true or false",
+			Code:    http.StatusBadRequest,
+		})
+		return nil, false
+	}
+
+	spellcheck, err := strconv.ParseBool(c.DefaultQuery("spellcheck", "true"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "spellcheck must be true or false",
+			Code:    http.StatusBadRequest,
+		})
+		return nil, false
+	}
+
+	cursor, err := services.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "cursor is invalid",
+			Code:    http.StatusBadRequest,
+		})
+		return nil, false
+	}
+
+	// Correct typos against known titles before searching, since OMDb matches words exactly.
+	// Later pages reuse the query of the first one, which the cursor carries.
+	searchQuery, correctedQuery := query, ""
+	if cursor.Query != "" {
+		searchQuery = cursor.Query
+	} else if spellcheck {
+		searchQuery, _ = h.omdbService.Dictionary.Correct(query)
+	}
+	if searchQuery != query {
+		correctedQuery = searchQuery
+	}
+
+	page, err := h.omdbService.SearchTitles(c.Request.Context(), searchQuery, titleType, c.Query("year"), cursor, limit)
+	if err != nil {
+		upstreamFailure(c, err, "Failed to search titles")
+		return nil, false
+	}
+
+	if len(page.Results) == 0 && c.Query("cursor") == "" {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "No titles found for the specified query",
+			Code:    http.StatusNotFound,
+		})
+		return nil, false
+	}
+
+	// Optionally expand every result with its full record
+	var details map[string]*models.OMDbResponse
+	if enrich {
+		imdbIDs := make([]string, 0, len(page.Results))
+		for _, result := range page.Results {
+			imdbIDs = append(imdbIDs, result.ImdbID)
+		}
+		details = h.omdbService.GetTitlesByID(services.WithPriority(c.Request.Context(), services.PriorityEnrichment), imdbIDs)
+	}
+
+	results := make([]models.SearchItem, 0, len(page.Results))
+	for _, result := range page.Results {
+		item := models.SearchItem{
+			Title:  result.Title,
+			Year:   result.Year,
+			ImdbID: result.ImdbID,
+			Type:   result.Type,
+			Poster: h.links.PosterHref(c, result.ImdbID, result.Poster),
+		}
+		if record, ok := details[result.ImdbID]; ok {
+			item.ImdbRating = record.ImdbRating
+			item.Genre = record.Genre
+			item.Director = record.Director
+			item.Plot = record.Plot
+			// Drop the full record as soon as its fields are copied
+			delete(details, result.ImdbID)
+		}
+		item.Links = h.links.ItemLinks(c, item)
+		results = append(results, item)
+	}
+
+	return &models.SearchTitlesResponse{
+		Query:          query,
+		CorrectedQuery: correctedQuery,
+		Type:           titleType,
+		Results:        results,
+		Total:          len(results),
+		TotalResults:   page.TotalResults,
+		Enriched:       enrich,
+		NextCursor:     page.NextCursor,
+		Links:          h.links.PageLinks(c, page.NextCursor),
+		Errors:         services.ScopeFrom(c.Request.Context()).FailedItems(),
+		Meta:           services.ScopeFrom(c.Request.Context()).Meta(),
+	}, true
+}
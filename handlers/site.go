@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SiteHandler serves the crawler endpoints for the demo UI
+type SiteHandler struct {
+	aliases    *services.AliasStore
+	links      *LinkBuilder
+	robotsPath string
+}
+
+// NewSiteHandler reads ROBOTS_TXT_PATH, a file replacing the generated robots.txt
+func NewSiteHandler(aliases *services.AliasStore, links *LinkBuilder) *SiteHandler {
+	return &SiteHandler{
+		aliases:    aliases,
+		links:      links,
+		robotsPath: os.Getenv("ROBOTS_TXT_PATH"),
+	}
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Sitemap handles GET /sitemap.xml. It lists the UI and a detail page for every title
+// curated through the alias table, which are the titles the deployment wants found.
+func (h *SiteHandler) Sitemap(c *gin.Context) {
+	base := h.links.baseURL(c)
+	urlSet := sitemapURLSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  []sitemapURL{{Loc: base + "/"}},
+	}
+
+	seen := make(map[string]bool)
+	for _, alias := range h.aliases.List() {
+		if seen[alias.ImdbID] {
+			continue
+		}
+		seen[alias.ImdbID] = true
+		urlSet.URLs = append(urlSet.URLs, sitemapURL{
+			Loc:     base + "/?" + url.Values{"imdb_id": {alias.ImdbID}}.Encode(),
+			LastMod: alias.UpdatedAt.UTC().Format("2006-01-02"),
+		})
+	}
+
+	body, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
+
+// Robots handles GET /robots.txt. Without ROBOTS_TXT_PATH, crawlers are kept out of the
+// admin routes only; the UI renders its pages from /api, so that must stay reachable.
+func (h *SiteHandler) Robots(c *gin.Context) {
+	if h.robotsPath != "" {
+		c.File(h.robotsPath)
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	b.WriteString("Disallow: /admin/\n")
+	b.WriteString("\nSitemap: " + h.links.baseURL(c) + "/sitemap.xml\n")
+
+	c.String(http.StatusOK, b.String())
+}
@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"movie-api-go/middleware"
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultFeedLimit and maxFeedLimit bound the items of one feed page
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+)
+
+// SocialHandler lets logged-in users follow each other and see what other users rate and
+// watch, as far as their privacy settings allow
+type SocialHandler struct {
+	social   *services.SocialGraph
+	privacy  *services.PrivacyStore
+	feed     *services.ActivityFeed
+	users    *services.UserStore
+	ratings  *services.RatingStore
+	monitors *services.MonitorService
+	likes    *services.LikeStore
+	reports  *services.ReportStore
+	links    *LinkBuilder
+}
+
+func NewSocialHandler(social *services.SocialGraph, privacy *services.PrivacyStore, feed *services.ActivityFeed, users *services.UserStore, ratings *services.RatingStore, monitors *services.MonitorService, likes *services.LikeStore, reports *services.ReportStore, links *LinkBuilder) *SocialHandler {
+	return &SocialHandler{social: social, privacy: privacy, feed: feed, users: users, ratings: ratings, monitors: monitors, likes: likes, reports: reports, links: links}
+}
+
+// Follow handles POST /api/users/:userID/follow. Following a user who doesn't share
+// everything publicly sends a request, answered with 202 and a pending follow, that
+// grants nothing until they approve it.
+func (h *SocialHandler) Follow(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	followee := c.Param("userID")
+	follow, err := h.social.Follow(user.Subject, followee, h.privacy.RequiresApproval(followee))
+	switch {
+	case errors.Is(err, services.ErrInvalidFollow):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	case errors.Is(err, services.ErrFollowLimit):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Conflict",
+			Message: "Follow limit reached; unfollow a user first",
+			Code:    http.StatusConflict,
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to follow user",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	if follow.Pending {
+		c.JSON(http.StatusAccepted, follow)
+		return
+	}
+	c.JSON(http.StatusOK, follow)
+}
+
+// Unfollow handles DELETE /api/users/:userID/follow, which also withdraws a pending request
+func (h *SocialHandler) Unfollow(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	unfollowed, err := h.social.Unfollow(user.Subject, c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to unfollow user",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	if !unfollowed {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "You don't follow this user",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Following handles GET /api/me/following
+func (h *SocialHandler) Following(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	following := h.social.Following(user.Subject)
+	c.JSON(http.StatusOK, gin.H{
+		"following": following,
+		"total":     len(following),
+	})
+}
+
+// Followers handles GET /api/me/followers
+func (h *SocialHandler) Followers(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	followers := h.social.Followers(user.Subject)
+	c.JSON(http.StatusOK, gin.H{
+		"followers": followers,
+		"total":     len(followers),
+	})
+}
+
+// FollowRequests handles GET /api/me/follow-requests, the requests waiting for approval
+func (h *SocialHandler) FollowRequests(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	requests := h.social.Requests(user.Subject)
+	c.JSON(http.StatusOK, gin.H{
+		"requests": requests,
+		"total":    len(requests),
+	})
+}
+
+// ApproveFollow handles POST /api/me/follow-requests/:userID/approve
+func (h *SocialHandler) ApproveFollow(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	follow, err := h.social.Approve(user.Subject, c.Param("userID"))
+	switch {
+	case errors.Is(err, services.ErrFollowRequestNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Follow request not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	case errors.Is(err, services.ErrFollowLimit):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Conflict",
+			Message: "The user already follows as many users as allowed",
+			Code:    http.StatusConflict,
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to approve follow request",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, follow)
+}
+
+// RejectFollow handles DELETE /api/me/follow-requests/:userID
+func (h *SocialHandler) RejectFollow(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	err := h.social.Reject(user.Subject, c.Param("userID"))
+	if errors.Is(err, services.ErrFollowRequestNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "Follow request not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to reject follow request",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Feed handles GET /api/me/feed?limit=20&cursor=Cursor
+func (h *SocialHandler) Feed(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	cursor, limit, ok := cursorPage(c, defaultFeedLimit, maxFeedLimit)
+	if !ok {
+		return
+	}
+
+	page := h.feed.Page(c.Request.Context(), user.Subject, cursor, limit)
+	for i := range page.Items {
+		page.Items[i].Links = h.links.TitleLinks(c, page.Items[i].ImdbID)
+	}
+	c.JSON(http.StatusOK, models.FeedResponse{
+		Items:      page.Items,
+		Total:      len(page.Items),
+		NextCursor: page.NextCursor,
+		Links:      h.links.PageLinks(c, page.NextCursor),
+	})
+}
+
+// GetPrivacy handles GET /api/me/privacy
+func (h *SocialHandler) GetPrivacy(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.privacy.Get(user.Subject))
+}
+
+// PutPrivacy handles PUT /api/me/privacy with body
+// {"lists": "public", "ratings": "followers", "history": "private"}
+func (h *SocialHandler) PutPrivacy(c *gin.Context) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+
+	var req models.PrivacySettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "Body must be JSON privacy settings",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	settings, err := h.privacy.Set(user.Subject, req)
+	if errors.Is(err, services.ErrInvalidPrivacy) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Message: "Failed to save privacy settings",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UserRatings handles GET /api/users/:userID/ratings
+func (h *SocialHandler) UserRatings(c *gin.Context) {
+	userID, ok := h.visible(c, services.PrivacyRatings)
+	if !ok {
+		return
+	}
+
+	ratings := h.ratings.List(userID)
+	c.JSON(http.StatusOK, gin.H{
+		"ratings": ratings,
+		"total":   len(ratings),
+	})
+}
+
+// UserWatchlist handles GET /api/users/:userID/watchlist
+func (h *SocialHandler) UserWatchlist(c *gin.Context) {
+	userID, ok := h.visibleWatchlist(c)
+	if !ok {
+		return
+	}
+
+	// Monitors of logged-in users are owned by their client ID
+	watchlist := h.monitors.Watchlist("user:" + userID)
+	for i := range watchlist {
+		watchlist[i].Links = h.links.TitleLinks(c, watchlist[i].ImdbID)
+	}
+	likes, liked := h.likes.Count(viewer(c), services.ContentList, userID)
+	c.JSON(http.StatusOK, gin.H{
+		"watchlist": watchlist,
+		"total":     len(watchlist),
+		"likes":     likes,
+		"liked":     liked,
+	})
+}
+
+// LikeWatchlist handles POST /api/users/:userID/watchlist/like
+func (h *SocialHandler) LikeWatchlist(c *gin.Context) {
+	h.likeWatchlist(c, true)
+}
+
+// UnlikeWatchlist handles DELETE /api/users/:userID/watchlist/like
+func (h *SocialHandler) UnlikeWatchlist(c *gin.Context) {
+	h.likeWatchlist(c, false)
+}
+
+func (h *SocialHandler) likeWatchlist(c *gin.Context, like bool) {
+	user, ok := loggedIn(c)
+	if !ok {
+		return
+	}
+	// Only lists shared with the caller can be liked
+	userID, ok := h.visibleWatchlist(c)
+	if !ok {
+		return
+	}
+	writeLike(c, h.likes, user.Subject, userID, services.ContentList, userID, like)
+}
+
+// UserFollowing handles GET /api/users/:userID/following
+func (h *SocialHandler) UserFollowing(c *gin.Context) {
+	userID, ok := h.visible(c, services.PrivacyLists)
+	if !ok {
+		return
+	}
+
+	following := h.visibleFollows(c, h.social.Following(userID))
+	c.JSON(http.StatusOK, gin.H{
+		"following": following,
+		"total":     len(following),
+	})
+}
+
+// UserFollowers handles GET /api/users/:userID/followers
+func (h *SocialHandler) UserFollowers(c *gin.Context) {
+	userID, ok := h.visible(c, services.PrivacyLists)
+	if !ok {
+		return
+	}
+
+	followers := h.visibleFollows(c, h.social.Followers(userID))
+	c.JSON(http.StatusOK, gin.H{
+		"followers": followers,
+		"total":     len(followers),
+	})
+}
+
+// visible returns the user named by the userID parameter if the caller may see the area
+// of their activity. Otherwise the error response has been written and ok is false.
+func (h *SocialHandler) visible(c *gin.Context, area string) (userID string, ok bool) {
+	userID = c.Param("userID")
+	if _, exists := h.users.Get(userID); !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not Found",
+			Message: "User not found",
+			Code:    http.StatusNotFound,
+		})
+		return userID, false
+	}
+
+	if !h.privacy.CanView(viewer(c), userID, area) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Forbidden",
+			Message: "This user doesn't share their " + area + " with you",
+			Code:    http.StatusForbidden,
+		})
+		return userID, false
+	}
+	return userID, true
+}
+
+// visibleWatchlist is visible for the watchlist, which is also withheld from everyone
+// but its owner while reports hide it
+func (h *SocialHandler) visibleWatchlist(c *gin.Context) (userID string, ok bool) {
+	userID, ok = h.visible(c, services.PrivacyLists)
+	if !ok {
+		return userID, false
+	}
+	if viewer(c) != userID && h.reports.Hidden(services.ContentList, userID) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Forbidden",
+			Message: "This watchlist is hidden while moderators review reports about it",
+			Code:    http.StatusForbidden,
+		})
+		return userID, false
+	}
+	return userID, true
+}
+
+// visibleFollows drops the follows of users whose lists the caller may not see: a follow
+// belongs to the lists of both users
+func (h *SocialHandler) visibleFollows(c *gin.Context, follows []models.Follow) []models.Follow {
+	visible := make([]models.Follow, 0, len(follows))
+	for _, follow := range follows {
+		if h.privacy.CanView(viewer(c), follow.UserID, services.PrivacyLists) {
+			visible = append(visible, follow)
+		}
+	}
+	return visible
+}
+
+// cursorPage reads the cursor and limit parameters of a paginated list. If one is invalid,
+// the error response has been written and ok is false.
+func cursorPage(c *gin.Context, defaultLimit, maxLimit int) (cursor *services.FeedCursor, limit int, ok bool) {
+	limit = defaultLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxLimit {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "limit must be a number between 1 and " + strconv.Itoa(maxLimit),
+				Code:    http.StatusBadRequest,
+			})
+			return nil, 0, false
+		}
+		limit = parsed
+	}
+	cursor, err := services.DecodeFeedCursor(c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Bad Request",
+			Message: "cursor is invalid",
+			Code:    http.StatusBadRequest,
+		})
+		return nil, 0, false
+	}
+	return cursor, limit, true
+}
+
+// viewer returns the ID of the logged-in user, or an empty string for anonymous callers
+func viewer(c *gin.Context) string {
+	user, _ := middleware.CurrentUser(c)
+	return user.Subject
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StaffPicksHandler serves the editorial staff picks list; admins curate it under
+// /admin/staff-picks
+type StaffPicksHandler struct {
+	picks   *services.StaffPicks
+	posters *services.PosterService
+	links   *LinkBuilder
+}
+
+func NewStaffPicksHandler(picks *services.StaffPicks, posters *services.PosterService, links *LinkBuilder) *StaffPicksHandler {
+	return &StaffPicksHandler{picks: picks, posters: posters, links: links}
+}
+
+// List handles GET /api/movies/staff-picks. Picks whose title can't be fetched are left
+// out and listed in missing.
+func (h *StaffPicksHandler) List(c *gin.Context) {
+	picks, missing := h.picks.Movies(c.Request.Context())
+	for i := range picks {
+		movie := &picks[i].Movie
+		if hash, ok := h.posters.Blurhashes.Get(movie.ImdbID); ok {
+			movie.Blurhash = hash
+		}
+		h.links.LinkBrief(c, movie)
+	}
+
+	streamJSON(c, http.StatusOK, models.StaffPicksResponse{
+		Picks:   picks,
+		Total:   len(picks),
+		Missing: missing,
+		Errors:  services.ScopeFrom(c.Request.Context()).FailedItems(),
+		Meta:    services.ScopeFrom(c.Request.Context()).Meta(),
+	})
+}
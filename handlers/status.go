@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusPage renders the status report for people; clients get the same data as JSON
+var statusPage = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="60">
+<title>Movie API status</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+.badge { display: inline-block; padding: .25rem .75rem; border-radius: 1rem; color: #fff; font-weight: 600; }
+.operational { background: #2e7d32; } .degraded { background: #ed6c02; } .outage { background: #c62828; }
+table { border-collapse: collapse; width: 100%; margin: 1rem 0; }
+th, td { text-align: left; padding: .4rem .6rem; border-bottom: 1px solid #ddd; }
+</style>
+</head>
+<body>
+<h1>Movie API status <span class="badge {{.Status}}">{{.Status}}</span></h1>
+{{if .Incidents}}<h2>Incidents</h2>
+<ul>{{range .Incidents}}<li><strong>{{.Flag}}</strong>: {{.Message}}</li>{{end}}</ul>{{end}}
+<h2>Last {{.WindowMinutes}} minutes</h2>
+<table>
+<tr><th></th><th>Requests</th><th>Errors</th><th>Error rate</th><th>Avg latency</th></tr>
+<tr><td>This API</td><td>{{.API.Requests}}</td><td>{{.API.Errors}}</td><td>{{.API.ErrorRate}}</td><td>{{.API.AvgLatencyMs}} ms</td></tr>
+<tr><td>OMDb</td><td>{{.Upstream.Requests}}</td><td>{{.Upstream.Errors}}</td><td>{{.Upstream.ErrorRate}}</td><td>{{.Upstream.AvgLatencyMs}} ms</td></tr>
+</table>
+<h2>OMDb endpoints</h2>
+<table>
+<tr><th>Host</th><th>Health score</th><th>State</th></tr>
+{{range .BaseURLs}}<tr><td>{{.Host}}</td><td>{{.Score}}</td><td>{{if .Benched}}skipped{{else}}in use{{end}}</td></tr>{{end}}
+</table>
+<h2>OMDb quota today (UTC)</h2>
+<p>{{.Quota.CallsToday}} calls{{if .Quota.DailyLimit}} of {{.Quota.DailyLimit}}, {{.Quota.Remaining}} remaining{{end}}{{if .Quota.Exhausted}}; the limit has been reached{{end}}.</p>
+<p><small>Generated {{.GeneratedAt.Format "2006-01-02 15:04:05 MST"}}. JSON: <code>GET /status</code> with <code>Accept: application/json</code>.</small></p>
+</body>
+</html>
+`))
+
+type StatusHandler struct {
+	omdbService *services.OMDbService
+	shedder     *services.LoadShedder
+	maintenance *services.MaintenanceMode
+}
+
+func NewStatusHandler(omdbService *services.OMDbService, shedder *services.LoadShedder, maintenance *services.MaintenanceMode) *StatusHandler {
+	return &StatusHandler{omdbService: omdbService, shedder: shedder, maintenance: maintenance}
+}
+
+// Status handles GET /status, as HTML for browsers (or format=html) and JSON otherwise
+func (h *StatusHandler) Status(c *gin.Context) {
+	report := h.omdbService.Status()
+	h.shedder.Report(&report)
+	h.maintenance.Report(&report)
+
+	c.Header("Cache-Control", "no-store")
+	format := c.Query("format")
+	if format == "" {
+		format = map[string]string{gin.MIMEHTML: "html"}[c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML)]
+	}
+	if format != "html" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := statusPage.Execute(c.Writer, report); err != nil {
+		c.Error(err)
+	}
+}
+
+// Scaling handles GET /metrics/scaling, the signals to drive autoscaling on (HPA external
+// metrics, KEDA's metrics-api or prometheus scalers). It is JSON by default and the
+// Prometheus text format with format=prometheus or Accept: text/plain.
+func (h *StatusHandler) Scaling(c *gin.Context) {
+	report := h.omdbService.Scaling(c.Request.Context())
+
+	c.Header("Cache-Control", "no-store")
+	format := c.Query("format")
+	if format == "" && c.NegotiateFormat(gin.MIMEJSON, gin.MIMEPlain) == gin.MIMEPlain {
+		format = "prometheus"
+	}
+	if format != "prometheus" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	var b strings.Builder
+	gauge := func(name, help string, value interface{}) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+	}
+	gauge("movieapi_upstream_queue_depth", "OMDb calls waiting for the scheduler's rate ceiling.", report.QueueDepth)
+	if len(report.QueueByPriority) > 0 {
+		priorities := make([]string, 0, len(report.QueueByPriority))
+		for priority := range report.QueueByPriority {
+			priorities = append(priorities, priority)
+		}
+		sort.Strings(priorities)
+		b.WriteString("# HELP movieapi_upstream_queue_depth_by_priority OMDb calls waiting, by priority.\n# TYPE movieapi_upstream_queue_depth_by_priority gauge\n")
+		for _, priority := range priorities {
+			fmt.Fprintf(&b, "movieapi_upstream_queue_depth_by_priority{priority=%q} %d\n", priority, report.QueueByPriority[priority])
+		}
+	}
+	gauge("movieapi_in_flight_requests", "Requests being served.", report.InFlightRequests)
+	gauge("movieapi_in_flight_fan_outs", "Requests being served that made more than one OMDb call.", report.InFlightFanOuts)
+	if report.UpstreamConcurrency > 0 {
+		gauge("movieapi_upstream_in_flight", "OMDb calls in progress.", *report.UpstreamInFlight)
+		gauge("movieapi_upstream_concurrency", "The most OMDb calls allowed in progress at once.", report.UpstreamConcurrency)
+	}
+	gauge("movieapi_cache_hit_ratio", fmt.Sprintf("Detail cache hit ratio over the last %d minutes.", report.WindowMinutes), report.CacheHitRatio)
+	gauge("movieapi_cache_lookups", fmt.Sprintf("Detail cache lookups over the last %d minutes.", report.WindowMinutes), report.CacheLookups)
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
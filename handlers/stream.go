@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+
+	"movie-api-go/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamJSON encodes v straight to the response instead of rendering it into a buffer
+// first, as c.JSON does. The response goes out chunked, so large list responses (enriched
+// search results, recommendation levels) don't hold a second, serialized copy in memory.
+func streamJSON(c *gin.Context, status int, v interface{}) {
+	middleware.Streamed(c)
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(status)
+
+	if err := json.NewEncoder(c.Writer).Encode(v); err != nil {
+		// Headers are already sent, so the client sees a truncated body
+		log.Printf("stream: failed to encode %s response: %v", c.Request.URL.Path, err)
+	}
+}
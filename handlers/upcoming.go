@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUpcoming handles GET /api/movies/upcoming?genre=, listing the movies of the local
+// corpus with a release date after today, soonest first
+func (h *MovieHandler) GetUpcoming(c *gin.Context) {
+	var genre string
+	if requested := c.Query("genre"); requested != "" {
+		var err error
+		genre, _, err = h.omdbService.Genres.Resolve(requested, c.Query("strict") == "true")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+	}
+
+	releases := h.omdbService.Upcoming(genre, time.Now().UTC())
+	for i := range releases {
+		movie := &releases[i].Movie
+		if hash, ok := h.posters.Blurhashes.Get(movie.ImdbID); ok {
+			movie.Blurhash = hash
+		}
+		h.links.LinkBrief(c, movie)
+	}
+
+	streamJSON(c, http.StatusOK, models.UpcomingResponse{
+		Genre:    genre,
+		Releases: releases,
+		Total:    len(releases),
+		Source:   "corpus",
+		Meta:     services.ScopeFrom(c.Request.Context()).Meta(),
+	})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version handles GET /version, reporting the build the server runs: the module version
+// and the commit it was built from when the Go toolchain recorded them
+func Version(c *gin.Context) {
+	version := gin.H{"go_version": runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version["version"] = info.Main.Version
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				version["revision"] = setting.Value
+			case "vcs.time":
+				version["built_at"] = setting.Value
+			case "vcs.modified":
+				version["modified"] = setting.Value == "true"
+			}
+		}
+	}
+	c.JSON(http.StatusOK, version)
+}
@@ -1,90 +1,134 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"movie-api-go/handlers"
+	"movie-api-go/server"
 	"movie-api-go/services"
 
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
+// shutdownTimeout bounds how long in-flight requests may take to finish on shutdown
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
 	}
 
-	// Validate required environment variables
-	apiKey := os.Getenv("OMDB_API_KEY")
-	if apiKey == "" || apiKey == "your_api_key_here" {
-		log.Fatal("OMDB_API_KEY environment variable is required. Please set it in your .env file")
-	}
-
-	baseURL := os.Getenv("OMDB_BASE_URL")
-	if baseURL == "" {
-		baseURL = "http://www.omdbapi.com/"
-	}
-
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	// Initialize services
-	omdbService := services.NewOMDbService()
-
-	// Initialize handlers
-	movieHandler := handlers.NewMovieHandler(omdbService)
-
-	// Setup Gin router
-	router := gin.Default()
-
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-
-		c.Next()
-	})
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Health check endpoint
-	router.GET("/health", movieHandler.HealthCheck)
+	// Without an API key, stay up in degraded mode so the misconfiguration is visible
+	apiKey := os.Getenv("OMDB_API_KEY")
+	if apiKey == "" || apiKey == "your_api_key_here" {
+		log.Printf("Error: OMDB_API_KEY environment variable is required. Starting in degraded mode on port %s: only /health and /version are served", port)
+		serve(ctx, port, server.Degraded("The server is not configured: OMDB_API_KEY is not set",
+			"Get a free key at https://www.omdbapi.com/apikey.aspx, set OMDB_API_KEY in the environment or the .env file and restart the server"))
+		return
+	}
 
-	// API routes
-	api := router.Group("/api")
-	{
-		// 1. Movie Details API
-		api.GET("/movie", movieHandler.GetMovieDetails)
+	omdbService, err := services.NewOMDbService()
+	if err != nil {
+		log.Fatal("Failed to configure OMDb client: ", err)
+	}
 
-		// 2. TV Episode Details API
-		api.GET("/episode", movieHandler.GetEpisodeDetails)
+	// Warm the detail cache from the last snapshot
+	snapshots := services.NewCacheSnapshotter(omdbService.Cache)
+	if restored, err := snapshots.Load(); err != nil {
+		log.Printf("Warning: cache snapshot not restored: %v", err)
+	} else if snapshots != nil {
+		log.Printf("Restored %d cache entries from %s", restored, snapshots.Path)
+	}
 
-		// 3. Genre-Based Movie API
-		api.GET("/movies/genre", movieHandler.GetMoviesByGenre)
+	go snapshots.Run(ctx)
+	go omdbService.History.Run(ctx)
+	go omdbService.Cache.Shared.Run(ctx)
 
-		// 4. Movie Recommendation Engine
-		api.GET("/recommendations", movieHandler.GetMovieRecommendations)
+	// Build the API from the environment
+	handler, err := server.New(server.WithOMDbService(omdbService), server.WithContext(ctx))
+	if err != nil {
+		log.Fatal("Failed to initialize server: ", err)
 	}
 
 	// Start server
 	log.Printf("Starting server on port %s", port)
 	log.Printf("API endpoints available:")
 	log.Printf("  GET /health - Health check")
-	log.Printf("  GET /api/movie?title=<movie_title> - Get movie details")
+	log.Printf("  GET /version - Build version")
+	log.Printf("  GET /metrics/scaling - Queue depth, in-flight fan-outs and cache hit ratio for autoscaling")
+	log.Printf("  GET /api/movie?title=<movie_title>&include=<expansions> - Get movie details")
+	log.Printf("  GET /api/movie/:imdbID/changes - Get how a title's record changed over time")
+	log.Printf("  GET /api/movie/:imdbID/rating-history - Get a title's IMDb rating and Metascore over time")
+	log.Printf("  GET /api/game?title=<game_title> - Get video game details")
 	log.Printf("  GET /api/episode?series_title=<series>&season=<num>&episode_number=<num> - Get episode details")
+	log.Printf("  GET /api/episode/id/:imdbID - Get episode details by IMDb ID")
+	log.Printf("  GET /api/episodes?series_title=<series>&season=<num>&from=<num>&to=<num> - Get a range of episodes")
 	log.Printf("  GET /api/movies/genre?genre=<genre> - Get top 15 movies by genre")
+	log.Printf("  GET /api/movies/staff-picks - Get the editorial staff picks")
+	log.Printf("  GET /api/movies/upcoming?genre=<genre> - Get upcoming releases")
+	log.Printf("  GET /api/collections, GET /api/collections/:slug - Get the themed collections active now")
+	log.Printf("  GET /api/awards/oscars/:year - Get the Academy Awards nominees and winners of a ceremony")
+	log.Printf("  GET /api/genres - List supported genres")
+	log.Printf("  GET /api/tags - List tags (filter discovery with tags=<tag,...>)")
 	log.Printf("  GET /api/recommendations?favorite_movie=<movie_title> - Get movie recommendations")
+	log.Printf("  GET /api/search?q=<query>&cursor=<cursor> - Search titles")
+	log.Printf("  GET /api/search/series?q=<query> - Search TV series")
+	log.Printf("  POST /api/query - Query titles by genre, year, rating, runtime and people")
+	log.Printf("  GET /api/person?name=<name> - Resolve a person name")
+	log.Printf("  GET /api/person/:name/bio - Get a person's biography and filmography")
+	log.Printf("  GET|POST /api/monitors, DELETE /api/monitors/:id - Manage rating alerts")
+	log.Printf("  GET /auth/:provider/login, GET /api/me - Log in with an external provider")
+	log.Printf("  POST|DELETE /api/users/:userID/follow, GET /api/me/feed - Follow users and see their activity")
+	log.Printf("  GET|PUT /api/me/privacy, GET /api/users/:userID/ratings - Share ratings, lists and history")
+	log.Printf("  GET|POST /api/movie/:imdbID/reviews - Read and write reviews of a title")
+	log.Printf("  POST|DELETE /api/reviews/:reviewID/like, /api/users/:userID/watchlist/like - Like reviews and watchlists")
+	log.Printf("  POST /api/reports - Report a review or watchlist to moderators")
+	log.Printf("  GET /api/poster/:imdbID - Get a poster image")
+	log.Printf("  GET /api/movie/:imdbID/card.png - Get a share card image of a title")
+	log.Printf("  GET /api/movie/:imdbID/qr.png, /api/users/:userID/watchlist/qr.png - Get a QR code of a title or watchlist page")
+	log.Printf("  GET /api/leaderboards/watchlisted, GET /api/leaderboards/user-rated?window=<week|month|year|all> - Leaderboards of user activity")
+	log.Printf("  GET /api/onboarding/titles, POST /api/onboarding/ratings - Rate a sample to get first recommendations")
+
+	serve(ctx, port, handler)
+
+	if err := snapshots.Save(); err != nil {
+		log.Printf("Warning: cache snapshot not saved: %v", err)
+	}
+	if err := omdbService.History.Save(); err != nil {
+		log.Printf("Warning: title history not saved: %v", err)
+	}
+}
+
+// serve runs handler on port until ctx is done, then lets in-flight requests finish
+func serve(ctx context.Context, port string, handler http.Handler) {
+	httpServer := &http.Server{Addr: ":" + port, Handler: handler}
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down")
 
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: shutdown did not complete: %v", err)
 	}
 }
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminActorKey is the context key under which AdminAuth stores the acting admin
+const adminActorKey = "admin_actor"
+
+// maxActorLength bounds the X-Admin-Actor value kept in the audit log
+const maxActorLength = 100
+
+// AdminAuth protects admin routes with the admin permission. Admins authenticate with the
+// shared token in the X-Admin-Token header (or as a bearer token), an admin API key, or
+// the login token of a user with the admin role. When neither the token nor an admin key
+// is configured the admin routes are disabled. Since the token is shared, its holders
+// name themselves in X-Admin-Actor for the audit log.
+func AdminAuth(policy *services.AccessPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !policy.AdminEnabled() {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Not Found",
+				Message: "Admin endpoints are disabled. Set ADMIN_API_KEY to enable them",
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+
+		if !authorized(c, policy, services.PermissionAdmin) {
+			return
+		}
+
+		caller := CurrentCaller(c, policy)
+		actor := caller.Name
+		if caller.Credential == "admin_token" {
+			if named := strings.TrimSpace(c.GetHeader("X-Admin-Actor")); named != "" {
+				actor = named
+			}
+		}
+		if len(actor) > maxActorLength {
+			actor = actor[:maxActorLength]
+		}
+		c.Set(adminActorKey, actor)
+
+		c.Next()
+	}
+}
+
+// AdminActor returns the admin making the request, as set by AdminAuth
+func AdminActor(c *gin.Context) string {
+	return c.GetString(adminActorKey)
+}
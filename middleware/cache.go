@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strconv"
+
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheHeaders reports how the detail cache served a request with X-Cache (HIT, MISS or
+// STALE) and, for cached data, its Age in seconds. It must run after RequestScope.
+func CacheHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer = &cacheHeaderWriter{ResponseWriter: c.Writer, scope: services.ScopeFrom(c.Request.Context())}
+		c.Next()
+	}
+}
+
+// cacheHeaderWriter adds the cache headers when the handler sets its status, which is
+// the last point at which headers can still be changed
+type cacheHeaderWriter struct {
+	gin.ResponseWriter
+	scope *services.RequestScope
+}
+
+func (w *cacheHeaderWriter) WriteHeader(code int) {
+	status, age := w.scope.CacheStatus()
+	if status != "" {
+		w.Header().Set("X-Cache", status)
+		if status != services.CacheMiss {
+			w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"movie-api-go/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Key cases a response can be asked for with case=
+const (
+	CaseSnake = "snake"
+	CaseCamel = "camel"
+)
+
+// identifierKey matches the object keys ResponseCase converts; keys holding data, such as
+// "Sci-Fi" or "Internet Movie Database", are left alone
+var identifierKey = regexp.MustCompile(`^_?[A-Za-z][A-Za-z0-9_]*$`)
+
+// ResponseCase converts every object key of JSON responses to snake_case (imdb_rating,
+// source) or camelCase (imdbRating, seriesTitle), including the keys proxied from OMDb,
+// so generated clients see one convention. The case comes from the case parameter, or
+// RESPONSE_CASE for requests without one; without either, keys are served as the models
+// name them. HAL's "_links" keeps its underscore.
+func ResponseCase() gin.HandlerFunc {
+	fallback := os.Getenv("RESPONSE_CASE")
+	if fallback != "" && fallback != CaseSnake && fallback != CaseCamel {
+		log.Printf("case: ignoring RESPONSE_CASE %q, it must be snake or camel", fallback)
+		fallback = ""
+	}
+
+	return func(c *gin.Context) {
+		keyCase := c.DefaultQuery("case", fallback)
+		var convert func(string) string
+		switch keyCase {
+		case "":
+			c.Next()
+			return
+		case CaseSnake:
+			convert = snakeCase
+		case CaseCamel:
+			convert = camelCase
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Bad Request",
+				Message: "case must be snake or camel",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+
+		rewriteKeys(c, func(key string) (string, bool) {
+			if !identifierKey.MatchString(key) {
+				return key, true
+			}
+			return convert(key), true
+		})
+	}
+}
+
+// snakeCase converts a key such as imdbID, BoxOffice or imdb_rating to imdb_id, box_office
+// or imdb_rating
+func snakeCase(key string) string {
+	prefix, name := splitKeyPrefix(key)
+	runes := []rune(name)
+
+	var out strings.Builder
+	out.WriteString(prefix)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// A word starts at an upper-case letter after a lower-case one or a digit, or
+			// at the last capital of an acronym followed by a lower-case letter (HTTPCode)
+			if i > 0 && runes[i-1] != '_' && (!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				out.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+// camelCase converts a key such as imdb_rating, Source or imdbID to imdbRating, source or
+// imdbID
+func camelCase(key string) string {
+	prefix, name := splitKeyPrefix(key)
+
+	var out strings.Builder
+	out.WriteString(prefix)
+	for i, word := range strings.Split(name, "_") {
+		if word == "" {
+			continue
+		}
+		runes := []rune(word)
+		if i == 0 || out.Len() == len(prefix) {
+			runes[0] = unicode.ToLower(runes[0])
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		out.WriteString(string(runes))
+	}
+	return out.String()
+}
+
+// splitKeyPrefix separates a leading underscore, as in _links, from the rest of a key
+func splitKeyPrefix(key string) (string, string) {
+	if strings.HasPrefix(key, "_") {
+		return "_", key[1:]
+	}
+	return "", key
+}
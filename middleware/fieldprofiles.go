@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FieldProfiles rewrites the JSON responses of tenants with their field profile. The
+// tenant is the one API_KEY_TENANTS registers for the request's X-API-Key; other callers
+// get the responses as they are. Mappings apply in order to every object of a response,
+// and keys keep their order. Streamed responses are held back too, since a mapping can
+// move a value ahead of where it was.
+func FieldProfiles(profiles *services.FieldProfileStore, policy *services.AccessPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		tenant, ok := policy.KeyTenant(key)
+		if key == "" || !ok {
+			c.Next()
+			return
+		}
+		profile, ok := profiles.Get(tenant)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		rewriteJSON(c, func(body []byte) ([]byte, error) {
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			document, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			applyMappings(document, profile.Mappings)
+			out, err := json.Marshal(document)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.HasSuffix(body, []byte("\n")) {
+				out = append(out, '\n')
+			}
+			return out, nil
+		}, nil)
+	}
+}
+
+// orderedObject is a JSON object that keeps the order of its keys
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *orderedObject) get(key string) (interface{}, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+func (o *orderedObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *orderedObject) remove(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, existing := range o.keys {
+		if existing == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// decodeOrdered decodes the next JSON value into orderedObjects, slices and scalars;
+// numbers stay as written with a decoder that uses json.Number
+func decodeOrdered(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := &orderedObject{values: make(map[string]interface{})}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyToken.(string)
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.set(key, value)
+		}
+		_, err := decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token()
+		return array, err
+	}
+	return token, nil
+}
+
+// applyMappings applies the mappings to every object in value, innermost first, so a
+// moved value isn't mapped again
+func applyMappings(value interface{}, mappings []models.FieldMapping) {
+	switch v := value.(type) {
+	case *orderedObject:
+		for _, key := range v.keys {
+			applyMappings(v.values[key], mappings)
+		}
+		for _, mapping := range mappings {
+			moveField(v, strings.Split(mapping.From, "."), strings.Split(mapping.To, "."))
+		}
+	case []interface{}:
+		for _, item := range v {
+			applyMappings(item, mappings)
+		}
+	}
+}
+
+// moveField moves the value at from to to within object. The mapping is skipped when
+// from isn't there or to runs through a value that isn't an object.
+func moveField(object *orderedObject, from, to []string) {
+	parent := object
+	for _, key := range from[:len(from)-1] {
+		child, ok := parent.values[key].(*orderedObject)
+		if !ok {
+			return
+		}
+		parent = child
+	}
+	value, ok := parent.get(from[len(from)-1])
+	if !ok {
+		return
+	}
+	parent.remove(from[len(from)-1])
+
+	target := object
+	for _, key := range to[:len(to)-1] {
+		existing, ok := target.get(key)
+		if !ok {
+			child := &orderedObject{values: make(map[string]interface{})}
+			target.set(key, child)
+			target = child
+			continue
+		}
+		child, ok := existing.(*orderedObject)
+		if !ok {
+			// Put the value back rather than lose it
+			parent.set(from[len(from)-1], value)
+			return
+		}
+		target = child
+	}
+	target.set(to[len(to)-1], value)
+}
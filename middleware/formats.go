@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RawFormats serves responses without the given machine-format fields (ISO-8601 dates,
+// runtimes in seconds) when the request has raw=true, for legacy clients that only expect
+// OMDb's display strings. The fields are dropped at any depth and the order of the others
+// is kept.
+func RawFormats(fields []string) gin.HandlerFunc {
+	drop := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		drop[field] = true
+	}
+
+	return func(c *gin.Context) {
+		if c.Query("raw") != "true" {
+			c.Next()
+			return
+		}
+
+		rewriteKeys(c, func(key string) (string, bool) {
+			return key, !drop[key]
+		})
+	}
+}
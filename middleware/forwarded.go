@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestHost matches a Host header that is a plain host name, IPv4 or bracketed IPv6
+// address, with an optional port
+var requestHost = regexp.MustCompile(`^([A-Za-z0-9](?:[A-Za-z0-9.-]*[A-Za-z0-9])?|\[[0-9A-Fa-f:.]+\])(:[0-9]{1,5})?$`)
+
+// ParseTrustedProxies parses TRUSTED_PROXIES, a comma-separated list of IP addresses and
+// CIDR ranges of the reverse proxies in front of the API
+func ParseTrustedProxies(list string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q (expected an IP address or CIDR range)", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q (expected an IP address or CIDR range)", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// RequestOrigin returns the scheme and host a request was addressed to, e.g.
+// https://movies.example.com, for links built without PUBLIC_BASE_URL. X-Forwarded-Proto
+// is honoured only when the request comes from one of the trusted proxies, and only for
+// http and https, so clients can't put a scheme of their choosing into links. A Host that
+// isn't a plain host and port is replaced by localhost.
+func RequestOrigin(c *gin.Context, trusted []*net.IPNet) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := strings.ToLower(strings.TrimSpace(c.GetHeader("X-Forwarded-Proto"))); (proto == "http" || proto == "https") && fromTrustedProxy(c, trusted) {
+		scheme = proto
+	}
+
+	host := c.Request.Host
+	if !requestHost.MatchString(host) {
+		host = "localhost"
+	}
+	return scheme + "://" + host
+}
+
+// fromTrustedProxy reports whether the request's peer is one of the trusted proxies
+func fromTrustedProxy(c *gin.Context, trusted []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		host = strings.TrimSpace(c.Request.RemoteAddr)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// localeKey and catalogKey are the context keys under which Localize stores the language
+// of the response and the catalog to translate with
+const (
+	localeKey  = "locale"
+	catalogKey = "message_catalog"
+)
+
+// Localize picks the language of each response from its Accept-Language header and
+// translates the error and message of error responses into it. Handlers translate other
+// human-readable strings with Localized.
+func Localize(catalog *services.MessageCatalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := catalog.Negotiate(c.GetHeader("Accept-Language"))
+		c.Set(localeKey, locale)
+		c.Set(catalogKey, catalog)
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		if locale == services.SourceLocale {
+			c.Next()
+			return
+		}
+
+		writer := &localizingWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}, context: c}
+		c.Writer = writer
+
+		c.Next()
+
+		if writer.buffering() {
+			writer.body = bytes.NewBuffer(translateError(catalog, locale, writer.body.Bytes()))
+			writer.Header().Del("Content-Length")
+		}
+		writer.flush()
+	}
+}
+
+// Localized returns text in the language of the response, or text itself when the
+// pipeline doesn't localize responses
+func Localized(c *gin.Context, text string) string {
+	locale, ok := c.Get(localeKey)
+	if !ok {
+		return text
+	}
+	catalog, ok := c.Get(catalogKey)
+	if !ok {
+		return text
+	}
+	return catalog.(*services.MessageCatalog).Translate(locale.(string), text)
+}
+
+// translateError translates an ErrorResponse body. Bodies that aren't one are returned as
+// they are.
+func translateError(catalog *services.MessageCatalog, locale string, body []byte) []byte {
+	var response models.ErrorResponse
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&response); err != nil {
+		return body
+	}
+
+	response.Error = catalog.Translate(locale, response.Error)
+	response.Message = catalog.Translate(locale, response.Message)
+	translated, err := json.Marshal(response)
+	if err != nil {
+		return body
+	}
+	return translated
+}
+
+// localizingWriter holds back the body of JSON error responses so they can be translated,
+// and writes everything else through
+type localizingWriter struct {
+	gin.ResponseWriter
+	body    *bytes.Buffer
+	status  int
+	context *gin.Context
+}
+
+// buffering reports whether the response is held back: a JSON error that isn't streamed
+func (w *localizingWriter) buffering() bool {
+	return w.status >= http.StatusBadRequest && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") && !isStreamed(w.context)
+}
+
+// WriteHeader only records the status until the body is written; the Content-Type that
+// decides whether it is held back may not be set yet
+func (w *localizingWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *localizingWriter) WriteHeaderNow() {
+	if !w.buffering() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *localizingWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.buffering() {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *localizingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *localizingWriter) Status() int {
+	if w.status == 0 {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *localizingWriter) Written() bool {
+	return w.status != 0 || w.ResponseWriter.Written()
+}
+
+func (w *localizingWriter) flush() {
+	if !w.buffering() {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.body.Bytes())
+}
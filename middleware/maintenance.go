@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maintenance answers every request with 503 and the configured message while
+// maintenance mode is on. It guards the data routes only, so health checks, the status
+// page and the admin routes that end the window stay reachable.
+func Maintenance(maintenance *services.MaintenanceMode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := maintenance.State()
+		if !state.Enabled {
+			c.Next()
+			return
+		}
+
+		response := models.ErrorResponse{
+			Error:   "Service Unavailable",
+			Message: state.Message,
+			Code:    http.StatusServiceUnavailable,
+		}
+		if state.RetryAfterSeconds > 0 {
+			c.Header("Retry-After", strconv.Itoa(state.RetryAfterSeconds))
+			response.Retryable = true
+			response.RetryAfterMs = (time.Duration(state.RetryAfterSeconds) * time.Second).Milliseconds()
+		}
+		c.Header("Cache-Control", "no-store")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, response)
+	}
+}
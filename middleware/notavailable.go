@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"encoding/json"
+
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotAvailable serves the "N/A" placeholders NAPolicy.Present puts back into responses as
+// JSON null under NA_POLICY=null, at any depth, so clients see the fields with no value
+// instead of either the placeholder or a missing field. Under the other policies it does
+// nothing.
+func NotAvailable(policy services.NAPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if policy != services.NAPolicyNull {
+			c.Next()
+			return
+		}
+
+		rewriteStrings(c, func(value string) (json.RawMessage, bool) {
+			return json.RawMessage("null"), value == services.NotAvailable
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNotAvailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	response := models.MovieDetailsResponse{
+		Title:   "The Matrix Reloaded",
+		Awards:  services.NotAvailable,
+		Ratings: []models.Rating{{Source: "Metacritic", Value: services.NotAvailable}},
+	}
+
+	tests := []struct {
+		policy   services.NAPolicy
+		streamed bool
+		want     interface{}
+	}{
+		{policy: services.NAPolicyKeep, want: services.NotAvailable},
+		{policy: services.NAPolicyKeep, streamed: true, want: services.NotAvailable},
+		{policy: services.NAPolicyNull, want: nil},
+		{policy: services.NAPolicyNull, streamed: true, want: nil},
+	}
+	for _, tt := range tests {
+		router := gin.New()
+		router.Use(NotAvailable(tt.policy))
+		router.GET("/", func(c *gin.Context) {
+			if !tt.streamed {
+				c.JSON(http.StatusOK, response)
+				return
+			}
+			Streamed(c)
+			c.Header("Content-Type", "application/json; charset=utf-8")
+			c.Status(http.StatusOK)
+			json.NewEncoder(c.Writer).Encode(response)
+		})
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		var body struct {
+			Title   string                   `json:"title"`
+			Awards  interface{}              `json:"awards"`
+			Ratings []map[string]interface{} `json:"ratings"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s (streamed %v): invalid body %q: %v", tt.policy, tt.streamed, recorder.Body, err)
+		}
+		if body.Title != response.Title || body.Awards != tt.want || len(body.Ratings) != 1 || body.Ratings[0]["Value"] != tt.want {
+			t.Errorf("%s (streamed %v): body = %s, want awards and the rating value %v", tt.policy, tt.streamed, recorder.Body, tt.want)
+		}
+	}
+}
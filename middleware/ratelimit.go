@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limits each client's requests and reports its usage on every response:
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset for the request rate,
+// and X-Quota-UpstreamCalls for the OMDb calls the client has caused today. Clients
+// are identified by their login token, their registered X-API-Key, or by IP address.
+func RateLimit(limiter *services.RateLimiter, usage *services.UsageTracker, policy *services.AccessPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := ClientID(c, policy)
+
+		if limiter.Enabled() {
+			status := limiter.Allow(client)
+			c.Header("X-RateLimit-Limit", strconv.Itoa(status.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(status.Remaining))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(status.Reset.Unix(), 10))
+
+			if !status.Allowed {
+				retryAfter := int(time.Until(status.Reset).Seconds()) + 1
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+				c.Header("X-Quota-UpstreamCalls", strconv.Itoa(usage.Used(client)))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, models.ErrorResponse{
+					Error:        "Too Many Requests",
+					Message:      "Rate limit exceeded, retry after " + strconv.Itoa(retryAfter) + " seconds",
+					Code:         http.StatusTooManyRequests,
+					Retryable:    true,
+					RetryAfterMs: time.Until(status.Reset).Milliseconds(),
+				})
+				return
+			}
+		}
+
+		c.Writer = &quotaHeaderWriter{ResponseWriter: c.Writer, c: c, usage: usage, client: client}
+
+		c.Next()
+
+		usage.Add(client, services.ScopeFrom(c.Request.Context()).Calls())
+	}
+}
+
+// ClientID identifies the caller for rate limiting and usage accounting. Only keys the
+// policy registers with a role or a tenant identify a client, named by a digest so the
+// secret isn't kept in counters; any other X-API-Key is ignored, since a fresh random
+// one on every request would otherwise get a fresh bucket and dodge the per-IP limit.
+func ClientID(c *gin.Context, policy *services.AccessPolicy) string {
+	if user, ok := CurrentUser(c); ok {
+		return "user:" + user.Subject
+	}
+	if key := c.GetHeader("X-API-Key"); key != "" && registeredKey(policy, key) {
+		return services.KeyClientID(key)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// registeredKey reports whether the policy knows an API key
+func registeredKey(policy *services.AccessPolicy, key string) bool {
+	if _, ok := policy.KeyRole(key); ok {
+		return true
+	}
+	_, ok := policy.KeyTenant(key)
+	return ok
+}
+
+// quotaHeaderWriter adds X-Quota-UpstreamCalls when the status is written, counting the
+// calls of the current request, which are only recorded in the tracker once it ends
+type quotaHeaderWriter struct {
+	gin.ResponseWriter
+	c      *gin.Context
+	usage  *services.UsageTracker
+	client string
+}
+
+func (w *quotaHeaderWriter) WriteHeader(code int) {
+	calls := w.usage.Used(w.client) + services.ScopeFrom(w.c.Request.Context()).Calls()
+	w.Header().Set("X-Quota-UpstreamCalls", strconv.Itoa(calls))
+	w.ResponseWriter.WriteHeader(code)
+}
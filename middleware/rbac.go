@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// callerKey is the context key under which the caller's role is cached
+const callerKey = "caller"
+
+// Caller is who is making a request and the role they act with
+type Caller struct {
+	Role string
+	// Credential is how the caller authenticated: admin_token, token, api_key, or empty
+	// for anonymous callers
+	Credential string
+	// Name identifies the caller in the audit log without revealing secrets
+	Name string
+}
+
+// CurrentCaller resolves the caller's role: the admin token grants admin, a login token
+// carries the user's role, a registered API key has its configured role, and everyone
+// else gets the anonymous role. Login tokens are only seen when Authenticate runs first.
+func CurrentCaller(c *gin.Context, policy *services.AccessPolicy) Caller {
+	if value, ok := c.Get(callerKey); ok {
+		return value.(Caller)
+	}
+
+	caller := Caller{Role: policy.AnonymousRole}
+	if user, ok := CurrentUser(c); ok {
+		caller = Caller{Role: user.Role, Credential: "token", Name: "user:" + user.Subject}
+		if !services.ValidRole(caller.Role) {
+			caller.Role = services.RoleUser
+		}
+	} else if key := c.GetHeader("X-API-Key"); key != "" {
+		if role, ok := policy.KeyRole(key); ok {
+			digest := sha256.Sum256([]byte(key))
+			caller = Caller{Role: role, Credential: "api_key", Name: "api_key:" + hex.EncodeToString(digest[:4])}
+		}
+	}
+
+	if policy.AdminToken != "" {
+		provided := c.GetHeader("X-Admin-Token")
+		if provided == "" {
+			provided = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(policy.AdminToken)) == 1 {
+			caller = Caller{Role: services.RoleAdmin, Credential: "admin_token", Name: "admin"}
+		}
+	}
+
+	c.Set(callerKey, caller)
+	return caller
+}
+
+// Authorize rejects callers whose role lacks permission
+func Authorize(policy *services.AccessPolicy, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authorized(c, policy, permission) {
+			c.Next()
+		}
+	}
+}
+
+// authorized checks the caller's permission and otherwise aborts: anonymous callers with
+// 401 so they know to authenticate, the others with 403
+func authorized(c *gin.Context, policy *services.AccessPolicy, permission string) bool {
+	caller := CurrentCaller(c, policy)
+	if policy.Allowed(caller.Role, permission) {
+		return true
+	}
+
+	if caller.Credential == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Message: "Authentication is required for this route",
+			Code:    http.StatusUnauthorized,
+		})
+		return false
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+		Error:   "Forbidden",
+		Message: "The " + caller.Role + " role does not have the " + permission + " permission",
+		Code:    http.StatusForbidden,
+	})
+	return false
+}
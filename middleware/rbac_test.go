@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ANONYMOUS_ROLE", services.RoleReadonly)
+	t.Setenv("API_KEY_ROLES", "svc:service,ro:readonly,root:admin")
+	t.Setenv("API_KEY_TENANTS", "")
+	policy, err := services.NewAccessPolicy("adm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		user       *services.Claims
+		permission string
+		wantRole   string
+		wantStatus int
+	}{
+		{name: "anonymous reads", permission: services.PermissionCatalogRead, wantRole: services.RoleReadonly, wantStatus: http.StatusOK},
+		{name: "anonymous writes", permission: services.PermissionRatingsWrite, wantRole: services.RoleReadonly, wantStatus: http.StatusUnauthorized},
+		{name: "unregistered key", headers: map[string]string{"X-API-Key": "nope"}, permission: services.PermissionMonitorsWrite, wantRole: services.RoleReadonly, wantStatus: http.StatusUnauthorized},
+		{name: "service key", headers: map[string]string{"X-API-Key": "svc"}, permission: services.PermissionMonitorsWrite, wantRole: services.RoleService, wantStatus: http.StatusOK},
+		{name: "service key without account", headers: map[string]string{"X-API-Key": "svc"}, permission: services.PermissionAccount, wantRole: services.RoleService, wantStatus: http.StatusForbidden},
+		{name: "readonly key", headers: map[string]string{"X-API-Key": "ro"}, permission: services.PermissionMonitorsWrite, wantRole: services.RoleReadonly, wantStatus: http.StatusForbidden},
+		{name: "admin key", headers: map[string]string{"X-API-Key": "root"}, permission: services.PermissionAdmin, wantRole: services.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "admin token", headers: map[string]string{"X-Admin-Token": "adm"}, permission: services.PermissionAdmin, wantRole: services.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "admin token as bearer", headers: map[string]string{"Authorization": "Bearer adm"}, permission: services.PermissionAdmin, wantRole: services.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "wrong admin token", headers: map[string]string{"X-Admin-Token": "adm2"}, permission: services.PermissionAdmin, wantRole: services.RoleReadonly, wantStatus: http.StatusUnauthorized},
+		{name: "user", user: &services.Claims{Subject: "u_alice", Role: services.RoleUser}, permission: services.PermissionRatingsWrite, wantRole: services.RoleUser, wantStatus: http.StatusOK},
+		{name: "user on admin route", user: &services.Claims{Subject: "u_alice", Role: services.RoleUser}, permission: services.PermissionAdmin, wantRole: services.RoleUser, wantStatus: http.StatusForbidden},
+		{name: "demoted user", user: &services.Claims{Subject: "u_bob", Role: services.RoleReadonly}, permission: services.PermissionRatingsWrite, wantRole: services.RoleReadonly, wantStatus: http.StatusForbidden},
+		{name: "user with unknown role", user: &services.Claims{Subject: "u_pub", Role: "owner"}, permission: services.PermissionAdmin, wantRole: services.RoleUser, wantStatus: http.StatusForbidden},
+		{name: "user over key", headers: map[string]string{"X-API-Key": "root"}, user: &services.Claims{Subject: "u_alice", Role: services.RoleUser}, permission: services.PermissionAdmin, wantRole: services.RoleUser, wantStatus: http.StatusForbidden},
+		{name: "admin token over user", headers: map[string]string{"X-Admin-Token": "adm"}, user: &services.Claims{Subject: "u_alice", Role: services.RoleReadonly}, permission: services.PermissionAdmin, wantRole: services.RoleAdmin, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/movie", nil)
+			for name, value := range tt.headers {
+				c.Request.Header.Set(name, value)
+			}
+			if tt.user != nil {
+				c.Set(userClaimsKey, *tt.user)
+			}
+
+			if caller := CurrentCaller(c, policy); caller.Role != tt.wantRole {
+				t.Errorf("role = %q, want %q", caller.Role, tt.wantRole)
+			}
+			status := http.StatusOK
+			if !authorized(c, policy, tt.permission) {
+				status = recorder.Code
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
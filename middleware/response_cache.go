@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CachedResponse is a complete handler response stored by the ResponseCache
+type CachedResponse struct {
+	ContentType string
+	Body        []byte
+	StoredAt    time.Time
+}
+
+// ResponseStore holds cached responses. The in-memory store suits a single instance;
+// a shared store (e.g. Redis) can be plugged in for multi-instance deployments.
+type ResponseStore interface {
+	Get(key string) (CachedResponse, bool)
+	Set(key string, response CachedResponse, ttl time.Duration)
+}
+
+// ResponseCache caches complete 200 responses of GET routes, keyed by origin, path and
+// normalized query, with a TTL per route. Clients bypass it with Cache-Control: no-cache,
+// and logged-in users, traced requests and explain=true requests always do.
+type ResponseCache struct {
+	// Maintenance, when set, stops the cache while maintenance mode is on, so that the
+	// cached responses aren't served past the Maintenance middleware of the /api routes,
+	// which runs after the global stack
+	Maintenance *services.MaintenanceMode
+
+	store   ResponseStore
+	ttls    map[string]time.Duration
+	trusted []*net.IPNet
+}
+
+// NewResponseCache creates a cache for the given routes (as registered with gin, e.g.
+// "/api/movie") and their TTLs. Routes not in the map are never cached, and neither are
+// streamed responses, which would otherwise be copied in full. trusted are the proxies
+// whose X-Forwarded-Proto links are built with (see RequestOrigin).
+func NewResponseCache(store ResponseStore, ttls map[string]time.Duration, trusted []*net.IPNet) *ResponseCache {
+	return &ResponseCache{store: store, ttls: ttls, trusted: trusted}
+}
+
+// Middleware serves cached responses with X-Response-Cache: HIT and an Age header, and
+// stores fresh ones with X-Response-Cache: MISS (or BYPASS when the client asked for it)
+func (rc *ResponseCache) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ttl, ok := rc.ttls[c.FullPath()]
+		if !ok || c.Request.Method != http.MethodGet || rc.Maintenance != nil && rc.Maintenance.State().Enabled {
+			c.Next()
+			return
+		}
+		// Responses to logged-in users may be personalized by their preferences
+		if _, loggedIn := CurrentUser(c); loggedIn {
+			c.Header("X-Response-Cache", "BYPASS")
+			c.Next()
+			return
+		}
+
+		key := responseCacheKey(c, rc.trusted)
+		status := "MISS"
+		if strings.Contains(strings.ToLower(c.GetHeader("Cache-Control")), "no-cache") || services.TraceFrom(c.Request.Context()) != nil || c.Query("explain") == "true" {
+			status = "BYPASS"
+		} else if cached, ok := rc.store.Get(key); ok {
+			c.Header("X-Response-Cache", "HIT")
+			c.Header("Age", strconv.Itoa(int(time.Since(cached.StoredAt).Seconds())))
+			c.Data(http.StatusOK, cached.ContentType, cached.Body)
+			c.Abort()
+			return
+		}
+
+		c.Header("X-Response-Cache", status)
+		writer := &recordingWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}, context: c}
+		c.Writer = writer
+
+		c.Next()
+
+		if writer.Status() == http.StatusOK && !isStreamed(c) {
+			rc.store.Set(key, CachedResponse{
+				ContentType: writer.Header().Get("Content-Type"),
+				Body:        writer.body.Bytes(),
+				StoredAt:    time.Now(),
+			}, ttl)
+		}
+	}
+}
+
+// responseCacheKey normalizes the request so that parameter order and repeated
+// parameters don't produce separate entries. It holds every input a cached body depends
+// on: the origin, scheme and host, that links in the response are built from, and the
+// score weights blended scores are. Responses personalized otherwise aren't cached.
+func responseCacheKey(c *gin.Context, trusted []*net.IPNet) string {
+	r := c.Request
+	query := r.URL.Query()
+	for _, values := range query {
+		sort.Strings(values)
+	}
+	weights := services.ScoreWeightsFrom(r.Context())
+	// Encode sorts by parameter name
+	return RequestOrigin(c, trusted) + r.URL.Path + "?" + query.Encode() + fmt.Sprintf("#%g:%g:%g", weights.IMDb, weights.Metascore, weights.RottenTomatoes)
+}
+
+// recordingWriter passes the response through while keeping a copy of the body, unless
+// it is streamed
+type recordingWriter struct {
+	gin.ResponseWriter
+	body    *bytes.Buffer
+	context *gin.Context
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	if !isStreamed(w.context) {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// MemoryResponseStore is an in-process ResponseStore bounded to maxEntries
+type MemoryResponseStore struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	response  CachedResponse
+	expiresAt time.Time
+}
+
+func NewMemoryResponseStore(maxEntries int) *MemoryResponseStore {
+	return &MemoryResponseStore{
+		maxEntries: maxEntries,
+		entries:    make(map[string]memoryEntry),
+	}
+}
+
+func (m *MemoryResponseStore) Get(key string) (CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return CachedResponse{}, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(m.entries, key)
+		return CachedResponse{}, false
+	}
+	return entry.response, true
+}
+
+func (m *MemoryResponseStore) Set(key string, response CachedResponse, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[key]; !ok && m.maxEntries > 0 && len(m.entries) >= m.maxEntries {
+		m.evictLocked()
+	}
+	m.entries[key] = memoryEntry{response: response, expiresAt: time.Now().Add(ttl)}
+}
+
+// evictLocked drops expired entries, or the entry closest to expiry if none have expired
+func (m *MemoryResponseStore) evictLocked() {
+	now := time.Now()
+	var soonestKey string
+	var soonest time.Time
+	for key, entry := range m.entries {
+		if now.After(entry.expiresAt) {
+			delete(m.entries, key)
+			continue
+		}
+		if soonestKey == "" || entry.expiresAt.Before(soonest) {
+			soonestKey, soonest = key, entry.expiresAt
+		}
+	}
+	if len(m.entries) >= m.maxEntries && soonestKey != "" {
+		delete(m.entries, soonestKey)
+	}
+}
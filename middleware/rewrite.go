@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rewriteJSON runs the rest of the chain with JSON bodies held back, and writes them out
+// through rewrite. Bodies rewrite fails on are written as they are. Streamed responses are
+// passed through stream as they are written instead, when it is set.
+func rewriteJSON(c *gin.Context, rewrite func([]byte) ([]byte, error), stream func(io.Reader, io.Writer) error) {
+	writer := &jsonBufferWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+	if stream != nil && isStreamed(c) {
+		writer.stream = stream
+	}
+	c.Writer = writer
+
+	c.Next()
+
+	if writer.streaming != nil {
+		writer.closeStream()
+		return
+	}
+	if writer.buffering() {
+		if rewritten, err := rewrite(writer.body.Bytes()); err == nil {
+			writer.body = bytes.NewBuffer(rewritten)
+		}
+		writer.Header().Del("Content-Length")
+	}
+	writer.flush()
+}
+
+// rewriteKeys runs the rest of the chain with every object key of JSON bodies passed
+// through rename, at any depth and in the original order. Members rename returns false
+// for are dropped.
+func rewriteKeys(c *gin.Context, rename func(string) (string, bool)) {
+	rewriteTokens(c, rename, nil)
+}
+
+// rewriteStrings runs the rest of the chain with every string value of JSON bodies, at any
+// depth and in the original order, replaced by the JSON replace returns for it; object
+// keys are left alone. Values replace returns false for are kept.
+func rewriteStrings(c *gin.Context, replace func(string) (json.RawMessage, bool)) {
+	rewriteTokens(c, func(key string) (string, bool) { return key, true }, replace)
+}
+
+// rewriteTokens is rewriteJSON with rewriteDocument, or rewriteStream for streamed
+// responses
+func rewriteTokens(c *gin.Context, rename func(string) (string, bool), replace func(string) (json.RawMessage, bool)) {
+	rewriteJSON(c, func(body []byte) ([]byte, error) {
+		return rewriteDocument(body, rename, replace)
+	}, func(r io.Reader, w io.Writer) error {
+		return rewriteStream(r, w, rename, replace)
+	})
+}
+
+// rewriteDocument re-encodes a JSON document for rewriteTokens. replace may be nil.
+func rewriteDocument(data []byte, rename func(string) (string, bool), replace func(string) (json.RawMessage, bool)) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '"' && replace != nil {
+		var value string
+		if err := json.Unmarshal(trimmed, &value); err != nil {
+			return nil, err
+		}
+		if replaced, ok := replace(value); ok {
+			return replaced, nil
+		}
+		return data, nil
+	}
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return data, nil
+	}
+	object := trimmed[0] == '{'
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteByte(trimmed[0])
+	first := true
+	for decoder.More() {
+		var name string
+		if object {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			name, _ = token.(string)
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		if object {
+			var keep bool
+			if name, keep = rename(name); !keep {
+				continue
+			}
+		}
+
+		value, err := rewriteDocument(value, rename, replace)
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			out.WriteByte(',')
+		}
+		first = false
+		if object {
+			key, _ := json.Marshal(name)
+			out.Write(key)
+			out.WriteByte(':')
+		}
+		out.Write(value)
+	}
+	if object {
+		out.WriteByte('}')
+	} else {
+		out.WriteByte(']')
+	}
+	if bytes.HasSuffix(data, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// jsonBufferWriter holds back JSON bodies so they can be rewritten, and writes everything
+// else through. With stream set, JSON bodies are piped through it to the response instead.
+type jsonBufferWriter struct {
+	gin.ResponseWriter
+	body   *bytes.Buffer
+	status int
+
+	stream    func(io.Reader, io.Writer) error
+	streaming *io.PipeWriter
+	done      chan error
+}
+
+// buffering reports whether the response is held back: a JSON body
+func (w *jsonBufferWriter) buffering() bool {
+	return strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+}
+
+// WriteHeader only records the status until the body is written; the Content-Type that
+// decides whether it is held back may not be set yet
+func (w *jsonBufferWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *jsonBufferWriter) WriteHeaderNow() {
+	if !w.buffering() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *jsonBufferWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = w.ResponseWriter.Status()
+	}
+	if !w.buffering() {
+		return w.ResponseWriter.Write(data)
+	}
+	if w.stream != nil {
+		if w.streaming == nil {
+			w.openStream()
+		}
+		return w.streaming.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+// openStream starts rewriting the body into the response as it is written
+func (w *jsonBufferWriter) openStream() {
+	w.Header().Del("Content-Length")
+	reader, writer := io.Pipe()
+	w.streaming, w.done = writer, make(chan error, 1)
+	go func() {
+		err := w.stream(reader, w.ResponseWriter)
+		// Fails the handler's further writes if the rewrite stopped early
+		reader.CloseWithError(err)
+		w.done <- err
+	}()
+}
+
+// closeStream ends the body and waits for its rewrite to be written
+func (w *jsonBufferWriter) closeStream() {
+	w.streaming.Close()
+	if err := <-w.done; err != nil {
+		log.Printf("rewrite: streamed response cut short: %v", err)
+	}
+}
+
+func (w *jsonBufferWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *jsonBufferWriter) Status() int {
+	if w.status == 0 {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *jsonBufferWriter) Written() bool {
+	return w.status != 0 || w.ResponseWriter.Written()
+}
+
+func (w *jsonBufferWriter) flush() {
+	if !w.buffering() || w.status == 0 {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRewriteStreamMatchesRewriteDocument(t *testing.T) {
+	rename := func(key string) (string, bool) { return "x_" + key, key != "drop" }
+	replace := func(value string) (json.RawMessage, bool) { return json.RawMessage("null"), value == "N/A" }
+
+	documents := []string{
+		`{}`,
+		`[]`,
+		`"N/A"`,
+		`{"a":1,"b":[1,2.5,-3e2,{"c":"N/A","d":[]}],"e":{},"f":null,"g":true,"h":"x<y"}`,
+		`[{"drop":{"x":[1,2]},"k":"v"},{"drop":1},{"k":[{"drop":"N/A"}]}]`,
+		`{"nested":{"deeper":{"deepest":["N/A","n/a",""]}}}`,
+	}
+	for _, document := range documents {
+		want, err := rewriteDocument([]byte(document+"\n"), rename, replace)
+		if err != nil {
+			t.Fatalf("rewriteDocument(%s) = %v", document, err)
+		}
+		var got bytes.Buffer
+		if err := rewriteStream(bytes.NewReader([]byte(document+"\n")), &got, rename, replace); err != nil {
+			t.Fatalf("rewriteStream(%s) = %v", document, err)
+		}
+		// Strings may be escaped differently, so the documents are compared decoded
+		var gotValue, wantValue interface{}
+		if err := json.Unmarshal(got.Bytes(), &gotValue); err != nil {
+			t.Fatalf("rewriteStream(%s) wrote invalid JSON %s: %v", document, got.Bytes(), err)
+		}
+		json.Unmarshal(want, &wantValue)
+		if !reflect.DeepEqual(gotValue, wantValue) {
+			t.Errorf("rewriteStream(%s) = %s, want %s", document, got.Bytes(), want)
+		}
+	}
+}
+
+func TestRewriteStreamInvalid(t *testing.T) {
+	keep := func(key string) (string, bool) { return key, true }
+	for _, document := range []string{`{"a":`, `[1,2`, `{"a" 1}`} {
+		var out bytes.Buffer
+		if err := rewriteStream(bytes.NewReader([]byte(document)), &out, keep, nil); err == nil {
+			t.Errorf("rewriteStream(%s) succeeded, want an error", document)
+		}
+	}
+}
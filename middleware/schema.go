@@ -0,0 +1,267 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"movie-api-go/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SchemaValidator checks outgoing JSON responses against the response models they are
+// supposed to serialize. The Go models are the API contract, so validating against them
+// catches drift between what OMDb returns and what clients were promised, e.g. required
+// fields that went missing or literal "N/A" values leaking through.
+type SchemaValidator struct {
+	schemas map[string]reflect.Type
+}
+
+// NewSchemaValidator creates a validator from a map of route paths (as registered with
+// gin, e.g. "/api/movie") to a zero value of the model the route responds with
+func NewSchemaValidator(schemas map[string]interface{}) *SchemaValidator {
+	validator := &SchemaValidator{
+		schemas: make(map[string]reflect.Type),
+	}
+	for route, model := range schemas {
+		validator.schemas[route] = reflect.TypeOf(model)
+	}
+	return validator
+}
+
+// Middleware buffers each response, validates it and adds an X-Schema-Mismatches header
+// with the number of problems found. Only intended for debug deployments.
+func (v *SchemaValidator) Middleware() gin.HandlerFunc {
+	errorSchema := reflect.TypeOf(models.ErrorResponse{})
+
+	return func(c *gin.Context) {
+		writer := &bufferedWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		c.Next()
+
+		schema, ok := v.schemas[c.FullPath()]
+		if writer.status >= http.StatusBadRequest {
+			schema, ok = errorSchema, true
+		}
+
+		if ok && strings.HasPrefix(writer.Header().Get("Content-Type"), "application/json") {
+			mismatches := v.Validate(schema, writer.body.Bytes())
+			if len(mismatches) > 0 {
+				log.Printf("schema: %d mismatch(es) in %s %s response:", len(mismatches), c.Request.Method, c.Request.URL.RequestURI())
+				for _, mismatch := range mismatches {
+					log.Printf("schema:   %s", mismatch)
+				}
+			}
+			writer.Header().Set("X-Schema-Mismatches", strconv.Itoa(len(mismatches)))
+		}
+
+		writer.flush()
+	}
+}
+
+// Validate returns a description of every way the JSON body deviates from the schema
+func (v *SchemaValidator) Validate(schema reflect.Type, body []byte) []string {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return []string{fmt.Sprintf("$: invalid JSON: %v", err)}
+	}
+
+	var mismatches []string
+	validateValue("$", schema, decoded, &mismatches)
+	return mismatches
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func validateValue(path string, schema reflect.Type, value interface{}, mismatches *[]string) {
+	for schema.Kind() == reflect.Ptr {
+		schema = schema.Elem()
+	}
+
+	if value == nil {
+		if schema.Kind() != reflect.Slice && schema.Kind() != reflect.Map && schema.Kind() != reflect.Interface {
+			*mismatches = append(*mismatches, fmt.Sprintf("%s: null where %s expected", path, schema.Kind()))
+		}
+		return
+	}
+
+	// Timestamps and other text-marshaled types serialize as strings such as ISO-8601 dates
+	if schema.Implements(textMarshalerType) || reflect.PtrTo(schema).Implements(textMarshalerType) {
+		if _, ok := value.(string); !ok {
+			*mismatches = append(*mismatches, fmt.Sprintf("%s: expected string, got %s", path, jsonKind(value)))
+		}
+		return
+	}
+
+	switch schema.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*mismatches = append(*mismatches, fmt.Sprintf("%s: expected object, got %s", path, jsonKind(value)))
+			return
+		}
+		validateObject(path, schema, object, mismatches)
+	case reflect.Slice:
+		array, ok := value.([]interface{})
+		if !ok {
+			*mismatches = append(*mismatches, fmt.Sprintf("%s: expected array, got %s", path, jsonKind(value)))
+			return
+		}
+		for i, item := range array {
+			validateValue(fmt.Sprintf("%s[%d]", path, i), schema.Elem(), item, mismatches)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*mismatches = append(*mismatches, fmt.Sprintf("%s: expected object, got %s", path, jsonKind(value)))
+			return
+		}
+		for key, item := range object {
+			validateValue(path+"."+key, schema.Elem(), item, mismatches)
+		}
+	case reflect.String:
+		text, ok := value.(string)
+		if !ok {
+			*mismatches = append(*mismatches, fmt.Sprintf("%s: expected string, got %s", path, jsonKind(value)))
+			return
+		}
+		if text == "N/A" {
+			*mismatches = append(*mismatches, fmt.Sprintf("%s: upstream placeholder \"N/A\"", path))
+		}
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		if _, ok := value.(float64); !ok {
+			*mismatches = append(*mismatches, fmt.Sprintf("%s: expected number, got %s", path, jsonKind(value)))
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			*mismatches = append(*mismatches, fmt.Sprintf("%s: expected boolean, got %s", path, jsonKind(value)))
+		}
+	}
+}
+
+func validateObject(path string, schema reflect.Type, object map[string]interface{}, mismatches *[]string) {
+	known := make(map[string]bool)
+
+	for i := 0; i < schema.NumField(); i++ {
+		field := schema.Field(i)
+		name, omitEmpty := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+		known[name] = true
+
+		value, present := object[name]
+		if !present {
+			if !omitEmpty {
+				*mismatches = append(*mismatches, fmt.Sprintf("%s.%s: required field missing", path, name))
+			}
+			continue
+		}
+		validateValue(path+"."+name, field.Type, value, mismatches)
+	}
+
+	var unknown []string
+	for name := range object {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		*mismatches = append(*mismatches, fmt.Sprintf("%s.%s: field not in schema", path, name))
+	}
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			return name, true
+		}
+	}
+	return name, false
+}
+
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// bufferedWriter holds the response body until validation has finished so that the
+// result can still be reported in a header
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   *bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.status != 0
+}
+
+func (w *bufferedWriter) flush() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	w.ResponseWriter.Write(w.body.Bytes())
+}
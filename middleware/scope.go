@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestScope attaches a fresh upstream-call scope to every request so that the
+// service can enforce per-request limits and report truncated work
+func RequestScope(omdbService *services.OMDbService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := omdbService.NewRequestScope()
+		defer scope.Finish()
+		c.Request = c.Request.WithContext(services.WithScope(c.Request.Context(), scope))
+		c.Next()
+	}
+}
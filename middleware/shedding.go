@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoadShedding rejects requests to the expensive routes with 503 and Retry-After while the
+// shedder reports the API as overloaded, and tracks the load of every other request
+func LoadShedding(shedder *services.LoadShedder, expensive map[string]bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		costly := expensive[c.FullPath()]
+		admitted, retryAfter := shedder.Admit(costly)
+		if !admitted {
+			seconds := int(retryAfter.Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error:        "Service Unavailable",
+				Message:      "The service is under heavy load and is not serving this request type, retry after " + strconv.Itoa(seconds) + " seconds",
+				Code:         http.StatusServiceUnavailable,
+				Retryable:    true,
+				RetryAfterMs: retryAfter.Milliseconds(),
+			})
+			return
+		}
+
+		start := time.Now()
+		defer func() {
+			shedder.Done(costly, time.Since(start))
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignedURL admits requests carrying a valid URL signature without further credentials,
+// so signed links can be opened by a browser. Requests without a signature need the
+// permission as usual; a signature that is forged or expired is rejected with 403.
+func SignedURL(signer *services.URLSigner, policy *services.AccessPolicy, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, signed := c.GetQuery("signature"); !signed {
+			if authorized(c, policy, permission) {
+				c.Next()
+			}
+			return
+		}
+
+		if err := signer.Verify(c.Request.URL.Path, c.Request.URL.Query()); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Forbidden",
+				Message: "The signed URL is invalid or has expired",
+				Code:    http.StatusForbidden,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
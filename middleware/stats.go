@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestStats records the latency and outcome of every request for the status page.
+// It should run outside recovery so that panics are counted as failures.
+func RequestStats(recorder *services.StatusRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		recorder.RecordRequest(time.Since(start), c.Writer.Status() >= http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+const streamedKey = "streamed"
+
+// Streamed marks the response of c as streamed: encoded straight to the client instead of
+// being rendered into a buffer first. The writers of the pipeline don't hold back a copy
+// of it. Stages renaming keys or replacing strings rewrite it as it is written;
+// field_profiles, which needs the whole document, is the only stage that buffers it.
+func Streamed(c *gin.Context) {
+	c.Set(streamedKey, true)
+}
+
+func isStreamed(c *gin.Context) bool {
+	return c.GetBool(streamedKey)
+}
+
+// streamFrame is an object or array rewriteStream is inside of
+type streamFrame struct {
+	object  bool
+	members int
+	// key is set while the next token of an object is a key
+	key bool
+}
+
+// rewriteStream does what rewriteDocument does to a document read from r, writing it to w
+// as it goes. Only the current token, or the value of a dropped member, is held in memory.
+func rewriteStream(r io.Reader, w io.Writer, rename func(string) (string, bool), replace func(string) (json.RawMessage, bool)) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	out := bufio.NewWriter(w)
+
+	var stack []*streamFrame
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF && len(stack) > 0 {
+			return io.ErrUnexpectedEOF
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var top *streamFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			out.WriteRune(rune(delim))
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 && stack[len(stack)-1].object {
+				stack[len(stack)-1].key = true
+			}
+			continue
+		}
+
+		if top != nil && top.key {
+			name, keep := rename(token.(string))
+			if !keep {
+				var dropped json.RawMessage
+				if err := decoder.Decode(&dropped); err != nil {
+					return err
+				}
+				continue
+			}
+			if top.members > 0 {
+				out.WriteByte(',')
+			}
+			top.members++
+			top.key = false
+			key, _ := json.Marshal(name)
+			out.Write(key)
+			out.WriteByte(':')
+			continue
+		}
+		if top != nil && !top.object {
+			if top.members > 0 {
+				out.WriteByte(',')
+			}
+			top.members++
+		}
+
+		switch value := token.(type) {
+		case json.Delim:
+			out.WriteRune(rune(value))
+			stack = append(stack, &streamFrame{object: value == '{', key: value == '{'})
+			continue
+		case string:
+			if replace != nil {
+				if replaced, ok := replace(value); ok {
+					out.Write(replaced)
+					break
+				}
+			}
+			encoded, _ := json.Marshal(value)
+			out.Write(encoded)
+		case json.Number:
+			out.WriteString(value.String())
+		case bool:
+			encoded, _ := json.Marshal(value)
+			out.Write(encoded)
+		case nil:
+			out.WriteString("null")
+		}
+		if top != nil && top.object {
+			top.key = true
+		}
+	}
+	out.WriteByte('\n')
+	return out.Flush()
+}
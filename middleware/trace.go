@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DebugTrace records a trace of the request's upstream work when it is sent with
+// X-Debug-Trace: true by an admin, and returns the trace ID in X-Debug-Trace-Id. The
+// trace is read at /admin/traces/:id. Traced requests bypass the response and
+// recommendation caches, so that the trace shows how the response was computed.
+func DebugTrace(traces *services.TraceStore, policy *services.AccessPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.EqualFold(c.GetHeader("X-Debug-Trace"), "true") {
+			c.Next()
+			return
+		}
+		if !policy.AdminEnabled() {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Debug traces require admin access, and admin endpoints are disabled",
+				Code:    http.StatusForbidden,
+			})
+			return
+		}
+		if !authorized(c, policy, services.PermissionAdmin) {
+			return
+		}
+
+		trace, err := traces.Start(c.Request.Method, c.Request.URL.RequestURI(), CurrentCaller(c, policy).Name)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Internal Server Error",
+				Message: "Failed to start debug trace",
+				Code:    http.StatusInternalServerError,
+			})
+			return
+		}
+		c.Header("X-Debug-Trace-Id", trace.ID())
+		c.Request = c.Request.WithContext(services.WithTrace(c.Request.Context(), trace))
+
+		defer func() {
+			trace.Finish(c.Writer.Status())
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScoreWeights sets the weights of the request's blended scores: those of the tenant
+// API_KEY_TENANTS registers for the request's X-API-Key, or the defaults. It has to be
+// listed before response_cache, whose entries are kept apart by weights.
+func ScoreWeights(weights *services.ScoreWeightStore, policy *services.AccessPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resolved := weights.Default
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			if tenant, ok := policy.KeyTenant(key); ok {
+				resolved = weights.For(tenant)
+			}
+		}
+		c.Request = c.Request.WithContext(services.WithScoreWeights(c.Request.Context(), resolved))
+		c.Next()
+	}
+}
@@ -1,35 +1,47 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // OMDbResponse represents the raw response from OMDb API
 type OMDbResponse struct {
-	Title      string `json:"Title"`
-	Year       string `json:"Year"`
-	Rated      string `json:"Rated"`
-	Released   string `json:"Released"`
-	Runtime    string `json:"Runtime"`
-	Genre      string `json:"Genre"`
-	Director   string `json:"Director"`
-	Writer     string `json:"Writer"`
-	Actors     string `json:"Actors"`
-	Plot       string `json:"Plot"`
-	Language   string `json:"Language"`
-	Country    string `json:"Country"`
-	Awards     string `json:"Awards"`
-	Poster     string `json:"Poster"`
+	Title      string   `json:"Title"`
+	Year       string   `json:"Year"`
+	Rated      string   `json:"Rated"`
+	Released   string   `json:"Released"`
+	Runtime    string   `json:"Runtime"`
+	Genre      string   `json:"Genre"`
+	Director   string   `json:"Director"`
+	Writer     string   `json:"Writer"`
+	Actors     string   `json:"Actors"`
+	Plot       string   `json:"Plot"`
+	Language   string   `json:"Language"`
+	Country    string   `json:"Country"`
+	Awards     string   `json:"Awards"`
+	Poster     string   `json:"Poster"`
 	Ratings    []Rating `json:"Ratings"`
-	Metascore  string `json:"Metascore"`
-	ImdbRating string `json:"imdbRating"`
-	ImdbVotes  string `json:"imdbVotes"`
-	ImdbID     string `json:"imdbID"`
-	Type       string `json:"Type"`
-	DVD        string `json:"DVD"`
-	BoxOffice  string `json:"BoxOffice"`
-	Production string `json:"Production"`
-	Website    string `json:"Website"`
-	Response   string `json:"Response"`
-	Error      string `json:"Error,omitempty"`
-	Season     string `json:"Season,omitempty"`
-	Episode    string `json:"Episode,omitempty"`
+	Metascore  string   `json:"Metascore"`
+	ImdbRating string   `json:"imdbRating"`
+	ImdbVotes  string   `json:"imdbVotes"`
+	ImdbID     string   `json:"imdbID"`
+	Type       string   `json:"Type"`
+	DVD        string   `json:"DVD"`
+	BoxOffice  string   `json:"BoxOffice"`
+	Production string   `json:"Production"`
+	Website    string   `json:"Website"`
+	Response   string   `json:"Response"`
+	Error      string   `json:"Error,omitempty"`
+	Season     string   `json:"Season,omitempty"`
+	Episode    string   `json:"Episode,omitempty"`
+
+	TotalSeasons string `json:"totalSeasons,omitempty"`
+	SeriesID     string `json:"seriesID,omitempty"`
+
+	// Unavailable names the fields OMDb had as "N/A", which are cleared when the record is
+	// decoded, and Ratings.<Source> for each rating dropped for the same reason
+	Unavailable []string `json:"-"`
 }
 
 // Rating represents individual rating from different sources
@@ -41,49 +53,491 @@ type Rating struct {
 // MovieDetailsResponse represents the cleaned response for movie details
 type MovieDetailsResponse struct {
 	Title    string   `json:"title"`
-	Year     string   `json:"year"`
-	Plot     string   `json:"plot"`
-	Country  string   `json:"country"`
-	Awards   string   `json:"awards"`
-	Director string   `json:"director"`
+	Year     string   `json:"year,omitempty"`
+	Plot     string   `json:"plot,omitempty"`
+	Country  string   `json:"country,omitempty"`
+	Awards   string   `json:"awards,omitempty"`
+	Director string   `json:"director,omitempty"`
+	Credits  []Credit `json:"credits,omitempty"`
 	Ratings  []Rating `json:"ratings"`
+	Links    Links    `json:"_links,omitempty"`
+
+	Released     string `json:"released,omitempty"`
+	ReleasedDate string `json:"released_date,omitempty"`
+	// ReleaseStatus is upcoming, in-theaters, home-release or classic
+	ReleaseStatus string `json:"release_status,omitempty"`
+
+	ImdbID     string      `json:"imdb_id,omitempty"`
+	Resolution *Resolution `json:"resolution,omitempty"`
+
+	Rated         string         `json:"rated,omitempty"`
+	Certification *Certification `json:"certification,omitempty"`
+
+	Expansions      map[string]interface{} `json:"expansions,omitempty"`
+	ExpansionErrors map[string]string      `json:"expansion_errors,omitempty"`
+
+	OtherVersions []TitleVersion `json:"other_versions,omitempty"`
+
+	SpoilersHidden bool `json:"spoilers_hidden,omitempty"`
+
+	PosterColors []PosterColor `json:"poster_colors,omitempty"`
+	Blurhash     string        `json:"blurhash,omitempty"`
+
+	// Completeness is the share of OMDb's fields the record has, 0 to 100
+	Completeness int `json:"completeness"`
+}
+
+// Credit is one person credited as director or writer of a title. Roles are the notes
+// OMDb puts in parentheses after the name, such as "screenplay", "story" or "characters".
+type Credit struct {
+	Name  string   `json:"name"`
+	Job   string   `json:"job"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// PosterColor is one dominant color of a poster and the share of its pixels close to it
+type PosterColor struct {
+	Hex   string  `json:"hex"`
+	Share float64 `json:"share"`
+}
+
+// TitleVersion is another release of the same work: an earlier film, a remake or an
+// edition such as a director's cut
+type TitleVersion struct {
+	ImdbID   string `json:"imdb_id"`
+	Title    string `json:"title"`
+	Year     string `json:"year,omitempty"`
+	Relation string `json:"relation"`
+	Links    Links  `json:"_links,omitempty"`
+}
+
+// GameDetailsResponse represents the cleaned response for video game details
+type GameDetailsResponse struct {
+	Title        string   `json:"title"`
+	Year         string   `json:"year,omitempty"`
+	Released     string   `json:"released,omitempty"`
+	ReleasedDate string   `json:"released_date,omitempty"`
+	Genre        string   `json:"genre,omitempty"`
+	Plot         string   `json:"plot,omitempty"`
+	Writer       string   `json:"writer,omitempty"`
+	Actors       string   `json:"actors,omitempty"`
+	Credits      []Credit `json:"credits,omitempty"`
+	ImdbID       string   `json:"imdb_id"`
+	ImdbRating   string   `json:"imdb_rating,omitempty"`
+	Ratings      []Rating `json:"ratings"`
+	Links        Links    `json:"_links,omitempty"`
+
+	SpoilersHidden bool `json:"spoilers_hidden,omitempty"`
+
+	PosterColors []PosterColor `json:"poster_colors,omitempty"`
+	Blurhash     string        `json:"blurhash,omitempty"`
 }
 
 // EpisodeDetailsResponse represents the cleaned response for episode details
 type EpisodeDetailsResponse struct {
-	Title       string   `json:"title"`
-	SeriesTitle string   `json:"series_title"`
-	Season      string   `json:"season"`
-	Episode     string   `json:"episode"`
-	Year        string   `json:"year"`
-	Plot        string   `json:"plot"`
-	Director    string   `json:"director"`
-	Actors      string   `json:"actors"`
-	ImdbRating  string   `json:"imdb_rating"`
-	Ratings     []Rating `json:"ratings"`
+	ImdbID       string   `json:"imdb_id,omitempty"`
+	Title        string   `json:"title"`
+	SeriesTitle  string   `json:"series_title"`
+	SeriesImdbID string   `json:"series_imdb_id,omitempty"`
+	Season       string   `json:"season,omitempty"`
+	Episode      string   `json:"episode,omitempty"`
+	Year         string   `json:"year,omitempty"`
+	Plot         string   `json:"plot,omitempty"`
+	Director     string   `json:"director,omitempty"`
+	Actors       string   `json:"actors,omitempty"`
+	Credits      []Credit `json:"credits,omitempty"`
+	ImdbRating   string   `json:"imdb_rating,omitempty"`
+	Ratings      []Rating `json:"ratings"`
+	Links        Links    `json:"_links,omitempty"`
+
+	// Released is OMDb's air date; AirDate is when the episode aired as an ISO-8601
+	// timestamp in AirTimezone
+	Released     string     `json:"released,omitempty"`
+	ReleasedDate string     `json:"released_date,omitempty"`
+	AirDate      *time.Time `json:"air_date,omitempty"`
+	AirTimezone  string     `json:"air_timezone,omitempty"`
+
+	SpoilersHidden bool `json:"spoilers_hidden,omitempty"`
+
+	PosterColors []PosterColor `json:"poster_colors,omitempty"`
+	Blurhash     string        `json:"blurhash,omitempty"`
+}
+
+// EpisodeRangeResponse represents a contiguous range of episodes of one season
+type EpisodeRangeResponse struct {
+	SeriesTitle  string                   `json:"series_title"`
+	SeriesImdbID string                   `json:"series_imdb_id,omitempty"`
+	Season       int                      `json:"season"`
+	From         int                      `json:"from"`
+	To           int                      `json:"to"`
+	Episodes     []EpisodeDetailsResponse `json:"episodes"`
+	Total        int                      `json:"total"`
+	Missing      []int                    `json:"missing,omitempty"`
+	Links        Links                    `json:"_links,omitempty"`
+	Errors       []FailedItem             `json:"errors,omitempty"`
+	Meta         *ResponseMeta            `json:"meta,omitempty"`
+}
+
+// TitleChangesResponse shows how the record of a title changed between the snapshots
+// taken when it was fetched, newest change first
+type TitleChangesResponse struct {
+	ImdbID      string        `json:"imdb_id"`
+	Title       string        `json:"title"`
+	Version     int           `json:"version"`
+	FirstSeen   time.Time     `json:"first_seen"`
+	LastChecked time.Time     `json:"last_checked"`
+	Changes     []TitleChange `json:"changes"`
+	Total       int           `json:"total"`
+	Links       Links         `json:"_links,omitempty"`
+}
+
+// TitleChange is one version of a title's record: the fields that changed from the
+// previous version
+type TitleChange struct {
+	Version int           `json:"version"`
+	At      time.Time     `json:"at"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is a field whose value changed. From is empty when the field was added
+// and To when it was removed. Ratings are named Ratings.<Source>.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+}
+
+// RatingHistoryResponse is the rating time series of a title, oldest point first
+type RatingHistoryResponse struct {
+	ImdbID      string        `json:"imdb_id"`
+	Title       string        `json:"title"`
+	FirstSeen   time.Time     `json:"first_seen"`
+	LastChecked time.Time     `json:"last_checked"`
+	Points      []RatingPoint `json:"points"`
+	Total       int           `json:"total"`
+	Links       Links         `json:"_links,omitempty"`
+}
+
+// RatingPoint is a title's ratings when it was fetched. Figures OMDb didn't have are
+// omitted.
+type RatingPoint struct {
+	At         time.Time `json:"at"`
+	ImdbRating *float64  `json:"imdb_rating,omitempty"`
+	Metascore  *int      `json:"metascore,omitempty"`
+	ImdbVotes  *int      `json:"imdb_votes,omitempty"`
 }
 
 // GenreMoviesResponse represents the response for genre-based movies
 type GenreMoviesResponse struct {
-	Genre  string       `json:"genre"`
-	Movies []MovieBrief `json:"movies"`
-	Total  int          `json:"total"`
+	Genre          string        `json:"genre"`
+	RequestedGenre string        `json:"requested_genre,omitempty"`
+	Movies         []MovieBrief  `json:"movies"`
+	Total          int           `json:"total"`
+	RefreshedAt    *time.Time    `json:"refreshed_at,omitempty"`
+	Links          Links         `json:"_links,omitempty"`
+	Errors         []FailedItem  `json:"errors,omitempty"`
+	Meta           *ResponseMeta `json:"meta,omitempty"`
+	Explain        *Explanation  `json:"explain,omitempty"`
+}
+
+// GenreList is the precomputed top list of a canonical genre, as of RefreshedAt
+type GenreList struct {
+	Genre       string       `json:"genre"`
+	Movies      []MovieBrief `json:"movies"`
+	RefreshedAt time.Time    `json:"refreshed_at"`
+	// Errors are the titles the refresh couldn't look up
+	Errors []FailedItem `json:"errors,omitempty"`
+}
+
+// QuarantineEntry is a title that failed enrichment Failures times in a row. Once
+// QuarantinedAt is set, background jobs skip it until an admin releases it.
+type QuarantineEntry struct {
+	ImdbID        string     `json:"imdb_id"`
+	Title         string     `json:"title,omitempty"`
+	Failures      int        `json:"failures"`
+	LastError     string     `json:"last_error"`
+	FirstFailedAt time.Time  `json:"first_failed_at"`
+	LastFailedAt  time.Time  `json:"last_failed_at"`
+	QuarantinedAt *time.Time `json:"quarantined_at,omitempty"`
+}
+
+// QuarantineResponse lists the quarantined titles and those still below the threshold
+type QuarantineResponse struct {
+	Threshold   int               `json:"threshold"`
+	Quarantined []QuarantineEntry `json:"quarantined"`
+	Suspects    []QuarantineEntry `json:"suspects"`
+}
+
+// GenreListInfo summarizes one materialized genre list for admins
+type GenreListInfo struct {
+	Genre       string    `json:"genre"`
+	Total       int       `json:"total"`
+	Errors      int       `json:"errors,omitempty"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+}
+
+// GenreListsResponse lists the materialized genre lists
+type GenreListsResponse struct {
+	Lists []GenreListInfo `json:"lists"`
+	Total int             `json:"total"`
+}
+
+// StaffPick is a title admins put on the editorial staff picks list
+type StaffPick struct {
+	ImdbID   string    `json:"imdb_id"`
+	Position int       `json:"position"`
+	Note     string    `json:"note,omitempty"`
+	AddedBy  string    `json:"added_by,omitempty"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// StaffPickRequest is the body of PUT /admin/staff-picks/:imdbID. A zero position keeps a
+// pick where it is, or adds a new one at the end.
+type StaffPickRequest struct {
+	Note     string `json:"note"`
+	Position int    `json:"position"`
+}
+
+// StaffPickMovie is a staff pick with the details of its title
+type StaffPickMovie struct {
+	ImdbID   string     `json:"imdb_id"`
+	Position int        `json:"position"`
+	Note     string     `json:"note,omitempty"`
+	Movie    MovieBrief `json:"movie"`
+}
+
+// StaffPicksResponse lists the staff picks in editorial order
+type StaffPicksResponse struct {
+	Picks   []StaffPickMovie `json:"picks"`
+	Total   int              `json:"total"`
+	Missing []string         `json:"missing,omitempty"`
+	Errors  []FailedItem     `json:"errors,omitempty"`
+	Meta    *ResponseMeta    `json:"meta,omitempty"`
+}
+
+// UpcomingRelease is a title released after today and its release date (2006-01-02)
+type UpcomingRelease struct {
+	ReleaseDate string     `json:"release_date"`
+	Movie       MovieBrief `json:"movie"`
+}
+
+// UpcomingResponse lists upcoming releases, soonest first. Source says where they come
+// from: "corpus" for the titles the API has looked up so far.
+type UpcomingResponse struct {
+	Genre    string            `json:"genre,omitempty"`
+	Releases []UpcomingRelease `json:"releases"`
+	Total    int               `json:"total"`
+	Source   string            `json:"source"`
+	Meta     *ResponseMeta     `json:"meta,omitempty"`
+}
+
+// Collection is a themed list of titles shown during its activation window. A window
+// bound is a date (2024-03-10) or a day of the year (10-01) for windows that recur every
+// year; a missing bound leaves the window open on that side.
+type Collection struct {
+	Slug        string    `json:"slug"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	ImdbIDs     []string  `json:"imdb_ids"`
+	ActiveFrom  string    `json:"active_from,omitempty"`
+	ActiveUntil string    `json:"active_until,omitempty"`
+	Active      bool      `json:"active"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CollectionRequest is the body of PUT /admin/collections/:slug
+type CollectionRequest struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	ImdbIDs     []string `json:"imdb_ids"`
+	ActiveFrom  string   `json:"active_from"`
+	ActiveUntil string   `json:"active_until"`
+}
+
+// CollectionResponse is an active collection with the details of its titles
+type CollectionResponse struct {
+	Slug        string        `json:"slug"`
+	Title       string        `json:"title"`
+	Description string        `json:"description,omitempty"`
+	ActiveFrom  string        `json:"active_from,omitempty"`
+	ActiveUntil string        `json:"active_until,omitempty"`
+	Movies      []MovieBrief  `json:"movies"`
+	Total       int           `json:"total"`
+	Missing     []string      `json:"missing,omitempty"`
+	Links       Links         `json:"_links,omitempty"`
+	Errors      []FailedItem  `json:"errors,omitempty"`
+	Meta        *ResponseMeta `json:"meta,omitempty"`
+}
+
+// CollectionsResponse lists collections
+type CollectionsResponse struct {
+	Collections []Collection `json:"collections"`
+	Total       int          `json:"total"`
+}
+
+// FieldMapping moves the value at From to To in every object of a response that has it.
+// Both are dotted paths, e.g. imdb_rating to rating.imdb.
+type FieldMapping struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// FieldProfile is the field mapping a tenant's responses are rewritten with, in order
+type FieldProfile struct {
+	Tenant    string         `json:"tenant"`
+	Mappings  []FieldMapping `json:"mappings"`
+	UpdatedBy string         `json:"updated_by,omitempty"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// FieldProfileRequest is the body of PUT /admin/field-profiles/:tenant
+type FieldProfileRequest struct {
+	Mappings []FieldMapping `json:"mappings"`
+}
+
+// ScoreWeights weigh the rating sources of a blended score; only their ratios matter
+type ScoreWeights struct {
+	IMDb           float64 `json:"imdb"`
+	Metascore      float64 `json:"metascore"`
+	RottenTomatoes float64 `json:"rotten_tomatoes"`
+}
+
+// TenantScoreWeights are the score weights of a tenant's requests
+type TenantScoreWeights struct {
+	Tenant    string       `json:"tenant"`
+	Weights   ScoreWeights `json:"weights"`
+	UpdatedBy string       `json:"updated_by,omitempty"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// ScoreWeightsResponse lists the default score weights and those of tenants
+type ScoreWeightsResponse struct {
+	Default ScoreWeights         `json:"default"`
+	Tenants []TenantScoreWeights `json:"tenants"`
+	Total   int                  `json:"total"`
+}
+
+// SeedRequest lists the IMDb IDs of the titles to seed the local corpus with
+type SeedRequest struct {
+	ImdbIDs []string `json:"imdb_ids"`
+}
+
+// SeedJob reports the progress of a job seeding the local corpus. Titles already in the
+// corpus or quarantined are skipped; Progress is the percentage of titles processed.
+type SeedJob struct {
+	ID              string       `json:"id"`
+	Status          string       `json:"status"`
+	Total           int          `json:"total"`
+	Processed       int          `json:"processed"`
+	Seeded          int          `json:"seeded"`
+	Skipped         int          `json:"skipped"`
+	Failed          int          `json:"failed"`
+	Progress        float64      `json:"progress"`
+	RatePerMinute   int          `json:"rate_per_minute"`
+	Errors          []FailedItem `json:"errors,omitempty"`
+	Error           string       `json:"error,omitempty"`
+	CreatedBy       string       `json:"created_by,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	FinishedAt      *time.Time   `json:"finished_at,omitempty"`
+	EstimatedFinish *time.Time   `json:"estimated_finish,omitempty"`
+}
+
+// SeedJobsResponse lists the seed jobs kept, newest first
+type SeedJobsResponse struct {
+	Jobs  []SeedJob `json:"jobs"`
+	Total int       `json:"total"`
+}
+
+// FieldProfilesResponse lists the tenants' field profiles
+type FieldProfilesResponse struct {
+	Profiles []FieldProfile `json:"profiles"`
+	Total    int            `json:"total"`
+}
+
+// OscarNominee is a film nominated in an Academy Awards category, with the nominated
+// person for the person categories
+type OscarNominee struct {
+	ImdbID string      `json:"imdb_id"`
+	Title  string      `json:"title"`
+	Person string      `json:"person,omitempty"`
+	Winner bool        `json:"winner"`
+	Movie  *MovieBrief `json:"movie,omitempty"`
+}
+
+// OscarCategory is one Academy Awards category of a ceremony
+type OscarCategory struct {
+	Category string         `json:"category"`
+	Slug     string         `json:"slug"`
+	Nominees []OscarNominee `json:"nominees"`
+}
+
+// OscarsResponse lists the nominees and winners of one Academy Awards ceremony. Year is
+// the year of the ceremony, which honors the films of FilmYear.
+type OscarsResponse struct {
+	Year       int             `json:"year"`
+	Ceremony   int             `json:"ceremony"`
+	FilmYear   int             `json:"film_year"`
+	Categories []OscarCategory `json:"categories"`
+	Links      Links           `json:"_links,omitempty"`
+	Errors     []FailedItem    `json:"errors,omitempty"`
+	Meta       *ResponseMeta   `json:"meta,omitempty"`
+}
+
+// GenreInfo is one genre of the taxonomy with the number of titles seen in it
+type GenreInfo struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// GenresResponse lists the supported genres
+type GenresResponse struct {
+	Genres []GenreInfo `json:"genres"`
+	Total  int         `json:"total"`
 }
 
 // MovieBrief represents a brief movie information
 type MovieBrief struct {
-	Title      string `json:"title"`
-	Year       string `json:"year"`
-	ImdbRating string `json:"imdb_rating"`
-	Genre      string `json:"genre"`
-	Director   string `json:"director"`
-	Plot       string `json:"plot"`
+	Title      string   `json:"title"`
+	Year       string   `json:"year,omitempty"`
+	ImdbID     string   `json:"imdb_id,omitempty"`
+	Type       string   `json:"type,omitempty"`
+	Poster     string   `json:"poster,omitempty"`
+	ImdbRating string   `json:"imdb_rating,omitempty"`
+	Genre      string   `json:"genre,omitempty"`
+	Director   string   `json:"director,omitempty"`
+	Plot       string   `json:"plot,omitempty"`
+	Rated      string   `json:"rated,omitempty"`
+	Runtime    string   `json:"runtime,omitempty"`
+	Language   string   `json:"language,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Blurhash   string   `json:"blurhash,omitempty"`
+	Links      Links    `json:"_links,omitempty"`
+
+	RuntimeSeconds int `json:"runtime_seconds,omitempty"`
+	// VoteCount is the number of IMDb votes, the popularity of the title
+	VoteCount int `json:"vote_count,omitempty"`
+	// Metascore is the Metacritic score, 0 to 100, of the critics' reviews
+	Metascore int `json:"metascore,omitempty"`
+	// RottenTomatoes is the Tomatometer, the percentage of positive reviews
+	RottenTomatoes int `json:"rotten_tomatoes,omitempty"`
+	// Completeness is the share of OMDb's fields the record has, 0 to 100
+	Completeness int `json:"completeness,omitempty"`
+	// BlendedScore weighs the IMDb, Metascore and Rotten Tomatoes scores, 0 to 100, with
+	// the caller's weights; it is set by the discovery endpoints
+	BlendedScore *float64 `json:"blended_score,omitempty"`
+	// Reasons are what a recommendation matched on (genre, director, actor) when it was
+	// found by more than one level
+	Reasons []string `json:"reasons,omitempty"`
 }
 
 // RecommendationResponse represents the movie recommendation response
 type RecommendationResponse struct {
-	FavoriteMovie   MovieBrief   `json:"favorite_movie"`
-	Recommendations []MovieLevel `json:"recommendations"`
+	FavoriteMovie   MovieBrief    `json:"favorite_movie"`
+	Recommendations []MovieLevel  `json:"recommendations"`
+	Links           Links         `json:"_links,omitempty"`
+	Errors          []FailedItem  `json:"errors,omitempty"`
+	Meta            *ResponseMeta `json:"meta,omitempty"`
+	Explain         *Explanation  `json:"explain,omitempty"`
 }
 
 // MovieLevel represents movies grouped by recommendation level
@@ -91,6 +545,10 @@ type MovieLevel struct {
 	Level       int          `json:"level"`
 	Description string       `json:"description"`
 	Movies      []MovieBrief `json:"movies"`
+	// Total and NextCursor are set when a single level is requested
+	Total      int    `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Links      Links  `json:"_links,omitempty"`
 }
 
 // SearchResponse represents OMDb search response
@@ -110,9 +568,860 @@ type SearchResult struct {
 	Poster string `json:"Poster"`
 }
 
+// RatingScore represents a rating normalized to a 0-100 scale
+type RatingScore struct {
+	Source string  `json:"source"`
+	Value  string  `json:"value"`
+	Score  float64 `json:"score"`
+}
+
+// RatingsExpansion represents the include=ratings expansion
+type RatingsExpansion struct {
+	Scores    []RatingScore `json:"scores"`
+	Metascore int           `json:"metascore,omitempty"`
+	ImdbVotes int           `json:"imdb_votes,omitempty"`
+}
+
+// ExtendedDetails represents the include=details expansion
+type ExtendedDetails struct {
+	ImdbID     string `json:"imdb_id"`
+	Rated      string `json:"rated,omitempty"`
+	Released   string `json:"released,omitempty"`
+	Runtime    string `json:"runtime,omitempty"`
+	Genre      string `json:"genre,omitempty"`
+	Writer     string `json:"writer,omitempty"`
+	Actors     string `json:"actors,omitempty"`
+	Language   string `json:"language,omitempty"`
+	BoxOffice  string `json:"box_office,omitempty"`
+	Production string `json:"production,omitempty"`
+	Website    string `json:"website,omitempty"`
+
+	ReleasedDate   string `json:"released_date,omitempty"`
+	RuntimeSeconds int    `json:"runtime_seconds,omitempty"`
+}
+
+// WikipediaSummary represents the include=wikipedia expansion
+type WikipediaSummary struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Extract     string `json:"extract"`
+	URL         string `json:"url"`
+	Thumbnail   string `json:"thumbnail,omitempty"`
+}
+
+// SearchItem represents a lightweight search result
+type SearchItem struct {
+	Title  string `json:"title"`
+	Year   string `json:"year,omitempty"`
+	ImdbID string `json:"imdb_id"`
+	Type   string `json:"type"`
+	Poster string `json:"poster,omitempty"`
+
+	// Populated when the search is enriched with full details
+	ImdbRating string `json:"imdb_rating,omitempty"`
+	Genre      string `json:"genre,omitempty"`
+	Director   string `json:"director,omitempty"`
+	Plot       string `json:"plot,omitempty"`
+
+	TotalSeasons int   `json:"total_seasons,omitempty"`
+	Links        Links `json:"_links,omitempty"`
+}
+
+// SearchTitlesResponse represents a page of title search results
+type SearchTitlesResponse struct {
+	Query          string        `json:"query"`
+	CorrectedQuery string        `json:"corrected_query,omitempty"`
+	Type           string        `json:"type"`
+	Results        []SearchItem  `json:"results"`
+	Total          int           `json:"total"`
+	TotalResults   int           `json:"total_results"`
+	Enriched       bool          `json:"enriched"`
+	NextCursor     string        `json:"next_cursor,omitempty"`
+	Links          Links         `json:"_links,omitempty"`
+	Errors         []FailedItem  `json:"errors,omitempty"`
+	Meta           *ResponseMeta `json:"meta,omitempty"`
+}
+
+// QueryRequest represents the body of a discovery query: filters that all have to hold,
+// a sort order such as "-rating" (a leading "-" sorts descending) and a result limit
+type QueryRequest struct {
+	Filters QueryFilters `json:"filters"`
+	Sort    string       `json:"sort,omitempty"`
+	Limit   int          `json:"limit,omitempty"`
+	// Weights replace the caller's score weights for blended_score
+	Weights *ScoreWeights `json:"weights,omitempty"`
+}
+
+// QueryFilters narrow the titles of a query; filters left out match every title. Genres
+// and People must all match, ExcludeGenres must not.
+type QueryFilters struct {
+	Type          string      `json:"type,omitempty"`
+	Genres        []string    `json:"genres,omitempty"`
+	ExcludeGenres []string    `json:"exclude_genres,omitempty"`
+	Year          *QueryRange `json:"year,omitempty"`
+	Rating        *QueryRange `json:"rating,omitempty"`
+	Metascore     *QueryRange `json:"metascore,omitempty"`
+	Runtime       *QueryRange `json:"runtime,omitempty"`
+	People        []string    `json:"people,omitempty"`
+}
+
+// QueryRange bounds a numeric field, inclusively; either end may be left open
+type QueryRange struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// QueryResponse lists the titles matching a discovery query. ProviderFallback is set when
+// the local corpus had too few matches and OMDb was searched for more.
+type QueryResponse struct {
+	Movies           []MovieBrief  `json:"movies"`
+	Total            int           `json:"total"`
+	ProviderFallback bool          `json:"provider_fallback"`
+	Links            Links         `json:"_links,omitempty"`
+	Errors           []FailedItem  `json:"errors,omitempty"`
+	Meta             *ResponseMeta `json:"meta,omitempty"`
+	Explain          *Explanation  `json:"explain,omitempty"`
+}
+
 // ErrorResponse represents error response
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message"`
 	Code    int    `json:"code"`
+
+	// Retryable tells clients whether repeating the request can succeed, after RetryAfterMs if set
+	Retryable    bool  `json:"retryable"`
+	RetryAfterMs int64 `json:"retry_after_ms,omitempty"`
+}
+
+// Resolution describes how a title reference was resolved to a canonical IMDb ID
+type Resolution struct {
+	ImdbID     string  `json:"imdb_id"`
+	Title      string  `json:"title"`
+	Year       string  `json:"year,omitempty"`
+	Type       string  `json:"type"`
+	Confidence float64 `json:"confidence"`
+	Method     string  `json:"method"`
+}
+
+// PersonMatch is the canonical spelling a person name query resolved to
+type PersonMatch struct {
+	Query  string `json:"query"`
+	Name   string `json:"name"`
+	Method string `json:"method"`
+}
+
+// PersonBio is what Wikidata and Wikipedia know about a person, as of RetrievedAt
+type PersonBio struct {
+	Name         string            `json:"name"`
+	WikidataID   string            `json:"wikidata_id"`
+	Description  string            `json:"description,omitempty"`
+	BirthDate    string            `json:"birth_date,omitempty"`
+	DeathDate    string            `json:"death_date,omitempty"`
+	Nationality  []string          `json:"nationality,omitempty"`
+	Photo        string            `json:"photo,omitempty"`
+	NotableWorks []string          `json:"notable_works,omitempty"`
+	ImdbID       string            `json:"imdb_id,omitempty"`
+	Wikipedia    *WikipediaSummary `json:"wikipedia,omitempty"`
+	RetrievedAt  time.Time         `json:"retrieved_at"`
+}
+
+// FilmographyEntry is a title a person worked on, with their roles in it
+type FilmographyEntry struct {
+	ImdbID string   `json:"imdb_id"`
+	Title  string   `json:"title"`
+	Year   string   `json:"year,omitempty"`
+	Type   string   `json:"type,omitempty"`
+	Roles  []string `json:"roles"`
+	Links  Links    `json:"_links,omitempty"`
+}
+
+// PersonBioResponse is a person's biography merged with their filmography among the
+// titles the API has seen
+type PersonBioResponse struct {
+	Query       string             `json:"query,omitempty"`
+	Bio         PersonBio          `json:"bio"`
+	Filmography []FilmographyEntry `json:"filmography"`
+	Meta        *ResponseMeta      `json:"meta,omitempty"`
+}
+
+// Alias maps an alternate title to a canonical IMDb ID
+type Alias struct {
+	Alias     string     `json:"alias"`
+	ImdbID    string     `json:"imdb_id"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// AliasRequest represents the body of an alias create/update request
+type AliasRequest struct {
+	ImdbID string `json:"imdb_id" binding:"required"`
+}
+
+// MonitorRequest represents the body of a monitor registration. Either or both of
+// Webhook and Email receive the alerts.
+type MonitorRequest struct {
+	ImdbID    string   `json:"imdb_id" binding:"required"`
+	Field     string   `json:"field" binding:"required"`
+	Condition string   `json:"condition" binding:"required"`
+	Threshold *float64 `json:"threshold,omitempty"`
+	Webhook   string   `json:"webhook,omitempty"`
+	Slack     string   `json:"slack,omitempty"`
+	Email     string   `json:"email,omitempty"`
+}
+
+// Monitor watches one field of a title and alerts when its condition starts to hold
+type Monitor struct {
+	ID        string    `json:"id"`
+	ImdbID    string    `json:"imdb_id"`
+	Title     string    `json:"title"`
+	Field     string    `json:"field"`
+	Condition string    `json:"condition"`
+	Threshold *float64  `json:"threshold,omitempty"`
+	Webhook   string    `json:"webhook,omitempty"`
+	Slack     string    `json:"slack,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Met is whether the condition held at the last check; alerts fire when it becomes true
+	Met           bool       `json:"met"`
+	LastValue     string     `json:"last_value,omitempty"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	LastAlertAt   *time.Time `json:"last_alert_at,omitempty"`
+}
+
+// MonitorAlert is the payload delivered when a monitor's condition starts to hold
+type MonitorAlert struct {
+	MonitorID string    `json:"monitor_id"`
+	ImdbID    string    `json:"imdb_id"`
+	Title     string    `json:"title"`
+	Field     string    `json:"field"`
+	Condition string    `json:"condition"`
+	Threshold *float64  `json:"threshold,omitempty"`
+	Value     string    `json:"value"`
+	Previous  string    `json:"previous,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// Explanation describes how a discovery response was produced (explain=true): the stages
+// it went through, in order, and the candidates each of them produced
+type Explanation struct {
+	Stages []ExplainStage `json:"stages"`
+}
+
+// ExplainStage is one step of a discovery request, such as an OMDb search, a filter or a
+// ranking. Input is the number of candidates the stage was given and Candidates the
+// number it passed on. Cache counts the detail cache lookups by outcome (HIT, STALE, MISS).
+type ExplainStage struct {
+	Stage         string         `json:"stage"`
+	Strategy      string         `json:"strategy,omitempty"`
+	Provider      string         `json:"provider,omitempty"`
+	Terms         []string       `json:"terms,omitempty"`
+	Filters       []string       `json:"filters,omitempty"`
+	Input         int            `json:"input"`
+	Candidates    int            `json:"candidates"`
+	UpstreamCalls int            `json:"upstream_calls"`
+	Cache         map[string]int `json:"cache,omitempty"`
+	DurationMs    float64        `json:"duration_ms"`
+}
+
+// ResponseMeta reports how the server limited the work done for a response
+type ResponseMeta struct {
+	Truncated     bool         `json:"truncated"`
+	Truncations   []Truncation `json:"truncations,omitempty"`
+	UpstreamCalls int          `json:"upstream_calls"`
+	// Variant is the recommendation engine that produced the response
+	Variant string `json:"variant,omitempty"`
+}
+
+// FailedItem is an item of a list (an IMDb ID, or a series ID with season and episode)
+// left out because looking it up failed, with the title when it is known. List responses
+// report them in errors rather than dropping them silently. Panicked marks a bug rather
+// than an upstream failure.
+type FailedItem struct {
+	Item     string `json:"item"`
+	Title    string `json:"title,omitempty"`
+	Reason   string `json:"reason"`
+	Panicked bool   `json:"panicked,omitempty"`
+}
+
+// Truncation describes one configured limit that cut work short
+type Truncation struct {
+	Limit  string `json:"limit"`
+	Value  int    `json:"value"`
+	Detail string `json:"detail"`
+}
+
+// Link represents a hypermedia link to a related resource
+type Link struct {
+	Href string `json:"href"`
+}
+
+// Links maps relation names (self, poster, similar, ...) to links
+type Links map[string]Link
+
+// Certification is a title's age rating in the rating system of the requested country
+type Certification struct {
+	Country  string `json:"country"`
+	System   string `json:"system"`
+	Rating   string `json:"rating"`
+	Original string `json:"original"`
+	// Approximate is set when the rating was mapped from the US certification rather than issued locally
+	Approximate bool `json:"approximate"`
+}
+
+// CanaryReport shows how recommendation traffic is split between the engines
+type CanaryReport struct {
+	Percent int            `json:"v2_percent"`
+	Served  map[string]int `json:"served"`
+}
+
+// ShadowReport summarizes how a secondary provider's answers compare with the primary's
+type ShadowReport struct {
+	Enabled         bool           `json:"enabled"`
+	Secondary       string         `json:"secondary,omitempty"`
+	SamplePercent   int            `json:"sample_percent,omitempty"`
+	Compared        int            `json:"compared"`
+	Matched         int            `json:"matched"`
+	Mismatched      int            `json:"mismatched"`
+	MatchRate       float64        `json:"match_rate"`
+	Failed          int            `json:"failed"`
+	Dropped         int            `json:"dropped"`
+	FieldMismatches map[string]int `json:"field_mismatches"`
+	RecentDiffs     []ShadowDiff   `json:"recent_diffs"`
+}
+
+// ShadowDiff is one lookup whose answers differed
+type ShadowDiff struct {
+	Lookup string            `json:"lookup"`
+	Fields []ShadowFieldDiff `json:"fields"`
+	At     time.Time         `json:"at"`
+}
+
+// ShadowFieldDiff is a field that differed between the providers
+type ShadowFieldDiff struct {
+	Field     string `json:"field"`
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+}
+
+// DebugTrace is the recorded upstream work of one request sent with X-Debug-Trace: true
+type DebugTrace struct {
+	ID         string    `json:"id"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Actor      string    `json:"actor"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Status     int       `json:"status"`
+	// Complete is false while the request is still running
+	Complete     bool        `json:"complete"`
+	Calls        []TraceCall `json:"calls,omitempty"`
+	CallsDropped int         `json:"calls_dropped,omitempty"`
+}
+
+// TraceCall is one OMDb call (source upstream) or detail cache hit (source cache) of a
+// traced request. OffsetMs is the time since the request started.
+type TraceCall struct {
+	Source           string      `json:"source"`
+	URL              string      `json:"url,omitempty"`
+	CacheKey         string      `json:"cache_key,omitempty"`
+	CacheStatus      string      `json:"cache_status,omitempty"`
+	CacheAgeMs       int64       `json:"cache_age_ms,omitempty"`
+	OffsetMs         int64       `json:"offset_ms"`
+	DurationMs       int64       `json:"duration_ms"`
+	Error            string      `json:"error,omitempty"`
+	Payload          interface{} `json:"payload,omitempty"`
+	PayloadTruncated bool        `json:"payload_truncated,omitempty"`
+}
+
+// SchemaDriftReport lists the ways upstream payloads departed from the expected schema
+type SchemaDriftReport struct {
+	// Checked and Drifted count the upstream payloads checked and those with findings
+	Checked int64              `json:"checked"`
+	Drifted int64              `json:"drifted"`
+	Fields  []SchemaDriftField `json:"fields"`
+}
+
+// SchemaDriftField is one field found to drift, with the latest sample. Kind is
+// unknown_field, type_mismatch or format.
+type SchemaDriftField struct {
+	Field        string    `json:"field"`
+	Kind         string    `json:"kind"`
+	Expected     string    `json:"expected,omitempty"`
+	Actual       string    `json:"actual"`
+	Count        int64     `json:"count"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+	Sample       string    `json:"sample"`
+	SampleLookup string    `json:"sample_lookup"`
+}
+
+// StatusReport is the public status page: recent upstream and API behaviour, quota use
+// and incident flags
+type StatusReport struct {
+	// Status is operational, degraded or outage
+	Status        string              `json:"status"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+	WindowMinutes int                 `json:"window_minutes"`
+	API           TrafficStatus       `json:"api"`
+	Upstream      TrafficStatus       `json:"upstream"`
+	BaseURLs      []BaseURLStatus     `json:"base_urls"`
+	Quota         QuotaStatus         `json:"quota"`
+	Queue         *QueueStatus        `json:"queue,omitempty"`
+	CacheShards   []CacheShardStatus  `json:"cache_shards,omitempty"`
+	Shedding      *LoadSheddingStatus `json:"shedding,omitempty"`
+	Incidents     []Incident          `json:"incidents"`
+}
+
+// LoadSheddingStatus describes the overload protection: the load it watches, its
+// thresholds and whether expensive requests are being rejected
+type LoadSheddingStatus struct {
+	Shedding    bool   `json:"shedding"`
+	Reason      string `json:"reason,omitempty"`
+	InFlight    int    `json:"in_flight"`
+	MaxInFlight int    `json:"max_in_flight"`
+	P95Ms       int64  `json:"p95_ms"`
+	TargetP95Ms int64  `json:"target_p95_ms"`
+	Shed        int64  `json:"shed"`
+}
+
+// CacheShardStatus is the health of one Redis node of the shared cache
+type CacheShardStatus struct {
+	Address   string     `json:"address"`
+	Healthy   bool       `json:"healthy"`
+	Failures  int64      `json:"failures"`
+	LastError string     `json:"last_error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// QueueStatus describes the OMDb request queue when a requests-per-second ceiling is set.
+// Waiting and Granted are keyed by priority class.
+type QueueStatus struct {
+	MaxRPS  float64          `json:"max_rps"`
+	Burst   int              `json:"burst"`
+	Waiting map[string]int   `json:"waiting"`
+	Granted map[string]int64 `json:"granted"`
+}
+
+// ScalingMetrics are the signals autoscalers act on. QueueDepth is the number of OMDb
+// calls waiting for the scheduler's rate ceiling; the upstream fields are set when
+// MAX_UPSTREAM_CONCURRENCY is. The request reading the metrics isn't counted in flight.
+type ScalingMetrics struct {
+	QueueDepth          int            `json:"queue_depth"`
+	QueueByPriority     map[string]int `json:"queue_by_priority,omitempty"`
+	InFlightRequests    int64          `json:"in_flight_requests"`
+	InFlightFanOuts     int64          `json:"in_flight_fan_outs"`
+	UpstreamInFlight    *int           `json:"upstream_in_flight,omitempty"`
+	UpstreamConcurrency int            `json:"upstream_concurrency,omitempty"`
+	CacheHitRatio       float64        `json:"cache_hit_ratio"`
+	CacheLookups        int64          `json:"cache_lookups"`
+	WindowMinutes       int            `json:"window_minutes"`
+	GeneratedAt         time.Time      `json:"generated_at"`
+}
+
+// GoroutineSummary counts the running goroutines by state and in groups of goroutines in
+// the same state, function and creator, largest first
+type GoroutineSummary struct {
+	Total       int              `json:"total"`
+	ByState     map[string]int   `json:"by_state"`
+	Groups      []GoroutineGroup `json:"groups"`
+	GeneratedAt time.Time        `json:"generated_at"`
+}
+
+// GoroutineGroup is a set of goroutines blocked in the same state and function, started by
+// the same function. MaxWaitMinutes is the longest any of them has been blocked.
+type GoroutineGroup struct {
+	State          string `json:"state"`
+	Function       string `json:"function"`
+	CreatedBy      string `json:"created_by,omitempty"`
+	Count          int    `json:"count"`
+	MaxWaitMinutes int    `json:"max_wait_minutes,omitempty"`
+}
+
+// TrafficStatus summarizes requests over the status window
+type TrafficStatus struct {
+	Requests     int     `json:"requests"`
+	Errors       int     `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	AvgLatencyMs int64   `json:"avg_latency_ms"`
+}
+
+// BaseURLStatus is the failover health of one OMDb base URL
+type BaseURLStatus struct {
+	Host    string  `json:"host"`
+	Score   float64 `json:"score"`
+	Benched bool    `json:"benched"`
+}
+
+// QuotaStatus is the OMDb quota used today (UTC)
+type QuotaStatus struct {
+	CallsToday int  `json:"calls_today"`
+	DailyLimit int  `json:"daily_limit,omitempty"`
+	Remaining  int  `json:"remaining"`
+	Exhausted  bool `json:"exhausted"`
+	// Shared is set when the figures cover all replicas (counted in Redis)
+	Shared bool `json:"shared,omitempty"`
+	// Keys is the usage of each pooled API key by this replica, when several are configured
+	Keys []APIKeyUsage `json:"keys,omitempty"`
+}
+
+// APIKeyUsage is one pooled OMDb API key's use today (UTC). The key is masked.
+type APIKeyUsage struct {
+	Index       int    `json:"index"`
+	Key         string `json:"key"`
+	CallsToday  int    `json:"calls_today"`
+	QuotaErrors int    `json:"quota_errors"`
+	Exhausted   bool   `json:"exhausted"`
+	Invalid     bool   `json:"invalid"`
+}
+
+// Incident is a condition flagged on the status page
+type Incident struct {
+	Flag    string `json:"flag"`
+	Message string `json:"message"`
+}
+
+// AuditEntry records one admin mutation with the state before and after it
+type AuditEntry struct {
+	ID       int64           `json:"id"`
+	At       time.Time       `json:"at"`
+	Actor    string          `json:"actor"`
+	ClientIP string          `json:"client_ip"`
+	Action   string          `json:"action"`
+	Target   string          `json:"target"`
+	Before   json.RawMessage `json:"before,omitempty"`
+	After    json.RawMessage `json:"after,omitempty"`
+}
+
+// ContentViolation records profanity or personal data found in text a user wrote
+type ContentViolation struct {
+	ID         int64     `json:"id"`
+	At         time.Time `json:"at"`
+	UserID     string    `json:"user_id"`
+	Kind       string    `json:"kind"`
+	Target     string    `json:"target"`
+	Violations []string  `json:"violations"`
+	Action     string    `json:"action"`
+}
+
+// User is a local account. Users are created on their first external login and keep
+// the identities that map to them.
+type User struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name,omitempty"`
+	Email       string     `json:"email,omitempty"`
+	Role        string     `json:"role"`
+	Identities  []Identity `json:"identities"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt time.Time  `json:"last_login_at"`
+	// TokensRevokedAt invalidates the tokens issued up to then, e.g. on a ban
+	TokensRevokedAt *time.Time `json:"tokens_revoked_at,omitempty"`
+}
+
+// Identity is an account at an external login provider
+type Identity struct {
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+}
+
+// LoginResponse carries the token issued after a successful login
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
+}
+
+// RoleRequest represents the body of a user role change
+type RoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// PermissionsMatrix lists what each role may do and what each route requires
+type PermissionsMatrix struct {
+	Roles  map[string][]string `json:"roles"`
+	Routes []RoutePermission   `json:"routes"`
+}
+
+// RoutePermission is the permission a route requires and the roles that have it. Public
+// routes have no permission.
+type RoutePermission struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Permission string   `json:"permission,omitempty"`
+	Roles      []string `json:"roles"`
+}
+
+// SignedURL is a time-limited link that works without credentials
+type SignedURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Preferences are a user's defaults for the discovery endpoints
+type Preferences struct {
+	PreferredGenres  []string   `json:"preferred_genres"`
+	DislikedGenres   []string   `json:"disliked_genres"`
+	MaxRuntime       int        `json:"max_runtime_minutes,omitempty"`
+	Language         string     `json:"language,omitempty"`
+	MaxContentRating string     `json:"max_content_rating,omitempty"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty"`
+}
+
+// Leaderboard ranks titles by user activity within a time window, as of ComputedAt
+type Leaderboard struct {
+	Board      string             `json:"board"`
+	Window     string             `json:"window"`
+	Entries    []LeaderboardEntry `json:"entries"`
+	Total      int                `json:"total"`
+	ComputedAt time.Time          `json:"computed_at"`
+	Links      Links              `json:"_links,omitempty"`
+}
+
+// LeaderboardEntry is a ranked title. Watchers is set on the watchlisted board, and
+// AverageRating and Ratings on the user-rated board.
+type LeaderboardEntry struct {
+	Rank          int     `json:"rank"`
+	ImdbID        string  `json:"imdb_id"`
+	Title         string  `json:"title,omitempty"`
+	Watchers      int     `json:"watchers,omitempty"`
+	AverageRating float64 `json:"average_rating,omitempty"`
+	Ratings       int     `json:"ratings,omitempty"`
+	Links         Links   `json:"_links,omitempty"`
+}
+
+// UserRating is a user's own rating of a title, from 1 to 10
+type UserRating struct {
+	ImdbID  string    `json:"imdb_id"`
+	Rating  int       `json:"rating"`
+	RatedAt time.Time `json:"rated_at"`
+}
+
+// Follow is a user followed by, or following, another user
+type Follow struct {
+	UserID     string    `json:"user_id"`
+	Name       string    `json:"name,omitempty"`
+	FollowedAt time.Time `json:"followed_at"`
+	// Pending is set on a follow request waiting for approval; FollowedAt is when it was sent
+	Pending bool `json:"pending,omitempty"`
+}
+
+// FeedItem is something a followed user did: rated a title (Kind rating, with Rating) or
+// started watching one with a monitor (Kind watch)
+type FeedItem struct {
+	Kind   string    `json:"kind"`
+	UserID string    `json:"user_id"`
+	Name   string    `json:"name,omitempty"`
+	ImdbID string    `json:"imdb_id"`
+	Title  string    `json:"title,omitempty"`
+	Rating int       `json:"rating,omitempty"`
+	At     time.Time `json:"at"`
+	Links  Links     `json:"_links,omitempty"`
+}
+
+// FeedResponse is a page of the activity feed, newest first
+type FeedResponse struct {
+	Items      []FeedItem `json:"items"`
+	Total      int        `json:"total"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	Links      Links      `json:"_links,omitempty"`
+}
+
+// PrivacySettings say who may see each area of a user's activity: everyone (public), the
+// users following them (followers) or nobody else (private)
+type PrivacySettings struct {
+	Lists     string     `json:"lists"`
+	Ratings   string     `json:"ratings"`
+	History   string     `json:"history"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// WatchlistItem is a title a user watches with a monitor, since the first was created
+type WatchlistItem struct {
+	ImdbID string    `json:"imdb_id"`
+	Title  string    `json:"title,omitempty"`
+	Since  time.Time `json:"since"`
+	Links  Links     `json:"_links,omitempty"`
+}
+
+// Review is a user's written review of a title with their rating from 1 to 10. Flags are
+// the moderation flags its text raised; flagged reviews are pending until a moderator
+// publishes or rejects them. Spoiler marks the whole review as a spoiler; the body may
+// also tag spans as [spoiler]...[/spoiler].
+type Review struct {
+	ID          string     `json:"id"`
+	ImdbID      string     `json:"imdb_id"`
+	UserID      string     `json:"user_id"`
+	Name        string     `json:"name,omitempty"`
+	Rating      int        `json:"rating"`
+	Body        string     `json:"body"`
+	Spoiler     bool       `json:"spoiler,omitempty"`
+	Status      string     `json:"status"`
+	Flags       []string   `json:"flags,omitempty"`
+	Redactions  []string   `json:"redactions,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	ModeratedAt *time.Time `json:"moderated_at,omitempty"`
+	Likes       int        `json:"likes"`
+	Liked       bool       `json:"liked,omitempty"`
+	Links       Links      `json:"_links,omitempty"`
+
+	SpoilersHidden bool `json:"spoilers_hidden,omitempty"`
+}
+
+// Likes is the like count of a review or list and whether the caller likes it
+type Likes struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	Likes int    `json:"likes"`
+	Liked bool   `json:"liked"`
+}
+
+// Report is a user's report of a review or watchlist breaking the rules
+type Report struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	TargetID   string     `json:"target_id"`
+	OwnerID    string     `json:"owner_id"`
+	ReporterID string     `json:"reporter_id"`
+	Reason     string     `json:"reason"`
+	Comment    string     `json:"comment,omitempty"`
+	Status     string     `json:"status"`
+	Resolution string     `json:"resolution,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+
+	// Hidden is whether the reported content is currently hidden
+	Hidden bool `json:"hidden"`
+}
+
+// ReportRequest represents the body of a report: the kind and ID of the content, a
+// reason of spam, abuse, spoiler or other, and an optional comment
+type ReportRequest struct {
+	Kind    string `json:"kind" binding:"required"`
+	ID      string `json:"id" binding:"required"`
+	Reason  string `json:"reason" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// ReportResolveRequest represents the body of a moderator's resolution of a report
+type ReportResolveRequest struct {
+	Action string `json:"action" binding:"required"`
+}
+
+// ReviewRequest represents the body of a review submission
+type ReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required"`
+	Body    string `json:"body" binding:"required"`
+	Spoiler bool   `json:"spoiler"`
+}
+
+// ReviewsResponse is a page of a title's reviews, newest first
+type ReviewsResponse struct {
+	ImdbID     string   `json:"imdb_id"`
+	Reviews    []Review `json:"reviews"`
+	Total      int      `json:"total"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	Links      Links    `json:"_links,omitempty"`
+}
+
+// ReviewStatusRequest represents the body of a moderation decision
+type ReviewStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// OnboardingTitle is a well-known title offered to new users to rate
+type OnboardingTitle struct {
+	ImdbID string `json:"imdb_id"`
+	Title  string `json:"title"`
+	Year   string `json:"year,omitempty"`
+	Genre  string `json:"genre,omitempty"`
+	Poster string `json:"poster,omitempty"`
+}
+
+// OnboardingTitlesResponse represents the onboarding sample
+type OnboardingTitlesResponse struct {
+	Titles []OnboardingTitle `json:"titles"`
+	Total  int               `json:"total"`
+	Errors []FailedItem      `json:"errors,omitempty"`
+	Meta   *ResponseMeta     `json:"meta,omitempty"`
+}
+
+// OnboardingRatingsRequest represents the body of a bulk rating submission
+type OnboardingRatingsRequest struct {
+	Ratings []UserRating `json:"ratings" binding:"required"`
+}
+
+// OnboardingResponse carries the first recommendations after onboarding
+type OnboardingResponse struct {
+	Stored          int           `json:"stored"`
+	Seeds           []MovieBrief  `json:"seeds"`
+	Recommendations []MovieBrief  `json:"recommendations"`
+	Meta            *ResponseMeta `json:"meta,omitempty"`
+}
+
+// Tag is a subgenre or theme of the tag taxonomy, e.g. heist or time-travel. Keywords
+// assign the tag to titles whose plot mentions them when extraction is enabled.
+type Tag struct {
+	Slug        string   `json:"slug"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	Titles      int      `json:"titles"`
+}
+
+// MaintenanceState is the admin-controlled maintenance switch. While it is enabled the
+// data routes answer 503 with Message and a Retry-After of RetryAfterSeconds.
+type MaintenanceState struct {
+	Enabled           bool       `json:"enabled"`
+	Message           string     `json:"message,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty"`
+	Until             *time.Time `json:"until,omitempty"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	UpdatedBy         string     `json:"updated_by,omitempty"`
+}
+
+// MaintenanceRequest enables or updates maintenance mode via the admin API. Until is the
+// expected end of the window; it sets the retry hint when RetryAfterSeconds is not given.
+type MaintenanceRequest struct {
+	Message           string     `json:"message"`
+	RetryAfterSeconds int        `json:"retry_after_seconds"`
+	Until             *time.Time `json:"until"`
+}
+
+// TagRequest creates or updates a tag via the admin API
+type TagRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Keywords    []string `json:"keywords"`
+}
+
+// TitleTags are the tags assigned to a title by admins
+type TitleTags struct {
+	ImdbID    string    `json:"imdb_id"`
+	Tags      []string  `json:"tags"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TitleTagsRequest replaces the tags of a title via the admin API
+type TitleTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// TitleSpoilers are the passages of a title's plot tagged as spoilers
+type TitleSpoilers struct {
+	ImdbID string   `json:"imdb_id"`
+	Spans  []string `json:"spans"`
+}
+
+// TitleSpoilersRequest replaces the spoiler spans of a title via the admin API
+type TitleSpoilersRequest struct {
+	Spans []string `json:"spans"`
+}
+
+// TagsResponse lists the tag taxonomy
+type TagsResponse struct {
+	Tags       []Tag `json:"tags"`
+	Total      int   `json:"total"`
+	Extraction bool  `json:"extraction"`
 }
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+)
+
+// EmailChannel sends plain-text email through an SMTP relay
+type EmailChannel struct {
+	// Addr is the relay's host:port
+	Addr string
+	// From is the sender address
+	From string
+	// Auth authenticates with the relay; nil sends without authentication
+	Auth smtp.Auth
+}
+
+// NewEmailChannel configures a relay, with PLAIN authentication when username is set
+func NewEmailChannel(addr, from, username, password string) *EmailChannel {
+	channel := &EmailChannel{Addr: addr, From: from}
+	if username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		channel.Auth = smtp.PlainAuth("", username, password, host)
+	}
+	return channel
+}
+
+func (e *EmailChannel) Validate(destination string) error {
+	if !strings.Contains(destination, "@") || strings.ContainsAny(destination, "\r\n") {
+		return fmt.Errorf("%w: email is invalid", ErrInvalidDestination)
+	}
+	return nil
+}
+
+func (e *EmailChannel) Send(ctx context.Context, destination string, msg Message) error {
+	// Line breaks in the subject would start new headers
+	subject := strings.Join(strings.Fields(msg.Subject), " ")
+	text := strings.ReplaceAll(strings.ReplaceAll(msg.Text, "\r\n", "\n"), "\n", "\r\n")
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s", e.From, destination, subject, text)
+
+	err := smtp.SendMail(e.Addr, e.Auth, e.From, []string{destination}, []byte(message))
+	// 5xx replies, such as an unknown mailbox, won't succeed on a retry
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,192 @@
+// Package notify delivers notifications to webhooks, Slack, email and the log. A feature
+// renders a Message from its Template and sends it through a Dispatcher, which picks the
+// channel by kind, validates destinations and retries failed deliveries, so features
+// don't each implement delivery themselves.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Channel kinds
+const (
+	KindWebhook = "webhook"
+	KindSlack   = "slack"
+	KindEmail   = "email"
+	KindLog     = "log"
+)
+
+// ErrInvalidDestination wraps the reason a destination was rejected
+var ErrInvalidDestination = errors.New("invalid destination")
+
+// Message is one notification. Subject and Text are for people; Data is the structured
+// payload that webhooks receive as JSON.
+type Message struct {
+	Subject string
+	Text    string
+	Data    interface{}
+}
+
+// Channel delivers messages to one kind of destination, such as webhook URLs or email
+// addresses
+type Channel interface {
+	// Validate checks a destination before a feature stores it
+	Validate(destination string) error
+	// Send delivers msg to destination. Failures that a retry won't fix are wrapped
+	// with Permanent.
+	Send(ctx context.Context, destination string, msg Message) error
+}
+
+// permanentError is a failure that retrying won't fix, such as a rejected request
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// Dispatcher sends messages through the channel registered for their kind. A failed
+// delivery is retried up to Attempts times in total, waiting Backoff, then twice as long
+// and so on, unless the failure is permanent.
+type Dispatcher struct {
+	// Attempts is the number of tries per delivery, at least 1
+	Attempts int
+	// Backoff is the wait before the first retry; it doubles after each one
+	Backoff time.Duration
+	// DryRun logs every delivery instead of sending it, e.g. in staging
+	DryRun bool
+
+	channels map[string]Channel
+}
+
+// NewDispatcher creates a dispatcher without channels
+func NewDispatcher(attempts int, backoff time.Duration) *Dispatcher {
+	return &Dispatcher{
+		Attempts: max(attempts, 1),
+		Backoff:  backoff,
+		channels: make(map[string]Channel),
+	}
+}
+
+// Register makes channel available under kind
+func (d *Dispatcher) Register(kind string, channel Channel) *Dispatcher {
+	d.channels[kind] = channel
+	return d
+}
+
+// Has reports whether a channel is registered for kind
+func (d *Dispatcher) Has(kind string) bool {
+	_, ok := d.channels[kind]
+	return ok
+}
+
+// Validate checks a destination of kind
+func (d *Dispatcher) Validate(kind, destination string) error {
+	channel, ok := d.channels[kind]
+	if !ok {
+		return fmt.Errorf("%w: %s delivery is not configured", ErrInvalidDestination, kind)
+	}
+	return channel.Validate(destination)
+}
+
+// Send delivers msg to a destination of kind, retrying failures, and returns the last
+// error if every attempt failed
+func (d *Dispatcher) Send(ctx context.Context, kind, destination string, msg Message) error {
+	channel, ok := d.channels[kind]
+	if !ok {
+		return fmt.Errorf("%w: %s delivery is not configured", ErrInvalidDestination, kind)
+	}
+	if d.DryRun {
+		return LogChannel{}.Send(ctx, kind+" "+destination, msg)
+	}
+
+	backoff := d.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = channel.Send(ctx, destination, msg); err == nil || IsPermanent(err) || attempt >= d.Attempts {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// Template renders messages with text/template. The data rendered becomes the message's
+// Data.
+type Template struct {
+	subject *template.Template
+	text    *template.Template
+}
+
+// NewTemplate parses the subject and text templates, which may use funcs
+func NewTemplate(name, subject, text string, funcs template.FuncMap) (*Template, error) {
+	subjectTemplate, err := template.New(name + " subject").Funcs(funcs).Parse(subject)
+	if err != nil {
+		return nil, err
+	}
+	textTemplate, err := template.New(name + " text").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{subject: subjectTemplate, text: textTemplate}, nil
+}
+
+// MustTemplate is NewTemplate for templates known to be valid; it panics on errors
+func MustTemplate(name, subject, text string, funcs template.FuncMap) *Template {
+	t, err := NewTemplate(name, subject, text, funcs)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render builds the message for data
+func (t *Template) Render(data interface{}) (Message, error) {
+	var subject, text bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return Message{}, err
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: subject.String(), Text: text.String(), Data: data}, nil
+}
+
+// LogChannel writes messages to the log instead of delivering them. Any destination is
+// accepted; it is logged as the recipient.
+type LogChannel struct{}
+
+func (LogChannel) Validate(destination string) error {
+	return nil
+}
+
+func (LogChannel) Send(ctx context.Context, destination string, msg Message) error {
+	log.Printf("notify: to %s: %s: %s", destination, msg.Subject, strings.Join(strings.Fields(msg.Text), " "))
+	return nil
+}
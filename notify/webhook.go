@@ -0,0 +1,187 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// WebhookChannel posts the message's Data as JSON to an http(s) URL. The subject and
+// text are sent instead when there is no Data. Destinations on loopback, private and
+// link-local addresses, such as the cloud metadata endpoint, are refused both when they
+// are validated and when they are delivered to, so that webhooks can't reach the
+// server's own network.
+type WebhookChannel struct {
+	Client *http.Client
+}
+
+func (w WebhookChannel) Validate(destination string) error {
+	return validateURL(destination, "webhook", "http", "https")
+}
+
+func (w WebhookChannel) Send(ctx context.Context, destination string, msg Message) error {
+	payload := msg.Data
+	if payload == nil {
+		payload = map[string]string{"subject": msg.Subject, "text": msg.Text}
+	}
+	return postJSON(ctx, w.Client, destination, payload)
+}
+
+// SlackChannel posts the message to a Slack incoming webhook URL, with the subject in bold
+// above the text
+type SlackChannel struct {
+	Client *http.Client
+}
+
+func (s SlackChannel) Validate(destination string) error {
+	return validateURL(destination, "slack", "https")
+}
+
+func (s SlackChannel) Send(ctx context.Context, destination string, msg Message) error {
+	text := msg.Text
+	if msg.Subject != "" {
+		text = "*" + msg.Subject + "*\n" + text
+	}
+	return postJSON(ctx, s.Client, destination, map[string]string{"text": text})
+}
+
+// validateURL accepts absolute URLs with one of schemes on a public host
+func validateURL(destination, kind string, schemes ...string) error {
+	parsed, err := url.Parse(destination)
+	if err == nil && parsed.Host != "" {
+		for _, scheme := range schemes {
+			if parsed.Scheme == scheme {
+				return checkHost(context.Background(), parsed.Hostname())
+			}
+		}
+	}
+	if len(schemes) == 1 {
+		return fmt.Errorf("%w: %s must be an %s URL", ErrInvalidDestination, kind, schemes[0])
+	}
+	return fmt.Errorf("%w: %s must be an http(s) URL", ErrInvalidDestination, kind)
+}
+
+// postJSON posts payload and treats any non-2xx status as a failure. Client errors other
+// than timeouts and rate limits are permanent, as are internal destinations.
+func postJSON(ctx context.Context, client *http.Client, destination string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, destination, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// A host may resolve to another address than when it was validated
+	if err := checkHost(ctx, req.URL.Hostname()); err != nil {
+		return Permanent(err)
+	}
+	if client == nil {
+		client = guardedClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s returned status %d", req.URL.Host, resp.StatusCode)
+	if resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
+
+// guardedClient delivers when a channel has no client of its own
+var guardedClient = &http.Client{Transport: GuardTransport(http.DefaultTransport.(*http.Transport).Clone())}
+
+// GuardTransport makes transport refuse to connect to internal addresses. The address
+// is checked as it is dialled, after resolution, so a host can't pass validation on a
+// public address and be delivered to on an internal one. Requests through a proxy dial
+// the proxy, which may well be internal; they rely on the check of the destination
+// before each delivery instead.
+func GuardTransport(transport *http.Transport) *http.Transport {
+	if usesProxy(transport) {
+		return transport
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: refuseInternal}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// usesProxy reports whether transport sends requests through a proxy
+func usesProxy(transport *http.Transport) bool {
+	if transport.Proxy == nil {
+		return false
+	}
+	req, err := http.NewRequest(http.MethodPost, "https://example.com", nil)
+	if err != nil {
+		return false
+	}
+	proxy, err := transport.Proxy(req)
+	return err != nil || proxy != nil
+}
+
+// refuseInternal is the dialer control of GuardTransport
+func refuseInternal(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || internalIP(ip) {
+		return fmt.Errorf("%w: %s is an internal address", ErrInvalidDestination, host)
+	}
+	return nil
+}
+
+// checkHost rejects hosts that are, or resolve to, internal addresses. Hosts that don't
+// resolve are left to fail on delivery.
+func checkHost(ctx context.Context, host string) error {
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrInvalidDestination)
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s is an internal address", ErrInvalidDestination, host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if internalIP(ip) {
+			return fmt.Errorf("%w: %s is an internal address", ErrInvalidDestination, host)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if internalIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to an internal address", ErrInvalidDestination, host)
+		}
+	}
+	return nil
+}
+
+// internalIP reports whether ip is loopback, private, link-local (such as the cloud
+// metadata endpoint 169.254.169.254), multicast or unspecified
+func internalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
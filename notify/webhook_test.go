@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestInternalIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"127.8.9.10", true},
+		{"::1", true},
+		{"10.0.0.5", true},
+		{"172.16.3.4", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"224.0.0.1", true},
+		{"ff02::1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"8.8.8.8", false},
+		{"172.32.0.1", false},
+		{"93.184.216.34", false},
+		{"2606:4700:4700::1111", false},
+	}
+	for _, tt := range tests {
+		if got := internalIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("internalIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		wantErr bool
+	}{
+		{"", true},
+		{"localhost", true},
+		{"LOCALHOST.", true},
+		{"api.localhost", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"8.8.8.8", false},
+		{"2606:4700:4700::1111", false},
+	}
+	for _, tt := range tests {
+		err := checkHost(context.Background(), tt.host)
+		if tt.wantErr && !errors.Is(err, ErrInvalidDestination) {
+			t.Errorf("checkHost(%q) = %v, want ErrInvalidDestination", tt.host, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("checkHost(%q) = %v, want nil", tt.host, err)
+		}
+	}
+}
+
+func TestWebhookValidate(t *testing.T) {
+	tests := []struct {
+		destination string
+		wantErr     bool
+	}{
+		{"https://8.8.8.8/hook", false},
+		{"http://8.8.8.8:8080/hook", false},
+		{"ftp://8.8.8.8/hook", true},
+		{"/hook", true},
+		{"http://localhost:8080/hook", true},
+		{"http://127.0.0.1/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"https://192.168.0.10/hook", true},
+	}
+	for _, tt := range tests {
+		err := WebhookChannel{}.Validate(tt.destination)
+		if tt.wantErr && !errors.Is(err, ErrInvalidDestination) {
+			t.Errorf("Validate(%q) = %v, want ErrInvalidDestination", tt.destination, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("Validate(%q) = %v, want nil", tt.destination, err)
+		}
+	}
+}
+
+func TestRefuseInternal(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"127.0.0.1:80", true},
+		{"[::1]:443", true},
+		{"10.0.0.1:443", true},
+		{"169.254.169.254:80", true},
+		{"8.8.8.8:443", false},
+		{"[2606:4700:4700::1111]:443", false},
+	}
+	for _, tt := range tests {
+		err := refuseInternal("tcp", tt.address, nil)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("refuseInternal(%q) = %v, want error %v", tt.address, err, tt.wantErr)
+		}
+	}
+}
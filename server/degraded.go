@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http"
+
+	"movie-api-go/handlers"
+	"movie-api-go/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Degraded builds the handler served when the API can't start, e.g. without an
+// OMDB_API_KEY. It keeps the process up, so an orchestrator reports the problem instead
+// of restarting the container in a loop. /health answers with status degraded and the
+// reason, /version works as usual, and every other route fails with 503 and setup
+// instructions.
+func Degraded(reason, instructions string) http.Handler {
+	router := gin.New()
+	router.Use(gin.Logger(), gin.Recovery())
+
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "degraded",
+			"message": reason,
+		})
+	})
+	router.GET("/version", handlers.Version)
+	router.NoRoute(func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "Service Unavailable",
+			Message: reason + ". " + instructions,
+			Code:    http.StatusServiceUnavailable,
+		})
+	})
+	return router
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMiddleware is the middleware order used when MIDDLEWARE is not set
+var DefaultMiddleware = []string{"logger", "request_stats", "recovery", "gzip", "i18n", "response_case", "field_profiles", "raw_formats", "not_available", "cors", "auth", "score_weights", "rate_limit", "load_shedding", "scope", "debug_trace", "cache_headers", "schema", "response_cache"}
+
+// orderConstraints are the pairs of middleware that only work in one order. Middleware
+// listed earlier wraps the middleware after it: it sees the request first and writes the
+// response last. That is why the stages rewriting JSON bodies (i18n, response_case,
+// field_profiles, raw_formats and not_available) come after gzip, which would otherwise
+// hand them compressed bytes.
+var orderConstraints = []struct {
+	before, after string
+	reason        string
+}{
+	{"request_stats", "recovery", "so that panics are counted"},
+	{"gzip", "i18n", "which would otherwise get compressed bodies"},
+	{"gzip", "response_case", "which would otherwise get compressed bodies"},
+	{"gzip", "field_profiles", "which would otherwise get compressed bodies"},
+	{"gzip", "raw_formats", "which would otherwise get compressed bodies"},
+	{"gzip", "not_available", "which would otherwise get compressed bodies"},
+	{"i18n", "auth", "so that authentication errors are translated"},
+	{"response_case", "field_profiles", "so that profiles name keys as the models do"},
+	{"response_case", "raw_formats", "so that the raw fields are found under their model names"},
+	{"auth", "rate_limit", "so that logged-in users are limited by their account"},
+	{"auth", "debug_trace", "which only traces requests of admins"},
+	{"score_weights", "response_cache", "so that responses are cached per weights"},
+	{"debug_trace", "response_cache", "so that traced requests bypass the cache"},
+	{"scope", "cache_headers", "which reads the cache status from the request scope"},
+}
+
+// Pipeline is a registry of named middleware from which a deployment picks its stack.
+// Which middleware runs, and in what order, is configuration rather than code.
+type Pipeline struct {
+	stages map[string]gin.HandlerFunc
+}
+
+func NewPipeline() *Pipeline {
+	return &Pipeline{
+		stages: make(map[string]gin.HandlerFunc),
+	}
+}
+
+// Register makes a middleware available under name. A nil handler registers the name
+// but leaves the stage disabled, e.g. when its feature is switched off in the environment.
+func (p *Pipeline) Register(name string, handler gin.HandlerFunc) *Pipeline {
+	p.stages[name] = handler
+	return p
+}
+
+// Build returns the middleware for the given names in order, skipping disabled stages.
+// Unknown or repeated names, and orders breaking one of the orderConstraints between the
+// listed middleware, are an error so that configuration mistakes fail at startup.
+func (p *Pipeline) Build(names []string) ([]gin.HandlerFunc, error) {
+	var handlers []gin.HandlerFunc
+	positions := make(map[string]int)
+	for i, name := range names {
+		handler, ok := p.stages[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q (available: %s)", name, strings.Join(p.Names(), ", "))
+		}
+		if _, seen := positions[name]; seen {
+			return nil, fmt.Errorf("middleware %q is listed more than once", name)
+		}
+		positions[name] = i
+
+		if handler != nil {
+			handlers = append(handlers, handler)
+		}
+	}
+
+	for _, constraint := range orderConstraints {
+		before, hasBefore := positions[constraint.before]
+		after, hasAfter := positions[constraint.after]
+		if hasBefore && hasAfter && before > after {
+			return nil, fmt.Errorf("middleware %q has to be listed before %q, %s", constraint.before, constraint.after, constraint.reason)
+		}
+	}
+	return handlers, nil
+}
+
+// Names lists the registered middleware in default order, followed by any others
+func (p *Pipeline) Names() []string {
+	var names []string
+	listed := make(map[string]bool)
+	for _, name := range DefaultMiddleware {
+		if _, ok := p.stages[name]; ok {
+			names = append(names, name)
+			listed[name] = true
+		}
+	}
+	for name := range p.stages {
+		if !listed[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ParseOrder reads a comma-separated middleware list such as "logger,cors,scope",
+// falling back to DefaultMiddleware when the value is empty
+func ParseOrder(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return DefaultMiddleware
+	}
+	return names
+}
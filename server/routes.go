@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"path"
+
+	"movie-api-go/models"
+	"movie-api-go/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeTable records the permission each route requires, so the permissions matrix
+// always matches what is enforced
+type routeTable struct {
+	policy *services.AccessPolicy
+	routes []models.RoutePermission
+}
+
+// routeGroup is a route group guarded by one permission
+type routeGroup struct {
+	group      *gin.RouterGroup
+	permission string
+	table      *routeTable
+}
+
+// Group adds a route group under parent that requires permission, enforced by guard.
+// Public groups have no permission and no guard.
+func (t *routeTable) Group(parent *gin.RouterGroup, relativePath, permission string, guard ...gin.HandlerFunc) *routeGroup {
+	return &routeGroup{
+		group:      parent.Group(relativePath, guard...),
+		permission: permission,
+		table:      t,
+	}
+}
+
+func (g *routeGroup) GET(relativePath string, handler gin.HandlerFunc) {
+	g.handle(http.MethodGet, relativePath, handler)
+}
+
+func (g *routeGroup) POST(relativePath string, handler gin.HandlerFunc) {
+	g.handle(http.MethodPost, relativePath, handler)
+}
+
+func (g *routeGroup) PUT(relativePath string, handler gin.HandlerFunc) {
+	g.handle(http.MethodPut, relativePath, handler)
+}
+
+func (g *routeGroup) DELETE(relativePath string, handler gin.HandlerFunc) {
+	g.handle(http.MethodDelete, relativePath, handler)
+}
+
+func (g *routeGroup) handle(method, relativePath string, handler gin.HandlerFunc) {
+	g.group.Handle(method, relativePath, handler)
+
+	roles := g.table.policy.RolesWith(g.permission)
+	g.table.routes = append(g.table.routes, models.RoutePermission{
+		Method:     method,
+		Path:       path.Join(g.group.BasePath(), relativePath),
+		Permission: g.permission,
+		Roles:      roles,
+	})
+}
+
+// Matrix returns the permissions matrix with the annotations of every registered route
+func (t *routeTable) Matrix() models.PermissionsMatrix {
+	return models.PermissionsMatrix{
+		Roles:  t.policy.Matrix(),
+		Routes: t.routes,
+	}
+}
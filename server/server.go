@@ -0,0 +1,620 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"movie-api-go/handlers"
+	"movie-api-go/middleware"
+	"movie-api-go/models"
+	"movie-api-go/services"
+	"movie-api-go/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultOMDbBaseURL is used when OMDB_BASE_URL is not set
+const defaultOMDbBaseURL = "http://www.omdbapi.com/"
+
+// Server holds the dependencies of the movie API. It is configured with Options and
+// turned into an http.Handler by New, so the API can be embedded in another service or
+// run under httptest.
+type Server struct {
+	omdbService    *services.OMDbService
+	aliasStore     *services.AliasStore
+	enrichers      []services.Enricher
+	publicBaseURL  string
+	trustedProxies string
+	adminToken     string
+	middleware     []string
+	extraStages    map[string]gin.HandlerFunc
+	tokens         *services.TokenIssuer
+	shedder        *services.LoadShedder
+	traces         *services.TraceStore
+	messages       *services.MessageCatalog
+	ctx            context.Context
+}
+
+// Option configures a Server. Anything not set by an option is read from the environment.
+type Option func(*Server)
+
+// WithOMDbService uses a preconfigured OMDb client, e.g. one pointed at a test server
+func WithOMDbService(omdbService *services.OMDbService) Option {
+	return func(s *Server) {
+		s.omdbService = omdbService
+	}
+}
+
+// WithAliasStore uses the given alias store instead of loading ALIASES_PATH
+func WithAliasStore(aliasStore *services.AliasStore) Option {
+	return func(s *Server) {
+		s.aliasStore = aliasStore
+	}
+}
+
+// WithEnrichers replaces the expansions offered through include=
+func WithEnrichers(enrichers ...services.Enricher) Option {
+	return func(s *Server) {
+		s.enrichers = enrichers
+	}
+}
+
+// WithPublicBaseURL sets the base URL used in `_links` (PUBLIC_BASE_URL)
+func WithPublicBaseURL(baseURL string) Option {
+	return func(s *Server) {
+		s.publicBaseURL = baseURL
+	}
+}
+
+// WithTrustedProxies sets the reverse proxies whose forwarded headers are honoured, a
+// comma-separated list of IP addresses and CIDR ranges (TRUSTED_PROXIES)
+func WithTrustedProxies(proxies string) Option {
+	return func(s *Server) {
+		s.trustedProxies = proxies
+	}
+}
+
+// WithAdminToken sets the token granting the admin role (ADMIN_API_KEY). Without it the
+// /admin routes are disabled unless an API key has the admin role.
+func WithAdminToken(token string) Option {
+	return func(s *Server) {
+		s.adminToken = token
+	}
+}
+
+// WithMiddleware sets the middleware stack by name, in order (MIDDLEWARE)
+func WithMiddleware(names ...string) Option {
+	return func(s *Server) {
+		s.middleware = names
+	}
+}
+
+// WithMiddlewareStage registers an additional middleware that can be listed in the stack
+func WithMiddlewareStage(name string, handler gin.HandlerFunc) Option {
+	return func(s *Server) {
+		s.extraStages[name] = handler
+	}
+}
+
+// WithContext bounds the background jobs (such as monitor checks); they stop when ctx is done
+func WithContext(ctx context.Context) Option {
+	return func(s *Server) {
+		s.ctx = ctx
+	}
+}
+
+// New builds the router with its services, middleware and routes
+func New(opts ...Option) (http.Handler, error) {
+	s := &Server{
+		publicBaseURL:  os.Getenv("PUBLIC_BASE_URL"),
+		trustedProxies: os.Getenv("TRUSTED_PROXIES"),
+		adminToken:     os.Getenv("ADMIN_API_KEY"),
+		middleware:     ParseOrder(os.Getenv("MIDDLEWARE")),
+		extraStages:    make(map[string]gin.HandlerFunc),
+		tokens:         services.NewTokenIssuer(),
+		shedder:        services.NewLoadShedder(),
+		traces:         services.NewTraceStore(),
+		ctx:            context.Background(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// Links built from request headers can't be trusted in production
+	if s.publicBaseURL == "" && gin.Mode() == gin.ReleaseMode {
+		return nil, fmt.Errorf("PUBLIC_BASE_URL is required in release mode (GIN_MODE=release)")
+	}
+	trustedProxies, err := middleware.ParseTrustedProxies(s.trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize services
+	if s.omdbService == nil {
+		omdbService, err := services.NewOMDbService()
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure OMDb client: %w", err)
+		}
+		s.omdbService = omdbService
+	}
+	if s.omdbService.BaseURL == "" {
+		s.omdbService.BaseURL = defaultOMDbBaseURL
+	}
+	if s.aliasStore == nil {
+		aliasStore, err := services.NewAliasStore()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load title aliases: %w", err)
+		}
+		s.aliasStore = aliasStore
+	}
+	if s.enrichers == nil {
+		wikipedia, err := services.NewWikipediaEnricher()
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure Wikipedia client: %w", err)
+		}
+		s.enrichers = []services.Enricher{
+			services.RatingsEnricher{},
+			services.DetailsEnricher{NAPolicy: s.omdbService.NAPolicy},
+			wikipedia,
+		}
+	}
+	// Seed the spelling dictionary with the alias titles; it learns the rest from responses
+	for _, alias := range s.aliasStore.List() {
+		s.omdbService.Dictionary.Learn(alias.Alias)
+	}
+	messages, err := services.NewMessageCatalog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load message catalogs: %w", err)
+	}
+	s.messages = messages
+	resolver := services.NewResolver(s.omdbService, s.aliasStore)
+	expansionService := services.NewExpansionService(s.enrichers...)
+	certifications, err := services.NewCertificationMapper()
+	if err != nil {
+		return nil, err
+	}
+	monitors, err := services.NewMonitorService(s.omdbService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load monitors: %w", err)
+	}
+	go monitors.Run(s.ctx)
+	users, err := services.NewUserStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load users: %w", err)
+	}
+	oidc, err := services.NewOIDCLogin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure login: %w", err)
+	}
+	tags, err := services.NewTagStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tags: %w", err)
+	}
+	preferences, err := services.NewPreferenceStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load preferences: %w", err)
+	}
+	spoilers, err := services.NewSpoilerStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spoilers: %w", err)
+	}
+	ratings, err := services.NewRatingStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ratings: %w", err)
+	}
+	posters, err := services.NewPosterService(s.omdbService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure poster proxy: %w", err)
+	}
+	canary := services.NewRecommendationCanary()
+	recommendationCache := services.NewRecommendationCache()
+	onboarding, err := services.NewOnboarding(s.omdbService, recommendationCache, ratings)
+	if err != nil {
+		return nil, err
+	}
+	leaderboards := services.NewLeaderboardService(s.omdbService, ratings, monitors)
+	go leaderboards.Run(s.ctx)
+	genreLists, err := services.NewGenreListStore(s.omdbService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load genre lists: %w", err)
+	}
+	go genreLists.Run(s.ctx)
+	go services.NewIncompleteRefresh(s.omdbService).Run(s.ctx)
+	go services.NewCacheRefresh(s.omdbService).Run(s.ctx)
+	seeder := services.NewSeeder(s.ctx, s.omdbService)
+	staffPicks, err := services.NewStaffPicks(s.omdbService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load staff picks: %w", err)
+	}
+	collections, err := services.NewCollectionStore(s.omdbService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collections: %w", err)
+	}
+	fieldProfiles, err := services.NewFieldProfileStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load field profiles: %w", err)
+	}
+	scoreWeights, err := services.NewScoreWeightStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load score weights: %w", err)
+	}
+	awards, err := services.NewAwardsService(s.omdbService)
+	if err != nil {
+		return nil, err
+	}
+	bios, err := services.NewPersonBios(s.omdbService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load person biographies: %w", err)
+	}
+	social, err := services.NewSocialGraph(users)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load follows: %w", err)
+	}
+	privacy, err := services.NewPrivacyStore(social)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load privacy settings: %w", err)
+	}
+	likes, err := services.NewLikeStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load likes: %w", err)
+	}
+	reports, err := services.NewReportStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reports: %w", err)
+	}
+	filter, err := services.NewContentFilter()
+	if err != nil {
+		return nil, fmt.Errorf("invalid content filter: %w", err)
+	}
+	reviews, err := services.NewReviewStore(users, likes, reports, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reviews: %w", err)
+	}
+	feed := services.NewActivityFeed(s.omdbService, social, privacy, ratings, monitors)
+
+	// Initialize handlers
+	links := handlers.NewLinkBuilder(s.publicBaseURL, trustedProxies)
+	movieHandler := handlers.NewMovieHandler(s.omdbService, resolver, expansionService, certifications, canary, recommendationCache, preferences, tags, spoilers, posters, genreLists, links)
+	siteHandler := handlers.NewSiteHandler(s.aliasStore, links)
+	maintenance, err := services.NewMaintenanceMode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load maintenance mode: %w", err)
+	}
+	statusHandler := handlers.NewStatusHandler(s.omdbService, s.shedder, maintenance)
+	authHandler := handlers.NewAuthHandler(oidc, users, s.tokens, links)
+	preferencesHandler := handlers.NewPreferencesHandler(preferences)
+	onboardingHandler := handlers.NewOnboardingHandler(onboarding, ratings, preferences, tags, links)
+	signer := services.NewURLSigner()
+	posterHandler := handlers.NewPosterHandler(posters, signer, links)
+	cardHandler := handlers.NewCardHandler(services.NewCardService(s.omdbService, posters))
+	qrHandler := handlers.NewQRHandler(s.omdbService, users, privacy, reports, links)
+	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboards, links)
+	staffPicksHandler := handlers.NewStaffPicksHandler(staffPicks, posters, links)
+	collectionHandler := handlers.NewCollectionHandler(collections, posters, links)
+	awardsHandler := handlers.NewAwardsHandler(awards, posters, links)
+	personHandler := handlers.NewPersonHandler(bios, links)
+	queryHandler := handlers.NewQueryHandler(services.NewQueryService(s.omdbService), posters, links)
+	reviewHandler := handlers.NewReviewHandler(s.omdbService, reviews, likes, links)
+	reportHandler := handlers.NewReportHandler(reports, reviews, users, privacy)
+	socialHandler := handlers.NewSocialHandler(social, privacy, feed, users, ratings, monitors, likes, reports, links)
+
+	auditLog, err := services.NewAuditLog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audit log: %w", err)
+	}
+	policy, err := services.NewAccessPolicy(s.adminToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access policy: %w", err)
+	}
+	monitorHandler := handlers.NewMonitorHandler(monitors, policy)
+	routes := &routeTable{policy: policy}
+	adminHandler := handlers.NewAdminHandler(s.aliasStore, s.omdbService.Shadow, s.omdbService.Drift, s.omdbService.Quarantine, canary, recommendationCache, auditLog, users, tags, maintenance, s.traces, reviews, spoilers, reports, filter, genreLists, s.omdbService.Genres, staffPicks, collections, fieldProfiles, scoreWeights, seeder, routes.Matrix)
+
+	// Setup Gin router
+	router := gin.New()
+	// Client IPs are taken from X-Forwarded-For of the same proxies
+	if len(trustedProxies) > 0 {
+		cidrs := make([]string, len(trustedProxies))
+		for i, network := range trustedProxies {
+			cidrs[i] = network.String()
+		}
+		if err := router.SetTrustedProxies(cidrs); err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+		}
+	}
+
+	stack, err := s.pipeline(policy, users, fieldProfiles, scoreWeights, trustedProxies, maintenance).Build(s.middleware)
+	if err != nil {
+		return nil, fmt.Errorf("invalid middleware configuration: %w", err)
+	}
+	router.Use(stack...)
+
+	// Demo UI
+	web.Register(router)
+
+	// Public routes: crawler endpoints, health, status and login
+	public := routes.Group(&router.RouterGroup, "", "")
+	{
+		public.GET("/sitemap.xml", siteHandler.Sitemap)
+		public.GET("/robots.txt", siteHandler.Robots)
+
+		// Health check endpoint
+		public.GET("/health", movieHandler.HealthCheck)
+		public.GET("/version", handlers.Version)
+
+		// Public status page
+		public.GET("/status", statusHandler.Status)
+
+		// Load signals for autoscalers
+		public.GET("/metrics/scaling", statusHandler.Scaling)
+
+		// Login with an external provider
+		public.GET("/auth/providers", authHandler.Providers)
+		public.GET("/auth/:provider/login", authHandler.Login)
+		public.GET("/auth/:provider/callback", authHandler.Callback)
+	}
+
+	// API routes, grouped by the permission they require and taken offline by maintenance mode
+	api := router.Group("/api", middleware.Maintenance(maintenance))
+	catalog := routes.Group(api, "", services.PermissionCatalogRead, middleware.Authorize(policy, services.PermissionCatalogRead))
+	{
+		// 1. Movie Details API
+		catalog.GET("/movie", movieHandler.GetMovieDetails)
+		catalog.GET("/movie/:imdbID/changes", movieHandler.GetMovieChanges)
+		catalog.GET("/movie/:imdbID/rating-history", movieHandler.GetRatingHistory)
+		catalog.GET("/movie/:imdbID/reviews", reviewHandler.GetReviews)
+		catalog.GET("/movie/:imdbID/card.png", cardHandler.GetCard)
+		catalog.GET("/movie/:imdbID/qr.png", qrHandler.MovieQR)
+
+		// 1b. Video Game Details API
+		catalog.GET("/game", movieHandler.GetGameDetails)
+
+		// 2. TV Episode Details API
+		catalog.GET("/episode", movieHandler.GetEpisodeDetails)
+		catalog.GET("/episode/id/:imdbID", movieHandler.GetEpisodeByID)
+
+		// 2b. Episode range API
+		catalog.GET("/episodes", movieHandler.GetEpisodeRange)
+
+		// 3. Genre-Based Movie API
+		catalog.GET("/movies/genre", movieHandler.GetMoviesByGenre)
+		catalog.GET("/movies/staff-picks", staffPicksHandler.List)
+		catalog.GET("/movies/upcoming", movieHandler.GetUpcoming)
+		catalog.GET("/collections", collectionHandler.List)
+		catalog.GET("/collections/:slug", collectionHandler.Get)
+		catalog.GET("/awards/oscars/:year", awardsHandler.Oscars)
+		catalog.GET("/genres", movieHandler.ListGenres)
+		catalog.GET("/tags", movieHandler.ListTags)
+
+		// 4. Movie Recommendation Engine
+		catalog.GET("/recommendations", movieHandler.GetMovieRecommendations)
+
+		// 5. Title Search
+		catalog.GET("/search", movieHandler.SearchTitles)
+		catalog.GET("/search/series", movieHandler.SearchSeries)
+		catalog.POST("/query", queryHandler.Query)
+
+		// 6. Person name resolution
+		catalog.GET("/person", movieHandler.ResolvePerson)
+		catalog.GET("/person/:name/bio", personHandler.Bio)
+
+		// 9. Cold-start onboarding sample
+		catalog.GET("/onboarding/titles", onboardingHandler.GetTitles)
+
+		// 13. Leaderboards of user activity
+		catalog.GET("/leaderboards/watchlisted", leaderboardHandler.Watchlisted)
+		catalog.GET("/leaderboards/user-rated", leaderboardHandler.UserRated)
+
+		// 14. Users' ratings, watchlists and follows, as their privacy settings allow
+		catalog.GET("/users/:userID/ratings", socialHandler.UserRatings)
+		catalog.GET("/users/:userID/watchlist", socialHandler.UserWatchlist)
+		catalog.GET("/users/:userID/watchlist/qr.png", qrHandler.WatchlistQR)
+		catalog.GET("/users/:userID/following", socialHandler.UserFollowing)
+		catalog.GET("/users/:userID/followers", socialHandler.UserFollowers)
+
+		// Signed links to posters for browsers
+		catalog.GET("/poster/:imdbID/signed", posterHandler.SignPoster)
+	}
+
+	// Poster proxy, also reachable through signed links without credentials
+	signed := routes.Group(api, "", services.PermissionCatalogRead, middleware.SignedURL(signer, policy, services.PermissionCatalogRead))
+	{
+		signed.GET("/poster/:imdbID", posterHandler.GetPoster)
+	}
+
+	// 7. Rating monitors
+	monitorsRead := routes.Group(api, "", services.PermissionMonitorsRead, middleware.Authorize(policy, services.PermissionMonitorsRead))
+	{
+		monitorsRead.GET("/monitors", monitorHandler.ListMonitors)
+	}
+	monitorsWrite := routes.Group(api, "", services.PermissionMonitorsWrite, middleware.Authorize(policy, services.PermissionMonitorsWrite))
+	{
+		monitorsWrite.POST("/monitors", monitorHandler.CreateMonitor)
+		monitorsWrite.DELETE("/monitors/:id", monitorHandler.DeleteMonitor)
+	}
+
+	// 8. Current user and preferences
+	account := routes.Group(api, "", services.PermissionAccount, middleware.Authorize(policy, services.PermissionAccount))
+	{
+		account.GET("/me", authHandler.Me)
+		account.GET("/me/preferences", preferencesHandler.GetPreferences)
+		account.PUT("/me/preferences", preferencesHandler.PutPreferences)
+		account.GET("/me/ratings", onboardingHandler.GetRatings)
+		account.GET("/me/following", socialHandler.Following)
+		account.GET("/me/followers", socialHandler.Followers)
+		account.GET("/me/follow-requests", socialHandler.FollowRequests)
+		account.GET("/me/feed", socialHandler.Feed)
+		account.GET("/me/privacy", socialHandler.GetPrivacy)
+		account.PUT("/me/privacy", socialHandler.PutPrivacy)
+	}
+
+	// 9. Onboarding ratings
+	ratingsWrite := routes.Group(api, "", services.PermissionRatingsWrite, middleware.Authorize(policy, services.PermissionRatingsWrite))
+	{
+		ratingsWrite.POST("/onboarding/ratings", onboardingHandler.PostRatings)
+	}
+
+	// 14. Follows, likes and reports
+	socialWrite := routes.Group(api, "", services.PermissionSocialWrite, middleware.Authorize(policy, services.PermissionSocialWrite))
+	{
+		socialWrite.POST("/users/:userID/follow", socialHandler.Follow)
+		socialWrite.DELETE("/users/:userID/follow", socialHandler.Unfollow)
+		socialWrite.POST("/me/follow-requests/:userID/approve", socialHandler.ApproveFollow)
+		socialWrite.DELETE("/me/follow-requests/:userID", socialHandler.RejectFollow)
+		socialWrite.POST("/users/:userID/watchlist/like", socialHandler.LikeWatchlist)
+		socialWrite.DELETE("/users/:userID/watchlist/like", socialHandler.UnlikeWatchlist)
+		socialWrite.POST("/reviews/:reviewID/like", reviewHandler.LikeReview)
+		socialWrite.DELETE("/reviews/:reviewID/like", reviewHandler.UnlikeReview)
+		socialWrite.POST("/reports", reportHandler.PostReport)
+	}
+
+	// 15. Reviews
+	reviewsWrite := routes.Group(api, "", services.PermissionReviewsWrite, middleware.Authorize(policy, services.PermissionReviewsWrite))
+	{
+		reviewsWrite.POST("/movie/:imdbID/reviews", reviewHandler.PostReview)
+	}
+
+	// Admin routes
+	admin := routes.Group(&router.RouterGroup, "/admin", services.PermissionAdmin, middleware.AdminAuth(policy))
+	{
+		admin.GET("/aliases", adminHandler.ListAliases)
+		admin.PUT("/aliases/:alias", adminHandler.PutAlias)
+		admin.DELETE("/aliases/:alias", adminHandler.DeleteAlias)
+		admin.GET("/shadow/report", adminHandler.ShadowReport)
+		admin.GET("/schema-drift", adminHandler.SchemaDrift)
+		admin.DELETE("/schema-drift", adminHandler.ResetSchemaDrift)
+		admin.GET("/quarantine", adminHandler.Quarantine)
+		admin.DELETE("/quarantine/:imdbID", adminHandler.ReleaseQuarantine)
+		admin.GET("/maintenance", adminHandler.Maintenance)
+		admin.PUT("/maintenance", adminHandler.EnableMaintenance)
+		admin.DELETE("/maintenance", adminHandler.DisableMaintenance)
+		admin.GET("/traces", adminHandler.ListTraces)
+		admin.GET("/traces/:id", adminHandler.GetTrace)
+		admin.GET("/recommendations/canary", adminHandler.RecommendationCanary)
+		admin.POST("/recommendations/invalidate", adminHandler.InvalidateRecommendations)
+		admin.GET("/genre-lists", adminHandler.GenreLists)
+		admin.POST("/genre-lists/:genre/refresh", adminHandler.RefreshGenreList)
+		admin.GET("/staff-picks", adminHandler.StaffPicks)
+		admin.PUT("/staff-picks/:imdbID", adminHandler.PutStaffPick)
+		admin.DELETE("/staff-picks/:imdbID", adminHandler.DeleteStaffPick)
+		admin.GET("/collections", adminHandler.Collections)
+		admin.PUT("/collections/:slug", adminHandler.PutCollection)
+		admin.DELETE("/collections/:slug", adminHandler.DeleteCollection)
+		admin.GET("/field-profiles", adminHandler.FieldProfiles)
+		admin.PUT("/field-profiles/:tenant", adminHandler.PutFieldProfile)
+		admin.DELETE("/field-profiles/:tenant", adminHandler.DeleteFieldProfile)
+		admin.GET("/score-weights", adminHandler.ScoreWeights)
+		admin.PUT("/score-weights/:tenant", adminHandler.PutScoreWeights)
+		admin.DELETE("/score-weights/:tenant", adminHandler.DeleteScoreWeights)
+		admin.GET("/seed", adminHandler.SeedJobs)
+		admin.POST("/seed", adminHandler.Seed)
+		admin.GET("/seed/:jobID", adminHandler.SeedJob)
+		admin.GET("/audit", adminHandler.Audit)
+		admin.GET("/users", adminHandler.ListUsers)
+		admin.PUT("/users/:id/role", adminHandler.SetUserRole)
+		admin.GET("/permissions", adminHandler.Permissions)
+		admin.PUT("/tags/:tag", adminHandler.PutTag)
+		admin.DELETE("/tags/:tag", adminHandler.DeleteTag)
+		admin.PUT("/titles/:imdbID/tags", adminHandler.PutTitleTags)
+		admin.PUT("/titles/:imdbID/spoilers", adminHandler.PutTitleSpoilers)
+		admin.GET("/reviews", adminHandler.ReviewQueue)
+		admin.PUT("/reviews/:id/status", adminHandler.ModerateReview)
+		admin.GET("/reports", adminHandler.ReportQueue)
+		admin.POST("/reports/:id/resolve", adminHandler.ResolveReport)
+		admin.GET("/violations", adminHandler.ContentViolations)
+		admin.GET("/debug/pprof/*profile", handlers.Pprof)
+		admin.GET("/debug/vars", handlers.Vars)
+		admin.GET("/debug/goroutines", handlers.Goroutines)
+	}
+
+	return router, nil
+}
+
+// pipeline registers the available middleware; the configured order picks the stack
+func (s *Server) pipeline(policy *services.AccessPolicy, users *services.UserStore, fieldProfiles *services.FieldProfileStore, scoreWeights *services.ScoreWeightStore, trustedProxies []*net.IPNet, maintenance *services.MaintenanceMode) *Pipeline {
+	pipeline := NewPipeline().
+		Register("logger", gin.Logger()).
+		Register("request_stats", middleware.RequestStats(s.omdbService.Stats)).
+		Register("recovery", gin.Recovery()).
+		Register("cors", middleware.CORS()).
+		Register("auth", middleware.Authenticate(s.tokens, users)).
+		Register("score_weights", middleware.ScoreWeights(scoreWeights, policy)).
+		Register("rate_limit", middleware.RateLimit(services.NewRateLimiter(), services.NewUsageTracker(), policy)).
+		Register("load_shedding", nil).
+		Register("gzip", middleware.Gzip()).
+		Register("i18n", middleware.Localize(s.messages)).
+		Register("response_case", middleware.ResponseCase()).
+		Register("field_profiles", middleware.FieldProfiles(fieldProfiles, policy)).
+		Register("raw_formats", middleware.RawFormats(services.MachineFormatFields)).
+		Register("not_available", middleware.NotAvailable(s.omdbService.NAPolicy)).
+		// Track upstream work per request so fan-out limits can be enforced
+		Register("scope", middleware.RequestScope(s.omdbService)).
+		Register("debug_trace", middleware.DebugTrace(s.traces, policy)).
+		Register("cache_headers", middleware.CacheHeaders()).
+		Register("schema", nil).
+		Register("response_cache", nil)
+
+	// Validate responses against the response models in debug deployments
+	if os.Getenv("DEBUG_SCHEMA_VALIDATION") == "true" {
+		validator := middleware.NewSchemaValidator(map[string]interface{}{
+			"/api/movie":                        models.MovieDetailsResponse{},
+			"/api/game":                         models.GameDetailsResponse{},
+			"/api/episode":                      models.EpisodeDetailsResponse{},
+			"/api/episode/id/:imdbID":           models.EpisodeDetailsResponse{},
+			"/api/episodes":                     models.EpisodeRangeResponse{},
+			"/api/movie/:imdbID/changes":        models.TitleChangesResponse{},
+			"/api/movie/:imdbID/rating-history": models.RatingHistoryResponse{},
+			"/api/movies/genre":                 models.GenreMoviesResponse{},
+			"/api/movies/staff-picks":           models.StaffPicksResponse{},
+			"/api/movies/upcoming":              models.UpcomingResponse{},
+			"/api/collections":                  models.CollectionsResponse{},
+			"/api/collections/:slug":            models.CollectionResponse{},
+			"/api/awards/oscars/:year":          models.OscarsResponse{},
+			"/api/person/:name/bio":             models.PersonBioResponse{},
+			"/api/recommendations":              models.RecommendationResponse{},
+			"/api/search":                       models.SearchTitlesResponse{},
+			"/api/search/series":                models.SearchTitlesResponse{},
+			"/api/query":                        models.QueryResponse{},
+			"/api/leaderboards/watchlisted":     models.Leaderboard{},
+			"/api/leaderboards/user-rated":      models.Leaderboard{},
+			"/api/me/feed":                      models.FeedResponse{},
+			"/api/movie/:imdbID/reviews":        models.ReviewsResponse{},
+		})
+		pipeline.Register("schema", validator.Middleware())
+		log.Println("Debug: response schema validation enabled")
+	}
+
+	// Cache complete responses of the read-only routes. The list routes stream their
+	// responses instead; genre lists are materialized and recommendations have their own
+	// cache keyed by seed and algorithm version.
+	if os.Getenv("RESPONSE_CACHE") != "false" {
+		responseCache := middleware.NewResponseCache(middleware.NewMemoryResponseStore(1000), map[string]time.Duration{
+			"/api/movie":                  5 * time.Minute,
+			"/api/game":                   5 * time.Minute,
+			"/api/episode":                5 * time.Minute,
+			"/api/episode/id/:imdbID":     5 * time.Minute,
+			"/api/movie/:imdbID/card.png": time.Hour,
+		}, trustedProxies)
+		responseCache.Maintenance = maintenance
+		pipeline.Register("response_cache", responseCache.Middleware())
+	}
+
+	// Under overload, shed the routes that fan out to many OMDb calls to keep lookups fast
+	if s.shedder != nil {
+		pipeline.Register("load_shedding", middleware.LoadShedding(s.shedder, map[string]bool{
+			"/api/movies/genre":    true,
+			"/api/recommendations": true,
+			"/api/query":           true,
+		}))
+	}
+
+	for name, handler := range s.extraStages {
+		pipeline.Register(name, handler)
+	}
+	return pipeline
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"log"
+	"os"
+	"time"
+	_ "time/tzdata" // air date time zones must resolve on hosts without a zoneinfo database
+)
+
+// AirDates turns the dates OMDb gives for episodes, which are the calendar day they aired
+// in their home market, into instants. Without a time zone a date such as "20 Jan 2008"
+// reads as the wrong day east of the source market, so the date is pinned to the source
+// zone at the usual air hour before it is converted.
+type AirDates struct {
+	Source *time.Location
+	Hour   int
+}
+
+// airDatesFromEnv reads EPISODE_SOURCE_TZ (default America/New_York) and EPISODE_AIR_HOUR
+// (default 20, prime time)
+func airDatesFromEnv() AirDates {
+	source := time.UTC
+	if name := os.Getenv("EPISODE_SOURCE_TZ"); name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			source = loc
+		} else {
+			log.Printf("omdb: unknown EPISODE_SOURCE_TZ %q, air dates are taken as UTC", name)
+		}
+	} else if loc, err := time.LoadLocation("America/New_York"); err == nil {
+		source = loc
+	}
+
+	hour := envInt("EPISODE_AIR_HOUR", 20)
+	if hour > 23 {
+		hour = 20
+	}
+	return AirDates{Source: source, Hour: hour}
+}
+
+// AirTime returns when an episode released on an OMDb date aired, in the zone tz, or
+// false when the date isn't known
+func (a AirDates) AirTime(released string, tz *time.Location) (time.Time, bool) {
+	date, ok := ParseOMDbDate(released)
+	if !ok {
+		return time.Time{}, false
+	}
+	aired := time.Date(date.Year(), date.Month(), date.Day(), a.Hour, 0, 0, 0, a.Source)
+	return aired.In(tz), true
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// ErrInvalidAlias wraps the reason an alias was rejected
+var ErrInvalidAlias = errors.New("invalid alias")
+
+// AliasStore is a persistent mapping of alternate titles (original-language titles,
+// common misspellings, ...) to canonical IMDb IDs. The resolver consults it before OMDb.
+type AliasStore struct {
+	path string
+
+	mu      sync.RWMutex
+	aliases map[string]models.Alias
+}
+
+// NewAliasStore loads the alias table from ALIASES_PATH (default data/aliases.json)
+func NewAliasStore() (*AliasStore, error) {
+	path := os.Getenv("ALIASES_PATH")
+	if path == "" {
+		path = "data/aliases.json"
+	}
+
+	var aliases []models.Alias
+	if err := store.LoadJSON(path, &aliases); err != nil {
+		return nil, err
+	}
+
+	s := &AliasStore{
+		path:    path,
+		aliases: make(map[string]models.Alias),
+	}
+	for _, alias := range aliases {
+		s.aliases[canonicalTitle(alias.Alias)] = alias
+	}
+	return s, nil
+}
+
+// Lookup returns the alias entry matching title, if any. Deleted aliases don't match.
+func (s *AliasStore) Lookup(title string) (models.Alias, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alias, ok := s.aliases[canonicalTitle(title)]
+	return alias, ok && alias.DeletedAt == nil
+}
+
+// List returns all active aliases sorted by alias title
+func (s *AliasStore) List() []models.Alias {
+	return s.filter(false)
+}
+
+// Deleted returns the soft-deleted aliases sorted by alias title
+func (s *AliasStore) Deleted() []models.Alias {
+	return s.filter(true)
+}
+
+func (s *AliasStore) filter(deleted bool) []models.Alias {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	aliases := []models.Alias{}
+	for _, alias := range s.sortedLocked() {
+		if (alias.DeletedAt != nil) == deleted {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+// Set creates or replaces the alias for title, restoring it if it was deleted, and
+// persists the table. It also returns the alias it replaced, if one was active.
+func (s *AliasStore) Set(title, imdbID string) (models.Alias, *models.Alias, error) {
+	key := canonicalTitle(title)
+	if key == "" {
+		return models.Alias{}, nil, fmt.Errorf("%w: alias must contain letters or digits", ErrInvalidAlias)
+	}
+
+	alias := models.Alias{
+		Alias:     title,
+		ImdbID:    imdbID,
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.aliases[key]
+	s.aliases[key] = alias
+	if err := store.SaveJSON(s.path, s.sortedLocked()); err != nil {
+		if existed {
+			s.aliases[key] = previous
+		} else {
+			delete(s.aliases, key)
+		}
+		return models.Alias{}, nil, err
+	}
+	if !existed || previous.DeletedAt != nil {
+		return alias, nil, nil
+	}
+	return alias, &previous, nil
+}
+
+// Delete soft-deletes the alias for title and persists the table. Deleted aliases stop
+// matching but stay in the table, so a deletion can be reviewed and undone by setting the
+// alias again. It returns the alias as it was before, or nil if there was no active alias.
+func (s *AliasStore) Delete(title string) (*models.Alias, error) {
+	key := canonicalTitle(title)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.aliases[key]
+	if !ok || previous.DeletedAt != nil {
+		return nil, nil
+	}
+
+	deleted := previous
+	now := time.Now().UTC()
+	deleted.DeletedAt = &now
+	s.aliases[key] = deleted
+	if err := store.SaveJSON(s.path, s.sortedLocked()); err != nil {
+		s.aliases[key] = previous
+		return nil, err
+	}
+	return &previous, nil
+}
+
+func (s *AliasStore) sortedLocked() []models.Alias {
+	aliases := make([]models.Alias, 0, len(s.aliases))
+	for _, alias := range s.aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return canonicalTitle(aliases[i].Alias) < canonicalTitle(aliases[j].Alias)
+	})
+	return aliases
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// AuditLog is an append-only record of admin mutations (alias edits, cache purges) with
+// who made them and the state before and after. It is persisted after every entry.
+type AuditLog struct {
+	// MaxEntries bounds the log; the oldest entries are dropped beyond it. Zero keeps everything.
+	MaxEntries int
+
+	path string
+
+	mu      sync.Mutex
+	entries []models.AuditEntry
+	nextID  int64
+}
+
+// AuditQuery filters the audit log. Empty fields match everything.
+type AuditQuery struct {
+	Action string
+	Actor  string
+	Target string
+	Since  time.Time
+	Limit  int
+}
+
+// NewAuditLog loads the log from AUDIT_LOG_PATH (default data/audit.json) and reads
+// AUDIT_LOG_MAX_ENTRIES (default 100000)
+func NewAuditLog() (*AuditLog, error) {
+	path := os.Getenv("AUDIT_LOG_PATH")
+	if path == "" {
+		path = "data/audit.json"
+	}
+
+	a := &AuditLog{
+		MaxEntries: envInt("AUDIT_LOG_MAX_ENTRIES", 100000),
+		path:       path,
+	}
+	if err := store.LoadJSON(path, &a.entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range a.entries {
+		if entry.ID > a.nextID {
+			a.nextID = entry.ID
+		}
+	}
+	return a, nil
+}
+
+// Record appends an entry for action on target. before and after are the affected state
+// (nil when it didn't exist) and are stored as JSON.
+func (a *AuditLog) Record(actor, clientIP, action, target string, before, after interface{}) (models.AuditEntry, error) {
+	entry := models.AuditEntry{
+		At:       time.Now().UTC(),
+		Actor:    actor,
+		ClientIP: clientIP,
+		Action:   action,
+		Target:   target,
+		Before:   auditState(before),
+		After:    auditState(after),
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.nextID++
+	entry.ID = a.nextID
+	a.entries = append(a.entries, entry)
+	if a.MaxEntries > 0 && len(a.entries) > a.MaxEntries {
+		a.entries = a.entries[len(a.entries)-a.MaxEntries:]
+	}
+	if err := store.SaveJSON(a.path, a.entries); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// auditState encodes a before/after state; nil and nil pointers are stored as absent
+func auditState(state interface{}) json.RawMessage {
+	if state == nil {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil || string(data) == "null" {
+		return nil
+	}
+	return data
+}
+
+// Query returns the matching entries, newest first
+func (a *AuditLog) Query(q AuditQuery) []models.AuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries := []models.AuditEntry{}
+	for i := len(a.entries) - 1; i >= 0; i-- {
+		entry := a.entries[i]
+		if !q.Since.IsZero() && entry.At.Before(q.Since) {
+			break
+		}
+		if q.Action != "" && entry.Action != q.Action ||
+			q.Actor != "" && !strings.EqualFold(entry.Actor, q.Actor) ||
+			q.Target != "" && entry.Target != q.Target {
+			continue
+		}
+		entries = append(entries, entry)
+		if q.Limit > 0 && len(entries) == q.Limit {
+			break
+		}
+	}
+	return entries
+}
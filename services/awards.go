@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"movie-api-go/models"
+)
+
+//go:embed data/oscars.json
+var oscarData []byte
+
+var (
+	// ErrNoAwardsData is returned for a ceremony year the bundled dataset doesn't cover
+	ErrNoAwardsData = errors.New("no awards data")
+
+	// ErrUnknownAwardCategory is returned for a category a ceremony doesn't have
+	ErrUnknownAwardCategory = errors.New("unknown award category")
+)
+
+// oscarCeremony is one ceremony of the bundled dataset
+type oscarCeremony struct {
+	Year       int                    `json:"year"`
+	Ceremony   int                    `json:"ceremony"`
+	FilmYear   int                    `json:"film_year"`
+	Categories []models.OscarCategory `json:"categories"`
+}
+
+// AwardsService answers Academy Awards questions such as "the Best Picture nominees of
+// 2020" from a bundled dataset of nominees and winners mapped to IMDb IDs. OMDb only has
+// a free-text Awards summary per title, which can't be queried by year or category.
+type AwardsService struct {
+	omdb       *OMDbService
+	ceremonies map[int]oscarCeremony
+	years      []int
+}
+
+func NewAwardsService(omdb *OMDbService) (*AwardsService, error) {
+	var data struct {
+		Ceremonies []oscarCeremony `json:"ceremonies"`
+	}
+	if err := json.Unmarshal(oscarData, &data); err != nil {
+		return nil, fmt.Errorf("invalid Oscars dataset: %w", err)
+	}
+
+	s := &AwardsService{omdb: omdb, ceremonies: make(map[int]oscarCeremony, len(data.Ceremonies))}
+	for _, ceremony := range data.Ceremonies {
+		for i := range ceremony.Categories {
+			ceremony.Categories[i].Slug = awardSlug(ceremony.Categories[i].Category)
+		}
+		s.ceremonies[ceremony.Year] = ceremony
+		s.years = append(s.years, ceremony.Year)
+	}
+	sort.Ints(s.years)
+	return s, nil
+}
+
+// Years returns the ceremony years the dataset covers, in order
+func (s *AwardsService) Years() []int {
+	return append([]int{}, s.years...)
+}
+
+// Oscars returns the nominees of a ceremony, or of one of its categories by slug
+// (best-picture), with the films' details looked up through the detail cache. Nominees
+// whose film can't be fetched are listed without details.
+func (s *AwardsService) Oscars(ctx context.Context, year int, category string) (models.OscarsResponse, error) {
+	ceremony, ok := s.ceremonies[year]
+	if !ok {
+		return models.OscarsResponse{}, fmt.Errorf("%w for %d; ceremonies %d to %d are available", ErrNoAwardsData, year, s.years[0], s.years[len(s.years)-1])
+	}
+
+	var categories []models.OscarCategory
+	for _, c := range ceremony.Categories {
+		if category == "" || c.Slug == category {
+			c.Nominees = append([]models.OscarNominee{}, c.Nominees...)
+			categories = append(categories, c)
+		}
+	}
+	if len(categories) == 0 {
+		slugs := make([]string, len(ceremony.Categories))
+		for i, c := range ceremony.Categories {
+			slugs[i] = c.Slug
+		}
+		return models.OscarsResponse{}, fmt.Errorf("%w %q; use one of %s", ErrUnknownAwardCategory, category, strings.Join(slugs, ", "))
+	}
+
+	var imdbIDs []string
+	seen := make(map[string]bool)
+	for _, c := range categories {
+		for _, nominee := range c.Nominees {
+			if !seen[nominee.ImdbID] {
+				seen[nominee.ImdbID] = true
+				imdbIDs = append(imdbIDs, nominee.ImdbID)
+			}
+		}
+	}
+	records := s.omdb.GetTitlesByID(ctx, imdbIDs)
+	for _, c := range categories {
+		for i := range c.Nominees {
+			if record, ok := records[c.Nominees[i].ImdbID]; ok {
+				movie := newMovieBrief(record)
+				c.Nominees[i].Movie = &movie
+			}
+		}
+	}
+
+	return models.OscarsResponse{
+		Year:       ceremony.Year,
+		Ceremony:   ceremony.Ceremony,
+		FilmYear:   ceremony.FilmYear,
+		Categories: categories,
+	}, nil
+}
+
+// awardSlug turns a category name into its slug, e.g. "Best Picture" into best-picture
+func awardSlug(category string) string {
+	return strings.Join(strings.Fields(strings.ToLower(category)), "-")
+}
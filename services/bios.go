@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// ErrPersonNotFound is returned when Wikidata has no film person by a name
+var ErrPersonNotFound = errors.New("person not found")
+
+const (
+	// bioLookupTimeout bounds the Wikidata and Wikipedia requests of one biography
+	bioLookupTimeout = 10 * time.Second
+
+	// maxNotableWorks bounds the notable works listed in a biography
+	maxNotableWorks = 10
+)
+
+// filmOccupations are words in a Wikidata description that mark a person who works in
+// film or television, used to pick the right person among namesakes
+var filmOccupations = []string{"actor", "actress", "director", "filmmaker", "screenwriter", "writer", "producer", "comedian", "cinematographer", "animator"}
+
+// Wikidata properties read for a biography
+const (
+	wikidataBirthDate   = "P569"
+	wikidataDeathDate   = "P570"
+	wikidataCitizenship = "P27"
+	wikidataImage       = "P18"
+	wikidataNotableWork = "P800"
+	wikidataImdbID      = "P345"
+)
+
+// PersonBios looks up people on Wikidata (birth and death dates, nationality, photo,
+// notable works) and Wikipedia (a summary of their article), since OMDb has no data about
+// people. Biographies are cached in a JSON file for TTL and merged with the person's
+// filmography among the titles the API has seen.
+type PersonBios struct {
+	// BaseURL is the Wikidata API endpoint
+	BaseURL string
+	// TTL is how long a biography is served from the cache before it is looked up again
+	TTL    time.Duration
+	Client *http.Client
+
+	omdb      *OMDbService
+	wikipedia *WikipediaEnricher
+	path      string
+
+	mu   sync.Mutex
+	bios map[string]models.PersonBio
+}
+
+// NewPersonBios reads WIKIDATA_API_URL (default https://www.wikidata.org/w/api.php),
+// PERSON_BIOS_PATH (default data/person_bios.json) and PERSON_BIO_TTL_HOURS (default 168).
+// Wikipedia summaries use the WIKIPEDIA_API_URL of the title enricher.
+func NewPersonBios(omdb *OMDbService) (*PersonBios, error) {
+	baseURL := os.Getenv("WIKIDATA_API_URL")
+	if baseURL == "" {
+		baseURL = "https://www.wikidata.org/w/api.php"
+	}
+	path := os.Getenv("PERSON_BIOS_PATH")
+	if path == "" {
+		path = "data/person_bios.json"
+	}
+
+	client, err := httpClientFromEnv("WIKIDATA")
+	if err != nil {
+		return nil, err
+	}
+	wikipedia, err := NewWikipediaEnricher()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &PersonBios{
+		BaseURL:   baseURL,
+		TTL:       time.Duration(envInt("PERSON_BIO_TTL_HOURS", 168)) * time.Hour,
+		Client:    client,
+		omdb:      omdb,
+		wikipedia: wikipedia,
+		path:      path,
+		bios:      make(map[string]models.PersonBio),
+	}
+	if err := store.LoadJSON(path, &b.bios); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Bio returns the biography and filmography of a person. The name is first resolved to
+// the spelling seen in OMDb records when the person index knows it. A cached biography
+// older than TTL is looked up again, and still served if Wikidata can't be reached.
+func (b *PersonBios) Bio(ctx context.Context, name string) (models.PersonBioResponse, error) {
+	response := models.PersonBioResponse{}
+	if match, ok := b.omdb.People.Resolve(name); ok && match.Name != name {
+		response.Query = name
+		name = match.Name
+	}
+	key := strings.ToLower(foldDiacritics(name))
+
+	b.mu.Lock()
+	cached, ok := b.bios[key]
+	b.mu.Unlock()
+
+	bio := cached
+	if !ok || time.Since(cached.RetrievedAt) > b.TTL {
+		lookupCtx, cancel := context.WithTimeout(ctx, bioLookupTimeout)
+		fetched, err := b.lookup(lookupCtx, name)
+		cancel()
+		switch {
+		case err == nil:
+			bio = fetched
+			b.mu.Lock()
+			b.bios[key] = bio
+			err = store.SaveJSON(b.path, b.bios)
+			b.mu.Unlock()
+			if err != nil {
+				return models.PersonBioResponse{}, err
+			}
+		case !ok || errors.Is(err, ErrPersonNotFound):
+			return models.PersonBioResponse{}, err
+		}
+	}
+
+	response.Bio = bio
+	response.Filmography = b.filmography(name)
+	return response, nil
+}
+
+// lookup searches Wikidata for a film person by name and reads their entity
+func (b *PersonBios) lookup(ctx context.Context, name string) (models.PersonBio, error) {
+	var search struct {
+		Search []struct {
+			ID          string `json:"id"`
+			Label       string `json:"label"`
+			Description string `json:"description"`
+		} `json:"search"`
+	}
+	err := b.get(ctx, url.Values{
+		"action":   {"wbsearchentities"},
+		"search":   {name},
+		"language": {"en"},
+		"type":     {"item"},
+		"limit":    {"5"},
+	}, &search)
+	if err != nil {
+		return models.PersonBio{}, err
+	}
+
+	var id string
+	for _, result := range search.Search {
+		description := strings.ToLower(result.Description)
+		for _, occupation := range filmOccupations {
+			if strings.Contains(description, occupation) {
+				id = result.ID
+				break
+			}
+		}
+		if id != "" {
+			break
+		}
+	}
+	if id == "" {
+		return models.PersonBio{}, fmt.Errorf("%w: no film person named %q on Wikidata", ErrPersonNotFound, name)
+	}
+
+	entity, err := b.entity(ctx, id)
+	if err != nil {
+		return models.PersonBio{}, err
+	}
+
+	bio := models.PersonBio{
+		Name:        entity.label(),
+		WikidataID:  id,
+		Description: entity.description(),
+		BirthDate:   wikidataDate(entity.first(wikidataBirthDate)),
+		DeathDate:   wikidataDate(entity.first(wikidataDeathDate)),
+		RetrievedAt: time.Now().UTC(),
+	}
+	if bio.Name == "" {
+		bio.Name = name
+	}
+	var file, imdbID string
+	if json.Unmarshal(entity.first(wikidataImage), &file) == nil && file != "" {
+		bio.Photo = "https://commons.wikimedia.org/wiki/Special:FilePath/" + url.PathEscape(strings.ReplaceAll(file, " ", "_"))
+	}
+	if json.Unmarshal(entity.first(wikidataImdbID), &imdbID) == nil {
+		bio.ImdbID = imdbID
+	}
+
+	// Nationalities and notable works are entities of their own; their names are looked
+	// up in one request
+	countries := entity.items(wikidataCitizenship)
+	works := entity.items(wikidataNotableWork)
+	if len(works) > maxNotableWorks {
+		works = works[:maxNotableWorks]
+	}
+	if ids := append(append([]string{}, countries...), works...); len(ids) > 0 {
+		labels, err := b.labels(ctx, ids)
+		if err != nil {
+			return models.PersonBio{}, err
+		}
+		bio.Nationality = labelsOf(countries, labels)
+		bio.NotableWorks = labelsOf(works, labels)
+	}
+
+	if article := entity.Sitelinks["enwiki"].Title; article != "" {
+		// The summary is optional; a person without one is still returned
+		if summary, err := b.wikipedia.fetchSummary(ctx, article); err == nil {
+			bio.Wikipedia = summary
+		}
+	}
+	return bio, nil
+}
+
+// wikidataEntity is the part of a Wikidata entity a biography reads
+type wikidataEntity struct {
+	Labels       map[string]struct{ Value string } `json:"labels"`
+	Descriptions map[string]struct{ Value string } `json:"descriptions"`
+	Claims       map[string][]struct {
+		Mainsnak struct {
+			Datavalue struct {
+				Value json.RawMessage `json:"value"`
+			} `json:"datavalue"`
+		} `json:"mainsnak"`
+	} `json:"claims"`
+	Sitelinks map[string]struct {
+		Title string `json:"title"`
+	} `json:"sitelinks"`
+}
+
+func (e wikidataEntity) label() string       { return e.Labels["en"].Value }
+func (e wikidataEntity) description() string { return e.Descriptions["en"].Value }
+
+// first returns the value of a property's first claim
+func (e wikidataEntity) first(property string) json.RawMessage {
+	if claims := e.Claims[property]; len(claims) > 0 {
+		return claims[0].Mainsnak.Datavalue.Value
+	}
+	return nil
+}
+
+// items returns the entity IDs a property's claims point to
+func (e wikidataEntity) items(property string) []string {
+	var ids []string
+	for _, claim := range e.Claims[property] {
+		var item struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal(claim.Mainsnak.Datavalue.Value, &item) == nil && item.ID != "" {
+			ids = append(ids, item.ID)
+		}
+	}
+	return ids
+}
+
+func (b *PersonBios) entity(ctx context.Context, id string) (wikidataEntity, error) {
+	var result struct {
+		Entities map[string]wikidataEntity `json:"entities"`
+	}
+	err := b.get(ctx, url.Values{
+		"action":    {"wbgetentities"},
+		"ids":       {id},
+		"props":     {"labels|descriptions|claims|sitelinks"},
+		"languages": {"en"},
+	}, &result)
+	if err != nil {
+		return wikidataEntity{}, err
+	}
+	entity, ok := result.Entities[id]
+	if !ok {
+		return wikidataEntity{}, fmt.Errorf("%w: Wikidata has no entity %s", ErrPersonNotFound, id)
+	}
+	return entity, nil
+}
+
+// labels returns the English names of entities by ID
+func (b *PersonBios) labels(ctx context.Context, ids []string) (map[string]string, error) {
+	var result struct {
+		Entities map[string]wikidataEntity `json:"entities"`
+	}
+	err := b.get(ctx, url.Values{
+		"action":    {"wbgetentities"},
+		"ids":       {strings.Join(ids, "|")},
+		"props":     {"labels"},
+		"languages": {"en"},
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[string]string, len(result.Entities))
+	for id, entity := range result.Entities {
+		labels[id] = entity.label()
+	}
+	return labels, nil
+}
+
+func labelsOf(ids []string, labels map[string]string) []string {
+	var names []string
+	for _, id := range ids {
+		if label := labels[id]; label != "" {
+			names = append(names, label)
+		}
+	}
+	return names
+}
+
+// get calls the Wikidata API with params and decodes the JSON response into v
+func (b *PersonBios) get(ctx context.Context, params url.Values, v interface{}) error {
+	params.Set("format", "json")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	// Wikimedia asks API clients to identify themselves
+	req.Header.Set("User-Agent", "movie-api-go (person biographies)")
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("wikidata returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// wikidataDate formats a Wikidata time value to its precision: 1963-03-27, 1963-03 or 1963
+func wikidataDate(value json.RawMessage) string {
+	var t struct {
+		Time      string `json:"time"`
+		Precision int    `json:"precision"`
+	}
+	if json.Unmarshal(value, &t) != nil {
+		return ""
+	}
+	date, _, _ := strings.Cut(strings.TrimPrefix(t.Time, "+"), "T")
+	if len(date) < len("0000-00-00") {
+		return ""
+	}
+	switch t.Precision {
+	case 9:
+		return date[:strings.Index(date, "-")]
+	case 10:
+		return date[:strings.LastIndex(date, "-")]
+	}
+	return date
+}
+
+// filmography lists the titles the API has seen that credit name as director, writer or
+// actor, newest first
+func (b *PersonBios) filmography(name string) []models.FilmographyEntry {
+	key := strings.ToLower(foldDiacritics(name))
+	credits := []struct {
+		role  string
+		field func(*models.OMDbResponse) string
+	}{
+		{"director", func(r *models.OMDbResponse) string { return r.Director }},
+		{"writer", func(r *models.OMDbResponse) string { return r.Writer }},
+		{"actor", func(r *models.OMDbResponse) string { return r.Actors }},
+	}
+
+	entries := []models.FilmographyEntry{}
+	for _, record := range b.omdb.History.Records() {
+		var roles []string
+		for _, credit := range credits {
+			for _, person := range splitList(credit.field(record)) {
+				if i := strings.Index(person, "("); i >= 0 {
+					person = strings.TrimSpace(person[:i])
+				}
+				if strings.ToLower(foldDiacritics(person)) == key {
+					roles = append(roles, credit.role)
+					break
+				}
+			}
+		}
+		if len(roles) > 0 {
+			entries = append(entries, models.FilmographyEntry{
+				ImdbID: record.ImdbID,
+				Title:  record.Title,
+				Year:   record.Year,
+				Type:   record.Type,
+				Roles:  roles,
+			})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Year != entries[j].Year {
+			return entries[i].Year > entries[j].Year
+		}
+		return entries[i].Title < entries[j].Title
+	})
+	return entries
+}
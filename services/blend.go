@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+var (
+	// ErrInvalidScoreWeights wraps the reason score weights were rejected
+	ErrInvalidScoreWeights = errors.New("invalid score weights")
+
+	// ErrScoreWeightsNotFound is returned for a tenant without score weights
+	ErrScoreWeightsNotFound = errors.New("score weights not found")
+)
+
+// DefaultScoreWeights weigh IMDb, Metascore and Rotten Tomatoes equally
+var DefaultScoreWeights = models.ScoreWeights{IMDb: 1, Metascore: 1, RottenTomatoes: 1}
+
+// ScoreWeightStore keeps the weights the blended score of each tenant is computed with.
+// Different clients trust different rating sources: one leans on IMDb's audience, another
+// on the critics of Metacritic and Rotten Tomatoes. Callers without weights of their own
+// get the deployment's defaults. The tenants' weights are persisted as a JSON file.
+type ScoreWeightStore struct {
+	// Default are the weights of callers without tenant weights
+	Default models.ScoreWeights
+
+	path string
+
+	mu      sync.RWMutex
+	tenants map[string]models.TenantScoreWeights
+}
+
+// NewScoreWeightStore loads the tenants' weights from SCORE_WEIGHTS_PATH (default
+// data/score_weights.json). BLENDED_SCORE_WEIGHTS sets the defaults, e.g.
+// "imdb:2,metascore:1,rotten_tomatoes:1"; all three sources weigh the same without it.
+func NewScoreWeightStore() (*ScoreWeightStore, error) {
+	path := os.Getenv("SCORE_WEIGHTS_PATH")
+	if path == "" {
+		path = "data/score_weights.json"
+	}
+
+	s := &ScoreWeightStore{Default: DefaultScoreWeights, path: path, tenants: make(map[string]models.TenantScoreWeights)}
+	if spec := os.Getenv("BLENDED_SCORE_WEIGHTS"); spec != "" {
+		weights, err := ParseScoreWeights(spec)
+		if err != nil {
+			return nil, fmt.Errorf("BLENDED_SCORE_WEIGHTS: %w", err)
+		}
+		s.Default = weights
+	}
+	if err := store.LoadJSON(path, &s.tenants); err != nil {
+		return nil, err
+	}
+	if s.tenants == nil {
+		s.tenants = make(map[string]models.TenantScoreWeights)
+	}
+	return s, nil
+}
+
+// List returns every tenant's weights sorted by tenant
+func (s *ScoreWeightStore) List() []models.TenantScoreWeights {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenants := make([]models.TenantScoreWeights, 0, len(s.tenants))
+	for _, weights := range s.tenants {
+		tenants = append(tenants, weights)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].Tenant < tenants[j].Tenant })
+	return tenants
+}
+
+// For returns the weights of a tenant, or the defaults when it has none
+func (s *ScoreWeightStore) For(tenant string) models.ScoreWeights {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if weights, ok := s.tenants[tenant]; ok {
+		return weights.Weights
+	}
+	return s.Default
+}
+
+// Put sets a tenant's weights, persists the store and returns them with the ones they
+// replaced, if any
+func (s *ScoreWeightStore) Put(tenant, actor string, weights models.ScoreWeights) (models.TenantScoreWeights, *models.TenantScoreWeights, error) {
+	if !tagSlugPattern.MatchString(tenant) {
+		return models.TenantScoreWeights{}, nil, fmt.Errorf("%w: tenant must be lowercase words joined by hyphens, e.g. acme-tv", ErrInvalidScoreWeights)
+	}
+	if err := validateScoreWeights(weights); err != nil {
+		return models.TenantScoreWeights{}, nil, err
+	}
+	entry := models.TenantScoreWeights{Tenant: tenant, Weights: weights, UpdatedBy: actor, UpdatedAt: time.Now().UTC()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var previous *models.TenantScoreWeights
+	if existing, ok := s.tenants[tenant]; ok {
+		previous = &existing
+	}
+	s.tenants[tenant] = entry
+	return entry, previous, store.SaveJSON(s.path, s.tenants)
+}
+
+// Delete removes a tenant's weights, persists the store and returns the removed weights
+func (s *ScoreWeightStore) Delete(tenant string) (models.TenantScoreWeights, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.tenants[tenant]
+	if !ok {
+		return models.TenantScoreWeights{}, ErrScoreWeightsNotFound
+	}
+	delete(s.tenants, tenant)
+	return entry, store.SaveJSON(s.path, s.tenants)
+}
+
+// ParseScoreWeights reads weights written as source:weight pairs, e.g.
+// "imdb:2,metascore:1,rotten_tomatoes:1". Sources left out weigh nothing.
+func ParseScoreWeights(spec string) (models.ScoreWeights, error) {
+	var weights models.ScoreWeights
+	for _, pair := range strings.Split(spec, ",") {
+		source, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return weights, fmt.Errorf("%w: %q must be source:weight, e.g. imdb:2", ErrInvalidScoreWeights, pair)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return weights, fmt.Errorf("%w: the weight of %s must be a number", ErrInvalidScoreWeights, source)
+		}
+		switch strings.ToLower(strings.TrimSpace(source)) {
+		case "imdb":
+			weights.IMDb = weight
+		case "metascore":
+			weights.Metascore = weight
+		case "rotten_tomatoes":
+			weights.RottenTomatoes = weight
+		default:
+			return weights, fmt.Errorf("%w: unknown source %q; use imdb, metascore or rotten_tomatoes", ErrInvalidScoreWeights, source)
+		}
+	}
+	return weights, validateScoreWeights(weights)
+}
+
+func validateScoreWeights(weights models.ScoreWeights) error {
+	for _, weight := range []float64{weights.IMDb, weights.Metascore, weights.RottenTomatoes} {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("%w: weights must not be negative", ErrInvalidScoreWeights)
+		}
+	}
+	if weights.IMDb+weights.Metascore+weights.RottenTomatoes == 0 {
+		return fmt.Errorf("%w: at least one source must have a weight", ErrInvalidScoreWeights)
+	}
+	return nil
+}
+
+// BlendedScore is the weighted mean of a movie's IMDb rating, Metascore and Rotten
+// Tomatoes score on a 0-100 scale, rounded to one decimal. Sources the movie has no score
+// from are left out and the others weighed up, so a title Metacritic never reviewed isn't
+// pulled down; it is nil when no weighted source has a score.
+func BlendedScore(movie models.MovieBrief, weights models.ScoreWeights) *float64 {
+	var sum, total float64
+	if rating, err := strconv.ParseFloat(movie.ImdbRating, 64); err == nil && weights.IMDb > 0 {
+		sum += rating * 10 * weights.IMDb
+		total += weights.IMDb
+	}
+	if movie.Metascore > 0 && weights.Metascore > 0 {
+		sum += float64(movie.Metascore) * weights.Metascore
+		total += weights.Metascore
+	}
+	if movie.RottenTomatoes > 0 && weights.RottenTomatoes > 0 {
+		sum += float64(movie.RottenTomatoes) * weights.RottenTomatoes
+		total += weights.RottenTomatoes
+	}
+	if total == 0 {
+		return nil
+	}
+	score := math.Round(sum/total*10) / 10
+	return &score
+}
+
+// RottenTomatoes returns the Rotten Tomatoes score of a title's ratings, 0 to 100, or 0
+// when there is none
+func RottenTomatoes(ratings []models.Rating) int {
+	for _, rating := range ratings {
+		if rating.Source != "Rotten Tomatoes" {
+			continue
+		}
+		if score, ok := normalizeRatingValue(rating.Value); ok {
+			return int(math.Round(score))
+		}
+	}
+	return 0
+}
+
+type scoreWeightsKey struct{}
+
+// WithScoreWeights sets the weights blended scores are computed with for a request
+func WithScoreWeights(ctx context.Context, weights models.ScoreWeights) context.Context {
+	return context.WithValue(ctx, scoreWeightsKey{}, weights)
+}
+
+// ScoreWeightsFrom returns the weights of the context, or DefaultScoreWeights if it has none
+func ScoreWeightsFrom(ctx context.Context) models.ScoreWeights {
+	if weights, ok := ctx.Value(scoreWeightsKey{}).(models.ScoreWeights); ok {
+		return weights
+	}
+	return DefaultScoreWeights
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"image"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	"movie-api-go/store"
+)
+
+const (
+	// blurhashX and blurhashY are the horizontal and vertical components of a placeholder,
+	// enough for the shapes of a portrait poster
+	blurhashX = 4
+	blurhashY = 3
+
+	// blurhashWidth is the width posters are scaled down to before hashing
+	blurhashWidth = 32
+)
+
+// base83 is the alphabet of Blurhash strings
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// BlurhashStore keeps the Blurhash placeholders computed from posters, persisted as a
+// JSON file. Clients decode a placeholder into a blurred preview of the poster to show
+// while the image loads.
+type BlurhashStore struct {
+	path string
+
+	mu     sync.Mutex
+	hashes map[string]string
+}
+
+// NewBlurhashStore loads the placeholders from POSTER_BLURHASHES_PATH (default
+// data/blurhashes.json)
+func NewBlurhashStore() (*BlurhashStore, error) {
+	path := os.Getenv("POSTER_BLURHASHES_PATH")
+	if path == "" {
+		path = "data/blurhashes.json"
+	}
+
+	s := &BlurhashStore{path: path, hashes: make(map[string]string)}
+	if err := store.LoadJSON(path, &s.hashes); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the placeholder computed for a title's poster
+func (s *BlurhashStore) Get(imdbID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash, ok := s.hashes[imdbID]
+	return hash, ok
+}
+
+// Compute hashes a title's poster and keeps the placeholder
+func (s *BlurhashStore) Compute(imdbID string, img image.Image) error {
+	hash := encodeBlurhash(resizeImage(img, blurhashWidth), blurhashX, blurhashY)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hashes[imdbID] = hash
+	return store.SaveJSON(s.path, s.hashes)
+}
+
+// encodeBlurhash returns the Blurhash of img with x by y components, following the
+// reference encoder: the image's colors in linear light are projected on a cosine basis,
+// and the average color and the quantized components are written in base 83
+func encodeBlurhash(img image.Image, x, y int) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return ""
+	}
+
+	factors := make([][3]float64, 0, x*y)
+	for j := 0; j < y; j++ {
+		for i := 0; i < x; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var factor [3]float64
+			for py := 0; py < height; py++ {
+				for px := 0; px < width; px++ {
+					basis := math.Cos(math.Pi*float64(i*px)/float64(width)) * math.Cos(math.Pi*float64(j*py)/float64(height))
+					r, g, b, _ := img.At(bounds.Min.X+px, bounds.Min.Y+py).RGBA()
+					factor[0] += basis * srgbToLinear(int(r>>8))
+					factor[1] += basis * srgbToLinear(int(g>>8))
+					factor[2] += basis * srgbToLinear(int(b>>8))
+				}
+			}
+			scale := normalisation / float64(width*height)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encodeBase83((x-1)+(y-1)*9, 1))
+
+	dc, ac := factors[0], factors[1:]
+	maximum := 1.0
+	if len(ac) > 0 {
+		actual := 0.0
+		for _, factor := range ac {
+			actual = math.Max(actual, math.Max(math.Abs(factor[0]), math.Max(math.Abs(factor[1]), math.Abs(factor[2]))))
+		}
+		quantised := int(math.Max(0, math.Min(82, math.Floor(actual*166-0.5))))
+		maximum = float64(quantised+1) / 166
+		hash.WriteString(encodeBase83(quantised, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	hash.WriteString(encodeBase83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+	for _, factor := range ac {
+		quantise := func(value float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(value/maximum, 0.5)*9+9.5))))
+		}
+		hash.WriteString(encodeBase83(quantise(factor[0])*19*19+quantise(factor[1])*19+quantise(factor[2]), 2))
+	}
+	return hash.String()
+}
+
+func encodeBase83(value, length int) string {
+	digits := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		digits[i] = base83[value%83]
+		value /= 83
+	}
+	return string(digits)
+}
+
+func srgbToLinear(value int) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
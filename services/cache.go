@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// Cache statuses reported through the request scope, in increasing order of precedence
+const (
+	CacheHit   = "HIT"
+	CacheMiss  = "MISS"
+	CacheStale = "STALE"
+)
+
+// cacheRefreshTimeout bounds a background refresh, which has no inbound request to inherit a deadline from
+const cacheRefreshTimeout = 10 * time.Second
+
+// DetailCache keeps raw OMDb responses for title, IMDb ID and episode lookups and search pages.
+// Entries are fresh for TTL and may then be served stale for up to Stale while a
+// background refresh replaces them, so hot titles stay fast when they expire.
+// Not-found answers are kept for the shorter NegativeTTL and are never served stale.
+// With Shared set, entries are also written to the sharded Redis tier, and a local miss
+// is looked up there before going upstream, so replicas fill each other's caches.
+// With a Policy, successful lookups are fresh for the TTL of their popularity tier
+// instead of TTL, and a CacheRefresh job refreshes them ahead of expiry.
+type DetailCache struct {
+	TTL         time.Duration
+	Stale       time.Duration
+	NegativeTTL time.Duration
+	MaxEntries  int
+	Shared      *ShardedCache
+	Policy      *RefreshPolicy
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	body       []byte
+	storedAt   time.Time
+	ttl        time.Duration
+	stale      time.Duration
+	negative   bool
+	refreshing bool
+
+	// accesses counts the lookups served since windowStart, previous those of the window before
+	accesses    int
+	previous    int
+	windowStart time.Time
+}
+
+// access counts a lookup served from the entry
+func (e *cacheEntry) access(window time.Duration) {
+	e.rotate(window)
+	e.accesses++
+}
+
+// popularity is the number of lookups served from the entry in the current and the
+// previous access window
+func (e *cacheEntry) popularity(window time.Duration) int {
+	e.rotate(window)
+	return e.accesses + e.previous
+}
+
+// rotate starts a new access window once the current one is over
+func (e *cacheEntry) rotate(window time.Duration) {
+	elapsed := time.Since(e.windowStart)
+	if elapsed < window {
+		return
+	}
+	e.previous = e.accesses
+	if elapsed >= 2*window {
+		e.previous = 0
+	}
+	e.accesses, e.windowStart = 0, time.Now()
+}
+
+// detailCacheFromEnv reads DETAIL_CACHE_TTL_SECONDS, DETAIL_CACHE_STALE_SECONDS,
+// NEGATIVE_CACHE_TTL_SECONDS and DETAIL_CACHE_MAX_ENTRIES, and the refresh policy
+// (see refreshPolicyFromEnv). A zero TTL disables the cache.
+func detailCacheFromEnv() *DetailCache {
+	return &DetailCache{
+		TTL:         time.Duration(envInt("DETAIL_CACHE_TTL_SECONDS", 3600)) * time.Second,
+		Stale:       time.Duration(envInt("DETAIL_CACHE_STALE_SECONDS", 600)) * time.Second,
+		NegativeTTL: time.Duration(envInt("NEGATIVE_CACHE_TTL_SECONDS", 300)) * time.Second,
+		MaxEntries:  envInt("DETAIL_CACHE_MAX_ENTRIES", 10000),
+		Policy:      refreshPolicyFromEnv(),
+		entries:     make(map[string]*cacheEntry),
+	}
+}
+
+// Enabled reports whether responses are cached at all
+func (c *DetailCache) Enabled() bool {
+	return c != nil && c.TTL > 0
+}
+
+// get returns the cached body for key with its age and status. A stale entry is
+// returned with refresh set if the caller should start the background refresh.
+func (c *DetailCache) get(key string) (body []byte, age time.Duration, status string, refresh bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, 0, CacheMiss, false
+	}
+
+	age = time.Since(entry.storedAt)
+	ttl := c.ttlLocked(entry)
+	switch {
+	case age < ttl:
+		c.accessLocked(entry)
+		return entry.body, age, CacheHit, false
+	case age < ttl+entry.stale:
+		c.accessLocked(entry)
+		refresh = !entry.refreshing
+		entry.refreshing = true
+		return entry.body, age, CacheStale, refresh
+	}
+
+	delete(c.entries, key)
+	return nil, 0, CacheMiss, false
+}
+
+// set stores a successful lookup, or a not-found answer if negative is set. A replaced
+// entry's access counts carry over.
+func (c *DetailCache) set(key string, body []byte, negative bool) {
+	entry := &cacheEntry{body: body, storedAt: time.Now(), ttl: c.TTL, stale: c.Stale, windowStart: time.Now()}
+	if negative {
+		if c.NegativeTTL == 0 {
+			return
+		}
+		entry.ttl, entry.stale, entry.negative = c.NegativeTTL, 0, true
+	} else if c.Policy != nil {
+		// Kept, in snapshots and the shared tier too, for as long as the least popular tier
+		entry.ttl = c.Policy.ColdTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing, ok := c.entries[key]
+	if !ok && c.MaxEntries > 0 && len(c.entries) >= c.MaxEntries {
+		c.evictLocked()
+	}
+	if ok {
+		entry.accesses, entry.previous, entry.windowStart = existing.accesses, existing.previous, existing.windowStart
+	}
+	c.entries[key] = entry
+	if c.Shared != nil {
+		go c.share(key, entry)
+	}
+}
+
+// share writes an entry to the shared tier, in the snapshot format, for as long as it
+// can be served
+func (c *DetailCache) share(key string, entry *cacheEntry) {
+	value, err := json.Marshal(cacheSnapshotEntry{Key: key, Body: entry.body, StoredAt: entry.storedAt, TTL: entry.ttl, Stale: entry.stale})
+	if err != nil {
+		return
+	}
+	c.Shared.Set(context.Background(), key, value, entry.ttl+entry.stale)
+}
+
+// loadShared copies an entry from the shared tier into memory, keeping its original age,
+// and reports whether one was found
+func (c *DetailCache) loadShared(ctx context.Context, key string) bool {
+	value := c.Shared.Get(ctx, key)
+	if value == nil {
+		return false
+	}
+	var entry cacheSnapshotEntry
+	if err := json.Unmarshal(value, &entry); err != nil || entry.Key != key {
+		return false
+	}
+	return c.restore([]cacheSnapshotEntry{entry}) > 0
+}
+
+// refreshFailed clears the refreshing flag so that a later request retries the refresh
+func (c *DetailCache) refreshFailed(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok {
+		entry.refreshing = false
+	}
+}
+
+// evictLocked drops expired entries, or the oldest entry if none have expired
+func (c *DetailCache) evictLocked() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if time.Since(entry.storedAt) >= c.ttlLocked(entry)+entry.stale {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.storedAt.Before(oldest) {
+			oldestKey, oldest = key, entry.storedAt
+		}
+	}
+	if len(c.entries) >= c.MaxEntries && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// ttlLocked is how long an entry is fresh: the TTL of its popularity tier under a refresh
+// policy, or the TTL it was stored with. Callers hold c.mu.
+func (c *DetailCache) ttlLocked(entry *cacheEntry) time.Duration {
+	if c.Policy == nil || entry.negative {
+		return entry.ttl
+	}
+	return c.Policy.TTL(c.Policy.Tier(entry.popularity(c.Policy.AccessWindow)))
+}
+
+// accessLocked counts a lookup served from an entry. Callers hold c.mu.
+func (c *DetailCache) accessLocked(entry *cacheEntry) {
+	if c.Policy != nil {
+		entry.access(c.Policy.AccessWindow)
+	}
+}
+
+// cacheKey identifies an upstream call by its parameters, without the API key
+func cacheKey(params url.Values) string {
+	keyed := url.Values{}
+	for name, values := range params {
+		if name != "apikey" {
+			keyed[name] = values
+		}
+	}
+	return keyed.Encode()
+}
+
+// fetchCached serves lookups from the cache, fetching on a miss and refreshing
+// stale entries in the background. The outcome is recorded in the request scope.
+func (s *OMDbService) fetchCached(ctx context.Context, params url.Values) ([]byte, error) {
+	if !s.Cache.Enabled() {
+		body, err := s.fetch(ctx, params)
+		if err == nil {
+			s.History.Record(body)
+		}
+		return body, err
+	}
+
+	key := cacheKey(params)
+	body, age, status, refresh := s.Cache.get(key)
+	if body == nil && s.Cache.loadShared(ctx, key) {
+		body, age, status, refresh = s.Cache.get(key)
+	}
+	if body != nil {
+		s.scaling.recordCacheLookup(true)
+		ScopeFrom(ctx).recordCache(status, age)
+		TraceFrom(ctx).recordCache(key, status, age, body)
+		ExplainFrom(ctx).recordCache(status)
+		if refresh {
+			go isolate(func() error {
+				return s.refresh(key, params)
+			})
+		}
+		return body, nil
+	}
+
+	s.scaling.recordCacheLookup(false)
+	body, err := s.fetch(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	ScopeFrom(ctx).recordCache(CacheMiss, 0)
+	ExplainFrom(ctx).recordCache(CacheMiss)
+	if ok, negative := cacheable(body); ok {
+		s.Cache.set(key, body, negative)
+	}
+	s.History.Record(body)
+	return body, nil
+}
+
+// cachedTitle returns the record the cache holds for the lookup with params, without going
+// upstream, and whether there was one. A stale record is refreshed in the background like
+// in fetchCached. Not-found answers aren't returned.
+func (s *OMDbService) cachedTitle(ctx context.Context, params url.Values) (*models.OMDbResponse, bool) {
+	if !s.Cache.Enabled() {
+		return nil, false
+	}
+
+	key := cacheKey(params)
+	body, age, status, refresh := s.Cache.get(key)
+	if body == nil {
+		return nil, false
+	}
+	if refresh {
+		go isolate(func() error {
+			return s.refresh(key, params)
+		})
+	}
+
+	var record models.OMDbResponse
+	if err := json.Unmarshal(body, &record); err != nil || record.Response != "True" || s.Quarantine.Quarantined(record.ImdbID) {
+		return nil, false
+	}
+	s.normalize(&record)
+
+	s.scaling.recordCacheLookup(true)
+	ScopeFrom(ctx).recordCache(status, age)
+	TraceFrom(ctx).recordCache(key, status, age, body)
+	ExplainFrom(ctx).recordCache(status)
+	return &record, true
+}
+
+// cacheable reports whether an upstream body is worth keeping: a successful lookup, or a
+// not-found answer (negative), which is cached briefly so that repeated lookups of
+// nonexistent titles don't each cost an upstream call. Other errors, such as an
+// exhausted request limit, must not be served from the cache.
+func cacheable(body []byte) (ok, negative bool) {
+	var status struct {
+		Response string
+		Error    string
+	}
+	if err := json.Unmarshal(body, &status); err != nil {
+		return false, false
+	}
+	if status.Response == "True" {
+		return true, false
+	}
+	return isNotFound(status.Error), true
+}
+
+// isNotFound matches OMDb's not-found errors ("Movie not found!", "Series or episode
+// not found!", "Incorrect IMDb ID.")
+func isNotFound(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "not found") || strings.Contains(message, "incorrect imdb id")
+}
+
+// refresh replaces a stale entry. It runs detached from the request that found the entry,
+// so it isn't cancelled with that request or charged to its call budget. Quarantined
+// titles are skipped without an error.
+func (s *OMDbService) refresh(key string, params url.Values) error {
+	ctx, cancel := context.WithTimeout(WithPriority(context.Background(), PriorityBackground), cacheRefreshTimeout)
+	defer cancel()
+	if imdbID := params.Get("i"); imdbID != "" && s.Quarantine.Quarantined(imdbID) {
+		// The stale entry is served until the title is released
+		s.Cache.refreshFailed(key)
+		return nil
+	}
+
+	body, err := s.fetch(ctx, params)
+	if err != nil {
+		log.Printf("cache: background refresh of %s failed: %s", key, errorText(err))
+		s.Cache.refreshFailed(key)
+		return err
+	}
+	ok, negative := cacheable(body)
+	if !ok {
+		s.Cache.refreshFailed(key)
+		return fmt.Errorf("refresh of %s returned an uncacheable response", key)
+	}
+	if s.Cache.Enabled() {
+		s.Cache.set(key, body, negative)
+	}
+	s.History.Record(body)
+	return nil
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"movie-api-go/models"
+)
+
+// Share card themes
+const (
+	CardDark  = "dark"
+	CardLight = "light"
+)
+
+// Layout of a share card, in pixels; 1200×630 is the size social networks show Open
+// Graph images at
+const (
+	cardWidth   = 1200
+	cardHeight  = 630
+	cardMargin  = 60
+	posterWidth = 340
+	titleScale  = 8
+	detailScale = 4
+)
+
+// cardTheme holds the colors of a theme
+type cardTheme struct {
+	background, panel, text, muted, accent color.RGBA
+}
+
+var cardThemes = map[string]cardTheme{
+	CardDark: {
+		background: color.RGBA{0x14, 0x18, 0x1c, 0xff},
+		panel:      color.RGBA{0x22, 0x28, 0x2e, 0xff},
+		text:       color.RGBA{0xf2, 0xf2, 0xf2, 0xff},
+		muted:      color.RGBA{0x9a, 0xa4, 0xad, 0xff},
+		accent:     color.RGBA{0xf5, 0xc5, 0x18, 0xff},
+	},
+	CardLight: {
+		background: color.RGBA{0xf6, 0xf4, 0xef, 0xff},
+		panel:      color.RGBA{0xe2, 0xdf, 0xd7, 0xff},
+		text:       color.RGBA{0x16, 0x19, 0x1c, 0xff},
+		muted:      color.RGBA{0x5c, 0x66, 0x70, 0xff},
+		accent:     color.RGBA{0xa6, 0x7c, 0x00, 0xff},
+	},
+}
+
+// minAccentContrast is how far in luminance, on a scale of 255, a poster color must be
+// from the card background to serve as the accent
+const minAccentContrast = 80
+
+// CardService renders share cards of titles: PNG images with the poster, title, year and
+// rating, for social networks to show when a link to a title is shared
+type CardService struct {
+	omdb    *OMDbService
+	posters *PosterService
+}
+
+func NewCardService(omdb *OMDbService, posters *PosterService) *CardService {
+	return &CardService{omdb: omdb, posters: posters}
+}
+
+// Themes returns the supported themes
+func (s *CardService) Themes() []string {
+	return []string{CardDark, CardLight}
+}
+
+// Render returns the share card of the title with the given IMDb ID as a PNG. Titles
+// without a poster, or whose poster can't be downloaded, get a card with an empty frame.
+func (s *CardService) Render(ctx context.Context, imdbID, theme string) ([]byte, error) {
+	record, err := s.omdb.GetTitleByID(ctx, imdbID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Response == "False" {
+		return nil, ErrNotFound
+	}
+	colors, ok := cardThemes[theme]
+	if !ok {
+		colors = cardThemes[CardDark]
+	}
+
+	var poster image.Image
+	if downloaded, err := s.posters.Get(ctx, imdbID); err == nil {
+		poster, _, _ = image.Decode(bytes.NewReader(downloaded.Data))
+	} else if !errors.Is(err, ErrNoPoster) {
+		log.Printf("cards: poster of %s left out: %v", imdbID, err)
+	}
+	if poster != nil {
+		colors.accent = posterAccent(poster, colors)
+	}
+
+	card := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(card, card.Bounds(), image.NewUniform(colors.background), image.Point{}, draw.Src)
+	// An accent stripe along the left edge
+	draw.Draw(card, image.Rect(0, 0, 12, cardHeight), image.NewUniform(colors.accent), image.Point{}, draw.Src)
+
+	frame := image.Rect(cardMargin, cardMargin, cardMargin+posterWidth, cardHeight-cardMargin)
+	draw.Draw(card, frame, image.NewUniform(colors.panel), image.Point{}, draw.Src)
+	if poster != nil {
+		drawPoster(card, frame, poster)
+	}
+
+	left := frame.Max.X + cardMargin
+	width := cardWidth - left - cardMargin
+	y := cardMargin + 10
+	for _, line := range wrapText(fontText(record.Title), titleScale, width, 3) {
+		drawText(card, left, y, line, titleScale, colors.text)
+		y += (glyphHeight + 4) * titleScale
+	}
+	y += 2 * detailScale
+
+	details := cardDetails(record)
+	if details != "" {
+		for _, line := range wrapText(fontText(details), detailScale, width, 1) {
+			drawText(card, left, y, line, detailScale, colors.muted)
+			y += (glyphHeight + 5) * detailScale
+		}
+	}
+	if genre := record.Genre; genre != "" {
+		for _, line := range wrapText(fontText(genre), detailScale, width, 1) {
+			drawText(card, left, y, line, detailScale, colors.muted)
+		}
+	}
+
+	if rating, err := strconv.ParseFloat(record.ImdbRating, 64); err == nil {
+		drawRating(card, left, cardHeight-cardMargin, width, rating, colors)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, card); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cardDetails is the line under the title: year, content rating and runtime
+func cardDetails(record *models.OMDbResponse) string {
+	var parts []string
+	for _, part := range []string{record.Year, record.Rated, record.Runtime} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// drawPoster scales the poster into the frame, keeping its aspect ratio, and centers it
+func drawPoster(card *image.RGBA, frame image.Rectangle, poster image.Image) {
+	bounds := poster.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return
+	}
+	// Fit the height when the poster is taller than the frame
+	width := frame.Dx()
+	if bounds.Dy()*frame.Dx() > frame.Dy()*bounds.Dx() {
+		width = bounds.Dx() * frame.Dy() / bounds.Dy()
+	}
+	scaled := resizeImage(poster, width)
+
+	size := scaled.Bounds().Size()
+	at := image.Pt(frame.Min.X+(frame.Dx()-size.X)/2, frame.Min.Y+(frame.Dy()-size.Y)/2)
+	target := image.Rectangle{Min: at, Max: at.Add(size)}.Intersect(frame)
+	draw.Draw(card, target, scaled, scaled.Bounds().Min.Add(target.Min.Sub(at)), draw.Over)
+}
+
+// drawRating draws the IMDb rating out of 10 above a bar filled in proportion, with the
+// bottom of the bar at bottom
+func drawRating(card *image.RGBA, left, bottom, width int, rating float64, colors cardTheme) {
+	bar := image.Rect(left, bottom-16, left+width, bottom)
+	draw.Draw(card, bar, image.NewUniform(colors.panel), image.Point{}, draw.Src)
+	filled := bar
+	filled.Max.X = left + int(math.Round(float64(width)*math.Min(math.Max(rating, 0), 10)/10))
+	draw.Draw(card, filled, image.NewUniform(colors.accent), image.Point{}, draw.Src)
+
+	label := "★ " + strconv.FormatFloat(rating, 'f', 1, 64)
+	drawText(card, left, bar.Min.Y-24-glyphHeight*6, label, 6, colors.accent)
+	drawText(card, left+textWidth(label+" ", 6), bar.Min.Y-24-glyphHeight*detailScale, "/ 10 IMDb", detailScale, colors.muted)
+}
+
+// posterAccent picks the most common color of the poster that stands out from the card
+// background, or the theme's accent when none does
+func posterAccent(poster image.Image, colors cardTheme) color.RGBA {
+	background := luminance([3]float64{float64(colors.background.R), float64(colors.background.G), float64(colors.background.B)})
+	for _, c := range extractPalette(poster, 5) {
+		var r, g, b uint8
+		if _, err := fmt.Sscanf(c.Hex, "#%02x%02x%02x", &r, &g, &b); err != nil {
+			continue
+		}
+		if math.Abs(luminance([3]float64{float64(r), float64(g), float64(b)})-background) >= minAccentContrast {
+			return color.RGBA{r, g, b, 0xff}
+		}
+	}
+	return colors.accent
+}
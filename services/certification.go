@@ -0,0 +1,78 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"movie-api-go/models"
+)
+
+//go:embed data/certifications.json
+var certificationData []byte
+
+// CertificationMapper translates OMDb's US certification (the Rated field) into the
+// rating system of another country. The table in data/certifications.json maps each US
+// rating to its closest local equivalent; classification boards rate films
+// independently, so the mapped value is an approximation, and reported as such.
+type CertificationMapper struct {
+	systems map[string]string
+	ratings map[string]map[string]string
+	aliases map[string]string
+}
+
+func NewCertificationMapper() (*CertificationMapper, error) {
+	var table struct {
+		Systems map[string]string            `json:"systems"`
+		Ratings map[string]map[string]string `json:"ratings"`
+		Aliases map[string]string            `json:"aliases"`
+	}
+	if err := json.Unmarshal(certificationData, &table); err != nil {
+		return nil, fmt.Errorf("invalid certification table: %w", err)
+	}
+
+	return &CertificationMapper{
+		systems: table.Systems,
+		ratings: table.Ratings,
+		aliases: table.Aliases,
+	}, nil
+}
+
+// Country normalizes a cert_country value (e.g. "uk" to "GB") and reports whether it is supported
+func (m *CertificationMapper) Country(country string) (string, bool) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if alias, ok := m.aliases[country]; ok {
+		country = alias
+	}
+	_, ok := m.systems[country]
+	return country, ok
+}
+
+// Countries lists the supported country codes
+func (m *CertificationMapper) Countries() []string {
+	countries := make([]string, 0, len(m.systems))
+	for country := range m.systems {
+		countries = append(countries, country)
+	}
+	sort.Strings(countries)
+	return countries
+}
+
+// Map returns the certification for rated in country, which must have been normalized
+// with Country. It returns nil when the title is unrated or its rating isn't in the table.
+func (m *CertificationMapper) Map(rated, country string) *models.Certification {
+	local, ok := m.ratings[strings.ToUpper(rated)][country]
+	if !ok {
+		return nil
+	}
+
+	return &models.Certification{
+		Country:     country,
+		System:      m.systems[country],
+		Rating:      local,
+		Original:    rated,
+		Approximate: country != "US",
+	}
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+//go:embed data/collections.json
+var defaultCollectionData []byte
+
+var (
+	// ErrInvalidCollection wraps the reason a collection was rejected
+	ErrInvalidCollection = errors.New("invalid collection")
+
+	// ErrCollectionNotFound is returned for a collection that doesn't exist
+	ErrCollectionNotFound = errors.New("collection not found")
+
+	// ErrCollectionInactive is returned for a collection outside its activation window
+	ErrCollectionInactive = errors.New("collection is not active")
+)
+
+// maxCollectionTitles bounds the titles of one collection
+const maxCollectionTitles = 100
+
+// CollectionStore keeps the themed collections (halloween, christmas, oscars-2024, ...)
+// marketing schedules, each shown only during its activation window. It starts from a
+// built-in set the first time and is persisted as a JSON file. Only IMDb IDs are stored;
+// the titles' details come from the detail cache when a collection is served.
+type CollectionStore struct {
+	path string
+	omdb *OMDbService
+
+	mu          sync.RWMutex
+	collections map[string]models.Collection
+}
+
+// NewCollectionStore loads the collections from COLLECTIONS_PATH (default data/collections.json)
+func NewCollectionStore(omdb *OMDbService) (*CollectionStore, error) {
+	path := os.Getenv("COLLECTIONS_PATH")
+	if path == "" {
+		path = "data/collections.json"
+	}
+
+	var collections map[string]models.Collection
+	if err := store.LoadJSON(path, &collections); err != nil {
+		return nil, err
+	}
+	if collections == nil {
+		var defaults []models.Collection
+		if err := json.Unmarshal(defaultCollectionData, &defaults); err != nil {
+			return nil, fmt.Errorf("invalid default collections: %w", err)
+		}
+		collections = make(map[string]models.Collection, len(defaults))
+		for _, collection := range defaults {
+			collection.UpdatedAt = time.Now().UTC()
+			collections[collection.Slug] = collection
+		}
+	}
+
+	return &CollectionStore{path: path, omdb: omdb, collections: collections}, nil
+}
+
+// List returns every collection sorted by slug, marked active or not as of now
+func (s *CollectionStore) List() []models.Collection {
+	now := time.Now().UTC()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	collections := make([]models.Collection, 0, len(s.collections))
+	for _, collection := range s.collections {
+		collection.Active = collectionActive(collection, now)
+		collections = append(collections, collection)
+	}
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Slug < collections[j].Slug })
+	return collections
+}
+
+// Active returns the collections active now, sorted by slug
+func (s *CollectionStore) Active() []models.Collection {
+	active := []models.Collection{}
+	for _, collection := range s.List() {
+		if collection.Active {
+			active = append(active, collection)
+		}
+	}
+	return active
+}
+
+// Get returns an active collection; one outside its window is ErrCollectionInactive
+func (s *CollectionStore) Get(slug string) (models.Collection, error) {
+	s.mu.RLock()
+	collection, ok := s.collections[slug]
+	s.mu.RUnlock()
+	if !ok {
+		return models.Collection{}, ErrCollectionNotFound
+	}
+
+	collection.Active = collectionActive(collection, time.Now().UTC())
+	if !collection.Active {
+		return collection, ErrCollectionInactive
+	}
+	return collection, nil
+}
+
+// Put creates or replaces a collection, persists the store and returns the collection
+// with the one it replaced, if any
+func (s *CollectionStore) Put(slug string, req models.CollectionRequest) (models.Collection, *models.Collection, error) {
+	if !tagSlugPattern.MatchString(slug) {
+		return models.Collection{}, nil, fmt.Errorf("%w: slug must be lowercase words joined by hyphens, e.g. oscars-2024", ErrInvalidCollection)
+	}
+	collection := models.Collection{
+		Slug:        slug,
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+		ImdbIDs:     cleanList(req.ImdbIDs),
+		ActiveFrom:  strings.TrimSpace(req.ActiveFrom),
+		ActiveUntil: strings.TrimSpace(req.ActiveUntil),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if collection.Title == "" {
+		return models.Collection{}, nil, fmt.Errorf("%w: title is required", ErrInvalidCollection)
+	}
+	if len(collection.ImdbIDs) == 0 || len(collection.ImdbIDs) > maxCollectionTitles {
+		return models.Collection{}, nil, fmt.Errorf("%w: a collection holds 1 to %d titles", ErrInvalidCollection, maxCollectionTitles)
+	}
+	for _, imdbID := range collection.ImdbIDs {
+		if !imdbIDPattern.MatchString(imdbID) {
+			return models.Collection{}, nil, fmt.Errorf("%w: %q is not a valid IMDb ID", ErrInvalidCollection, imdbID)
+		}
+	}
+	if err := validateWindow(collection.ActiveFrom, collection.ActiveUntil); err != nil {
+		return models.Collection{}, nil, fmt.Errorf("%w: %v", ErrInvalidCollection, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var previous *models.Collection
+	if existing, ok := s.collections[slug]; ok {
+		previous = &existing
+	}
+	s.collections[slug] = collection
+	collection.Active = collectionActive(collection, collection.UpdatedAt)
+	return collection, previous, store.SaveJSON(s.path, s.collections)
+}
+
+// Delete removes a collection, persists the store and returns the removed collection
+func (s *CollectionStore) Delete(slug string) (models.Collection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collection, ok := s.collections[slug]
+	if !ok {
+		return models.Collection{}, ErrCollectionNotFound
+	}
+	delete(s.collections, slug)
+	return collection, store.SaveJSON(s.path, s.collections)
+}
+
+// Movies returns the details of a collection's titles in its order, looked up through the
+// detail cache, and the IDs of the titles that couldn't be fetched
+func (s *CollectionStore) Movies(ctx context.Context, collection models.Collection) ([]models.MovieBrief, []string) {
+	records := s.omdb.GetTitlesByID(ctx, collection.ImdbIDs)
+
+	movies := make([]models.MovieBrief, 0, len(collection.ImdbIDs))
+	var missing []string
+	for _, imdbID := range collection.ImdbIDs {
+		record, ok := records[imdbID]
+		if !ok {
+			missing = append(missing, imdbID)
+			continue
+		}
+		movies = append(movies, newMovieBrief(record))
+	}
+	return movies, missing
+}
+
+// windowBound is one side of an activation window: a date, or a day of every year
+type windowBound struct {
+	yearly bool
+	date   time.Time
+	day    int // month*100 + day of month, for yearly bounds
+}
+
+func parseWindowBound(value string) (windowBound, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return windowBound{date: date}, nil
+	}
+	if day, err := time.Parse("01-02", value); err == nil {
+		return windowBound{yearly: true, day: int(day.Month())*100 + day.Day()}, nil
+	}
+	return windowBound{}, fmt.Errorf("%q must be a date (2024-03-10) or a day of the year (10-31)", value)
+}
+
+// validateWindow checks that both bounds are dates, or both days of the year, in order.
+// Yearly windows may wrap around the new year (12-01 to 01-06) but need both bounds.
+func validateWindow(from, until string) error {
+	var bounds []windowBound
+	for _, value := range []string{from, until} {
+		if value == "" {
+			continue
+		}
+		bound, err := parseWindowBound(value)
+		if err != nil {
+			return err
+		}
+		bounds = append(bounds, bound)
+	}
+
+	yearly := len(bounds) > 0 && bounds[0].yearly
+	for _, bound := range bounds {
+		if bound.yearly != yearly {
+			return errors.New("active_from and active_until must both be dates or both days of the year")
+		}
+	}
+	if yearly && len(bounds) < 2 {
+		return errors.New("a yearly window needs both active_from and active_until")
+	}
+	if !yearly && len(bounds) == 2 && bounds[1].date.Before(bounds[0].date) {
+		return errors.New("active_until is before active_from")
+	}
+	return nil
+}
+
+// collectionActive reports whether now falls within a collection's window, both bounds
+// included. Collections without a window are always active.
+func collectionActive(collection models.Collection, now time.Time) bool {
+	var from, until windowBound
+	var hasFrom, hasUntil bool
+	if collection.ActiveFrom != "" {
+		from, hasFrom = storedWindowBound(collection.ActiveFrom)
+	}
+	if collection.ActiveUntil != "" {
+		until, hasUntil = storedWindowBound(collection.ActiveUntil)
+	}
+
+	if from.yearly && until.yearly {
+		day := int(now.Month())*100 + now.Day()
+		if from.day <= until.day {
+			return day >= from.day && day <= until.day
+		}
+		return day >= from.day || day <= until.day
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if hasFrom && today.Before(from.date) {
+		return false
+	}
+	if hasUntil && today.After(until.date) {
+		return false
+	}
+	return true
+}
+
+// storedWindowBound parses a stored bound, which was validated when it was saved
+func storedWindowBound(value string) (windowBound, bool) {
+	bound, err := parseWindowBound(value)
+	return bound, err == nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/url"
+	"sort"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// completenessFields are the fields of a complete title record. Stub records, such as
+// titles added to IMDb before release, have most of them empty.
+var completenessFields = []func(record *models.OMDbResponse) string{
+	func(r *models.OMDbResponse) string { return r.Title },
+	func(r *models.OMDbResponse) string { return r.Year },
+	func(r *models.OMDbResponse) string { return r.Rated },
+	func(r *models.OMDbResponse) string { return r.Released },
+	func(r *models.OMDbResponse) string { return r.Runtime },
+	func(r *models.OMDbResponse) string { return r.Genre },
+	func(r *models.OMDbResponse) string { return r.Director },
+	func(r *models.OMDbResponse) string { return r.Writer },
+	func(r *models.OMDbResponse) string { return r.Actors },
+	func(r *models.OMDbResponse) string { return r.Plot },
+	func(r *models.OMDbResponse) string { return r.Language },
+	func(r *models.OMDbResponse) string { return r.Country },
+	func(r *models.OMDbResponse) string { return r.Poster },
+	func(r *models.OMDbResponse) string { return r.ImdbRating },
+	func(r *models.OMDbResponse) string { return r.ImdbVotes },
+	func(r *models.OMDbResponse) string { return r.Metascore },
+}
+
+// Completeness scores a record's data quality from 0 to 100: the share of the fields of
+// a complete record that OMDb filled in rather than leaving empty
+func Completeness(record *models.OMDbResponse) int {
+	present := 0
+	for _, field := range completenessFields {
+		if value := field(record); value != "" {
+			present++
+		}
+	}
+	return int(math.Round(float64(present) * 100 / float64(len(completenessFields))))
+}
+
+// IncompleteRefresh re-fetches incomplete records in the background, most popular first.
+// OMDb fills in stub records over time, but a title is only looked up again when its
+// cached copy expires and someone asks for it, so the popular titles users see most would
+// otherwise keep their gaps for as long as they stay hot.
+type IncompleteRefresh struct {
+	// Interval between passes; 0 disables the job
+	Interval time.Duration
+	// Batch is the number of titles refreshed per pass
+	Batch int
+	// Below is the completeness under which a title is refreshed
+	Below int
+	// Recheck is how long a title fetched upstream is left alone
+	Recheck time.Duration
+
+	omdb *OMDbService
+}
+
+// NewIncompleteRefresh reads INCOMPLETE_REFRESH_MINUTES (default 60, 0 disables),
+// INCOMPLETE_REFRESH_BATCH (default 20), INCOMPLETE_REFRESH_BELOW (default 80) and
+// INCOMPLETE_RECHECK_HOURS (default 24)
+func NewIncompleteRefresh(omdb *OMDbService) *IncompleteRefresh {
+	return &IncompleteRefresh{
+		Interval: time.Duration(envInt("INCOMPLETE_REFRESH_MINUTES", 60)) * time.Minute,
+		Batch:    envInt("INCOMPLETE_REFRESH_BATCH", 20),
+		Below:    envInt("INCOMPLETE_REFRESH_BELOW", 80),
+		Recheck:  time.Duration(envInt("INCOMPLETE_RECHECK_HOURS", 24)) * time.Hour,
+		omdb:     omdb,
+	}
+}
+
+// Run refreshes a batch of incomplete titles every Interval until ctx is done
+func (r *IncompleteRefresh) Run(ctx context.Context) {
+	if r.Interval == 0 || r.Batch <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if refreshed := r.RefreshBatch(ctx); refreshed > 0 {
+				log.Printf("completeness: refreshed %d incomplete titles", refreshed)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RefreshBatch re-fetches up to Batch of the titles returned by Candidates and returns
+// how many were refreshed. A pass stops early once the upstream quota is exhausted.
+func (r *IncompleteRefresh) RefreshBatch(ctx context.Context) int {
+	refreshed := 0
+	for _, record := range r.Candidates() {
+		if refreshed == r.Batch || ctx.Err() != nil {
+			break
+		}
+		params := url.Values{}
+		params.Add("apikey", r.omdb.APIKey)
+		params.Add("i", record.ImdbID)
+		if err := r.omdb.refresh(cacheKey(params), params); err != nil {
+			if errors.Is(err, ErrUpstreamQuota) {
+				break
+			}
+			continue
+		}
+		refreshed++
+	}
+	return refreshed
+}
+
+// Candidates lists the titles of the local corpus below the completeness threshold that
+// weren't fetched within Recheck and aren't quarantined, by IMDb votes, most first, then
+// least complete first
+func (r *IncompleteRefresh) Candidates() []*models.OMDbResponse {
+	checkedBefore := time.Now().Add(-r.Recheck)
+	var candidates []*models.OMDbResponse
+	for _, record := range r.omdb.History.Records() {
+		if Completeness(record) >= r.Below || r.omdb.Quarantine.Quarantined(record.ImdbID) {
+			continue
+		}
+		if checked, ok := r.omdb.History.checkedAt(record.ImdbID); ok && checked.After(checkedBefore) {
+			continue
+		}
+		candidates = append(candidates, record)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		votesI, votesJ := VoteCount(candidates[i].ImdbVotes), VoteCount(candidates[j].ImdbVotes)
+		if votesI != votesJ {
+			return votesI > votesJ
+		}
+		return Completeness(candidates[i]) < Completeness(candidates[j])
+	})
+	return candidates
+}
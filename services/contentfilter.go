@@ -0,0 +1,223 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// What the content filter does with text that has violations
+const (
+	FilterRedact = "redact"
+	FilterReject = "reject"
+)
+
+// Kinds of violation the content filter finds
+const (
+	ViolationProfanity = "profanity"
+	ViolationEmail     = "email"
+	ViolationPhone     = "phone"
+)
+
+// defaultProfanity is the word list used without CONTENT_FILTER_WORDS_PATH
+var defaultProfanity = []string{"fuck", "shit", "cunt", "bitch", "asshole", "bastard", "motherfucker", "dickhead"}
+
+var (
+	emailPattern = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9-]+(?:\.[a-z0-9-]+)*\.[a-z]{2,}\b`)
+
+	// phonePattern matches phone numbers written with the usual separators, such as
+	// +1 (555) 123-4567 or 020 7946 0958
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[\s.-]?\d{3,4}[\s.-]?\d{3,4}\b`)
+)
+
+// minPhoneDigits keeps shorter numbers, such as years and runtimes, from counting as phone numbers
+const minPhoneDigits = 9
+
+// ContentFilter finds profanity, email addresses and phone numbers in text users write
+// for others to read, and redacts it or rejects the text. Violations are recorded in a
+// log persisted as a JSON file.
+type ContentFilter struct {
+	// Mode is FilterRedact or FilterReject
+	Mode string
+	// PII enables the email and phone checks
+	PII bool
+	// MaxViolations bounds the log; the oldest entries are dropped beyond it
+	MaxViolations int
+
+	words *regexp.Regexp
+	path  string
+
+	mu         sync.Mutex
+	violations []models.ContentViolation
+	nextID     int64
+}
+
+// NewContentFilter reads CONTENT_FILTER_MODE (redact or reject, default redact),
+// CONTENT_FILTER_PII (default true), the JSON array of words at CONTENT_FILTER_WORDS_PATH
+// (default a short list of English profanity) and CONTENT_VIOLATIONS_MAX (default 1000),
+// and loads the log from CONTENT_VIOLATIONS_PATH (default data/violations.json)
+func NewContentFilter() (*ContentFilter, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("CONTENT_FILTER_MODE")))
+	if mode == "" {
+		mode = FilterRedact
+	}
+	if mode != FilterRedact && mode != FilterReject {
+		return nil, fmt.Errorf("CONTENT_FILTER_MODE must be %s or %s, not %q", FilterRedact, FilterReject, mode)
+	}
+	path := os.Getenv("CONTENT_VIOLATIONS_PATH")
+	if path == "" {
+		path = "data/violations.json"
+	}
+
+	words := defaultProfanity
+	if wordsPath := os.Getenv("CONTENT_FILTER_WORDS_PATH"); wordsPath != "" {
+		words = nil
+		if err := store.LoadJSON(wordsPath, &words); err != nil {
+			return nil, err
+		}
+	}
+
+	f := &ContentFilter{
+		Mode:          mode,
+		PII:           !strings.EqualFold(strings.TrimSpace(os.Getenv("CONTENT_FILTER_PII")), "false"),
+		MaxViolations: envInt("CONTENT_VIOLATIONS_MAX", 1000),
+		words:         wordPattern(words),
+		path:          path,
+	}
+	if err := store.LoadJSON(path, &f.violations); err != nil {
+		return nil, err
+	}
+	for _, violation := range f.violations {
+		if violation.ID > f.nextID {
+			f.nextID = violation.ID
+		}
+	}
+	return f, nil
+}
+
+// Check returns text with its violations redacted, and the kinds of violation found.
+// Profane words keep their first letter; email addresses and phone numbers are
+// replaced by [email] and [phone].
+func (f *ContentFilter) Check(text string) (string, []string) {
+	var found []string
+	if f.words != nil {
+		redacted := f.words.ReplaceAllStringFunc(text, func(word string) string {
+			first, size := utf8.DecodeRuneInString(word)
+			return string(first) + strings.Repeat("*", utf8.RuneCountInString(word[size:]))
+		})
+		if redacted != text {
+			text = redacted
+			found = append(found, ViolationProfanity)
+		}
+	}
+	if !f.PII {
+		return text, found
+	}
+
+	if redacted := emailPattern.ReplaceAllString(text, "[email]"); redacted != text {
+		text = redacted
+		found = append(found, ViolationEmail)
+	}
+	phone := false
+	text = phonePattern.ReplaceAllStringFunc(text, func(number string) string {
+		digits := 0
+		for _, r := range number {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if digits < minPhoneDigits {
+			return number
+		}
+		phone = true
+		return "[phone]"
+	})
+	if phone {
+		found = append(found, ViolationPhone)
+	}
+	return text, found
+}
+
+// Record logs the violations found in text a user wrote. kind and target name what it
+// was written for, such as a review of a title, and action is what the filter did.
+func (f *ContentFilter) Record(userID, kind, target string, violations []string, action string) error {
+	violation := models.ContentViolation{
+		At:         time.Now().UTC(),
+		UserID:     userID,
+		Kind:       kind,
+		Target:     target,
+		Violations: violations,
+		Action:     action,
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextID++
+	violation.ID = f.nextID
+	f.violations = append(f.violations, violation)
+	if f.MaxViolations > 0 && len(f.violations) > f.MaxViolations {
+		f.violations = f.violations[len(f.violations)-f.MaxViolations:]
+	}
+	return store.SaveJSON(f.path, f.violations)
+}
+
+// Violations returns up to limit logged violations, newest first, and how many of each
+// kind the log holds
+func (f *ContentFilter) Violations(limit int) ([]models.ContentViolation, map[string]int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, violation := range f.violations {
+		for _, kind := range violation.Violations {
+			counts[kind]++
+		}
+	}
+	recent := make([]models.ContentViolation, 0, min(limit, len(f.violations)))
+	for i := len(f.violations) - 1; i >= 0 && len(recent) < limit; i-- {
+		recent = append(recent, f.violations[i])
+	}
+	return recent, counts
+}
+
+// violationNames describes kinds of violation for error messages
+func violationNames(violations []string) []string {
+	names := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		switch violation {
+		case ViolationProfanity:
+			names = append(names, "profanity")
+		case ViolationEmail:
+			names = append(names, "email addresses")
+		case ViolationPhone:
+			names = append(names, "phone numbers")
+		}
+	}
+	return names
+}
+
+// wordPattern matches the words, case-insensitively, along with their common endings.
+// It is nil for an empty list.
+func wordPattern(words []string) *regexp.Regexp {
+	var quoted []string
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(strings.ToLower(word)))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	// Longer words first, so that a word containing another is matched whole
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)(?:s|es|ed|er|ers|ing|y)?\b`)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"strings"
+
+	"movie-api-go/models"
+)
+
+// Credit jobs
+const (
+	CreditDirector = "director"
+	CreditWriter   = "writer"
+)
+
+// ParseCredits turns OMDb's Director and Writer fields, such as
+// "Jonathan Nolan (screenplay by), Christopher Nolan (story), Bob Kane (characters)", into
+// credit entries. The parenthesized notes become roles without a trailing "by", and a
+// person listed several times for one job gets one entry with all the roles.
+func ParseCredits(director, writer string) []models.Credit {
+	var credits []models.Credit
+	for _, field := range []struct{ job, value string }{
+		{CreditDirector, director},
+		{CreditWriter, writer},
+	} {
+		index := make(map[string]int)
+		for _, entry := range splitCredits(field.value) {
+			name, role := parseCredit(entry)
+			if name == "" {
+				continue
+			}
+			key := strings.ToLower(name)
+			i, ok := index[key]
+			if !ok {
+				i = len(credits)
+				index[key] = i
+				credits = append(credits, models.Credit{Name: name, Job: field.job})
+			}
+			if role != "" && !containsFold(credits[i].Roles, role) {
+				credits[i].Roles = append(credits[i].Roles, role)
+			}
+		}
+	}
+	return credits
+}
+
+// splitCredits splits a credits field on the commas outside parentheses, so a note such
+// as "(based on the novel, "Dune")" stays with its name
+func splitCredits(field string) []string {
+	var entries []string
+	depth, start := 0, 0
+	for i, r := range field {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				entries = append(entries, field[start:i])
+				start = i + 1
+			}
+		}
+	}
+	entries = append(entries, field[start:])
+
+	cleaned := entries[:0]
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			cleaned = append(cleaned, entry)
+		}
+	}
+	return cleaned
+}
+
+// parseCredit splits "Jonathan Nolan (screenplay by)" into the name and the role "screenplay"
+func parseCredit(entry string) (string, string) {
+	open := strings.Index(entry, "(")
+	if open < 0 {
+		return entry, ""
+	}
+	name := strings.TrimSpace(entry[:open])
+	role := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(entry[open+1:]), ")"))
+	if strings.HasSuffix(strings.ToLower(role), " by") {
+		role = strings.TrimSpace(role[:len(role)-len(" by")])
+	}
+	return name, role
+}
+
+func containsFold(items []string, item string) bool {
+	for _, existing := range items {
+		if strings.EqualFold(existing, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// maxDriftSample bounds the sample value kept for a drifted field
+const maxDriftSample = 200
+
+// Kinds of schema drift
+const (
+	DriftUnknownField = "unknown_field"
+	DriftTypeMismatch = "type_mismatch"
+	DriftFormat       = "format"
+)
+
+// driftFormats are the formats of the OMDb fields clients parse. "N/A" always passes.
+var driftFormats = map[string]*regexp.Regexp{
+	"Year":         regexp.MustCompile(`^\d{4}(–(\d{4})?)?$`),
+	"Released":     regexp.MustCompile(`^\d{2} [A-Z][a-z]{2} \d{4}$`),
+	"DVD":          regexp.MustCompile(`^\d{2} [A-Z][a-z]{2} \d{4}$`),
+	"Runtime":      regexp.MustCompile(`^\d+ min$`),
+	"Metascore":    regexp.MustCompile(`^\d{1,3}$`),
+	"imdbRating":   regexp.MustCompile(`^\d{1,2}\.\d$`),
+	"imdbVotes":    regexp.MustCompile(`^\d{1,3}(,\d{3})*$`),
+	"imdbID":       regexp.MustCompile(`^tt\d+$`),
+	"seriesID":     regexp.MustCompile(`^tt\d+$`),
+	"totalSeasons": regexp.MustCompile(`^\d+$`),
+	"totalResults": regexp.MustCompile(`^\d+$`),
+	"Response":     regexp.MustCompile(`^(True|False)$`),
+}
+
+// jsonSchema is the expected JSON type of a field, and of the fields of an object or the
+// items of an array
+type jsonSchema struct {
+	kind   string
+	fields map[string]*jsonSchema
+	items  *jsonSchema
+}
+
+// The expected shapes of detail and search responses, derived from the models they are
+// decoded into, so that a field added to a model is expected from then on
+var (
+	detailSchema = schemaOf(reflect.TypeOf(models.OMDbResponse{}))
+	searchSchema = schemaOf(reflect.TypeOf(models.SearchResponse{}))
+)
+
+func schemaOf(t reflect.Type) *jsonSchema {
+	switch t.Kind() {
+	case reflect.Struct:
+		schema := &jsonSchema{kind: "object", fields: make(map[string]*jsonSchema, t.NumField())}
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				schema.fields[name] = schemaOf(t.Field(i).Type)
+			}
+		}
+		return schema
+	case reflect.Slice:
+		return &jsonSchema{kind: "array", items: schemaOf(t.Elem())}
+	case reflect.Bool:
+		return &jsonSchema{kind: "boolean"}
+	case reflect.String:
+		return &jsonSchema{kind: "string"}
+	default:
+		return &jsonSchema{kind: "number"}
+	}
+}
+
+// SchemaDrift compares OMDb payloads with the shape the service decodes them into and
+// records what no longer fits: fields it doesn't know, fields of another JSON type and
+// values in another format than the one clients parse. Each finding is logged when first
+// seen and kept with a sample, so a format change shows up before clients break on it.
+type SchemaDrift struct {
+	mu       sync.Mutex
+	checked  int64
+	drifted  int64
+	findings map[string]*models.SchemaDriftField
+}
+
+func newSchemaDrift() *SchemaDrift {
+	return &SchemaDrift{findings: make(map[string]*models.SchemaDriftField)}
+}
+
+// Check validates an upstream body against the expected shape of the lookup in params
+func (d *SchemaDrift) Check(params url.Values, body []byte) {
+	if d == nil {
+		return
+	}
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return
+	}
+
+	schema := detailSchema
+	if params.Get("s") != "" {
+		schema = searchSchema
+	}
+	var findings []models.SchemaDriftField
+	compareSchema(schema, payload, "", &findings)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.checked++
+	if len(findings) == 0 {
+		return
+	}
+	d.drifted++
+	now := time.Now().UTC()
+	lookup := cacheKey(params)
+	for _, finding := range findings {
+		id := finding.Kind + ":" + finding.Field
+		existing, ok := d.findings[id]
+		if !ok {
+			log.Printf("schema drift: %s on %s in %s, sample %s", finding.Kind, finding.Field, lookup, finding.Sample)
+			first := finding
+			first.FirstSeen = now
+			existing = &first
+			d.findings[id] = existing
+		}
+		existing.Count++
+		existing.LastSeen = now
+		existing.Actual = finding.Actual
+		existing.Sample = finding.Sample
+		existing.SampleLookup = lookup
+	}
+}
+
+// compareSchema appends the findings for value at path
+func compareSchema(schema *jsonSchema, value interface{}, path string, findings *[]models.SchemaDriftField) {
+	actual := jsonKind(value)
+	if actual != schema.kind {
+		*findings = append(*findings, driftFinding(DriftTypeMismatch, path, schema.kind, actual, value))
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for name, field := range value {
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			fieldSchema, ok := schema.fields[name]
+			if !ok {
+				*findings = append(*findings, driftFinding(DriftUnknownField, fieldPath, "", jsonKind(field), field))
+				continue
+			}
+			compareSchema(fieldSchema, field, fieldPath, findings)
+		}
+	case []interface{}:
+		for _, item := range value {
+			compareSchema(schema.items, item, path+"[]", findings)
+		}
+	case string:
+		name := path[strings.LastIndex(path, ".")+1:]
+		if format, ok := driftFormats[name]; ok && value != NotAvailable && value != "" && !format.MatchString(value) {
+			*findings = append(*findings, driftFinding(DriftFormat, path, format.String(), value, value))
+		}
+	}
+}
+
+func driftFinding(kind, field, expected, actual string, sample interface{}) models.SchemaDriftField {
+	data, _ := json.Marshal(sample)
+	if len(data) > maxDriftSample {
+		data = append(data[:maxDriftSample], "..."...)
+	}
+	return models.SchemaDriftField{Field: field, Kind: kind, Expected: expected, Actual: actual, Sample: string(data)}
+}
+
+// jsonKind names the JSON type of a decoded value
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Report returns the findings, most frequent first
+func (d *SchemaDrift) Report() models.SchemaDriftReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	report := models.SchemaDriftReport{Checked: d.checked, Drifted: d.drifted, Fields: make([]models.SchemaDriftField, 0, len(d.findings))}
+	for _, finding := range d.findings {
+		report.Fields = append(report.Fields, *finding)
+	}
+	sort.Slice(report.Fields, func(i, j int) bool {
+		if report.Fields[i].Count != report.Fields[j].Count {
+			return report.Fields[i].Count > report.Fields[j].Count
+		}
+		return report.Fields[i].Field < report.Fields[j].Field
+	})
+	return report
+}
+
+// Reset clears the findings, e.g. once a change has been handled, and returns how many there were
+func (d *SchemaDrift) Reset() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cleared := len(d.findings)
+	d.checked, d.drifted = 0, 0
+	d.findings = make(map[string]*models.SchemaDriftField)
+	return cleared
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// RatingsEnricher expands the raw rating strings into scores normalized to a 0-100 scale
+type RatingsEnricher struct{}
+
+func (RatingsEnricher) Name() string           { return "ratings" }
+func (RatingsEnricher) Timeout() time.Duration { return 500 * time.Millisecond }
+
+func (RatingsEnricher) Enrich(ctx context.Context, movie *models.OMDbResponse) (interface{}, error) {
+	expansion := models.RatingsExpansion{
+		Scores: []models.RatingScore{},
+	}
+
+	for _, rating := range movie.Ratings {
+		if score, ok := normalizeRatingValue(rating.Value); ok {
+			expansion.Scores = append(expansion.Scores, models.RatingScore{
+				Source: rating.Source,
+				Value:  rating.Value,
+				Score:  score,
+			})
+		}
+	}
+
+	if votes, err := strconv.Atoi(strings.ReplaceAll(movie.ImdbVotes, ",", "")); err == nil {
+		expansion.ImdbVotes = votes
+	}
+	if metascore, err := strconv.Atoi(movie.Metascore); err == nil {
+		expansion.Metascore = metascore
+	}
+
+	return expansion, nil
+}
+
+// DetailsEnricher exposes the OMDb fields that are not part of the core detail response
+type DetailsEnricher struct {
+	NAPolicy NAPolicy
+}
+
+func (DetailsEnricher) Name() string           { return "details" }
+func (DetailsEnricher) Timeout() time.Duration { return 500 * time.Millisecond }
+
+func (e DetailsEnricher) Enrich(ctx context.Context, movie *models.OMDbResponse) (interface{}, error) {
+	presented := e.NAPolicy.Present(movie)
+	return models.ExtendedDetails{
+		ImdbID:     movie.ImdbID,
+		Rated:      presented.Rated,
+		Released:   presented.Released,
+		Runtime:    presented.Runtime,
+		Genre:      presented.Genre,
+		Writer:     presented.Writer,
+		Actors:     presented.Actors,
+		Language:   presented.Language,
+		BoxOffice:  presented.BoxOffice,
+		Production: presented.Production,
+		Website:    presented.Website,
+
+		ReleasedDate:   ISODate(movie.Released),
+		RuntimeSeconds: RuntimeSeconds(movie.Runtime),
+	}, nil
+}
+
+// normalizeRatingValue converts "8.7/10", "87%" and "73/100" style values to a 0-100 score
+func normalizeRatingValue(value string) (float64, bool) {
+	if strings.HasSuffix(value, "%") {
+		score, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		return score, err == nil
+	}
+
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return 0, false
+	}
+	score, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	scale, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || scale <= 0 {
+		return 0, false
+	}
+	return math.Round(score/scale*1000) / 10, true
+}
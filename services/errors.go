@@ -0,0 +1,133 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUpstreamQuota is returned when OMDb rejects calls because the API key's daily limit is used up
+	ErrUpstreamQuota = errors.New("upstream request limit reached")
+
+	// ErrUpstreamAuth is returned when OMDb rejects the configured API key
+	ErrUpstreamAuth = errors.New("upstream rejected the API key")
+
+	// errUpstreamStatus marks server errors returned by the upstream host
+	errUpstreamStatus = errors.New("upstream server error")
+)
+
+// apiKeyParam matches the API key parameter of a request URL, however it is embedded
+var apiKeyParam = regexp.MustCompile(`(?i)(apikey=)[^&\s"']*`)
+
+// redactURL removes the API key from a request URL before it is shown in a trace or a log
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return apiKeyParam.ReplaceAllString(rawURL, "${1}REDACTED")
+	}
+	query := parsed.Query()
+	query.Del("apikey")
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// redactURLError rebuilds the *url.Error of a failed request around the URL without its
+// API key. Its text has the full request URL, which would otherwise carry the key into
+// traces, logs, quarantine entries and failed items. Other errors are returned as they are.
+func redactURLError(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	return &url.Error{Op: urlErr.Op, URL: redactURL(urlErr.URL), Err: urlErr.Err}
+}
+
+// errorText is the text of an error from an upstream call as it may be stored or shown,
+// with any API key that slipped into it replaced
+func errorText(err error) string {
+	return apiKeyParam.ReplaceAllString(err.Error(), "${1}REDACTED")
+}
+
+const (
+	// timeoutRetryAfter is the suggested wait after an upstream timeout
+	timeoutRetryAfter = time.Second
+
+	// unavailableRetryAfter is the suggested wait after a network or upstream server error
+	unavailableRetryAfter = 2 * time.Second
+)
+
+// RetryHint tells clients whether repeating a failed request can succeed, and when
+type RetryHint struct {
+	Retryable  bool
+	RetryAfter time.Duration
+}
+
+// RetryHintFor classifies an error from the service. Timeouts, network failures and an
+// exhausted upstream quota are transient; not-found titles, a spent per-request call
+// budget and a rejected API key fail the same way on every retry.
+func RetryHintFor(err error) RetryHint {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrNotFound),
+		errors.Is(err, ErrCallBudgetExceeded),
+		errors.Is(err, ErrUpstreamAuth):
+		return RetryHint{}
+	case errors.Is(err, ErrUpstreamQuota):
+		// OMDb's limit is daily and resets at midnight UTC
+		now := time.Now().UTC()
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		return RetryHint{Retryable: true, RetryAfter: midnight.Sub(now)}
+	case IsTimeout(err):
+		return RetryHint{Retryable: true, RetryAfter: timeoutRetryAfter}
+	case errors.Is(err, errUpstreamStatus):
+		return RetryHint{Retryable: true, RetryAfter: unavailableRetryAfter}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return RetryHint{Retryable: true, RetryAfter: unavailableRetryAfter}
+	}
+	return RetryHint{}
+}
+
+// IsTimeout reports whether err is an upstream call running out of time
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// upstreamError turns OMDb's account-level failures, which arrive as ordinary
+// {"Response":"False"} bodies, into errors. Lookup failures such as "Movie not found!"
+// are left to the callers.
+func upstreamError(body []byte) error {
+	if !bytes.Contains(body, []byte(`"False"`)) {
+		return nil
+	}
+
+	var status struct {
+		Response string
+		Error    string
+	}
+	if json.Unmarshal(body, &status) != nil || status.Response != "False" {
+		return nil
+	}
+
+	message := strings.ToLower(status.Error)
+	switch {
+	case strings.Contains(message, "request limit"):
+		return ErrUpstreamQuota
+	case strings.Contains(message, "api key"):
+		return ErrUpstreamAuth
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// Enricher resolves one optional expansion of a movie detail response (include=<name>)
+type Enricher interface {
+	// Name is the value clients pass in the include parameter
+	Name() string
+	// Timeout bounds how long the enricher may run before its expansion is dropped
+	Timeout() time.Duration
+	// Enrich computes the expansion payload for the given movie
+	Enrich(ctx context.Context, movie *models.OMDbResponse) (interface{}, error)
+}
+
+// ExpansionService runs the requested enrichers concurrently, isolating their failures
+type ExpansionService struct {
+	enrichers map[string]Enricher
+}
+
+func NewExpansionService(enrichers ...Enricher) *ExpansionService {
+	service := &ExpansionService{
+		enrichers: make(map[string]Enricher),
+	}
+	for _, enricher := range enrichers {
+		service.enrichers[enricher.Name()] = enricher
+	}
+	return service
+}
+
+// Supported returns the sorted names of all registered expansions
+func (s *ExpansionService) Supported() []string {
+	names := make([]string, 0, len(s.enrichers))
+	for name := range s.enrichers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate returns the first requested expansion that is not registered, if any
+func (s *ExpansionService) Validate(names []string) (string, bool) {
+	for _, name := range names {
+		if _, ok := s.enrichers[name]; !ok {
+			return name, false
+		}
+	}
+	return "", true
+}
+
+// Expand resolves the named expansions for a movie. Each enricher runs in its own goroutine
+// with its own timeout; a slow or failing enricher only removes its own expansion and is
+// reported in the returned error map instead.
+func (s *ExpansionService) Expand(ctx context.Context, movie *models.OMDbResponse, names []string) (map[string]interface{}, map[string]string) {
+	results := make(map[string]interface{})
+	failures := make(map[string]string)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, name := range names {
+		enricher, ok := s.enrichers[name]
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(enricher Enricher) {
+			defer wg.Done()
+
+			var value interface{}
+			err := isolate(func() (err error) {
+				value, err = runEnricher(ctx, enricher, movie)
+				return err
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[enricher.Name()] = err.Error()
+				return
+			}
+			results[enricher.Name()] = value
+		}(enricher)
+	}
+
+	wg.Wait()
+	return results, failures
+}
+
+func runEnricher(ctx context.Context, enricher Enricher, movie *models.OMDbResponse) (value interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, enricher.Timeout())
+	defer cancel()
+
+	type outcome struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan outcome, 1)
+
+	go func() {
+		var value interface{}
+		err := isolate(func() (err error) {
+			value, err = enricher.Enrich(ctx, movie)
+			return err
+		})
+		done <- outcome{value: value, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.value, result.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("expansion timed out after %s", enricher.Timeout())
+	}
+}
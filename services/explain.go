@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// Explainer collects the stages of a discovery request with explain=true: the searches,
+// caches and filters that produced the response and the candidates each one produced.
+// Genre heuristics are tuned by seeing which stage lost the titles that were expected.
+type Explainer struct {
+	mu     sync.Mutex
+	stages []models.ExplainStage
+	open   *ExplainStep
+}
+
+// ExplainStep measures one stage while it runs: its duration, its upstream calls and its
+// detail cache lookups
+type ExplainStep struct {
+	explainer *Explainer
+	scope     *RequestScope
+	calls     int
+	start     time.Time
+	cache     map[string]int
+}
+
+func NewExplainer() *Explainer {
+	return &Explainer{stages: []models.ExplainStage{}}
+}
+
+type explainKey struct{}
+
+// WithExplainer attaches an explainer to the context passed to the service methods
+func WithExplainer(ctx context.Context, explainer *Explainer) context.Context {
+	return context.WithValue(ctx, explainKey{}, explainer)
+}
+
+// ExplainFrom returns the explainer of the context, or nil if the request isn't explained
+func ExplainFrom(ctx context.Context) *Explainer {
+	explainer, _ := ctx.Value(explainKey{}).(*Explainer)
+	return explainer
+}
+
+// Begin starts measuring a stage. Cache lookups are counted to the stage begun last
+// until it ends.
+func (e *Explainer) Begin(ctx context.Context) *ExplainStep {
+	if e == nil {
+		return nil
+	}
+	scope := ScopeFrom(ctx)
+	step := &ExplainStep{
+		explainer: e,
+		scope:     scope,
+		calls:     scope.Calls(),
+		start:     time.Now(),
+		cache:     make(map[string]int),
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.open = step
+	return step
+}
+
+// End records the stage with what was measured since Begin
+func (s *ExplainStep) End(stage models.ExplainStage) {
+	if s == nil {
+		return
+	}
+	stage.UpstreamCalls = s.scope.Calls() - s.calls
+	stage.DurationMs = float64(time.Since(s.start).Microseconds()) / 1000
+
+	e := s.explainer
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(s.cache) > 0 {
+		stage.Cache = s.cache
+	}
+	if e.open == s {
+		e.open = nil
+	}
+	e.stages = append(e.stages, stage)
+}
+
+// Add records a stage that makes no lookups, such as a filter or a sort
+func (e *Explainer) Add(stage models.ExplainStage) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stages = append(e.stages, stage)
+}
+
+// recordCache counts a detail cache lookup to the open stage
+func (e *Explainer) recordCache(status string) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.open != nil {
+		e.open.cache[status]++
+	}
+}
+
+// Explanation returns the stages recorded so far
+func (e *Explainer) Explanation() *models.Explanation {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return &models.Explanation{Stages: append([]models.ExplainStage{}, e.stages...)}
+}
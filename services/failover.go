@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// upstreamFailureThreshold is the number of consecutive failures after which a base URL is benched
+	upstreamFailureThreshold = 3
+
+	// upstreamCooldown is how long a benched base URL is skipped before it is tried again
+	upstreamCooldown = 30 * time.Second
+
+	// upstreamScoreWeight is the weight of the latest outcome in a base URL's success score
+	upstreamScoreWeight = 0.2
+)
+
+// fallbackURLsFromEnv reads OMDB_FALLBACK_URLS, a comma-separated list of alternative
+// OMDb base URLs (mirrors or proxies) tried when the primary one fails
+func fallbackURLsFromEnv() []string {
+	var urls []string
+	for _, baseURL := range strings.Split(os.Getenv("OMDB_FALLBACK_URLS"), ",") {
+		if baseURL = strings.TrimSpace(baseURL); baseURL != "" {
+			urls = append(urls, baseURL)
+		}
+	}
+	return urls
+}
+
+// upstreamHealth scores the configured base URLs so that calls go to the healthiest one
+// first. The score is a moving average of successes; a base URL that fails repeatedly is
+// skipped for a cooldown period and then given another chance.
+type upstreamHealth struct {
+	mu    sync.Mutex
+	stats map[string]*upstreamStats
+}
+
+type upstreamStats struct {
+	score       float64
+	failures    int
+	benchedTill time.Time
+}
+
+func newUpstreamHealth() *upstreamHealth {
+	return &upstreamHealth{
+		stats: make(map[string]*upstreamStats),
+	}
+}
+
+// order returns the base URLs to try, healthiest first. Configured order breaks ties, so
+// the primary URL is used while everything is healthy. Benched URLs go last rather than
+// being dropped, in case every alternative is failing too.
+func (h *upstreamHealth) order(baseURLs []string) []string {
+	if h == nil {
+		return baseURLs
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	ordered := append([]string(nil), baseURLs...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := h.statsLocked(ordered[i]), h.statsLocked(ordered[j])
+		benchedA, benchedB := now.Before(a.benchedTill), now.Before(b.benchedTill)
+		if benchedA != benchedB {
+			return !benchedA
+		}
+		return a.score > b.score
+	})
+	return ordered
+}
+
+// record updates the score of baseURL with the outcome of a call
+func (h *upstreamHealth) record(baseURL string, err error) {
+	// A cancelled call says nothing about the upstream
+	if h == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := h.statsLocked(baseURL)
+	outcome := 1.0
+	if err != nil {
+		outcome = 0
+	}
+	stats.score = (1-upstreamScoreWeight)*stats.score + upstreamScoreWeight*outcome
+
+	if err == nil {
+		stats.failures = 0
+		return
+	}
+	stats.failures++
+	if stats.failures >= upstreamFailureThreshold {
+		stats.benchedTill = time.Now().Add(upstreamCooldown)
+		stats.failures = 0
+	}
+}
+
+func (h *upstreamHealth) statsLocked(baseURL string) *upstreamStats {
+	stats, ok := h.stats[baseURL]
+	if !ok {
+		stats = &upstreamStats{score: 1}
+		h.stats[baseURL] = stats
+	}
+	return stats
+}
+
+// baseURLs returns the primary base URL followed by the fallbacks
+func (s *OMDbService) baseURLs() []string {
+	return append([]string{s.BaseURL}, s.FallbackURLs...)
+}
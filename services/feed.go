@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"movie-api-go/models"
+)
+
+// Feed item kinds
+const (
+	FeedRating = "rating"
+	FeedWatch  = "watch"
+)
+
+// FeedCursor marks the last item of a feed or review page; the next page starts after it
+type FeedCursor struct {
+	At  int64  `json:"t"`
+	Key string `json:"k"`
+	// Score is the rank of the item in pages sorted by more than time, such as by likes
+	Score int `json:"s,omitempty"`
+}
+
+// EncodeFeedCursor serializes a cursor into an opaque, URL-safe token
+func EncodeFeedCursor(cursor FeedCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeFeedCursor parses a token produced by EncodeFeedCursor. An empty token is the
+// start of the feed.
+func DecodeFeedCursor(token string) (*FeedCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var cursor FeedCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Key == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// ActivityFeed shows users what the users they follow did recently: the titles they
+// rated and the titles they started watching with a monitor, newest first. A followed
+// user's activity appears only as far as their privacy settings let the reader see their
+// history and, respectively, their ratings or lists.
+type ActivityFeed struct {
+	omdb     *OMDbService
+	social   *SocialGraph
+	privacy  *PrivacyStore
+	ratings  *RatingStore
+	monitors *MonitorService
+}
+
+func NewActivityFeed(omdb *OMDbService, social *SocialGraph, privacy *PrivacyStore, ratings *RatingStore, monitors *MonitorService) *ActivityFeed {
+	return &ActivityFeed{omdb: omdb, social: social, privacy: privacy, ratings: ratings, monitors: monitors}
+}
+
+// FeedPage is one page of a feed along with the cursor for the next page
+type FeedPage struct {
+	Items      []models.FeedItem
+	NextCursor string
+}
+
+// Page returns up to limit items of user's feed after cursor (nil for the first page).
+// Titles that the activity doesn't name are looked up.
+func (f *ActivityFeed) Page(ctx context.Context, user string, cursor *FeedCursor, limit int) FeedPage {
+	// Names of the followed users whose ratings, respectively watches, the reader may see
+	rated := make(map[string]string)
+	watched := make(map[string]string)
+	for _, follow := range f.social.Following(user) {
+		if !f.privacy.CanView(user, follow.UserID, PrivacyHistory) {
+			continue
+		}
+		if f.privacy.CanView(user, follow.UserID, PrivacyRatings) {
+			rated[follow.UserID] = follow.Name
+		}
+		if f.privacy.CanView(user, follow.UserID, PrivacyLists) {
+			watched[follow.UserID] = follow.Name
+		}
+	}
+
+	var items []models.FeedItem
+	for userID, name := range rated {
+		for _, rating := range f.ratings.List(userID) {
+			items = append(items, models.FeedItem{Kind: FeedRating, UserID: userID, Name: name, ImdbID: rating.ImdbID, Rating: rating.Rating, At: rating.RatedAt})
+		}
+	}
+	for _, watch := range f.monitors.watches() {
+		// Monitors of logged-in users are owned by their client ID, user:<id>
+		userID, ok := strings.CutPrefix(watch.owner, "user:")
+		if !ok {
+			continue
+		}
+		if name, ok := watched[userID]; ok {
+			items = append(items, models.FeedItem{Kind: FeedWatch, UserID: userID, Name: name, ImdbID: watch.imdbID, Title: watch.title, At: watch.since})
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].At.Equal(items[j].At) {
+			return items[i].At.After(items[j].At)
+		}
+		return feedKey(items[i]) < feedKey(items[j])
+	})
+
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(items), func(i int) bool {
+			at := items[i].At.UnixNano()
+			return at < cursor.At || (at == cursor.At && feedKey(items[i]) > cursor.Key)
+		})
+	}
+	var page FeedPage
+	end := min(start+limit, len(items))
+	page.Items = append([]models.FeedItem{}, items[start:end]...)
+	if end < len(items) && end > start {
+		last := page.Items[len(page.Items)-1]
+		page.NextCursor = EncodeFeedCursor(FeedCursor{At: last.At.UnixNano(), Key: feedKey(last)})
+	}
+
+	f.addTitles(ctx, page.Items)
+	return page
+}
+
+// addTitles looks up the titles of items that don't name theirs
+func (f *ActivityFeed) addTitles(ctx context.Context, items []models.FeedItem) {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if item.Title == "" && !seen[item.ImdbID] {
+			seen[item.ImdbID] = true
+			missing = append(missing, item.ImdbID)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	records := f.omdb.GetTitlesByID(WithPriority(ctx, PriorityEnrichment), missing)
+	for i := range items {
+		if record, ok := records[items[i].ImdbID]; ok && items[i].Title == "" {
+			items[i].Title = record.Title
+		}
+	}
+}
+
+// feedKey orders items of the same time
+func feedKey(item models.FeedItem) string {
+	return item.Kind + "|" + item.UserID + "|" + item.ImdbID
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+var (
+	// ErrInvalidFieldProfile wraps the reason a field profile was rejected
+	ErrInvalidFieldProfile = errors.New("invalid field profile")
+
+	// ErrFieldProfileNotFound is returned for a tenant without a field profile
+	ErrFieldProfileNotFound = errors.New("field profile not found")
+)
+
+// maxFieldMappings bounds the mappings of one profile
+const maxFieldMappings = 100
+
+// fieldPathPattern matches a dotted path of response keys, e.g. rating.imdb
+var fieldPathPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// FieldProfileStore keeps the field mapping profiles of tenants, the integrations that
+// call the API with an API key registered in API_KEY_TENANTS. A tenant's responses are
+// rewritten with its profile, so each consumer doesn't maintain the same adapter.
+// Profiles are persisted as a JSON file.
+type FieldProfileStore struct {
+	path string
+
+	mu       sync.RWMutex
+	profiles map[string]models.FieldProfile
+}
+
+// NewFieldProfileStore loads the profiles from FIELD_PROFILES_PATH (default data/field_profiles.json)
+func NewFieldProfileStore() (*FieldProfileStore, error) {
+	path := os.Getenv("FIELD_PROFILES_PATH")
+	if path == "" {
+		path = "data/field_profiles.json"
+	}
+
+	s := &FieldProfileStore{path: path, profiles: make(map[string]models.FieldProfile)}
+	if err := store.LoadJSON(path, &s.profiles); err != nil {
+		return nil, err
+	}
+	if s.profiles == nil {
+		s.profiles = make(map[string]models.FieldProfile)
+	}
+	return s, nil
+}
+
+// List returns every profile sorted by tenant
+func (s *FieldProfileStore) List() []models.FieldProfile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profiles := make([]models.FieldProfile, 0, len(s.profiles))
+	for _, profile := range s.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Tenant < profiles[j].Tenant })
+	return profiles
+}
+
+// Get returns the profile of a tenant
+func (s *FieldProfileStore) Get(tenant string) (models.FieldProfile, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profile, ok := s.profiles[tenant]
+	return profile, ok
+}
+
+// Put creates or replaces a tenant's profile, persists the store and returns the profile
+// with the one it replaced, if any
+func (s *FieldProfileStore) Put(tenant, actor string, req models.FieldProfileRequest) (models.FieldProfile, *models.FieldProfile, error) {
+	if !tagSlugPattern.MatchString(tenant) {
+		return models.FieldProfile{}, nil, fmt.Errorf("%w: tenant must be lowercase words joined by hyphens, e.g. acme-tv", ErrInvalidFieldProfile)
+	}
+	if len(req.Mappings) == 0 || len(req.Mappings) > maxFieldMappings {
+		return models.FieldProfile{}, nil, fmt.Errorf("%w: a profile holds 1 to %d mappings", ErrInvalidFieldProfile, maxFieldMappings)
+	}
+
+	profile := models.FieldProfile{Tenant: tenant, UpdatedBy: actor, UpdatedAt: time.Now().UTC()}
+	for _, mapping := range req.Mappings {
+		mapping.From = strings.TrimSpace(mapping.From)
+		mapping.To = strings.TrimSpace(mapping.To)
+		if !fieldPathPattern.MatchString(mapping.From) || !fieldPathPattern.MatchString(mapping.To) {
+			return models.FieldProfile{}, nil, fmt.Errorf("%w: %q to %q; from and to must be dotted key paths such as rating.imdb", ErrInvalidFieldProfile, mapping.From, mapping.To)
+		}
+		if mapping.From == mapping.To {
+			return models.FieldProfile{}, nil, fmt.Errorf("%w: %q is mapped to itself", ErrInvalidFieldProfile, mapping.From)
+		}
+		profile.Mappings = append(profile.Mappings, mapping)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var previous *models.FieldProfile
+	if existing, ok := s.profiles[tenant]; ok {
+		previous = &existing
+	}
+	s.profiles[tenant] = profile
+	return profile, previous, store.SaveJSON(s.path, s.profiles)
+}
+
+// Delete removes a tenant's profile, persists the store and returns the removed profile
+func (s *FieldProfileStore) Delete(tenant string) (models.FieldProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile, ok := s.profiles[tenant]
+	if !ok {
+		return models.FieldProfile{}, ErrFieldProfileNotFound
+	}
+	delete(s.profiles, tenant)
+	return profile, store.SaveJSON(s.path, s.profiles)
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MachineFormatFields are the response fields that repeat an OMDb date or duration in a
+// machine format, next to the display string they are derived from. raw=true leaves them
+// out for clients that predate them.
+var MachineFormatFields = []string{"released_date", "runtime_seconds", "air_date", "air_timezone"}
+
+// runtimePart matches one component of a runtime: "136 min", "2 h", "2h 16m"
+var runtimePart = regexp.MustCompile(`(?i)(\d+)\s*(hours?|hrs?|h|minutes?|mins?|m)\b`)
+
+// ISODate returns an OMDb date such as "31 Mar 1999" as 1999-03-31, or "" when it isn't one
+func ISODate(value string) string {
+	date, ok := ParseOMDbDate(value)
+	if !ok {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
+// RuntimeSeconds returns an OMDb runtime such as "136 min" in seconds, or 0 when it can't
+// be read. Hours are accepted too, as some records have "2 h 16 min".
+func RuntimeSeconds(runtime string) int {
+	seconds := 0
+	for _, part := range runtimePart.FindAllStringSubmatch(strings.TrimSpace(runtime), -1) {
+		value, err := strconv.Atoi(part[1])
+		if err != nil {
+			return 0
+		}
+		if strings.HasPrefix(strings.ToLower(part[2]), "h") {
+			seconds += value * 3600
+		} else {
+			seconds += value * 60
+		}
+	}
+	return seconds
+}
+
+// Metascore returns an OMDb Metascore such as "73" as a number from 0 to 100, or 0 when
+// OMDb has none
+func Metascore(score string) int {
+	value, err := strconv.Atoi(strings.TrimSpace(score))
+	if err != nil || value < 0 || value > 100 {
+		return 0
+	}
+	return value
+}
+
+// VoteCount returns an OMDb vote count such as "1,234,567" as a number, or 0 when it
+// can't be read
+func VoteCount(votes string) int {
+	count, err := strconv.Atoi(strings.ReplaceAll(strings.TrimSpace(votes), ",", ""))
+	if err != nil || count < 0 {
+		return 0
+	}
+	return count
+}
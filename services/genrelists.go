@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// genreListRefreshTimeout bounds the searches refreshing one genre list
+const genreListRefreshTimeout = 2 * time.Minute
+
+// GenreListStore materializes the top list of every canonical genre, persisted as a JSON
+// file. Searching a genre takes over a dozen upstream searches, so the lists are refreshed
+// in the background every Interval and /api/movies/genre reads them instead.
+type GenreListStore struct {
+	// Interval after which a list is refreshed
+	Interval time.Duration
+
+	path string
+	omdb *OMDbService
+
+	mu    sync.RWMutex
+	lists map[string]models.GenreList
+}
+
+// NewGenreListStore loads the lists from GENRE_LISTS_PATH (default data/genre_lists.json)
+// and reads GENRE_LISTS_REFRESH_MINUTES (default 360)
+func NewGenreListStore(omdb *OMDbService) (*GenreListStore, error) {
+	path := os.Getenv("GENRE_LISTS_PATH")
+	if path == "" {
+		path = "data/genre_lists.json"
+	}
+
+	s := &GenreListStore{
+		Interval: time.Duration(envInt("GENRE_LISTS_REFRESH_MINUTES", 360)) * time.Minute,
+		path:     path,
+		omdb:     omdb,
+		lists:    make(map[string]models.GenreList),
+	}
+	if err := store.LoadJSON(path, &s.lists); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run refreshes the missing and stale lists now and then every Interval until ctx is done
+func (s *GenreListStore) Run(ctx context.Context) {
+	s.RefreshStale(ctx)
+	if s.Interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.RefreshStale(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RefreshStale refreshes, one genre at a time, the lists never computed or refreshed more
+// than Interval ago. A pass stops early once the upstream quota is exhausted.
+func (s *GenreListStore) RefreshStale(ctx context.Context) {
+	for _, genre := range s.omdb.Genres.List() {
+		if ctx.Err() != nil {
+			return
+		}
+		s.mu.RLock()
+		list, ok := s.lists[genre.Name]
+		s.mu.RUnlock()
+		if ok && time.Since(list.RefreshedAt) < s.Interval {
+			continue
+		}
+
+		if _, err := s.Refresh(ctx, genre.Name); err != nil {
+			log.Printf("genre lists: refreshing %s: %v", genre.Name, err)
+			if errors.Is(err, ErrUpstreamQuota) {
+				return
+			}
+		}
+	}
+}
+
+// Refresh searches a canonical genre's top list now and keeps it
+func (s *GenreListStore) Refresh(ctx context.Context, genre string) (models.GenreList, error) {
+	searchCtx, cancel := context.WithTimeout(WithPriority(ctx, PriorityBackground), genreListRefreshTimeout)
+	defer cancel()
+	// The refresh collects the titles that fail in a scope of its own, to be served with the list
+	scope := newBackgroundScope()
+	movies, err := s.omdb.SearchMoviesByGenre(WithScope(searchCtx, scope), genre)
+	if err != nil {
+		return models.GenreList{}, err
+	}
+
+	list := models.GenreList{
+		Genre:       genre,
+		Movies:      append([]models.MovieBrief{}, movies...),
+		RefreshedAt: time.Now().UTC(),
+		Errors:      scope.FailedItems(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lists[genre] = list
+	if err := store.SaveJSON(s.path, s.lists); err != nil {
+		return models.GenreList{}, err
+	}
+	return list, nil
+}
+
+// Get returns a copy of a genre's materialized list, which callers may filter and annotate
+func (s *GenreListStore) Get(genre string) (models.GenreList, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list, ok := s.lists[genre]
+	if !ok {
+		return models.GenreList{}, false
+	}
+	list.Movies = append([]models.MovieBrief{}, list.Movies...)
+	return list, true
+}
+
+// List summarizes the materialized lists in genre order
+func (s *GenreListStore) List() []models.GenreListInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lists := make([]models.GenreListInfo, 0, len(s.lists))
+	for _, list := range s.lists {
+		lists = append(lists, models.GenreListInfo{
+			Genre:       list.Genre,
+			Total:       len(list.Movies),
+			Errors:      len(list.Errors),
+			RefreshedAt: list.RefreshedAt,
+		})
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Genre < lists[j].Genre })
+	return lists
+}
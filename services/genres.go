@@ -0,0 +1,158 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"movie-api-go/models"
+)
+
+//go:embed data/genres.json
+var genreData []byte
+
+// ErrUnknownGenre is returned for a genre that isn't in the taxonomy
+var ErrUnknownGenre = errors.New("unknown genre")
+
+// GenreTaxonomy is the canonical list of genres OMDb uses, with common aliases ("science
+// fiction" for Sci-Fi). It also counts the distinct titles of each genre among the records
+// the service has seen, so clients can tell which genres have a meaningful corpus.
+type GenreTaxonomy struct {
+	genres  []string
+	byKey   map[string]string
+	aliases map[string]string
+
+	mu     sync.RWMutex
+	seen   map[string]bool
+	counts map[string]int
+}
+
+func newGenreTaxonomy() (*GenreTaxonomy, error) {
+	var table struct {
+		Genres  []string          `json:"genres"`
+		Aliases map[string]string `json:"aliases"`
+	}
+	if err := json.Unmarshal(genreData, &table); err != nil {
+		return nil, fmt.Errorf("invalid genre table: %w", err)
+	}
+
+	t := &GenreTaxonomy{
+		genres:  table.Genres,
+		byKey:   make(map[string]string, len(table.Genres)),
+		aliases: make(map[string]string, len(table.Aliases)),
+		seen:    make(map[string]bool),
+		counts:  make(map[string]int),
+	}
+	for _, genre := range table.Genres {
+		t.byKey[genreKey(genre)] = genre
+	}
+	for alias, genre := range table.Aliases {
+		if _, ok := t.byKey[genreKey(genre)]; !ok {
+			return nil, fmt.Errorf("invalid genre table: alias %q names unknown genre %q", alias, genre)
+		}
+		t.aliases[genreKey(alias)] = genre
+	}
+	return t, nil
+}
+
+// genreKey ignores case, spaces and hyphens, so "sci fi", "SciFi" and "Sci-Fi" match
+func genreKey(genre string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, genre)
+}
+
+// LearnRecord counts a record once under each of its canonical genres
+func (t *GenreTaxonomy) LearnRecord(record *models.OMDbResponse) {
+	if t == nil || record.ImdbID == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.seen[record.ImdbID] {
+		return
+	}
+	t.seen[record.ImdbID] = true
+	for _, genre := range splitList(record.Genre) {
+		if canonical, ok := t.byKey[genreKey(genre)]; ok {
+			t.counts[canonical]++
+		}
+	}
+}
+
+// List returns the canonical genres in alphabetical order with their title counts
+func (t *GenreTaxonomy) List() []models.GenreInfo {
+	if t == nil {
+		return []models.GenreInfo{}
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	genres := make([]models.GenreInfo, 0, len(t.genres))
+	for _, genre := range t.genres {
+		genres = append(genres, models.GenreInfo{Name: genre, Count: t.counts[genre]})
+	}
+	return genres
+}
+
+// Resolve returns the canonical spelling of genre. Case, spaces and hyphens never matter;
+// unless strict is set, aliases and typos of up to one edit (two for longer names) are
+// accepted too, with corrected set. Unknown genres are an ErrUnknownGenre that suggests
+// the closest genre when there is one.
+func (t *GenreTaxonomy) Resolve(genre string, strict bool) (canonical string, corrected bool, err error) {
+	if t == nil {
+		return genre, false, nil
+	}
+
+	key := genreKey(genre)
+	if canonical, ok := t.byKey[key]; ok {
+		return canonical, false, nil
+	}
+
+	suggestion, ok := t.aliases[key]
+	if !ok {
+		suggestion, ok = t.closest(key)
+	}
+	if ok && !strict {
+		return suggestion, true, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("%w %q; did you mean %q?", ErrUnknownGenre, genre, suggestion)
+	}
+	return "", false, fmt.Errorf("%w %q; see GET /api/genres for the supported genres", ErrUnknownGenre, genre)
+}
+
+// closest finds the genre or alias within the allowed edit distance of key
+func (t *GenreTaxonomy) closest(key string) (string, bool) {
+	if len(key) < 3 {
+		return "", false
+	}
+	maxDistance := 1
+	if len(key) >= 6 {
+		maxDistance = 2
+	}
+
+	best, bestDistance := "", maxDistance+1
+	consider := func(candidate, genre string) {
+		if distance := editDistance(key, candidate); distance < bestDistance {
+			best, bestDistance = genre, distance
+		}
+	}
+	for _, genre := range t.genres {
+		consider(genreKey(genre), genre)
+	}
+	for alias, genre := range t.aliases {
+		consider(alias, genre)
+	}
+	return best, best != ""
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// Goroutines summarizes the running goroutines from a full stack dump. Goroutines are
+// grouped by state, the function they are in and the function that started them, so a
+// leak shows up as one group whose count keeps growing and whose wait keeps getting longer.
+func Goroutines() models.GoroutineSummary {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	summary := models.GoroutineSummary{ByState: make(map[string]int), GeneratedAt: time.Now().UTC()}
+	groups := make(map[models.GoroutineGroup]*models.GoroutineGroup)
+	var current *goroutineDump
+	add := func() {
+		if current == nil {
+			return
+		}
+		summary.Total++
+		summary.ByState[current.state]++
+		key := models.GoroutineGroup{State: current.state, Function: current.function, CreatedBy: current.createdBy}
+		group, ok := groups[key]
+		if !ok {
+			group = &key
+			groups[key] = group
+		}
+		group.Count++
+		if current.waitMinutes > group.MaxWaitMinutes {
+			group.MaxWaitMinutes = current.waitMinutes
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	scanner.Buffer(make([]byte, 64*1024), len(buf)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "goroutine "):
+			add()
+			current = parseGoroutineHeader(line)
+		case current == nil || line == "" || strings.HasPrefix(line, "\t"):
+		case strings.HasPrefix(line, "created by "):
+			createdBy := strings.TrimPrefix(line, "created by ")
+			if i := strings.Index(createdBy, " in goroutine "); i >= 0 {
+				createdBy = createdBy[:i]
+			}
+			current.createdBy = createdBy
+		case current.function == "":
+			current.function = stackFunction(line)
+		}
+	}
+	add()
+
+	summary.Groups = make([]models.GoroutineGroup, 0, len(groups))
+	for _, group := range groups {
+		summary.Groups = append(summary.Groups, *group)
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		a, b := summary.Groups[i], summary.Groups[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Function+a.CreatedBy < b.Function+b.CreatedBy
+	})
+	return summary
+}
+
+// goroutineDump is what the summary keeps of one goroutine of a stack dump
+type goroutineDump struct {
+	state       string
+	waitMinutes int
+	function    string
+	createdBy   string
+}
+
+// parseGoroutineHeader reads a line such as "goroutine 7 [chan receive, 12 minutes]:"
+func parseGoroutineHeader(line string) *goroutineDump {
+	dump := &goroutineDump{}
+	start, end := strings.Index(line, "["), strings.LastIndex(line, "]")
+	if start < 0 || end < start {
+		return dump
+	}
+	for i, part := range strings.Split(line[start+1:end], ", ") {
+		if i == 0 {
+			dump.state = part
+			continue
+		}
+		if minutes, ok := strings.CutSuffix(part, " minutes"); ok {
+			dump.waitMinutes, _ = strconv.Atoi(minutes)
+		} else if part == "1 minute" {
+			dump.waitMinutes = 1
+		}
+	}
+	return dump
+}
+
+// stackFunction strips the arguments from a frame line such as "time.Sleep(0x3b9aca00)"
+func stackFunction(line string) string {
+	if i := strings.LastIndex(line, "("); i > 0 {
+		return line[:i]
+	}
+	return line
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// hedgedGet sends the request and, if no response has arrived after HedgeAfter, sends an
+// identical second request and returns whichever succeeds first. The slower request is
+// cancelled. The hedge counts against the request's call budget and is only sent when a
+// concurrency slot is free, so hedging never exceeds the configured upstream limits.
+func (s *OMDbService) hedgedGet(ctx context.Context, reqURL string) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	results := make(chan result, 2)
+
+	launch := func(release func()) {
+		go func() {
+			defer release()
+			var body []byte
+			err := isolate(func() (err error) {
+				body, err = s.get(ctx, reqURL)
+				return err
+			})
+			results <- result{body: body, err: err}
+		}()
+	}
+	launch(func() {})
+
+	timer := time.NewTimer(s.HedgeAfter)
+	defer timer.Stop()
+
+	inFlight := 1
+	var lastErr error
+	for {
+		select {
+		case <-timer.C:
+			release, ok := s.tryAcquireSlot()
+			if !ok {
+				continue
+			}
+			if !s.Scheduler.TryTake() {
+				release()
+				continue
+			}
+			if err := ScopeFrom(ctx).chargeCall(); err != nil {
+				release()
+				continue
+			}
+			inFlight++
+			launch(release)
+
+		case r := <-results:
+			inFlight--
+			if r.err == nil {
+				return r.body, nil
+			}
+			lastErr = r.err
+			if inFlight == 0 {
+				return nil, lastErr
+			}
+		}
+	}
+}
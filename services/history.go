@@ -0,0 +1,419 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// TitleHistory keeps versioned snapshots of the title records fetched from OMDb and the
+// fields that changed between them: rating drift, added awards, box office updates. It
+// also keeps a time series of each title's IMDb rating, Metascore and vote count for
+// charting. A record is snapshotted whenever it is fetched upstream, i.e. on cache misses
+// and on the background refreshes of stale entries, so hot titles are compared about once
+// per cache TTL. The history is persisted as a JSON file, saved periodically and on shutdown.
+type TitleHistory struct {
+	// MaxVersions bounds the changes kept per title; the oldest are dropped first
+	MaxVersions int
+	// MaxPoints bounds the rating points kept per title; the oldest are dropped first
+	MaxPoints int
+	// PointInterval is the spacing of rating points: a fetch within PointInterval of the
+	// last point updates that point instead of adding one
+	PointInterval time.Duration
+	// Interval between saves of a changed history
+	Interval time.Duration
+
+	path string
+
+	mu     sync.Mutex
+	titles map[string]*titleHistoryEntry
+	dirty  bool
+}
+
+// titleHistoryEntry is the history of one title as stored on disk: its current fields
+// and the changes that led to them
+type titleHistoryEntry struct {
+	Title       string               `json:"title"`
+	Version     int                  `json:"version"`
+	FirstSeen   time.Time            `json:"first_seen"`
+	LastChecked time.Time            `json:"last_checked"`
+	Fields      map[string]string    `json:"fields"`
+	Changes     []models.TitleChange `json:"changes"`
+	Ratings     []models.RatingPoint `json:"ratings,omitempty"`
+}
+
+// newTitleHistory loads the history from TITLE_HISTORY_PATH (default data/history.json).
+// TITLE_HISTORY_MAX_VERSIONS (default 50) and TITLE_HISTORY_MAX_POINTS (default 1000)
+// bound the changes and rating points kept per title, TITLE_HISTORY_POINT_MINUTES
+// (default 60) spaces the rating points and TITLE_HISTORY_SAVE_SECONDS (default 60) sets
+// how often changes are saved.
+func newTitleHistory() (*TitleHistory, error) {
+	path := os.Getenv("TITLE_HISTORY_PATH")
+	if path == "" {
+		path = "data/history.json"
+	}
+
+	h := &TitleHistory{
+		MaxVersions:   max(envInt("TITLE_HISTORY_MAX_VERSIONS", 50), 1),
+		MaxPoints:     max(envInt("TITLE_HISTORY_MAX_POINTS", 1000), 1),
+		PointInterval: time.Duration(envInt("TITLE_HISTORY_POINT_MINUTES", 60)) * time.Minute,
+		Interval:      time.Duration(max(envInt("TITLE_HISTORY_SAVE_SECONDS", 60), 1)) * time.Second,
+		path:          path,
+		titles:        make(map[string]*titleHistoryEntry),
+	}
+	if err := store.LoadJSON(path, &h.titles); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Record snapshots a successful upstream response, adding a version with the changed
+// fields if it differs from the last snapshot of the title, and a rating point
+func (h *TitleHistory) Record(body []byte) {
+	if h == nil {
+		return
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(body, &record); err != nil || record["Response"] != "True" {
+		return
+	}
+	imdbID, _ := record["imdbID"].(string)
+	if imdbID == "" {
+		return
+	}
+	fields := historyFields(record)
+	now := time.Now().UTC()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.dirty = true
+	entry, ok := h.titles[imdbID]
+	if !ok {
+		entry = &titleHistoryEntry{
+			Title:       fields["Title"],
+			Version:     1,
+			FirstSeen:   now,
+			LastChecked: now,
+			Fields:      fields,
+			Changes:     []models.TitleChange{},
+		}
+		h.titles[imdbID] = entry
+		h.addPointLocked(entry, fields, now)
+		return
+	}
+
+	entry.LastChecked = now
+	h.addPointLocked(entry, fields, now)
+	changes := diffFields(entry.Fields, fields)
+	if len(changes) == 0 {
+		return
+	}
+	entry.Version++
+	entry.Title = fields["Title"]
+	entry.Fields = fields
+	entry.Changes = append(entry.Changes, models.TitleChange{Version: entry.Version, At: now, Changes: changes})
+	if len(entry.Changes) > h.MaxVersions {
+		entry.Changes = entry.Changes[len(entry.Changes)-h.MaxVersions:]
+	}
+}
+
+// addPointLocked appends the rating point of fields, or updates the last point if it is
+// within PointInterval. Records without any of the figures add no point.
+func (h *TitleHistory) addPointLocked(entry *titleHistoryEntry, fields map[string]string, at time.Time) {
+	point := models.RatingPoint{
+		At:         at,
+		ImdbRating: parseFloat(fields["imdbRating"]),
+		Metascore:  parseCount(fields["Metascore"]),
+		ImdbVotes:  parseCount(fields["imdbVotes"]),
+	}
+	if point.ImdbRating == nil && point.Metascore == nil && point.ImdbVotes == nil {
+		return
+	}
+
+	if last := len(entry.Ratings) - 1; last >= 0 && at.Sub(entry.Ratings[last].At) < h.PointInterval {
+		entry.Ratings[last] = point
+		return
+	}
+	entry.Ratings = append(entry.Ratings, point)
+	if len(entry.Ratings) > h.MaxPoints {
+		entry.Ratings = entry.Ratings[len(entry.Ratings)-h.MaxPoints:]
+	}
+}
+
+// Changes returns the history of a title, with its changes newest first, and whether
+// the title has been seen at all
+func (h *TitleHistory) Changes(imdbID string) (models.TitleChangesResponse, bool) {
+	if h == nil {
+		return models.TitleChangesResponse{}, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.titles[imdbID]
+	if !ok {
+		return models.TitleChangesResponse{}, false
+	}
+	changes := make([]models.TitleChange, len(entry.Changes))
+	for i, change := range entry.Changes {
+		changes[len(changes)-1-i] = change
+	}
+	return models.TitleChangesResponse{
+		ImdbID:      imdbID,
+		Title:       entry.Title,
+		Version:     entry.Version,
+		FirstSeen:   entry.FirstSeen,
+		LastChecked: entry.LastChecked,
+		Changes:     changes,
+	}, true
+}
+
+// Ratings returns the rating time series of a title, oldest point first, and whether the
+// title has been seen at all
+func (h *TitleHistory) Ratings(imdbID string) (models.RatingHistoryResponse, bool) {
+	if h == nil {
+		return models.RatingHistoryResponse{}, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.titles[imdbID]
+	if !ok {
+		return models.RatingHistoryResponse{}, false
+	}
+	return models.RatingHistoryResponse{
+		ImdbID:      imdbID,
+		Title:       entry.Title,
+		FirstSeen:   entry.FirstSeen,
+		LastChecked: entry.LastChecked,
+		Points:      append([]models.RatingPoint{}, entry.Ratings...),
+	}, true
+}
+
+// Records returns the current record of every title in the history, i.e. of every title
+// fetched upstream, in no particular order. Ratings are rebuilt from their
+// Ratings.<Source> fields, sorted by source.
+func (h *TitleHistory) Records() []*models.OMDbResponse {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := make([]*models.OMDbResponse, 0, len(h.titles))
+	for imdbID, entry := range h.titles {
+		fields := entry.Fields
+		var ratings []models.Rating
+		for name, value := range fields {
+			if source := strings.TrimPrefix(name, "Ratings."); source != name {
+				ratings = append(ratings, models.Rating{Source: source, Value: value})
+			}
+		}
+		sort.Slice(ratings, func(i, j int) bool { return ratings[i].Source < ratings[j].Source })
+		records = append(records, &models.OMDbResponse{
+			Title:      fields["Title"],
+			Year:       fields["Year"],
+			Rated:      fields["Rated"],
+			Released:   fields["Released"],
+			Runtime:    fields["Runtime"],
+			Genre:      fields["Genre"],
+			Director:   fields["Director"],
+			Writer:     fields["Writer"],
+			Actors:     fields["Actors"],
+			Plot:       fields["Plot"],
+			Language:   fields["Language"],
+			Country:    fields["Country"],
+			Awards:     fields["Awards"],
+			Poster:     fields["Poster"],
+			Ratings:    ratings,
+			Metascore:  fields["Metascore"],
+			ImdbRating: fields["imdbRating"],
+			ImdbVotes:  fields["imdbVotes"],
+			ImdbID:     imdbID,
+			Type:       fields["Type"],
+			Response:   "True",
+		})
+	}
+	return records
+}
+
+// checkedAt returns when a title was last fetched upstream
+func (h *TitleHistory) checkedAt(imdbID string) (time.Time, bool) {
+	if h == nil {
+		return time.Time{}, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.titles[imdbID]
+	if !ok {
+		return time.Time{}, false
+	}
+	return entry.LastChecked, true
+}
+
+func (h *TitleHistory) has(imdbID string) bool {
+	if h == nil {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.titles[imdbID]
+	return ok
+}
+
+// TitleChanges returns the history of a title. A title without history yet is looked up
+// so that its first snapshot is taken; ErrNotFound is returned if OMDb doesn't know it.
+func (s *OMDbService) TitleChanges(ctx context.Context, imdbID string) (models.TitleChangesResponse, error) {
+	if err := s.trackTitle(ctx, imdbID); err != nil {
+		return models.TitleChangesResponse{}, err
+	}
+	changes, _ := s.History.Changes(imdbID)
+	return changes, nil
+}
+
+// RatingHistory returns the rating time series of a title, looking it up like TitleChanges
+// if it has no history yet
+func (s *OMDbService) RatingHistory(ctx context.Context, imdbID string) (models.RatingHistoryResponse, error) {
+	if err := s.trackTitle(ctx, imdbID); err != nil {
+		return models.RatingHistoryResponse{}, err
+	}
+	ratings, _ := s.History.Ratings(imdbID)
+	return ratings, nil
+}
+
+// trackTitle takes the first snapshot of a title without history
+func (s *OMDbService) trackTitle(ctx context.Context, imdbID string) error {
+	if s.History.has(imdbID) {
+		return nil
+	}
+
+	params := url.Values{}
+	params.Add("apikey", s.APIKey)
+	params.Add("i", imdbID)
+	body, err := s.fetchCached(ctx, params)
+	if err != nil {
+		return err
+	}
+	// A lookup served from the cache wasn't snapshotted
+	if !s.History.has(imdbID) {
+		s.History.Record(body)
+	}
+	if !s.History.has(imdbID) {
+		return fmt.Errorf("%w: %s", ErrNotFound, imdbID)
+	}
+	return nil
+}
+
+// Save writes the history if it changed since the last save
+func (h *TitleHistory) Save() error {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.dirty {
+		return nil
+	}
+	if err := store.SaveJSON(h.path, h.titles); err != nil {
+		return err
+	}
+	h.dirty = false
+	return nil
+}
+
+// Run saves the history every Interval until ctx is done
+func (h *TitleHistory) Run(ctx context.Context) {
+	if h == nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := h.Save(); err != nil {
+				log.Printf("history: save failed: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// historyFields flattens a record into the fields compared between versions: every
+// string field except Response, and each rating as Ratings.<Source>. "N/A" values are
+// left out, so a field that gains a value shows up as added.
+func historyFields(record map[string]interface{}) map[string]string {
+	fields := make(map[string]string)
+	for name, value := range record {
+		if text, ok := value.(string); ok && name != "Response" && text != "" && text != NotAvailable {
+			fields[name] = text
+		}
+	}
+	ratings, _ := record["Ratings"].([]interface{})
+	for _, rating := range ratings {
+		rating, _ := rating.(map[string]interface{})
+		source, _ := rating["Source"].(string)
+		value, _ := rating["Value"].(string)
+		if source != "" && value != "" && value != NotAvailable {
+			fields["Ratings."+source] = value
+		}
+	}
+	return fields
+}
+
+// diffFields lists the fields whose values differ, sorted by field name
+func diffFields(before, after map[string]string) []models.FieldChange {
+	var changes []models.FieldChange
+	for name, value := range after {
+		if before[name] != value {
+			changes = append(changes, models.FieldChange{Field: name, From: before[name], To: value})
+		}
+	}
+	for name, value := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, models.FieldChange{Field: name, From: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// parseFloat parses a rating such as "8.7", returning nil for missing values
+func parseFloat(text string) *float64 {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+// parseCount parses a count such as "2,000,000", returning nil for missing values
+func parseCount(text string) *int {
+	value, err := strconv.Atoi(strings.ReplaceAll(text, ",", ""))
+	if err != nil {
+		return nil
+	}
+	return &value
+}
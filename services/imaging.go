@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// Poster formats the proxy can convert to
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// contentTypes maps the formats to their content types
+var contentTypes = map[string]string{
+	FormatJPEG: "image/jpeg",
+	FormatPNG:  "image/png",
+}
+
+// formatOf returns the format of a content type, or "" for other image types
+func formatOf(contentType string) string {
+	for format, known := range contentTypes {
+		if contentType == known {
+			return format
+		}
+	}
+	return ""
+}
+
+// resizeImage scales img down to width pixels, keeping its aspect ratio. Each pixel
+// averages the source pixels it covers, so detail isn't lost to aliasing. Images no wider
+// than width are returned as they are.
+func resizeImage(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if width <= 0 || width >= bounds.Dx() {
+		return img
+	}
+	height := max(bounds.Dy()*width/bounds.Dx(), 1)
+
+	resized := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		top := bounds.Min.Y + y*bounds.Dy()/height
+		bottom := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, top+1)
+		for x := 0; x < width; x++ {
+			left := bounds.Min.X + x*bounds.Dx()/width
+			right := max(bounds.Min.X+(x+1)*bounds.Dx()/width, left+1)
+
+			var r, g, b, a, n uint64
+			for sy := top; sy < bottom; sy++ {
+				for sx := left; sx < right; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa), n+1
+				}
+			}
+			// Averages of premultiplied colors, converted back for NRGBA
+			if a == 0 {
+				continue
+			}
+			resized.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r * 0xff / a),
+				G: uint8(g * 0xff / a),
+				B: uint8(b * 0xff / a),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return resized
+}
+
+// encodeImage writes img in a format. JPEGs have the given quality from 1 to 100.
+func encodeImage(img image.Image, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case FormatPNG:
+		err = png.Encode(&buf, img)
+	default:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
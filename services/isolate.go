@@ -0,0 +1,25 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// ErrPanicked wraps a panic recovered in a goroutine working on one item of a request
+var ErrPanicked = errors.New("panic")
+
+// isolate runs fn and turns a panic into an error. Every goroutine the service spawns runs
+// its work through isolate: gin only recovers panics on the request's own goroutine, so an
+// unrecovered panic in a fan-out would take down the whole process, not just one item.
+// The stack is logged for the bug to be found.
+func isolate(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Error: recovered panic: %v\n%s", r, debug.Stack())
+			err = fmt.Errorf("%w: %v", ErrPanicked, r)
+		}
+	}()
+	return fn()
+}
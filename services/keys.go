@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// Key rotation strategies
+const (
+	// KeyRotationFailover uses the first key until OMDb rejects it, then the next one
+	KeyRotationFailover = "failover"
+	// KeyRotationRoundRobin spreads calls evenly over the keys
+	KeyRotationRoundRobin = "round_robin"
+)
+
+// APIKeyPool pools several OMDb API keys, e.g. free-tier keys of a team, and picks the key
+// of each call. A key that reaches its daily request limit is set aside until midnight
+// UTC, when OMDb resets it, and a key OMDb reports as invalid is set aside until restart.
+type APIKeyPool struct {
+	// Rotation is KeyRotationFailover or KeyRotationRoundRobin
+	Rotation string
+
+	mu     sync.Mutex
+	keys   []*pooledKey
+	cursor int
+}
+
+// pooledKey is one key with its usage today (UTC)
+type pooledKey struct {
+	value       string
+	day         string
+	calls       int
+	quotaErrors int
+	exhausted   bool
+	invalid     bool
+}
+
+// apiKeyPoolFromEnv reads OMDB_API_KEY, a key or a comma-separated list of keys, and
+// OMDB_KEY_ROTATION (failover or round_robin, default failover). It returns nil without keys.
+func apiKeyPoolFromEnv() *APIKeyPool {
+	pool := &APIKeyPool{Rotation: KeyRotationFailover}
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("OMDB_KEY_ROTATION")), KeyRotationRoundRobin) {
+		pool.Rotation = KeyRotationRoundRobin
+	}
+	seen := make(map[string]bool)
+	for _, key := range strings.Split(os.Getenv("OMDB_API_KEY"), ",") {
+		if key = strings.TrimSpace(key); key != "" && !seen[key] {
+			seen[key] = true
+			pool.keys = append(pool.keys, &pooledKey{value: key})
+		}
+	}
+	if len(pool.keys) == 0 {
+		return nil
+	}
+	return pool
+}
+
+// Len returns the number of keys in the pool
+func (p *APIKeyPool) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.keys)
+}
+
+// First returns the first key, or "" without keys
+func (p *APIKeyPool) First() string {
+	if p.Len() == 0 {
+		return ""
+	}
+	return p.keys[0].value
+}
+
+// Next picks the key of a call. When every key is set aside it still returns one, so
+// that the call fails with OMDb's own error.
+func (p *APIKeyPool) Next() string {
+	if p == nil {
+		return ""
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	start := 0
+	if p.Rotation == KeyRotationRoundRobin {
+		start = p.cursor
+		p.cursor = (p.cursor + 1) % len(p.keys)
+	}
+	for i := range p.keys {
+		key := p.keys[(start+i)%len(p.keys)]
+		if key.usableLocked() {
+			if p.Rotation == KeyRotationRoundRobin {
+				p.cursor = (start + i + 1) % len(p.keys)
+			}
+			return key.value
+		}
+	}
+	return p.keys[start].value
+}
+
+// record counts a call made with key and sets the key aside if OMDb rejected it. It
+// reports whether the call should be retried with another key.
+func (p *APIKeyPool) record(value string, err error) (retry bool) {
+	if p == nil || value == "" {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var key *pooledKey
+	for _, candidate := range p.keys {
+		if candidate.value == value {
+			key = candidate
+		}
+	}
+	if key == nil {
+		return false
+	}
+	key.usableLocked()
+	key.calls++
+
+	switch {
+	case errors.Is(err, ErrUpstreamQuota):
+		key.quotaErrors++
+		if !key.exhausted {
+			log.Printf("omdb: API key %s reached its daily limit", maskKey(value))
+		}
+		key.exhausted = true
+	case errors.Is(err, ErrUpstreamAuth):
+		if !key.invalid {
+			log.Printf("omdb: API key %s was rejected as invalid", maskKey(value))
+		}
+		key.invalid = true
+	default:
+		return false
+	}
+	for _, other := range p.keys {
+		if other.usableLocked() {
+			return true
+		}
+	}
+	return false
+}
+
+// Exhausted reports whether no key can be used until the limits reset
+func (p *APIKeyPool) Exhausted() bool {
+	if p == nil {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, key := range p.keys {
+		if key.usableLocked() {
+			return false
+		}
+	}
+	return true
+}
+
+// Usage reports each key's calls today, with the keys masked
+func (p *APIKeyPool) Usage() []models.APIKeyUsage {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	usage := make([]models.APIKeyUsage, len(p.keys))
+	for i, key := range p.keys {
+		key.usableLocked()
+		usage[i] = models.APIKeyUsage{
+			Index:       i,
+			Key:         maskKey(key.value),
+			CallsToday:  key.calls,
+			QuotaErrors: key.quotaErrors,
+			Exhausted:   key.exhausted,
+			Invalid:     key.invalid,
+		}
+	}
+	return usage
+}
+
+// usableLocked resets the day's counts when the day has changed and reports whether the
+// key may be used
+func (k *pooledKey) usableLocked() bool {
+	if today := time.Now().UTC().Format("2006-01-02"); k.day != today {
+		k.day, k.calls, k.quotaErrors, k.exhausted = today, 0, 0, false
+	}
+	return !k.exhausted && !k.invalid
+}
+
+// maskKey shows only the first two characters of a key
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return key[:2] + "******"
+}
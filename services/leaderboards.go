@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// Leaderboards
+const (
+	LeaderboardWatchlisted = "watchlisted"
+	LeaderboardUserRated   = "user-rated"
+)
+
+// leaderboardWindows are the time windows each leaderboard is computed for; zero is all time
+var leaderboardWindows = map[string]time.Duration{
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+	"all":   0,
+}
+
+// leaderboardLookupTimeout bounds the title lookups of one aggregation
+const leaderboardLookupTimeout = 30 * time.Second
+
+// LeaderboardService ranks titles by what users do with them: the most watchlisted titles,
+// i.e. monitored by the most clients, and the highest rated by users. The boards are
+// aggregated from the stored monitors and ratings every Interval for each time window, so
+// requests don't scan the stores. A window counts the watches started and the ratings
+// given within it.
+type LeaderboardService struct {
+	// Interval between aggregations
+	Interval time.Duration
+	// Size is the number of titles kept per board
+	Size int
+	// MinRatings is the number of user ratings a title needs to be ranked by rating
+	MinRatings int
+
+	omdb     *OMDbService
+	ratings  *RatingStore
+	monitors *MonitorService
+
+	mu     sync.RWMutex
+	boards map[string]models.Leaderboard
+}
+
+// NewLeaderboardService reads LEADERBOARD_INTERVAL_SECONDS (default 600), LEADERBOARD_SIZE
+// (default 50) and LEADERBOARD_MIN_RATINGS (default 3)
+func NewLeaderboardService(omdb *OMDbService, ratings *RatingStore, monitors *MonitorService) *LeaderboardService {
+	return &LeaderboardService{
+		Interval:   time.Duration(envInt("LEADERBOARD_INTERVAL_SECONDS", 600)) * time.Second,
+		Size:       max(envInt("LEADERBOARD_SIZE", 50), 1),
+		MinRatings: max(envInt("LEADERBOARD_MIN_RATINGS", 3), 1),
+		omdb:       omdb,
+		ratings:    ratings,
+		monitors:   monitors,
+	}
+}
+
+// ValidWindow reports whether window is one of the supported time windows
+func ValidWindow(window string) bool {
+	_, ok := leaderboardWindows[window]
+	return ok
+}
+
+// Get returns a board for a window as of the last aggregation. The boards are aggregated
+// on first use if the periodic job hasn't run yet.
+func (l *LeaderboardService) Get(ctx context.Context, board, window string) models.Leaderboard {
+	l.mu.RLock()
+	boards := l.boards
+	l.mu.RUnlock()
+	if boards == nil {
+		l.Aggregate(ctx)
+		l.mu.RLock()
+		boards = l.boards
+		l.mu.RUnlock()
+	}
+	leaderboard := boards[board+"|"+window]
+	leaderboard.Entries = append([]models.LeaderboardEntry{}, leaderboard.Entries...)
+	return leaderboard
+}
+
+// Run aggregates the boards now and then every Interval until ctx is done
+func (l *LeaderboardService) Run(ctx context.Context) {
+	l.Aggregate(ctx)
+	if l.Interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(l.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.Aggregate(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Aggregate recomputes every board from the stored monitors and ratings
+func (l *LeaderboardService) Aggregate(ctx context.Context) {
+	now := time.Now().UTC()
+	watches := l.monitors.watches()
+	ratings := l.ratings.all()
+
+	boards := make(map[string]models.Leaderboard, 2*len(leaderboardWindows))
+	titles := make(map[string]string)
+	for window, span := range leaderboardWindows {
+		var since time.Time
+		if span > 0 {
+			since = now.Add(-span)
+		}
+		watchlisted := l.watchlisted(watches, since, titles)
+		watchlisted.Window = window
+		boards[LeaderboardWatchlisted+"|"+window] = watchlisted
+		userRated := l.userRated(ratings, since)
+		userRated.Window = window
+		boards[LeaderboardUserRated+"|"+window] = userRated
+	}
+
+	// Ratings don't carry titles; look up the ranked titles monitors didn't name
+	var missing []string
+	for _, board := range boards {
+		for _, entry := range board.Entries {
+			if _, ok := titles[entry.ImdbID]; !ok {
+				titles[entry.ImdbID] = ""
+				missing = append(missing, entry.ImdbID)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		lookupCtx, cancel := context.WithTimeout(WithPriority(ctx, PriorityBackground), leaderboardLookupTimeout)
+		for imdbID, record := range l.omdb.GetTitlesByID(lookupCtx, missing) {
+			titles[imdbID] = record.Title
+		}
+		cancel()
+	}
+
+	for key, board := range boards {
+		for i := range board.Entries {
+			board.Entries[i].Title = titles[board.Entries[i].ImdbID]
+		}
+		board.ComputedAt = now
+		boards[key] = board
+	}
+
+	l.mu.Lock()
+	l.boards = boards
+	l.mu.Unlock()
+}
+
+// watchlisted ranks titles by the clients that started watching them since since,
+// recording the titles' names in titles
+func (l *LeaderboardService) watchlisted(watches []titleWatch, since time.Time, titles map[string]string) models.Leaderboard {
+	watchers := make(map[string]int)
+	for _, watch := range watches {
+		if watch.since.Before(since) {
+			continue
+		}
+		watchers[watch.imdbID]++
+		if watch.title != "" {
+			titles[watch.imdbID] = watch.title
+		}
+	}
+
+	entries := make([]models.LeaderboardEntry, 0, len(watchers))
+	for imdbID, count := range watchers {
+		entries = append(entries, models.LeaderboardEntry{ImdbID: imdbID, Watchers: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Watchers != entries[j].Watchers {
+			return entries[i].Watchers > entries[j].Watchers
+		}
+		return entries[i].ImdbID < entries[j].ImdbID
+	})
+	return l.board(LeaderboardWatchlisted, entries)
+}
+
+// userRated ranks titles with at least MinRatings ratings given since since by their
+// average rating, then by the number of ratings
+func (l *LeaderboardService) userRated(ratings []models.UserRating, since time.Time) models.Leaderboard {
+	sums := make(map[string]int)
+	counts := make(map[string]int)
+	for _, rating := range ratings {
+		if rating.RatedAt.Before(since) {
+			continue
+		}
+		sums[rating.ImdbID] += rating.Rating
+		counts[rating.ImdbID]++
+	}
+
+	var entries []models.LeaderboardEntry
+	for imdbID, count := range counts {
+		if count < l.MinRatings {
+			continue
+		}
+		average := math.Round(float64(sums[imdbID])/float64(count)*100) / 100
+		entries = append(entries, models.LeaderboardEntry{ImdbID: imdbID, AverageRating: average, Ratings: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].AverageRating != entries[j].AverageRating {
+			return entries[i].AverageRating > entries[j].AverageRating
+		}
+		if entries[i].Ratings != entries[j].Ratings {
+			return entries[i].Ratings > entries[j].Ratings
+		}
+		return entries[i].ImdbID < entries[j].ImdbID
+	})
+	return l.board(LeaderboardUserRated, entries)
+}
+
+// board keeps the top Size entries and ranks them
+func (l *LeaderboardService) board(name string, entries []models.LeaderboardEntry) models.Leaderboard {
+	if len(entries) > l.Size {
+		entries = entries[:l.Size]
+	}
+	if entries == nil {
+		entries = []models.LeaderboardEntry{}
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return models.Leaderboard{Board: name, Entries: entries}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// Kinds of user content that others can like and report
+const (
+	ContentReview = "review"
+	ContentList   = "list"
+)
+
+// LikeStore keeps which users like which reviews and lists, persisted as a JSON file. A
+// user likes a thing at most once, so counts can't be inflated by repeating a like.
+type LikeStore struct {
+	// Limiter bounds the likes and unlikes of each user
+	Limiter *RateLimiter
+
+	path string
+
+	mu sync.Mutex
+	// likes maps a target, kind:id, to the users liking it and when they did
+	likes map[string]map[string]time.Time
+}
+
+// NewLikeStore loads the likes from LIKES_PATH (default data/likes.json) and reads
+// LIKES_PER_HOUR (default 60; zero is unlimited)
+func NewLikeStore() (*LikeStore, error) {
+	path := os.Getenv("LIKES_PATH")
+	if path == "" {
+		path = "data/likes.json"
+	}
+
+	s := &LikeStore{
+		Limiter: newRateLimiter(envInt("LIKES_PER_HOUR", 60), time.Hour),
+		path:    path,
+		likes:   make(map[string]map[string]time.Time),
+	}
+	if err := store.LoadJSON(path, &s.likes); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Allow counts a like or unlike by a user and reports whether it is within the limit
+func (s *LikeStore) Allow(userID string) RateLimitStatus {
+	if !s.Limiter.Enabled() {
+		return RateLimitStatus{Allowed: true}
+	}
+	// Counted apart from the user's request rate, which shares the limiter's key space
+	return s.Limiter.Allow("likes:user:" + userID)
+}
+
+// Like records that a user likes a target. Liking it again changes nothing.
+func (s *LikeStore) Like(userID, kind, id string) (models.Likes, error) {
+	key := kind + ":" + id
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := s.likes[key]
+	if _, ok := users[userID]; !ok {
+		if users == nil {
+			users = make(map[string]time.Time)
+			s.likes[key] = users
+		}
+		users[userID] = time.Now().UTC()
+		if err := store.SaveJSON(s.path, s.likes); err != nil {
+			delete(users, userID)
+			return models.Likes{}, err
+		}
+	}
+	return models.Likes{Kind: kind, ID: id, Likes: len(users), Liked: true}, nil
+}
+
+// Unlike removes a user's like of a target, if any
+func (s *LikeStore) Unlike(userID, kind, id string) (models.Likes, error) {
+	key := kind + ":" + id
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := s.likes[key]
+	if at, ok := users[userID]; ok {
+		delete(users, userID)
+		if len(users) == 0 {
+			delete(s.likes, key)
+		}
+		if err := store.SaveJSON(s.path, s.likes); err != nil {
+			users[userID] = at
+			s.likes[key] = users
+			return models.Likes{}, err
+		}
+	}
+	return models.Likes{Kind: kind, ID: id, Likes: len(users)}, nil
+}
+
+// Count returns the number of users liking a target and whether userID (empty for
+// anonymous callers) is one of them
+func (s *LikeStore) Count(userID, kind, id string) (likes int, liked bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := s.likes[kind+":"+id]
+	_, liked = users[userID]
+	return len(users), liked && userID != ""
+}
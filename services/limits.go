@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// ErrCallBudgetExceeded is returned for upstream calls made after the inbound request
+// has used up its MAX_UPSTREAM_CALLS_PER_REQUEST budget
+var ErrCallBudgetExceeded = errors.New("upstream call budget exceeded")
+
+// Limits bounds the upstream work the service performs. Zero means unlimited.
+type Limits struct {
+	// MaxConcurrency is the number of OMDb calls that may be in flight across all requests
+	MaxConcurrency int
+	// MaxCallsPerRequest is the number of OMDb calls a single inbound request may cause
+	MaxCallsPerRequest int
+	// MaxSearchTerms is the number of search terms the genre search fans out to
+	MaxSearchTerms int
+}
+
+// limitsFromEnv reads MAX_UPSTREAM_CONCURRENCY, MAX_UPSTREAM_CALLS_PER_REQUEST and MAX_SEARCH_TERMS
+func limitsFromEnv() Limits {
+	return Limits{
+		MaxConcurrency:     envInt("MAX_UPSTREAM_CONCURRENCY", 10),
+		MaxCallsPerRequest: envInt("MAX_UPSTREAM_CALLS_PER_REQUEST", 0),
+		MaxSearchTerms:     envInt("MAX_SEARCH_TERMS", 0),
+	}
+}
+
+// envInt reads a non-negative integer environment variable, falling back to def
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value < 0 {
+		return def
+	}
+	return value
+}
+
+// RequestScope tracks the upstream work done on behalf of one inbound request
+type RequestScope struct {
+	maxCalls int
+	scaling  *ScalingMetrics
+
+	mu          sync.Mutex
+	calls       int
+	truncations map[string]models.Truncation
+	order       []string
+	failed      []models.FailedItem
+	failedIDs   map[string]bool
+
+	cacheStatus string
+	cacheAge    time.Duration
+}
+
+// NewRequestScope creates a scope using the service's per-request call budget
+// and counts the request in flight until Finish
+func (s *OMDbService) NewRequestScope() *RequestScope {
+	s.scaling.requests.Add(1)
+	return &RequestScope{
+		maxCalls:    s.Limits.MaxCallsPerRequest,
+		scaling:     &s.scaling,
+		truncations: make(map[string]models.Truncation),
+	}
+}
+
+// newBackgroundScope creates a scope for work done outside a request, such as a scheduled
+// refresh, to collect its failed items. It has no call budget and isn't counted in flight.
+func newBackgroundScope() *RequestScope {
+	return &RequestScope{truncations: make(map[string]models.Truncation)}
+}
+
+// Finish marks the request as done for the in-flight counts
+func (r *RequestScope) Finish() {
+	if r == nil || r.scaling == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.scaling.requests.Add(-1)
+	if r.calls > 1 {
+		r.scaling.fanOuts.Add(-1)
+	}
+	r.scaling = nil
+}
+
+type scopeKey struct{}
+
+// WithScope attaches a request scope to the context passed to the service methods
+func WithScope(ctx context.Context, scope *RequestScope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFrom returns the request scope of the context, or nil if there is none
+func ScopeFrom(ctx context.Context) *RequestScope {
+	scope, _ := ctx.Value(scopeKey{}).(*RequestScope)
+	return scope
+}
+
+// Calls returns the number of upstream calls made so far
+func (r *RequestScope) Calls() int {
+	if r == nil {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+// Meta summarizes the limits that truncated work, or returns nil if none did
+func (r *RequestScope) Meta() *models.ResponseMeta {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.order) == 0 {
+		return nil
+	}
+
+	meta := &models.ResponseMeta{
+		Truncated:     true,
+		UpstreamCalls: r.calls,
+	}
+	for _, limit := range r.order {
+		meta.Truncations = append(meta.Truncations, r.truncations[limit])
+	}
+	return meta
+}
+
+// chargeCall accounts for one upstream call, failing once the budget is spent
+func (r *RequestScope) chargeCall() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxCalls > 0 && r.calls >= r.maxCalls {
+		r.recordLocked("max_upstream_calls_per_request", r.maxCalls, "further upstream calls were skipped")
+		return ErrCallBudgetExceeded
+	}
+	r.calls++
+	if r.calls == 2 && r.scaling != nil {
+		// The request fans out from its second upstream call
+		r.scaling.fanOuts.Add(1)
+	}
+	return nil
+}
+
+// truncate records that a limit cut work short
+func (r *RequestScope) truncate(limit string, value int, detail string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recordLocked(limit, value, detail)
+}
+
+// FailedItems returns the items that failed, in the order they failed, or nil if none did
+func (r *RequestScope) FailedItems() []models.FailedItem {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.failed) == 0 {
+		return nil
+	}
+	return append([]models.FailedItem{}, r.failed...)
+}
+
+// failItem records an item of a list that failed while the rest of the response was
+// served; title is empty when it isn't known yet. An item is recorded once however often
+// it fails, and failItem reports whether this was its first failure. Calls skipped for the
+// call budget are already reported as a truncation.
+func (r *RequestScope) failItem(item, title string, err error) bool {
+	if r == nil {
+		return true
+	}
+	if errors.Is(err, ErrCallBudgetExceeded) {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failedIDs[item] {
+		return false
+	}
+	if r.failedIDs == nil {
+		r.failedIDs = make(map[string]bool)
+	}
+	r.failedIDs[item] = true
+	r.failed = append(r.failed, models.FailedItem{Item: item, Title: title, Reason: errorText(err), Panicked: errors.Is(err, ErrPanicked)})
+	return true
+}
+
+func (r *RequestScope) recordLocked(limit string, value int, detail string) {
+	if _, ok := r.truncations[limit]; ok {
+		return
+	}
+	r.truncations[limit] = models.Truncation{Limit: limit, Value: value, Detail: detail}
+	r.order = append(r.order, limit)
+}
+
+// recordCache notes how a cached lookup was served. The request reports its least fresh
+// outcome (STALE over MISS over HIT) and the age of the oldest cached data it used.
+func (r *RequestScope) recordCache(status string, age time.Duration) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cacheRank[status] > cacheRank[r.cacheStatus] {
+		r.cacheStatus = status
+	}
+	if age > r.cacheAge {
+		r.cacheAge = age
+	}
+}
+
+var cacheRank = map[string]int{CacheHit: 1, CacheMiss: 2, CacheStale: 3}
+
+// CacheStatus returns the request's cache outcome and data age, or an empty status if
+// no cached lookup was made
+func (r *RequestScope) CacheStatus() (string, time.Duration) {
+	if r == nil {
+		return "", 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cacheStatus, r.cacheAge
+}
+
+// acquireSlot waits for one of the MAX_UPSTREAM_CONCURRENCY slots. The returned
+// function releases it.
+func (s *OMDbService) acquireSlot(ctx context.Context) (func(), error) {
+	if s.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return func() { <-s.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// tryAcquireSlot takes a concurrency slot only if one is free right now
+func (s *OMDbService) tryAcquireSlot() (func(), bool) {
+	if s.slots == nil {
+		return func() {}, true
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return func() { <-s.slots }, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// ErrInvalidMaintenance wraps the reason a maintenance window was rejected
+var ErrInvalidMaintenance = errors.New("invalid maintenance window")
+
+// defaultMaintenanceMessage is served when maintenance is enabled without a message
+const defaultMaintenanceMessage = "The API is down for planned maintenance"
+
+// MaintenanceMode is an admin switch that takes the data routes offline, e.g. while the
+// OMDb keys are rotated or data is migrated, while health checks, the status page and
+// the admin routes stay up. The state is persisted as a JSON file so that a restart
+// during the window doesn't end it.
+type MaintenanceMode struct {
+	path string
+
+	mu    sync.RWMutex
+	state models.MaintenanceState
+}
+
+// NewMaintenanceMode loads the state from MAINTENANCE_PATH (default data/maintenance.json)
+func NewMaintenanceMode() (*MaintenanceMode, error) {
+	path := os.Getenv("MAINTENANCE_PATH")
+	if path == "" {
+		path = "data/maintenance.json"
+	}
+
+	m := &MaintenanceMode{path: path}
+	if err := store.LoadJSON(path, &m.state); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// State returns the current state
+func (m *MaintenanceMode) State() models.MaintenanceState {
+	if m == nil {
+		return models.MaintenanceState{}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Active reports whether the data routes are offline
+func (m *MaintenanceMode) Active() bool {
+	return m.State().Enabled
+}
+
+// Enable takes the data routes offline with the message and retry hint of req, or
+// updates them during a window, and persists the state. It also returns the previous state.
+func (m *MaintenanceMode) Enable(req models.MaintenanceRequest, actor string) (models.MaintenanceState, models.MaintenanceState, error) {
+	if req.RetryAfterSeconds < 0 {
+		return models.MaintenanceState{}, models.MaintenanceState{}, fmt.Errorf("%w: retry_after_seconds must not be negative", ErrInvalidMaintenance)
+	}
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		return models.MaintenanceState{}, models.MaintenanceState{}, fmt.Errorf("%w: until must be in the future", ErrInvalidMaintenance)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := m.state
+	state := models.MaintenanceState{
+		Enabled:           true,
+		Message:           strings.TrimSpace(req.Message),
+		RetryAfterSeconds: req.RetryAfterSeconds,
+		Until:             req.Until,
+		StartedAt:         previous.StartedAt,
+		UpdatedBy:         actor,
+	}
+	if state.Message == "" {
+		state.Message = defaultMaintenanceMessage
+	}
+	if state.RetryAfterSeconds == 0 && state.Until != nil {
+		state.RetryAfterSeconds = int(time.Until(*state.Until).Seconds()) + 1
+	}
+	if !previous.Enabled {
+		now := time.Now().UTC()
+		state.StartedAt = &now
+	}
+	if err := store.SaveJSON(m.path, state); err != nil {
+		return models.MaintenanceState{}, previous, err
+	}
+	m.state = state
+	return state, previous, nil
+}
+
+// Disable brings the data routes back and persists the state. It returns the state the
+// window had.
+func (m *MaintenanceMode) Disable() (models.MaintenanceState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := m.state
+	if err := store.SaveJSON(m.path, models.MaintenanceState{}); err != nil {
+		return previous, err
+	}
+	m.state = models.MaintenanceState{}
+	return previous, nil
+}
+
+// Report flags an incident on a status report while maintenance is on
+func (m *MaintenanceMode) Report(report *models.StatusReport) {
+	state := m.State()
+	if !state.Enabled {
+		return
+	}
+	report.Incidents = append(report.Incidents, models.Incident{Flag: "maintenance", Message: state.Message})
+	report.Status = "outage"
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourceLocale is the language the API's messages are written in
+const SourceLocale = "en"
+
+//go:embed data/messages/*.json
+var messageFiles embed.FS
+
+// placeholderPattern matches the {0}, {1}, ... placeholders of catalog entries
+var placeholderPattern = regexp.MustCompile(`\{(\d)\}`)
+
+// MessageCatalog translates the human-readable strings of responses, such as error
+// messages and the descriptions of recommendation levels. A catalog for a locale maps
+// English strings to their translations; entries may hold placeholders such as {0} for
+// the variable parts of a message ("No title found with IMDb ID {0}"). Strings without
+// an entry are left in English.
+type MessageCatalog struct {
+	// DefaultLocale is the language of responses to clients that don't ask for one the
+	// catalog has
+	DefaultLocale string
+
+	exact    map[string]map[string]string
+	patterns map[string][]messagePattern
+}
+
+// messagePattern is a catalog entry with placeholders
+type messagePattern struct {
+	match       *regexp.Regexp
+	order       []int
+	translation string
+}
+
+// NewMessageCatalog loads the catalogs shipped in services/data/messages, and the
+// <locale>.json files in MESSAGES_DIR, which add languages or replace entries. It reads
+// DEFAULT_LANGUAGE (default en), which must be English or a language with a catalog.
+func NewMessageCatalog() (*MessageCatalog, error) {
+	m := &MessageCatalog{
+		exact:    make(map[string]map[string]string),
+		patterns: make(map[string][]messagePattern),
+	}
+
+	shipped, err := messageFiles.ReadDir("data/messages")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range shipped {
+		data, err := messageFiles.ReadFile("data/messages/" + file.Name())
+		if err != nil {
+			return nil, err
+		}
+		if err := m.add(strings.TrimSuffix(file.Name(), ".json"), data); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir := os.Getenv("MESSAGES_DIR"); dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if err := m.add(strings.TrimSuffix(filepath.Base(file), ".json"), data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	m.DefaultLocale = strings.ToLower(strings.TrimSpace(os.Getenv("DEFAULT_LANGUAGE")))
+	if m.DefaultLocale == "" {
+		m.DefaultLocale = SourceLocale
+	}
+	if !m.Supports(m.DefaultLocale) {
+		return nil, fmt.Errorf("DEFAULT_LANGUAGE must be one of %s, not %q", strings.Join(m.Locales(), ", "), m.DefaultLocale)
+	}
+	return m, nil
+}
+
+// add merges the JSON catalog of a locale
+func (m *MessageCatalog) add(locale string, data []byte) error {
+	locale = strings.ToLower(locale)
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse %s message catalog: %w", locale, err)
+	}
+
+	if m.exact[locale] == nil {
+		m.exact[locale] = make(map[string]string)
+	}
+	for source, translation := range entries {
+		if !placeholderPattern.MatchString(source) {
+			m.exact[locale][source] = translation
+			continue
+		}
+
+		// Each placeholder matches the text in its place, and translations may move them
+		var order []int
+		for _, placeholder := range placeholderPattern.FindAllStringSubmatch(source, -1) {
+			index, _ := strconv.Atoi(placeholder[1])
+			order = append(order, index)
+		}
+		// QuoteMeta escapes the braces of the placeholders, which are then unescaped to be replaced
+		quoted := strings.NewReplacer(`\{`, "{", `\}`, "}").Replace(regexp.QuoteMeta(source))
+		match, err := regexp.Compile("^" + placeholderPattern.ReplaceAllString(quoted, "(.+?)") + "$")
+		if err != nil {
+			return fmt.Errorf("invalid %s message catalog entry %q: %w", locale, source, err)
+		}
+		m.patterns[locale] = append(m.patterns[locale], messagePattern{match: match, order: order, translation: translation})
+	}
+	// Longer entries first, so that the most specific one matches
+	sort.SliceStable(m.patterns[locale], func(i, j int) bool {
+		return len(m.patterns[locale][i].match.String()) > len(m.patterns[locale][j].match.String())
+	})
+	return nil
+}
+
+// Locales lists the supported languages, English first
+func (m *MessageCatalog) Locales() []string {
+	locales := []string{SourceLocale}
+	for locale := range m.exact {
+		if locale != SourceLocale {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// Supports reports whether responses can be given in a language
+func (m *MessageCatalog) Supports(locale string) bool {
+	_, ok := m.exact[locale]
+	return locale == SourceLocale || ok
+}
+
+// Negotiate picks the language of a response from an Accept-Language header, such as
+// "es-MX,es;q=0.9,en;q=0.8": the supported language the client prefers most, matching a
+// regional tag by its language, or DefaultLocale
+func (m *MessageCatalog) Negotiate(acceptLanguage string) string {
+	type preference struct {
+		tag string
+		q   float64
+	}
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && q > 0 {
+			preferences = append(preferences, preference{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].q > preferences[j].q })
+
+	for _, preference := range preferences {
+		if preference.tag == "*" {
+			return m.DefaultLocale
+		}
+		language, _, _ := strings.Cut(preference.tag, "-")
+		for _, locale := range []string{preference.tag, language} {
+			if m.Supports(locale) {
+				return locale
+			}
+		}
+	}
+	return m.DefaultLocale
+}
+
+// Translate returns text in a language, or text itself if the catalog has no entry for it
+func (m *MessageCatalog) Translate(locale, text string) string {
+	if locale == SourceLocale || text == "" {
+		return text
+	}
+	if translation, ok := m.exact[locale][text]; ok {
+		return translation
+	}
+	for _, pattern := range m.patterns[locale] {
+		groups := pattern.match.FindStringSubmatch(text)
+		if groups == nil {
+			continue
+		}
+		values := make(map[int]string, len(pattern.order))
+		for i, index := range pattern.order {
+			values[index] = groups[i+1]
+		}
+		return placeholderPattern.ReplaceAllStringFunc(pattern.translation, func(placeholder string) string {
+			index, _ := strconv.Atoi(placeholder[1 : len(placeholder)-1])
+			return values[index]
+		})
+	}
+	return text
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/notify"
+	"movie-api-go/store"
+)
+
+// Monitor conditions
+const (
+	ConditionBelow   = "below"
+	ConditionAbove   = "above"
+	ConditionAppears = "appears"
+)
+
+// monitorFields maps the monitorable fields to their value in an OMDb record
+var monitorFields = map[string]func(*models.OMDbResponse) string{
+	"imdb_rating": func(r *models.OMDbResponse) string { return r.ImdbRating },
+	"metascore":   func(r *models.OMDbResponse) string { return r.Metascore },
+}
+
+// monitorCheckTimeout bounds the lookup of one title during a check
+const monitorCheckTimeout = 15 * time.Second
+
+// monitorAlertTemplate renders alerts for people; webhooks receive the alert itself
+var monitorAlertTemplate = notify.MustTemplate("monitor alert",
+	`{{.Title}}: {{label .Field}} {{condition .}}`,
+	"{{.Title}} ({{.ImdbID}})\n{{.Field}} is now {{.Value}} (was {{orNone .Previous}}).\n",
+	template.FuncMap{
+		"label":     func(field string) string { return strings.ReplaceAll(field, "_", " ") },
+		"condition": describeCondition,
+		"orNone":    valueOrNone,
+	})
+
+var (
+	// ErrMonitorLimit is returned when a client already has MAX_MONITORS_PER_CLIENT monitors
+	ErrMonitorLimit = errors.New("monitor limit reached")
+
+	// ErrInvalidMonitor wraps the reason a monitor registration was rejected
+	ErrInvalidMonitor = errors.New("invalid monitor")
+)
+
+// MonitorService keeps the monitors clients registered, checks them on a schedule and
+// delivers an alert by webhook, Slack and/or email when a condition starts to hold.
+type MonitorService struct {
+	// Interval between checks of all monitors
+	Interval time.Duration
+	// MaxPerClient bounds the monitors one client may register; zero means unlimited
+	MaxPerClient int
+
+	omdb     *OMDbService
+	path     string
+	notifier *notify.Dispatcher
+
+	mu       sync.Mutex
+	monitors map[string]*storedMonitor
+}
+
+// keyDigestPattern matches the key digest of KeyClientID
+var keyDigestPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// storedMonitor is a monitor with the client that registered it
+type storedMonitor struct {
+	Owner string `json:"owner"`
+	models.Monitor
+}
+
+// NewMonitorService loads the monitors from MONITORS_PATH (default data/monitors.json) and
+// reads MONITOR_INTERVAL_SECONDS (default 3600) and MAX_MONITORS_PER_CLIENT (default 50).
+// Alerts are delivered through the shared notifier (see notifierFromEnv).
+func NewMonitorService(omdb *OMDbService) (*MonitorService, error) {
+	path := os.Getenv("MONITORS_PATH")
+	if path == "" {
+		path = "data/monitors.json"
+	}
+
+	var stored []*storedMonitor
+	if err := store.LoadJSON(path, &stored); err != nil {
+		return nil, err
+	}
+
+	notifier, err := notifierFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MonitorService{
+		Interval:     time.Duration(envInt("MONITOR_INTERVAL_SECONDS", 3600)) * time.Second,
+		MaxPerClient: envInt("MAX_MONITORS_PER_CLIENT", 50),
+		omdb:         omdb,
+		path:         path,
+		notifier:     notifier,
+		monitors:     make(map[string]*storedMonitor),
+	}
+	for _, monitor := range stored {
+		// Monitors of API keys were once owned by the raw key
+		if key, ok := strings.CutPrefix(monitor.Owner, "key:"); ok && !keyDigestPattern.MatchString(key) {
+			monitor.Owner = KeyClientID(key)
+		}
+		m.monitors[monitor.ID] = monitor
+	}
+	return m, nil
+}
+
+// Create validates and registers a monitor for owner. The title is looked up once so that
+// unknown IMDb IDs are rejected and the monitor carries the title's name.
+func (m *MonitorService) Create(ctx context.Context, owner string, req models.MonitorRequest) (models.Monitor, error) {
+	if err := m.validate(req); err != nil {
+		return models.Monitor{}, err
+	}
+
+	record, err := m.omdb.GetTitleByID(ctx, req.ImdbID)
+	if err != nil {
+		return models.Monitor{}, err
+	}
+	if record.Response == "False" {
+		return models.Monitor{}, ErrNotFound
+	}
+
+	id, err := newMonitorID()
+	if err != nil {
+		return models.Monitor{}, err
+	}
+	monitor := &storedMonitor{
+		Owner: owner,
+		Monitor: models.Monitor{
+			ID:        id,
+			ImdbID:    record.ImdbID,
+			Title:     record.Title,
+			Field:     req.Field,
+			Condition: req.Condition,
+			Threshold: req.Threshold,
+			Webhook:   req.Webhook,
+			Slack:     req.Slack,
+			Email:     req.Email,
+			CreatedAt: time.Now().UTC(),
+		},
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.MaxPerClient > 0 && len(m.ownedLocked(owner)) >= m.MaxPerClient {
+		return models.Monitor{}, ErrMonitorLimit
+	}
+	m.monitors[id] = monitor
+	if err := m.saveLocked(); err != nil {
+		delete(m.monitors, id)
+		return models.Monitor{}, err
+	}
+	return monitor.Monitor, nil
+}
+
+func (m *MonitorService) validate(req models.MonitorRequest) error {
+	if _, ok := monitorFields[req.Field]; !ok {
+		return fmt.Errorf("%w: field must be imdb_rating or metascore", ErrInvalidMonitor)
+	}
+	switch req.Condition {
+	case ConditionBelow, ConditionAbove:
+		if req.Threshold == nil {
+			return fmt.Errorf("%w: %s requires a threshold", ErrInvalidMonitor, req.Condition)
+		}
+	case ConditionAppears:
+	default:
+		return fmt.Errorf("%w: condition must be below, above or appears", ErrInvalidMonitor)
+	}
+
+	destinations := monitorDestinations(req.Webhook, req.Slack, req.Email)
+	if len(destinations) == 0 {
+		return fmt.Errorf("%w: a webhook, slack or email is required", ErrInvalidMonitor)
+	}
+	for _, destination := range destinations {
+		if err := m.notifier.Validate(destination.kind, destination.address); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidMonitor, strings.TrimPrefix(err.Error(), notify.ErrInvalidDestination.Error()+": "))
+		}
+	}
+	return nil
+}
+
+// monitorDestination is where a monitor's alerts are delivered, by notification kind
+type monitorDestination struct {
+	kind    string
+	address string
+}
+
+func monitorDestinations(webhook, slack, email string) []monitorDestination {
+	var destinations []monitorDestination
+	for _, destination := range []monitorDestination{{notify.KindWebhook, webhook}, {notify.KindSlack, slack}, {notify.KindEmail, email}} {
+		if destination.address != "" {
+			destinations = append(destinations, destination)
+		}
+	}
+	return destinations
+}
+
+// List returns owner's monitors, oldest first
+func (m *MonitorService) List(owner string) []models.Monitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	owned := m.ownedLocked(owner)
+	monitors := make([]models.Monitor, 0, len(owned))
+	for _, monitor := range owned {
+		monitors = append(monitors, monitor.Monitor)
+	}
+	return monitors
+}
+
+// titleWatch is a client watching a title with at least one monitor, since its first
+// monitor of the title was created
+type titleWatch struct {
+	owner  string
+	imdbID string
+	title  string
+	since  time.Time
+}
+
+// watches lists the titles each client monitors, i.e. the clients' watchlists
+func (m *MonitorService) watches() []titleWatch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byOwnerTitle := make(map[[2]string]titleWatch)
+	for _, monitor := range m.monitors {
+		key := [2]string{monitor.Owner, monitor.ImdbID}
+		if watch, ok := byOwnerTitle[key]; ok && !monitor.CreatedAt.Before(watch.since) {
+			continue
+		}
+		byOwnerTitle[key] = titleWatch{owner: monitor.Owner, imdbID: monitor.ImdbID, title: monitor.Title, since: monitor.CreatedAt}
+	}
+	watches := make([]titleWatch, 0, len(byOwnerTitle))
+	for _, watch := range byOwnerTitle {
+		watches = append(watches, watch)
+	}
+	return watches
+}
+
+// Watchlist returns the titles owner monitors, most recently added first
+func (m *MonitorService) Watchlist(owner string) []models.WatchlistItem {
+	items := []models.WatchlistItem{}
+	for _, watch := range m.watches() {
+		if watch.owner == owner {
+			items = append(items, models.WatchlistItem{ImdbID: watch.imdbID, Title: watch.title, Since: watch.since})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].Since.Equal(items[j].Since) {
+			return items[i].Since.After(items[j].Since)
+		}
+		return items[i].ImdbID < items[j].ImdbID
+	})
+	return items
+}
+
+// Delete removes one of owner's monitors. It reports whether the monitor existed.
+func (m *MonitorService) Delete(owner, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	monitor, ok := m.monitors[id]
+	if !ok || monitor.Owner != owner {
+		return false, nil
+	}
+
+	delete(m.monitors, id)
+	if err := m.saveLocked(); err != nil {
+		m.monitors[id] = monitor
+		return false, err
+	}
+	return true, nil
+}
+
+// Run checks all monitors every Interval until ctx is done
+func (m *MonitorService) Run(ctx context.Context) {
+	if m.Interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.Check(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check looks up every monitored title once and alerts the monitors whose condition
+// started to hold since the previous check
+func (m *MonitorService) Check(ctx context.Context) {
+	m.mu.Lock()
+	byTitle := make(map[string][]string)
+	for id, monitor := range m.monitors {
+		byTitle[monitor.ImdbID] = append(byTitle[monitor.ImdbID], id)
+	}
+	m.mu.Unlock()
+
+	var alerts []pendingAlert
+	for imdbID, ids := range byTitle {
+		lookupCtx, cancel := context.WithTimeout(WithPriority(ctx, PriorityBackground), monitorCheckTimeout)
+		record, err := m.omdb.GetTitleByID(lookupCtx, imdbID)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil || record.Response == "False" {
+			log.Printf("monitor: lookup of %s failed: %v", imdbID, err)
+			continue
+		}
+		alerts = append(alerts, m.evaluate(ids, record)...)
+	}
+
+	m.mu.Lock()
+	if err := m.saveLocked(); err != nil {
+		log.Printf("monitor: failed to save state: %v", err)
+	}
+	m.mu.Unlock()
+
+	for _, alert := range alerts {
+		m.deliver(ctx, alert)
+	}
+}
+
+// pendingAlert is an alert with where to deliver it
+type pendingAlert struct {
+	alert        models.MonitorAlert
+	destinations []monitorDestination
+}
+
+// evaluate records the current value of record on each monitor and returns the alerts
+// of the monitors whose condition now holds but didn't before
+func (m *MonitorService) evaluate(ids []string, record *models.OMDbResponse) []pendingAlert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	var alerts []pendingAlert
+	for _, id := range ids {
+		monitor, ok := m.monitors[id]
+		if !ok {
+			continue
+		}
+
+		value := monitorFields[monitor.Field](record)
+		met := conditionMet(monitor.Condition, monitor.Threshold, value)
+		previous := monitor.LastValue
+
+		monitor.LastValue = value
+		monitor.LastCheckedAt = &now
+		if met && !monitor.Met {
+			monitor.LastAlertAt = &now
+			alerts = append(alerts, pendingAlert{
+				alert: models.MonitorAlert{
+					MonitorID: monitor.ID,
+					ImdbID:    monitor.ImdbID,
+					Title:     monitor.Title,
+					Field:     monitor.Field,
+					Condition: monitor.Condition,
+					Threshold: monitor.Threshold,
+					Value:     value,
+					Previous:  previous,
+					At:        now,
+				},
+				destinations: monitorDestinations(monitor.Webhook, monitor.Slack, monitor.Email),
+			})
+		}
+		monitor.Met = met
+	}
+	return alerts
+}
+
+// conditionMet evaluates a condition against a field value; missing values satisfy no
+// condition
+func conditionMet(condition string, threshold *float64, value string) bool {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false
+	}
+	switch condition {
+	case ConditionBelow:
+		return number < *threshold
+	case ConditionAbove:
+		return number > *threshold
+	case ConditionAppears:
+		return true
+	}
+	return false
+}
+
+// deliver sends an alert to each of its destinations. Failures are logged; the alert is
+// not sent again on the next check.
+func (m *MonitorService) deliver(ctx context.Context, pending pendingAlert) {
+	message, err := monitorAlertTemplate.Render(pending.alert)
+	if err != nil {
+		log.Printf("monitor: failed to render alert for %s: %v", pending.alert.MonitorID, err)
+		return
+	}
+	for _, destination := range pending.destinations {
+		if err := m.notifier.Send(ctx, destination.kind, destination.address, message); err != nil {
+			log.Printf("monitor: %s for %s failed: %v", destination.kind, pending.alert.MonitorID, err)
+		}
+	}
+}
+
+func describeCondition(alert models.MonitorAlert) string {
+	if alert.Condition == ConditionAppears {
+		return "appeared"
+	}
+	return fmt.Sprintf("%s %s", alert.Condition, strconv.FormatFloat(*alert.Threshold, 'f', -1, 64))
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+func (m *MonitorService) ownedLocked(owner string) []*storedMonitor {
+	var owned []*storedMonitor
+	for _, monitor := range m.monitors {
+		if monitor.Owner == owner {
+			owned = append(owned, monitor)
+		}
+	}
+	sort.Slice(owned, func(i, j int) bool { return owned[i].CreatedAt.Before(owned[j].CreatedAt) })
+	return owned
+}
+
+func (m *MonitorService) saveLocked() error {
+	monitors := make([]*storedMonitor, 0, len(m.monitors))
+	for _, monitor := range m.monitors {
+		monitors = append(monitors, monitor)
+	}
+	sort.Slice(monitors, func(i, j int) bool { return monitors[i].ID < monitors[j].ID })
+	return store.SaveJSON(m.path, monitors)
+}
+
+func newMonitorID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
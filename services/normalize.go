@@ -0,0 +1,135 @@
+package services
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	"movie-api-go/models"
+)
+
+// NAPolicy controls how OMDb's literal "N/A" placeholder is exposed to clients. Internally
+// the placeholders are always cleared, whatever the policy; it only decides what detail
+// responses show for the cleared fields, see Present.
+type NAPolicy string
+
+const (
+	// NAPolicyOmit omits fields OMDb has no value for from responses
+	NAPolicyOmit NAPolicy = "omit"
+	// NAPolicyKeep serves them as "N/A" (legacy behaviour)
+	NAPolicyKeep NAPolicy = "keep"
+	// NAPolicyNull serves them as JSON null, keeping the fields in responses. Present puts
+	// "N/A" back like with NAPolicyKeep, and the not_available middleware replaces it with
+	// null as responses are written, since an omitted field can't be told from an
+	// unavailable one in the encoded response.
+	NAPolicyNull NAPolicy = "null"
+)
+
+// NotAvailable is OMDb's placeholder for missing data
+const NotAvailable = "N/A"
+
+// naPolicyFromEnv reads NA_POLICY, defaulting to NAPolicyOmit
+func naPolicyFromEnv() NAPolicy {
+	switch policy := NAPolicy(os.Getenv("NA_POLICY")); policy {
+	case NAPolicyKeep, NAPolicyNull:
+		return policy
+	}
+	return NAPolicyOmit
+}
+
+// normalize clears the "N/A" placeholders of a decoded OMDb payload. Every upstream
+// response passes through here, so nothing downstream has to special-case "N/A".
+func (s *OMDbService) normalize(payload interface{}) {
+	clearNotAvailable(reflect.ValueOf(payload))
+}
+
+// Present returns a record as it is served to clients. Under NAPolicyKeep and NAPolicyNull
+// it is a copy with "N/A" put back into the fields normalize cleared; under NAPolicyOmit
+// it is the record itself. Only the response is built from the result.
+func (p NAPolicy) Present(record *models.OMDbResponse) *models.OMDbResponse {
+	if (p != NAPolicyKeep && p != NAPolicyNull) || len(record.Unavailable) == 0 {
+		return record
+	}
+
+	presented := *record
+	presented.Ratings = append([]models.Rating(nil), record.Ratings...)
+	fields := reflect.ValueOf(&presented).Elem()
+	for _, name := range record.Unavailable {
+		if source, ok := strings.CutPrefix(name, "Ratings."); ok {
+			presented.Ratings = append(presented.Ratings, models.Rating{Source: source, Value: NotAvailable})
+			continue
+		}
+		if field := fields.FieldByName(name); field.Kind() == reflect.String {
+			field.SetString(NotAvailable)
+		}
+	}
+	return &presented
+}
+
+func clearNotAvailable(value reflect.Value) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if !value.IsNil() {
+			clearNotAvailable(value.Elem())
+		}
+	case reflect.Struct:
+		if value.CanAddr() {
+			if record, ok := value.Addr().Interface().(*models.OMDbResponse); ok {
+				clearRecord(record)
+				return
+			}
+		}
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				clearNotAvailable(value.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if !value.CanAddr() {
+			return
+		}
+		if ratings, ok := value.Addr().Interface().(*[]models.Rating); ok {
+			*ratings, _ = dropUnavailableRatings(*ratings)
+			return
+		}
+		for i := 0; i < value.Len(); i++ {
+			clearNotAvailable(value.Index(i))
+		}
+	case reflect.String:
+		if value.String() == NotAvailable && value.CanSet() {
+			value.SetString("")
+		}
+	}
+}
+
+// clearRecord clears the placeholders of a title, noting the fields in Unavailable
+func clearRecord(record *models.OMDbResponse) {
+	record.Unavailable = nil
+	fields := reflect.ValueOf(record).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		if field := fields.Field(i); field.Kind() == reflect.String && field.String() == NotAvailable {
+			field.SetString("")
+			record.Unavailable = append(record.Unavailable, fields.Type().Field(i).Name)
+		}
+	}
+
+	var dropped []string
+	record.Ratings, dropped = dropUnavailableRatings(record.Ratings)
+	for _, source := range dropped {
+		record.Unavailable = append(record.Unavailable, "Ratings."+source)
+	}
+}
+
+// dropUnavailableRatings removes the ratings without a value, returning their sources
+func dropUnavailableRatings(ratings []models.Rating) ([]models.Rating, []string) {
+	var dropped []string
+	kept := ratings[:0]
+	for _, rating := range ratings {
+		if rating.Value == NotAvailable {
+			dropped = append(dropped, rating.Source)
+			continue
+		}
+		kept = append(kept, rating)
+	}
+	return kept, dropped
+}
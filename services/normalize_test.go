@@ -0,0 +1,125 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"movie-api-go/models"
+)
+
+func TestNAPolicyFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  NAPolicy
+	}{
+		{"", NAPolicyOmit},
+		{"omit", NAPolicyOmit},
+		{"keep", NAPolicyKeep},
+		{"null", NAPolicyNull},
+		{"NULL", NAPolicyOmit},
+		{"drop", NAPolicyOmit},
+	}
+	for _, tt := range tests {
+		t.Setenv("NA_POLICY", tt.value)
+		if got := naPolicyFromEnv(); got != tt.want {
+			t.Errorf("NA_POLICY=%q: got %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+// unavailableRecord is a title with some of its fields "N/A", as OMDb sends it
+func unavailableRecord() *models.OMDbResponse {
+	return &models.OMDbResponse{
+		Title:    "The Matrix Reloaded",
+		Year:     "2003",
+		Director: "Lana Wachowski, Lilly Wachowski",
+		Awards:   NotAvailable,
+		Poster:   NotAvailable,
+		Ratings: []models.Rating{
+			{Source: "Internet Movie Database", Value: "7.2/10"},
+			{Source: "Metacritic", Value: NotAvailable},
+		},
+		ImdbID:   "tt0234215",
+		Response: "True",
+	}
+}
+
+func TestNormalizeClearsNotAvailable(t *testing.T) {
+	for _, policy := range []NAPolicy{NAPolicyOmit, NAPolicyKeep, NAPolicyNull} {
+		t.Run(string(policy), func(t *testing.T) {
+			s := &OMDbService{NAPolicy: policy}
+			record := unavailableRecord()
+			s.normalize(record)
+
+			if record.Awards != "" || record.Poster != "" {
+				t.Errorf("Awards = %q, Poster = %q, want both cleared", record.Awards, record.Poster)
+			}
+			if record.Director != "Lana Wachowski, Lilly Wachowski" {
+				t.Errorf("Director = %q, want it unchanged", record.Director)
+			}
+			wantRatings := []models.Rating{{Source: "Internet Movie Database", Value: "7.2/10"}}
+			if !reflect.DeepEqual(record.Ratings, wantRatings) {
+				t.Errorf("Ratings = %v, want %v", record.Ratings, wantRatings)
+			}
+			wantUnavailable := []string{"Awards", "Poster", "Ratings.Metacritic"}
+			if !reflect.DeepEqual(record.Unavailable, wantUnavailable) {
+				t.Errorf("Unavailable = %v, want %v", record.Unavailable, wantUnavailable)
+			}
+		})
+	}
+}
+
+func TestNormalizeSearchResults(t *testing.T) {
+	s := &OMDbService{}
+	search := &models.SearchResponse{Search: []models.SearchResult{{Title: "The Matrix", Poster: NotAvailable}}}
+	s.normalize(search)
+
+	if search.Search[0].Poster != "" {
+		t.Errorf("Poster = %q, want it cleared", search.Search[0].Poster)
+	}
+}
+
+func TestNAPolicyPresent(t *testing.T) {
+	tests := []struct {
+		policy      NAPolicy
+		wantAwards  string
+		wantRatings []models.Rating
+	}{
+		{
+			policy:      NAPolicyOmit,
+			wantAwards:  "",
+			wantRatings: []models.Rating{{Source: "Internet Movie Database", Value: "7.2/10"}},
+		},
+		{
+			policy:      NAPolicyKeep,
+			wantAwards:  NotAvailable,
+			wantRatings: []models.Rating{{Source: "Internet Movie Database", Value: "7.2/10"}, {Source: "Metacritic", Value: NotAvailable}},
+		},
+		{
+			policy:      NAPolicyNull,
+			wantAwards:  NotAvailable,
+			wantRatings: []models.Rating{{Source: "Internet Movie Database", Value: "7.2/10"}, {Source: "Metacritic", Value: NotAvailable}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			record := unavailableRecord()
+			(&OMDbService{}).normalize(record)
+			presented := tt.policy.Present(record)
+
+			if presented.Awards != tt.wantAwards || presented.Poster != tt.wantAwards {
+				t.Errorf("Awards = %q, Poster = %q, want %q", presented.Awards, presented.Poster, tt.wantAwards)
+			}
+			if presented.Director != record.Director {
+				t.Errorf("Director = %q, want %q", presented.Director, record.Director)
+			}
+			if !reflect.DeepEqual(presented.Ratings, tt.wantRatings) {
+				t.Errorf("Ratings = %v, want %v", presented.Ratings, tt.wantRatings)
+			}
+			// The normalized record itself is left as it was
+			if record.Awards != "" || len(record.Ratings) != 1 {
+				t.Errorf("Present changed the record: Awards = %q, Ratings = %v", record.Awards, record.Ratings)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/notify"
+)
+
+// notificationTimeout bounds one delivery attempt to a webhook or Slack
+const notificationTimeout = 10 * time.Second
+
+var (
+	notifierOnce sync.Once
+	notifierErr  error
+	notifier     *notify.Dispatcher
+)
+
+// notifierFromEnv returns the dispatcher every feature sends notifications through. It
+// reads NOTIFY_ATTEMPTS (default 3), NOTIFY_BACKOFF_MS (default 1000) and NOTIFY_DRY_RUN
+// (default false, which logs notifications instead of sending them). Webhook and Slack
+// deliveries go through the WEBHOOK_ outbound settings. Email is enabled by SMTP_ADDR
+// (host:port) and SMTP_FROM, with optional SMTP_USERNAME and SMTP_PASSWORD.
+func notifierFromEnv() (*notify.Dispatcher, error) {
+	notifierOnce.Do(func() {
+		client, err := httpClientFromEnv("WEBHOOK")
+		if err != nil {
+			notifierErr = err
+			return
+		}
+		client.Timeout = notificationTimeout
+		if transport, ok := client.Transport.(*http.Transport); ok {
+			notify.GuardTransport(transport)
+		}
+
+		notifier = notify.NewDispatcher(envInt("NOTIFY_ATTEMPTS", 3), time.Duration(envInt("NOTIFY_BACKOFF_MS", 1000))*time.Millisecond)
+		notifier.DryRun = strings.EqualFold(strings.TrimSpace(os.Getenv("NOTIFY_DRY_RUN")), "true")
+		notifier.
+			Register(notify.KindWebhook, notify.WebhookChannel{Client: client}).
+			Register(notify.KindSlack, notify.SlackChannel{Client: client}).
+			Register(notify.KindLog, notify.LogChannel{})
+		if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+			notifier.Register(notify.KindEmail, notify.NewEmailChannel(addr, os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD")))
+		}
+	})
+	return notifier, notifierErr
+}
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,130 +12,415 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"movie-api-go/models"
 )
 
+const (
+	// maxEpisodeConcurrency bounds the parallel upstream calls of a single episode range request
+	maxEpisodeConcurrency = 5
+
+	// maxEnrichmentConcurrency bounds the parallel detail lookups used to enrich a list of titles
+	maxEnrichmentConcurrency = 5
+)
+
 type OMDbService struct {
-	APIKey  string
-	BaseURL string
-	Client  *http.Client
+	// APIKey is sent with every call; with Keys set, each call uses the key Keys picks
+	APIKey   string
+	Keys     *APIKeyPool
+	BaseURL  string
+	Client   *http.Client
+	NAPolicy NAPolicy
+	Limits   Limits
+	Cache    *DetailCache
+
+	// Shadow replays lookups against a secondary provider for comparison; nil when disabled
+	Shadow *Shadow
+
+	// FallbackURLs are alternative base URLs used when BaseURL fails
+	FallbackURLs []string
+
+	// HedgeAfter is how long to wait for OMDb before sending a duplicate request; zero disables hedging
+	HedgeAfter time.Duration
+
+	// Dictionary learns the titles seen in responses to correct misspelled search queries
+	Dictionary *TitleDictionary
+
+	// People learns the cast and crew names seen in responses for phonetic name matching
+	People *PersonIndex
+
+	// Genres is the genre taxonomy, counting the titles seen in responses per genre
+	Genres *GenreTaxonomy
+
+	// Stats counts upstream calls and API requests for the status page
+	Stats *StatusRecorder
+
+	// health scores the base URLs for failover
+	health *upstreamHealth
+
+	// Scheduler holds calls to the OMDB_MAX_RPS ceiling, releasing them by priority
+	Scheduler *RequestScheduler
+
+	// Drift records upstream payloads that no longer match the expected schema
+	Drift *SchemaDrift
+
+	// History snapshots fetched title records to show how they changed over time
+	History *TitleHistory
+
+	// Quarantine holds the titles that keep failing enrichment, skipped by background jobs
+	Quarantine *Quarantine
+
+	// Releases classifies titles by where they are in their release cycle
+	Releases ReleaseWindows
+
+	// AirDates places episode air dates in time
+	AirDates AirDates
+
+	// slots holds one token per in-flight upstream call when MaxConcurrency is set
+	slots chan struct{}
+
+	// scaling tracks the load signals reported to autoscalers
+	scaling ScalingMetrics
 }
 
-func NewOMDbService() *OMDbService {
-	return &OMDbService{
-		APIKey:  os.Getenv("OMDB_API_KEY"),
-		BaseURL: os.Getenv("OMDB_BASE_URL"),
-		Client:  &http.Client{},
+func NewOMDbService() (*OMDbService, error) {
+	client, err := httpClientFromEnv("OMDB")
+	if err != nil {
+		return nil, err
+	}
+	redis, err := redisFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := apiKeyPoolFromEnv()
+	service := &OMDbService{
+		APIKey:   keys.First(),
+		Keys:     keys,
+		BaseURL:  os.Getenv("OMDB_BASE_URL"),
+		Client:   client,
+		NAPolicy: naPolicyFromEnv(),
+		Limits:   limitsFromEnv(),
+		Cache:    detailCacheFromEnv(),
+
+		Dictionary: titleDictionaryFromEnv(),
+		People:     personIndexFromEnv(),
+		Stats:      newStatusRecorder(redis),
+		Scheduler:  schedulerFromEnv(),
+		Drift:      newSchemaDrift(),
+		Releases:   releaseWindowsFromEnv(),
+		AirDates:   airDatesFromEnv(),
+
+		FallbackURLs: fallbackURLsFromEnv(),
+		HedgeAfter:   time.Duration(envInt("OMDB_HEDGE_AFTER_MS", 0)) * time.Millisecond,
+
+		health: newUpstreamHealth(),
 	}
+	if keys.Len() > 1 {
+		// OMDB_DAILY_LIMIT is per key
+		service.Stats.DailyLimit *= keys.Len()
+	}
+	if service.Limits.MaxConcurrency > 0 {
+		service.slots = make(chan struct{}, service.Limits.MaxConcurrency)
+	}
+
+	service.Cache.Shared, err = shardedCacheFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	service.Genres, err = newGenreTaxonomy()
+	if err != nil {
+		return nil, err
+	}
+
+	service.History, err = newTitleHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	service.Quarantine, err = newQuarantine()
+	if err != nil {
+		return nil, err
+	}
+
+	service.Shadow, err = shadowFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
 }
 
 // GetMovieByTitle fetches movie details by title
-func (s *OMDbService) GetMovieByTitle(title string) (*models.OMDbResponse, error) {
+func (s *OMDbService) GetMovieByTitle(ctx context.Context, title string) (*models.OMDbResponse, error) {
+	return s.GetTitle(ctx, title, "", "movie")
+}
+
+// GetTitle fetches a title of the given type (any type when empty), optionally narrowed by year
+func (s *OMDbService) GetTitle(ctx context.Context, title, year, titleType string) (*models.OMDbResponse, error) {
+	return s.makeRequest(ctx, s.titleParams(title, year, titleType))
+}
+
+// titleParams are the parameters of a title lookup
+func (s *OMDbService) titleParams(title, year, titleType string) url.Values {
 	params := url.Values{}
 	params.Add("apikey", s.APIKey)
 	params.Add("t", title)
-	params.Add("type", "movie")
+	if year != "" {
+		params.Add("y", year)
+	}
+	if titleType != "" {
+		params.Add("type", titleType)
+	}
+	return params
+}
 
-	return s.makeRequest(params)
+// GetTitleByID fetches any title (movie, series, episode or game) by its IMDb ID.
+// Background lookups of a quarantined title fail with ErrQuarantined without a call.
+func (s *OMDbService) GetTitleByID(ctx context.Context, imdbID string) (*models.OMDbResponse, error) {
+	if err := s.Quarantine.skip(ctx, imdbID); err != nil {
+		return nil, err
+	}
+
+	record, err := s.makeRequest(ctx, s.idParams(imdbID))
+	if err == nil && record.Response != "False" {
+		s.Quarantine.Succeeded(imdbID)
+	}
+	return record, err
 }
 
-// GetEpisodeDetails fetches TV episode details
-func (s *OMDbService) GetEpisodeDetails(seriesTitle string, season, episode int) (*models.OMDbResponse, error) {
+// idParams are the parameters of an IMDb ID lookup
+func (s *OMDbService) idParams(imdbID string) url.Values {
 	params := url.Values{}
 	params.Add("apikey", s.APIKey)
-	params.Add("t", seriesTitle)
+	params.Add("i", imdbID)
+	return params
+}
+
+// enrichmentFailed reports a title that failed enrichment in the request's errors and
+// counts the failure toward its quarantine, once per request
+func (s *OMDbService) enrichmentFailed(ctx context.Context, imdbID, title string, err error) {
+	if ScopeFrom(ctx).failItem(imdbID, title, err) {
+		s.Quarantine.Failed(imdbID, title, err)
+	}
+}
+
+// GetTitlesByID fetches the full records of several titles concurrently, at most
+// maxEnrichmentConcurrency at a time. Titles that can't be fetched are left out of the result;
+// those that failed with an error or a panic are reported as failed items of the request.
+func (s *OMDbService) GetTitlesByID(ctx context.Context, imdbIDs []string) map[string]*models.OMDbResponse {
+	records := make(map[string]*models.OMDbResponse)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxEnrichmentConcurrency)
+
+	for _, imdbID := range imdbIDs {
+		wg.Add(1)
+		go func(imdbID string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			var record *models.OMDbResponse
+			err := isolate(func() (err error) {
+				record, err = s.GetTitleByID(ctx, imdbID)
+				return err
+			})
+			if err != nil {
+				s.enrichmentFailed(ctx, imdbID, "", err)
+				return
+			}
+			if record.Response == "False" {
+				s.Quarantine.Failed(imdbID, "", ErrNotFound)
+				return
+			}
+
+			mu.Lock()
+			records[imdbID] = record
+			mu.Unlock()
+		}(imdbID)
+	}
+	wg.Wait()
+
+	return records
+}
+
+// GetTotalSeasons looks up the season count of each series concurrently.
+// Series whose details can't be fetched are left out of the result.
+func (s *OMDbService) GetTotalSeasons(ctx context.Context, imdbIDs []string) map[string]int {
+	totalSeasons := make(map[string]int)
+	for imdbID, series := range s.GetTitlesByID(ctx, imdbIDs) {
+		if seasons, err := strconv.Atoi(series.TotalSeasons); err == nil {
+			totalSeasons[imdbID] = seasons
+		}
+	}
+	return totalSeasons
+}
+
+// GetEpisodeDetails fetches TV episode details of the series with the given IMDb ID
+func (s *OMDbService) GetEpisodeDetails(ctx context.Context, seriesID string, season, episode int) (*models.OMDbResponse, error) {
+	params := url.Values{}
+	params.Add("apikey", s.APIKey)
+	params.Add("i", seriesID)
 	params.Add("Season", strconv.Itoa(season))
 	params.Add("Episode", strconv.Itoa(episode))
 
-	return s.makeRequest(params)
+	return s.makeRequest(ctx, params)
 }
 
-// SearchMoviesByGenre searches for movies by genre and returns top 15 by IMDb rating
-func (s *OMDbService) SearchMoviesByGenre(genre string) ([]models.MovieBrief, error) {
-	var allMovies []models.MovieBrief
-	
+// GetEpisodeRange fetches episodes from through to of a season concurrently.
+// The result is indexed by episode offset; episodes that OMDb doesn't know or that
+// failed to load are nil, and the first upstream error is returned with the partial result.
+// Failed episodes are reported as failed items of the request.
+func (s *OMDbService) GetEpisodeRange(ctx context.Context, seriesID string, season, from, to int) ([]*models.OMDbResponse, error) {
+	episodes := make([]*models.OMDbResponse, to-from+1)
+	errs := make([]error, len(episodes))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxEpisodeConcurrency)
+
+	for i := range episodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			var episode *models.OMDbResponse
+			err := isolate(func() (err error) {
+				episode, err = s.GetEpisodeDetails(ctx, seriesID, season, from+i)
+				return err
+			})
+			if err != nil {
+				errs[i] = err
+				ScopeFrom(ctx).failItem(fmt.Sprintf("%s S%dE%d", seriesID, season, from+i), "", err)
+				return
+			}
+			if episode.Response != "False" {
+				episodes[i] = episode
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return episodes, err
+		}
+	}
+	return episodes, nil
+}
+
+// SearchMoviesByGenre searches for movies by genre and returns every match, sorted by IMDb
+// rating. The genre endpoint orders them as requested and serves the top GenreListSize.
+func (s *OMDbService) SearchMoviesByGenre(ctx context.Context, genre string) ([]models.MovieBrief, error) {
+	collected := getBriefs()
+	defer putBriefs(collected)
+	allMovies := *collected
+
 	// Search with different popular movie titles to find movies of the specified genre
 	searchTerms := []string{
 		genre,
 		fmt.Sprintf("%s movie", genre),
 		fmt.Sprintf("best %s", genre),
 	}
-	
+
 	// Also search by year to get more diverse results
 	currentYear := 2024
 	for year := currentYear; year >= currentYear-10; year-- {
 		searchTerms = append(searchTerms, fmt.Sprintf("%s %d", genre, year))
 	}
-	
+
+	if limit := s.Limits.MaxSearchTerms; limit > 0 && len(searchTerms) > limit {
+		ScopeFrom(ctx).truncate("max_search_terms", limit, fmt.Sprintf("searched %d of %d terms", limit, len(searchTerms)))
+		searchTerms = searchTerms[:limit]
+	}
+
+	explain := ExplainFrom(ctx)
+	step := explain.Begin(ctx)
+	var searched []string
 	for _, term := range searchTerms {
-		movies, err := s.searchMovies(term, genre)
+		searched = append(searched, term)
+		movies, err := s.searchMovies(ctx, term, genre)
+		if errors.Is(err, ErrUpstreamQuota) {
+			return nil, err
+		}
+		if errors.Is(err, ErrCallBudgetExceeded) {
+			allMovies = append(allMovies, movies...)
+			break
+		}
 		if err != nil {
 			continue
 		}
 		allMovies = append(allMovies, movies...)
-		
+
 		// Stop if we have enough movies
 		if len(allMovies) >= 50 {
 			break
 		}
 	}
-	
+	step.End(models.ExplainStage{
+		Stage:      "search",
+		Strategy:   "genre_terms",
+		Provider:   "omdb",
+		Terms:      searched,
+		Filters:    []string{"genre includes " + genre},
+		Candidates: len(allMovies),
+	})
+
+	*collected = allMovies
+
 	// Remove duplicates and filter by genre
 	uniqueMovies := s.removeDuplicatesAndFilter(allMovies, genre)
-	
-	// Sort by IMDb rating
-	sort.Slice(uniqueMovies, func(i, j int) bool {
-		ratingI, _ := strconv.ParseFloat(uniqueMovies[i].ImdbRating, 64)
-		ratingJ, _ := strconv.ParseFloat(uniqueMovies[j].ImdbRating, 64)
-		return ratingI > ratingJ
+	explain.Add(models.ExplainStage{
+		Stage:      "dedupe",
+		Strategy:   "imdb_id",
+		Filters:    []string{"genre includes " + genre, "imdb_rating > 0"},
+		Input:      len(allMovies),
+		Candidates: len(uniqueMovies),
 	})
-	
-	// Return top 15
-	if len(uniqueMovies) > 15 {
-		uniqueMovies = uniqueMovies[:15]
-	}
-	
+
+	// Sort by IMDb rating; callers pick the top GenreListSize in the order they serve
+	SortBriefs(uniqueMovies, SortRating)
+
 	return uniqueMovies, nil
 }
 
-// GetMovieRecommendations generates movie recommendations based on favorite movie
-func (s *OMDbService) GetMovieRecommendations(favoriteTitle string) (*models.RecommendationResponse, error) {
-	// Get favorite movie details
-	favoriteMovie, err := s.GetMovieByTitle(favoriteTitle)
-	if err != nil {
-		return nil, err
-	}
-	
-	if favoriteMovie.Response == "False" {
-		return nil, fmt.Errorf("movie not found: %s", favoriteTitle)
-	}
-	
+// GetMovieRecommendations generates movie recommendations based on the resolved favorite movie
+func (s *OMDbService) GetMovieRecommendations(ctx context.Context, favoriteMovie *models.OMDbResponse) (*models.RecommendationResponse, error) {
 	response := &models.RecommendationResponse{
-		FavoriteMovie: models.MovieBrief{
-			Title:      favoriteMovie.Title,
-			Year:       favoriteMovie.Year,
-			ImdbRating: favoriteMovie.ImdbRating,
-			Genre:      favoriteMovie.Genre,
-			Director:   favoriteMovie.Director,
-			Plot:       favoriteMovie.Plot,
-		},
+		FavoriteMovie:   newMovieBrief(favoriteMovie),
 		Recommendations: []models.MovieLevel{},
 	}
-	
+
+	// Candidates for each level are collected in a pooled buffer; only the deduplicated
+	// picks are copied into the response
+	collected := getBriefs()
+	defer putBriefs(collected)
+
+	explain := ExplainFrom(ctx)
+
 	// Level 1: Genre-based recommendations
 	genres := strings.Split(favoriteMovie.Genre, ", ")
-	var level1Movies []models.MovieBrief
-	
+	level1Movies := (*collected)[:0]
+
+	step := explain.Begin(ctx)
 	for _, genre := range genres {
-		movies, err := s.searchMoviesForRecommendation(genre, favoriteTitle)
+		movies, err := s.searchMoviesForRecommendation(ctx, genre, favoriteMovie)
 		if err != nil {
 			continue
 		}
 		level1Movies = append(level1Movies, movies...)
 	}
-	
+	step.End(models.ExplainStage{Stage: "search", Strategy: "level_1_genre", Provider: "omdb", Terms: genres, Candidates: len(level1Movies)})
+
+	*collected = level1Movies
+	candidates := len(level1Movies)
 	level1Movies = s.removeDuplicatesAndLimit(level1Movies, 20)
+	explain.Add(models.ExplainStage{Stage: "rank", Strategy: "level_1: -imdb_rating, top 20", Filters: []string{"imdb_rating > 0"}, Input: candidates, Candidates: len(level1Movies)})
 	if len(level1Movies) > 0 {
 		response.Recommendations = append(response.Recommendations, models.MovieLevel{
 			Level:       1,
@@ -141,22 +428,29 @@ func (s *OMDbService) GetMovieRecommendations(favoriteTitle string) (*models.Rec
 			Movies:      level1Movies,
 		})
 	}
-	
+
 	// Level 2: Director-based recommendations
 	directors := strings.Split(favoriteMovie.Director, ", ")
-	var level2Movies []models.MovieBrief
-	
+	level2Movies := (*collected)[:0]
+
+	step = explain.Begin(ctx)
+	var terms []string
 	for _, director := range directors {
-		if director != "N/A" && director != "" {
-			movies, err := s.searchMoviesForRecommendation(director, favoriteTitle)
+		if director != "" {
+			terms = append(terms, director)
+			movies, err := s.searchMoviesForRecommendation(ctx, director, favoriteMovie)
 			if err != nil {
 				continue
 			}
 			level2Movies = append(level2Movies, movies...)
 		}
 	}
-	
+	step.End(models.ExplainStage{Stage: "search", Strategy: "level_2_director", Provider: "omdb", Terms: terms, Candidates: len(level2Movies)})
+
+	*collected = level2Movies
+	candidates = len(level2Movies)
 	level2Movies = s.removeDuplicatesAndLimit(level2Movies, 20)
+	explain.Add(models.ExplainStage{Stage: "rank", Strategy: "level_2: -imdb_rating, top 20", Filters: []string{"imdb_rating > 0"}, Input: candidates, Candidates: len(level2Movies)})
 	if len(level2Movies) > 0 {
 		response.Recommendations = append(response.Recommendations, models.MovieLevel{
 			Level:       2,
@@ -164,25 +458,32 @@ func (s *OMDbService) GetMovieRecommendations(favoriteTitle string) (*models.Rec
 			Movies:      level2Movies,
 		})
 	}
-	
+
 	// Level 3: Actor-based recommendations
 	actors := strings.Split(favoriteMovie.Actors, ", ")
-	var level3Movies []models.MovieBrief
-	
+	level3Movies := (*collected)[:0]
+
+	step = explain.Begin(ctx)
+	terms = nil
 	for i, actor := range actors {
 		if i >= 2 { // Only use first 2 main actors
 			break
 		}
-		if actor != "N/A" && actor != "" {
-			movies, err := s.searchMoviesForRecommendation(actor, favoriteTitle)
+		if actor != "" {
+			terms = append(terms, actor)
+			movies, err := s.searchMoviesForRecommendation(ctx, actor, favoriteMovie)
 			if err != nil {
 				continue
 			}
 			level3Movies = append(level3Movies, movies...)
 		}
 	}
-	
+	step.End(models.ExplainStage{Stage: "search", Strategy: "level_3_actor", Provider: "omdb", Terms: terms, Candidates: len(level3Movies)})
+
+	*collected = level3Movies
+	candidates = len(level3Movies)
 	level3Movies = s.removeDuplicatesAndLimit(level3Movies, 20)
+	explain.Add(models.ExplainStage{Stage: "rank", Strategy: "level_3: -imdb_rating, top 20", Filters: []string{"imdb_rating > 0"}, Input: candidates, Candidates: len(level3Movies)})
 	if len(level3Movies) > 0 {
 		response.Recommendations = append(response.Recommendations, models.MovieLevel{
 			Level:       3,
@@ -190,154 +491,268 @@ func (s *OMDbService) GetMovieRecommendations(favoriteTitle string) (*models.Rec
 			Movies:      level3Movies,
 		})
 	}
-	
+
 	return response, nil
 }
 
 // Helper methods
 
-func (s *OMDbService) makeRequest(params url.Values) (*models.OMDbResponse, error) {
-	reqURL := fmt.Sprintf("%s?%s", s.BaseURL, params.Encode())
-	
-	resp, err := s.Client.Get(reqURL)
+func (s *OMDbService) makeRequest(ctx context.Context, params url.Values) (*models.OMDbResponse, error) {
+	body, err := s.fetchCached(ctx, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-	
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
-	
+
 	var omdbResp models.OMDbResponse
 	if err := json.Unmarshal(body, &omdbResp); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
-	
+
+	s.normalize(&omdbResp)
+	if omdbResp.Response == "True" {
+		s.Dictionary.Learn(omdbResp.Title)
+		s.People.LearnRecord(&omdbResp)
+		s.Genres.LearnRecord(&omdbResp)
+	}
+
+	primary := omdbResp
+	s.Shadow.replay(params, &primary, s.normalize)
+
 	return &omdbResp, nil
 }
 
-func (s *OMDbService) searchMovies(searchTerm, targetGenre string) ([]models.MovieBrief, error) {
-	params := url.Values{}
-	params.Add("apikey", s.APIKey)
-	params.Add("s", searchTerm)
-	params.Add("type", "movie")
-	
-	reqURL := fmt.Sprintf("%s?%s", s.BaseURL, params.Encode())
-	
-	resp, err := s.Client.Get(reqURL)
+// fetch performs one OMDb call within the request's call budget and the global concurrency limit
+func (s *OMDbService) fetch(ctx context.Context, params url.Values) ([]byte, error) {
+	if err := ScopeFrom(ctx).chargeCall(); err != nil {
+		return nil, err
+	}
+
+	if err := s.Scheduler.Wait(ctx); err != nil {
+		return nil, err
+	}
+	release, err := s.acquireSlot(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
+
+	baseURLs := s.baseURLs()
+	if len(baseURLs) > 1 {
+		baseURLs = s.health.order(baseURLs)
+	}
+
+	// Fail over to the next base URL on network errors and server errors
+	var lastErr error
+	for i, baseURL := range baseURLs {
+		if i > 0 {
+			if ctx.Err() != nil {
+				break
+			}
+			if err := ScopeFrom(ctx).chargeCall(); err != nil {
+				break
+			}
+			if err := s.Scheduler.Wait(ctx); err != nil {
+				break
+			}
+		}
+
+		body, unreachable, err := s.call(ctx, baseURL, params)
+		if !unreachable {
+			return body, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// call sends a lookup to one base URL; unreachable is set for the errors after which the
+// next base URL is tried. With several API keys, a call rejected because its key reached
+// the daily limit or is invalid is repeated with the next key.
+func (s *OMDbService) call(ctx context.Context, baseURL string, params url.Values) (body []byte, unreachable bool, err error) {
+	for {
+		query := params
+		key := s.Keys.Next()
+		if key != "" {
+			query = url.Values{}
+			for name, values := range params {
+				query[name] = values
+			}
+			query.Set("apikey", key)
+		}
+
+		start := time.Now()
+		reqURL := fmt.Sprintf("%s?%s", baseURL, query.Encode())
+		body, err = s.send(ctx, reqURL)
+		s.health.record(baseURL, err)
+		if err != nil {
+			s.Stats.recordUpstream(time.Since(start), err)
+			TraceFrom(ctx).recordUpstream(reqURL, start, nil, err)
+			return nil, true, err
+		}
+		err = upstreamError(body)
+		s.Stats.recordUpstream(time.Since(start), err)
+		TraceFrom(ctx).recordUpstream(reqURL, start, body, err)
+		if s.Keys.record(key, err) && ScopeFrom(ctx).chargeCall() == nil && s.Scheduler.Wait(ctx) == nil {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		s.Drift.Check(params, body)
+		return body, false, nil
+	}
+}
+
+// send performs the GET request, hedged if configured
+func (s *OMDbService) send(ctx context.Context, reqURL string) ([]byte, error) {
+	if s.HedgeAfter > 0 {
+		return s.hedgedGet(ctx, reqURL)
+	}
+	return s.get(ctx, reqURL)
+}
+
+// get performs a single GET request and returns the response body
+func (s *OMDbService) get(ctx context.Context, reqURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", redactURLError(err))
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", redactURLError(err))
+	}
 	defer resp.Body.Close()
-	
+
+	// OMDb reports lookup errors in a JSON body; a server error means the host itself is failing
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("%w: status %d", errUpstreamStatus, resp.StatusCode)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
-	
-	var searchResp models.SearchResponse
-	if err := json.Unmarshal(body, &searchResp); err != nil {
+
+	return body, nil
+}
+
+func (s *OMDbService) searchMovies(ctx context.Context, searchTerm, targetGenre string) ([]models.MovieBrief, error) {
+	searchResp, err := s.searchPage(ctx, searchTerm, "movie", "", 1)
+	if err != nil {
 		return nil, err
 	}
-	
+
 	if searchResp.Response == "False" {
 		return []models.MovieBrief{}, nil
 	}
-	
+
 	var movies []models.MovieBrief
 	for _, result := range searchResp.Search {
 		// Get detailed info for each movie
-		movieDetails, err := s.GetMovieByTitle(result.Title)
+		movieDetails, err := s.GetTitleByID(WithPriority(ctx, PriorityEnrichment), result.ImdbID)
+		if errors.Is(err, ErrCallBudgetExceeded) {
+			// Keep what has been collected; the truncation is reported in the response meta
+			break
+		}
 		if err != nil {
+			s.enrichmentFailed(ctx, result.ImdbID, result.Title, err)
 			continue
 		}
-		
+
 		if movieDetails.Response == "False" {
+			s.enrichmentFailed(ctx, result.ImdbID, result.Title, ErrNotFound)
 			continue
 		}
-		
+
 		// Check if movie contains the target genre
 		if strings.Contains(strings.ToLower(movieDetails.Genre), strings.ToLower(targetGenre)) {
-			movies = append(movies, models.MovieBrief{
-				Title:      movieDetails.Title,
-				Year:       movieDetails.Year,
-				ImdbRating: movieDetails.ImdbRating,
-				Genre:      movieDetails.Genre,
-				Director:   movieDetails.Director,
-				Plot:       movieDetails.Plot,
-			})
+			movies = append(movies, newMovieBrief(movieDetails))
 		}
 	}
-	
+
 	return movies, nil
 }
 
-func (s *OMDbService) searchMoviesForRecommendation(searchTerm, excludeTitle string) ([]models.MovieBrief, error) {
-	params := url.Values{}
-	params.Add("apikey", s.APIKey)
-	params.Add("s", searchTerm)
-	params.Add("type", "movie")
-	
-	reqURL := fmt.Sprintf("%s?%s", s.BaseURL, params.Encode())
-	
-	resp, err := s.Client.Get(reqURL)
+func (s *OMDbService) searchMoviesForRecommendation(ctx context.Context, searchTerm string, exclude *models.OMDbResponse) ([]models.MovieBrief, error) {
+	searchResp, err := s.searchPage(ctx, searchTerm, "movie", "", 1)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	
-	var searchResp models.SearchResponse
-	if err := json.Unmarshal(body, &searchResp); err != nil {
-		return nil, err
-	}
-	
+
 	if searchResp.Response == "False" {
 		return []models.MovieBrief{}, nil
 	}
-	
+
 	var movies []models.MovieBrief
 	for _, result := range searchResp.Search {
 		// Skip the original movie
-		if strings.EqualFold(result.Title, excludeTitle) {
+		if result.ImdbID == exclude.ImdbID {
 			continue
 		}
-		
+
 		// Get detailed info for each movie
-		movieDetails, err := s.GetMovieByTitle(result.Title)
+		movieDetails, err := s.GetTitleByID(WithPriority(ctx, PriorityEnrichment), result.ImdbID)
+		if errors.Is(err, ErrCallBudgetExceeded) {
+			// Keep what has been collected; the truncation is reported in the response meta
+			break
+		}
 		if err != nil {
+			s.enrichmentFailed(ctx, result.ImdbID, result.Title, err)
 			continue
 		}
-		
+
 		if movieDetails.Response == "False" {
+			s.enrichmentFailed(ctx, result.ImdbID, result.Title, ErrNotFound)
 			continue
 		}
-		
-		movies = append(movies, models.MovieBrief{
-			Title:      movieDetails.Title,
-			Year:       movieDetails.Year,
-			ImdbRating: movieDetails.ImdbRating,
-			Genre:      movieDetails.Genre,
-			Director:   movieDetails.Director,
-			Plot:       movieDetails.Plot,
-		})
+
+		movies = append(movies, newMovieBrief(movieDetails))
 	}
-	
+
 	return movies, nil
 }
 
+// newMovieBrief summarizes a title for genre and recommendation lists. The poster is
+// OMDb's URL, left out when OMDb has none.
+func newMovieBrief(movie *models.OMDbResponse) models.MovieBrief {
+	return models.MovieBrief{
+		Title:      movie.Title,
+		Year:       movie.Year,
+		ImdbID:     movie.ImdbID,
+		Type:       movie.Type,
+		Poster:     movie.Poster,
+		ImdbRating: movie.ImdbRating,
+		Genre:      movie.Genre,
+		Director:   movie.Director,
+		Plot:       movie.Plot,
+		Rated:      movie.Rated,
+		Runtime:    movie.Runtime,
+		Language:   movie.Language,
+
+		RuntimeSeconds: RuntimeSeconds(movie.Runtime),
+		VoteCount:      VoteCount(movie.ImdbVotes),
+		Metascore:      Metascore(movie.Metascore),
+		RottenTomatoes: RottenTomatoes(movie.Ratings),
+		Completeness:   Completeness(movie),
+	}
+}
+
+// briefKey identifies a movie for deduplication: its IMDb ID, so remakes and editions
+// sharing a title and year stay distinct, or else its title and year
+func briefKey(movie models.MovieBrief) string {
+	if movie.ImdbID != "" {
+		return movie.ImdbID
+	}
+	return titleYearKey(movie.Title, movie.Year)
+}
+
 func (s *OMDbService) removeDuplicatesAndFilter(movies []models.MovieBrief, targetGenre string) []models.MovieBrief {
 	seen := make(map[string]bool)
 	var unique []models.MovieBrief
-	
+
 	for _, movie := range movies {
-		key := strings.ToLower(movie.Title + movie.Year)
+		key := briefKey(movie)
 		if !seen[key] && strings.Contains(strings.ToLower(movie.Genre), strings.ToLower(targetGenre)) {
 			// Only include movies with valid IMDb ratings
 			if rating, err := strconv.ParseFloat(movie.ImdbRating, 64); err == nil && rating > 0 {
@@ -346,35 +761,35 @@ func (s *OMDbService) removeDuplicatesAndFilter(movies []models.MovieBrief, targ
 			}
 		}
 	}
-	
+
 	return unique
 }
 
 func (s *OMDbService) removeDuplicatesAndLimit(movies []models.MovieBrief, limit int) []models.MovieBrief {
 	seen := make(map[string]bool)
 	var unique []models.MovieBrief
-	
+
 	// Sort by IMDb rating first
 	sort.Slice(movies, func(i, j int) bool {
 		ratingI, _ := strconv.ParseFloat(movies[i].ImdbRating, 64)
 		ratingJ, _ := strconv.ParseFloat(movies[j].ImdbRating, 64)
 		return ratingI > ratingJ
 	})
-	
+
 	for _, movie := range movies {
-		key := strings.ToLower(movie.Title + movie.Year)
+		key := briefKey(movie)
 		if !seen[key] {
 			// Only include movies with valid IMDb ratings
 			if rating, err := strconv.ParseFloat(movie.ImdbRating, 64); err == nil && rating > 0 {
 				seen[key] = true
 				unique = append(unique, movie)
-				
+
 				if len(unique) >= limit {
 					break
 				}
 			}
 		}
 	}
-	
+
 	return unique
 }
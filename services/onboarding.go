@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"movie-api-go/models"
+)
+
+//go:embed data/onboarding.json
+var onboardingData []byte
+
+const (
+	// onboardingLikedRating is the lowest rating that makes a title a recommendation seed
+	onboardingLikedRating = 6
+
+	// onboardingMaxSeeds bounds the titles recommendations are generated from, so that a
+	// large first batch of ratings doesn't fan out into too many upstream calls
+	onboardingMaxSeeds = 3
+
+	// onboardingMaxRecommendations is the size of the initial recommendation set
+	onboardingMaxRecommendations = 20
+)
+
+// onboardingTitle is an entry of the curated sample in data/onboarding.json
+type onboardingTitle struct {
+	ImdbID string `json:"imdb_id"`
+	Title  string `json:"title"`
+}
+
+// Onboarding offers new users a diverse sample of well-known movies to rate and turns
+// their first ratings into recommendations
+type Onboarding struct {
+	omdb        *OMDbService
+	recommended *RecommendationCache
+	ratings     *RatingStore
+
+	// genres maps each genre of the curated sample to its titles
+	genres map[string][]onboardingTitle
+}
+
+func NewOnboarding(omdb *OMDbService, recommended *RecommendationCache, ratings *RatingStore) (*Onboarding, error) {
+	var genres map[string][]onboardingTitle
+	if err := json.Unmarshal(onboardingData, &genres); err != nil {
+		return nil, fmt.Errorf("invalid onboarding titles: %w", err)
+	}
+
+	return &Onboarding{
+		omdb:        omdb,
+		recommended: recommended,
+		ratings:     ratings,
+		genres:      genres,
+	}, nil
+}
+
+// Titles returns count titles drawn round-robin from the curated genres, so that every
+// genre is represented before any repeats, in random order within each genre
+func (o *Onboarding) Titles(ctx context.Context, count int) []models.OnboardingTitle {
+	names := make([]string, 0, len(o.genres))
+	pools := make(map[string][]onboardingTitle, len(o.genres))
+	for genre, titles := range o.genres {
+		names = append(names, genre)
+		pool := append([]onboardingTitle(nil), titles...)
+		rand.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+		pools[genre] = pool
+	}
+	rand.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+
+	var picked []onboardingTitle
+	for round := 0; len(picked) < count; round++ {
+		added := false
+		for _, genre := range names {
+			if round < len(pools[genre]) && len(picked) < count {
+				picked = append(picked, pools[genre][round])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+
+	imdbIDs := make([]string, len(picked))
+	for i, title := range picked {
+		imdbIDs[i] = title.ImdbID
+	}
+	records := o.omdb.GetTitlesByID(WithPriority(ctx, PriorityEnrichment), imdbIDs)
+
+	titles := make([]models.OnboardingTitle, 0, len(picked))
+	for _, title := range picked {
+		entry := models.OnboardingTitle{ImdbID: title.ImdbID, Title: title.Title}
+		if record, ok := records[title.ImdbID]; ok {
+			entry.Title = record.Title
+			entry.Year = record.Year
+			entry.Genre = record.Genre
+			entry.Poster = record.Poster
+		}
+		titles = append(titles, entry)
+	}
+	return titles
+}
+
+// Size is the number of titles in the curated sample
+func (o *Onboarding) Size() int {
+	size := 0
+	for _, titles := range o.genres {
+		size += len(titles)
+	}
+	return size
+}
+
+// Rate stores a user's first ratings and returns recommendations seeded by the best
+// rated titles: the v2 engine's results for each seed are merged, weighted by the seed's
+// rating and the rank within its results, leaving out titles the user has rated
+func (o *Onboarding) Rate(ctx context.Context, userID string, ratings []models.UserRating) (*models.OnboardingResponse, error) {
+	stored, err := o.ratings.SetMany(userID, ratings)
+	if err != nil {
+		return nil, err
+	}
+
+	liked := make([]models.UserRating, 0, len(stored))
+	for _, rating := range stored {
+		if rating.Rating >= onboardingLikedRating {
+			liked = append(liked, rating)
+		}
+	}
+	sort.SliceStable(liked, func(i, j int) bool { return liked[i].Rating > liked[j].Rating })
+	if len(liked) > onboardingMaxSeeds {
+		liked = liked[:onboardingMaxSeeds]
+	}
+
+	response := &models.OnboardingResponse{
+		Stored:          len(stored),
+		Seeds:           []models.MovieBrief{},
+		Recommendations: []models.MovieBrief{},
+	}
+
+	// The submitted titles were usually just fetched for the onboarding sample, so their
+	// records come from the detail cache
+	imdbIDs := make([]string, len(stored))
+	for i, rating := range stored {
+		imdbIDs[i] = rating.ImdbID
+	}
+	ratedTitles := make(map[string]bool)
+	for _, record := range o.omdb.GetTitlesByID(ctx, imdbIDs) {
+		ratedTitles[titleYearKey(record.Title, record.Year)] = true
+	}
+
+	type candidate struct {
+		movie models.MovieBrief
+		score float64
+	}
+	candidates := make(map[string]*candidate)
+	for _, seed := range liked {
+		record, err := o.omdb.GetTitleByID(ctx, seed.ImdbID)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, ErrUpstreamQuota) {
+				return nil, err
+			}
+			continue
+		}
+		if record.Response == "False" {
+			continue
+		}
+		response.Seeds = append(response.Seeds, newMovieBrief(record))
+
+		recommendations, err := o.recommended.Recommend(ctx, record.ImdbID, RecommendationsV2, func(ctx context.Context) (*models.RecommendationResponse, error) {
+			return o.omdb.GetMovieRecommendationsV2(ctx, record)
+		})
+		if err != nil {
+			continue
+		}
+		for _, level := range recommendations.Recommendations {
+			for rank, movie := range level.Movies {
+				key := titleYearKey(movie.Title, movie.Year)
+				c, ok := candidates[key]
+				if !ok {
+					c = &candidate{movie: movie}
+					candidates[key] = c
+				}
+				c.score += float64(seed.Rating) / 10 * float64(len(level.Movies)-rank) / float64(len(level.Movies))
+			}
+		}
+	}
+
+	ranked := make([]*candidate, 0, len(candidates))
+	for key, c := range candidates {
+		if !ratedTitles[key] {
+			ranked = append(ranked, c)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].movie.Title < ranked[j].movie.Title
+	})
+	for _, c := range ranked {
+		if len(response.Recommendations) == onboardingMaxRecommendations {
+			break
+		}
+		response.Recommendations = append(response.Recommendations, c.movie)
+	}
+	return response, nil
+}
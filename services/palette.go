@@ -0,0 +1,178 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"sort"
+	"sync"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+const (
+	// paletteSampleSide bounds the sampled grid of a poster to paletteSampleSide squared pixels
+	paletteSampleSide = 64
+	// paletteIterations bounds the k-means rounds
+	paletteIterations = 12
+)
+
+// PaletteStore keeps the dominant colors computed from posters, persisted as a JSON file.
+// Posters rarely change, so palettes are kept until the file is removed.
+type PaletteStore struct {
+	// Size is the number of colors in a palette; zero disables palettes
+	Size int
+
+	path string
+
+	mu       sync.Mutex
+	palettes map[string][]models.PosterColor
+}
+
+// NewPaletteStore loads the palettes from POSTER_PALETTES_PATH (default
+// data/palettes.json) and reads POSTER_PALETTE_SIZE (default 5)
+func NewPaletteStore() (*PaletteStore, error) {
+	path := os.Getenv("POSTER_PALETTES_PATH")
+	if path == "" {
+		path = "data/palettes.json"
+	}
+
+	s := &PaletteStore{
+		Size:     min(envInt("POSTER_PALETTE_SIZE", 5), 16),
+		path:     path,
+		palettes: make(map[string][]models.PosterColor),
+	}
+	if err := store.LoadJSON(path, &s.palettes); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Enabled reports whether palettes are computed
+func (s *PaletteStore) Enabled() bool {
+	return s != nil && s.Size > 0
+}
+
+// Get returns the palette computed for a title's poster
+func (s *PaletteStore) Get(imdbID string) ([]models.PosterColor, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	palette, ok := s.palettes[imdbID]
+	return palette, ok
+}
+
+// Compute extracts the palette of a title's poster and keeps it
+func (s *PaletteStore) Compute(imdbID string, img image.Image) error {
+	palette := extractPalette(img, s.Size)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.palettes[imdbID] = palette
+	return store.SaveJSON(s.path, s.palettes)
+}
+
+// extractPalette clusters the colors of a downscaled image with k-means and returns the
+// cluster centers, the most common first
+func extractPalette(img image.Image, k int) []models.PosterColor {
+	pixels := samplePixels(img)
+	if len(pixels) == 0 || k <= 0 {
+		return []models.PosterColor{}
+	}
+	k = min(k, len(pixels))
+
+	// Seed the centers deterministically at quantiles of brightness, so a poster always
+	// gets the same palette
+	sorted := append([][3]float64{}, pixels...)
+	sort.Slice(sorted, func(i, j int) bool { return luminance(sorted[i]) < luminance(sorted[j]) })
+	centers := make([][3]float64, k)
+	for i := range centers {
+		centers[i] = sorted[(2*i+1)*len(sorted)/(2*k)]
+	}
+
+	assigned := make([]int, len(pixels))
+	counts := make([]int, k)
+	for round := 0; round < paletteIterations; round++ {
+		changed := false
+		for i, pixel := range pixels {
+			nearest, best := 0, math.MaxFloat64
+			for c, center := range centers {
+				if d := colorDistance(pixel, center); d < best {
+					nearest, best = c, d
+				}
+			}
+			if round == 0 || assigned[i] != nearest {
+				assigned[i], changed = nearest, true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][3]float64, k)
+		counts = make([]int, k)
+		for i, pixel := range pixels {
+			c := assigned[i]
+			counts[c]++
+			for ch := range pixel {
+				sums[c][ch] += pixel[ch]
+			}
+		}
+		for c := range centers {
+			if counts[c] > 0 {
+				for ch := range sums[c] {
+					centers[c][ch] = sums[c][ch] / float64(counts[c])
+				}
+			}
+		}
+	}
+
+	palette := make([]models.PosterColor, 0, k)
+	for c, center := range centers {
+		if counts[c] == 0 {
+			continue
+		}
+		palette = append(palette, models.PosterColor{
+			Hex:   fmt.Sprintf("#%02x%02x%02x", uint8(math.Round(center[0])), uint8(math.Round(center[1])), uint8(math.Round(center[2]))),
+			Share: math.Round(float64(counts[c])/float64(len(pixels))*1000) / 1000,
+		})
+	}
+	sort.SliceStable(palette, func(i, j int) bool { return palette[i].Share > palette[j].Share })
+	return palette
+}
+
+// samplePixels returns the 8-bit RGB colors of a grid of up to paletteSampleSide squared
+// pixels spread over the image, skipping transparent ones
+func samplePixels(img image.Image) [][3]float64 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil
+	}
+	stepX := max(width/paletteSampleSide, 1)
+	stepY := max(height/paletteSampleSide, 1)
+
+	var pixels [][3]float64
+	for y := bounds.Min.Y + stepY/2; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X + stepX/2; x < bounds.Max.X; x += stepX {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < 0x8000 {
+				continue
+			}
+			pixels = append(pixels, [3]float64{float64(r >> 8), float64(g >> 8), float64(b >> 8)})
+		}
+	}
+	return pixels
+}
+
+func luminance(c [3]float64) float64 {
+	return 0.2126*c[0] + 0.7152*c[1] + 0.0722*c[2]
+}
+
+func colorDistance(a, b [3]float64) float64 {
+	dr, dg, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dr*dr + dg*dg + db*db
+}
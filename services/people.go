@@ -0,0 +1,169 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+
+	"movie-api-go/models"
+)
+
+// PersonIndex matches spoken or misspelled actor, director and writer names to the
+// canonical spelling of names seen in OMDb records, so "Quintin Tarentino" resolves to
+// "Quentin Tarantino". Names are indexed by the Soundex code of each of their words;
+// candidates sharing the query's code are ranked by edit distance.
+type PersonIndex struct {
+	// MaxNames bounds the number of distinct names; new names are ignored once it is reached
+	MaxNames int
+
+	mu     sync.RWMutex
+	names  map[string]*personEntry
+	byCode map[string][]*personEntry
+}
+
+type personEntry struct {
+	name  string
+	count int
+}
+
+// personIndexFromEnv reads PERSON_INDEX_MAX_NAMES (default 50000)
+func personIndexFromEnv() *PersonIndex {
+	return &PersonIndex{
+		MaxNames: envInt("PERSON_INDEX_MAX_NAMES", 50000),
+		names:    make(map[string]*personEntry),
+		byCode:   make(map[string][]*personEntry),
+	}
+}
+
+// LearnRecord adds the directors, writers and actors of a record
+func (p *PersonIndex) LearnRecord(record *models.OMDbResponse) {
+	if p == nil {
+		return
+	}
+	for _, field := range []string{record.Director, record.Writer, record.Actors} {
+		for _, name := range splitList(field) {
+			// Writer credits carry the role in parentheses, e.g. "Jonathan Nolan (screenplay)"
+			if i := strings.Index(name, "("); i >= 0 {
+				name = strings.TrimSpace(name[:i])
+			}
+			p.Learn(name)
+		}
+	}
+}
+
+// Learn adds one person name
+func (p *PersonIndex) Learn(name string) {
+	if p == nil || name == "" {
+		return
+	}
+	key := strings.ToLower(foldDiacritics(name))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if entry, ok := p.names[key]; ok {
+		entry.count++
+		return
+	}
+	if p.MaxNames > 0 && len(p.names) >= p.MaxNames {
+		return
+	}
+	entry := &personEntry{name: name, count: 1}
+	p.names[key] = entry
+	code := phoneticKey(name)
+	p.byCode[code] = append(p.byCode[code], entry)
+}
+
+// Resolve returns the canonical spelling of name. An exact match, ignoring case and
+// diacritics, is returned as-is; otherwise the closest name that sounds the same is
+// accepted if it is within a quarter of the name's length in edits (at least two).
+func (p *PersonIndex) Resolve(name string) (models.PersonMatch, bool) {
+	if p == nil {
+		return models.PersonMatch{}, false
+	}
+	name = strings.Join(strings.Fields(name), " ")
+	key := strings.ToLower(foldDiacritics(name))
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if entry, ok := p.names[key]; ok {
+		return models.PersonMatch{Query: name, Name: entry.name, Method: "exact"}, true
+	}
+
+	maxDistance := len([]rune(key)) / 4
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	var best *personEntry
+	bestDistance := maxDistance + 1
+	for _, entry := range p.byCode[phoneticKey(name)] {
+		distance := editDistance(key, strings.ToLower(foldDiacritics(entry.name)))
+		if distance < bestDistance || distance == bestDistance && entry.count > best.count {
+			best, bestDistance = entry, distance
+		}
+	}
+	if best == nil {
+		return models.PersonMatch{}, false
+	}
+	return models.PersonMatch{Query: name, Name: best.name, Method: "phonetic"}, true
+}
+
+// phoneticKey is the Soundex code of every word of a name, e.g. "Q535 T653"
+func phoneticKey(name string) string {
+	var codes []string
+	for _, word := range strings.FieldsFunc(foldDiacritics(name), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if code := soundex(word); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return strings.Join(codes, " ")
+}
+
+// soundexDigits maps consonants to their Soundex digit; vowels, h, w and y have none
+var soundexDigits = map[rune]byte{
+	'b': '1', 'f': '1', 'p': '1', 'v': '1',
+	'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+	'd': '3', 't': '3',
+	'l': '4',
+	'm': '5', 'n': '5',
+	'r': '6',
+}
+
+// soundex returns the American Soundex code of a word (first letter and three digits).
+// Letters outside a-z are skipped.
+func soundex(word string) string {
+	var code []byte
+	var last byte
+	for _, r := range strings.ToLower(word) {
+		if r < 'a' || r > 'z' {
+			continue
+		}
+		digit := soundexDigits[r]
+		if code == nil {
+			code = []byte{byte(unicode.ToUpper(r))}
+			last = digit
+			continue
+		}
+		switch {
+		case digit == 0:
+			// Vowels separate repeated digits; h and w don't
+			if r != 'h' && r != 'w' {
+				last = 0
+			}
+		case digit != last:
+			code = append(code, digit)
+			last = digit
+		}
+		if len(code) == 4 {
+			break
+		}
+	}
+	if code == nil {
+		return ""
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"sync"
+
+	"movie-api-go/models"
+)
+
+// briefPool recycles the scratch slices that collect candidate movies before they are
+// deduplicated and ranked. The genre and recommendation endpoints build several of
+// these per request, so reusing their backing arrays keeps allocation flat under load.
+var briefPool = sync.Pool{
+	New: func() interface{} {
+		briefs := make([]models.MovieBrief, 0, 64)
+		return &briefs
+	},
+}
+
+// getBriefs returns an empty slice from the pool
+func getBriefs() *[]models.MovieBrief {
+	briefs := briefPool.Get().(*[]models.MovieBrief)
+	*briefs = (*briefs)[:0]
+	return briefs
+}
+
+// putBriefs returns a slice to the pool. Its elements are cleared first so pooled
+// arrays don't keep plot and title strings alive; callers must not retain the slice.
+func putBriefs(briefs *[]models.MovieBrief) {
+	clear((*briefs)[:cap(*briefs)])
+	*briefs = (*briefs)[:0]
+	briefPool.Put(briefs)
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// posterFetchTimeout bounds the download of one poster
+const posterFetchTimeout = 15 * time.Second
+
+// ErrNoPoster is returned for titles without a poster image
+var ErrNoPoster = errors.New("title has no poster")
+
+// Poster is a downloaded poster image
+type Poster struct {
+	Data        []byte
+	ContentType string
+	FetchedAt   time.Time
+}
+
+// PosterService proxies poster images, so clients load them from the API rather than
+// from the image host, and keeps recently served posters in memory and, when configured,
+// in the sharded Redis tier shared by all replicas
+type PosterService struct {
+	// TTL is how long a downloaded poster is served from memory
+	TTL time.Duration
+	// MaxEntries bounds the posters kept in memory
+	MaxEntries int
+	// MaxBytes bounds the size of one poster
+	MaxBytes int64
+	// JPEGQuality is the quality of resized and converted JPEG posters
+	JPEGQuality int
+	// Palettes keeps the dominant colors of the posters served
+	Palettes *PaletteStore
+	// Blurhashes keeps the placeholder hashes of the posters served
+	Blurhashes *BlurhashStore
+
+	omdb   *OMDbService
+	client *http.Client
+	shared *ShardedCache
+
+	mu      sync.Mutex
+	entries map[string]*Poster
+	// warming holds the titles whose posters are being analyzed in the background
+	warming map[string]bool
+}
+
+// NewPosterService reads POSTER_CACHE_TTL_SECONDS (default 86400),
+// POSTER_CACHE_MAX_ENTRIES (default 500), POSTER_MAX_BYTES (default 5 MB) and
+// POSTER_JPEG_QUALITY (default 80). Poster
+// downloads go through POSTER_PROXY_URL when set. Palettes and placeholders are
+// configured as in NewPaletteStore and NewBlurhashStore.
+func NewPosterService(omdb *OMDbService) (*PosterService, error) {
+	client, err := httpClientFromEnv("POSTER")
+	if err != nil {
+		return nil, err
+	}
+	client.Timeout = posterFetchTimeout
+	palettes, err := NewPaletteStore()
+	if err != nil {
+		return nil, err
+	}
+	blurhashes, err := NewBlurhashStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return &PosterService{
+		TTL:         time.Duration(envInt("POSTER_CACHE_TTL_SECONDS", 86400)) * time.Second,
+		MaxEntries:  envInt("POSTER_CACHE_MAX_ENTRIES", 500),
+		MaxBytes:    int64(envInt("POSTER_MAX_BYTES", 5<<20)),
+		JPEGQuality: min(max(envInt("POSTER_JPEG_QUALITY", 80), 1), 100),
+		Palettes:    palettes,
+		Blurhashes:  blurhashes,
+		omdb:        omdb,
+		client:      client,
+		shared:      omdb.Cache.Shared,
+		entries:     make(map[string]*Poster),
+		warming:     make(map[string]bool),
+	}, nil
+}
+
+// Get returns the poster of the title with the given IMDb ID
+func (p *PosterService) Get(ctx context.Context, imdbID string) (*Poster, error) {
+	if poster := p.cached(imdbID); poster != nil {
+		ScopeFrom(ctx).recordCache(CacheHit, time.Since(poster.FetchedAt))
+		return poster, nil
+	}
+	if poster := p.loadShared(ctx, imdbID); poster != nil {
+		ScopeFrom(ctx).recordCache(CacheHit, time.Since(poster.FetchedAt))
+		p.store(imdbID, poster)
+		return poster, nil
+	}
+
+	record, err := p.omdb.GetTitleByID(ctx, imdbID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Response == "False" {
+		return nil, ErrNotFound
+	}
+	posterURL, err := url.Parse(record.Poster)
+	if record.Poster == "" || err != nil || (posterURL.Scheme != "http" && posterURL.Scheme != "https") {
+		return nil, ErrNoPoster
+	}
+
+	poster, err := p.download(ctx, posterURL.String())
+	if err != nil {
+		return nil, err
+	}
+	p.store(imdbID, poster)
+	if p.shared != nil {
+		go p.share(imdbID, poster)
+	}
+	if p.unanalyzed(imdbID) {
+		go p.analyze(imdbID, poster)
+	}
+	return poster, nil
+}
+
+// Variant returns a title's poster scaled down to width pixels (zero keeps the size) in
+// format ("" keeps the format of the original, or JPEG for formats it can't convert to).
+// Each variant is cached like the posters themselves.
+func (p *PosterService) Variant(ctx context.Context, imdbID string, width int, format string) (*Poster, error) {
+	key := fmt.Sprintf("%s@w%d.%s", imdbID, width, format)
+	if poster := p.cached(key); poster != nil {
+		ScopeFrom(ctx).recordCache(CacheHit, time.Since(poster.FetchedAt))
+		return poster, nil
+	}
+	if poster := p.loadShared(ctx, key); poster != nil {
+		ScopeFrom(ctx).recordCache(CacheHit, time.Since(poster.FetchedAt))
+		p.store(key, poster)
+		return poster, nil
+	}
+
+	original, err := p.Get(ctx, imdbID)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(original.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode poster: %w", err)
+	}
+	if format == "" {
+		format = formatOf(original.ContentType)
+	}
+	if format == "" {
+		format = FormatJPEG
+	}
+	data, err := encodeImage(resizeImage(img, width), format, p.JPEGQuality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode poster: %w", err)
+	}
+
+	// A variant expires with the poster it was made from
+	poster := &Poster{Data: data, ContentType: contentTypes[format], FetchedAt: original.FetchedAt}
+	p.store(key, poster)
+	if p.shared != nil {
+		go p.share(key, poster)
+	}
+	return poster, nil
+}
+
+// Palette returns the dominant colors of a title's poster. Until they have been computed,
+// it returns nil and has the poster fetched in the background to compute them.
+func (p *PosterService) Palette(imdbID string) []models.PosterColor {
+	if !p.Palettes.Enabled() {
+		return nil
+	}
+	if palette, ok := p.Palettes.Get(imdbID); ok {
+		return palette
+	}
+	p.warm(imdbID)
+	return nil
+}
+
+// Blurhash returns the placeholder hash of a title's poster. Until it has been computed,
+// it returns "" and has the poster fetched in the background to compute it.
+func (p *PosterService) Blurhash(imdbID string) string {
+	if hash, ok := p.Blurhashes.Get(imdbID); ok {
+		return hash
+	}
+	p.warm(imdbID)
+	return ""
+}
+
+// unanalyzed reports whether the palette or placeholder of a title's poster is missing
+func (p *PosterService) unanalyzed(imdbID string) bool {
+	_, hashed := p.Blurhashes.Get(imdbID)
+	_, colored := p.Palettes.Get(imdbID)
+	return !hashed || (p.Palettes.Enabled() && !colored)
+}
+
+// analyze computes the missing palette and placeholder of a title's poster
+func (p *PosterService) analyze(imdbID string, poster *Poster) {
+	img, _, err := image.Decode(bytes.NewReader(poster.Data))
+	if err != nil {
+		log.Printf("posters: %s not analyzed: failed to decode poster: %v", imdbID, err)
+		return
+	}
+	if _, ok := p.Palettes.Get(imdbID); p.Palettes.Enabled() && !ok {
+		if err := p.Palettes.Compute(imdbID, img); err != nil {
+			log.Printf("posters: palette of %s not saved: %v", imdbID, err)
+		}
+	}
+	if _, ok := p.Blurhashes.Get(imdbID); !ok {
+		if err := p.Blurhashes.Compute(imdbID, img); err != nil {
+			log.Printf("posters: blurhash of %s not saved: %v", imdbID, err)
+		}
+	}
+}
+
+// warm fetches a title's poster in the background to analyze it, unless that is
+// already under way
+func (p *PosterService) warm(imdbID string) {
+	p.mu.Lock()
+	if p.warming[imdbID] {
+		p.mu.Unlock()
+		return
+	}
+	p.warming[imdbID] = true
+	p.mu.Unlock()
+
+	go isolate(func() error {
+		defer func() {
+			p.mu.Lock()
+			delete(p.warming, imdbID)
+			p.mu.Unlock()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), posterFetchTimeout)
+		defer cancel()
+		poster, err := p.Get(ctx, imdbID)
+		if err != nil {
+			log.Printf("posters: %s not analyzed: %v", imdbID, err)
+			return nil
+		}
+		if p.unanalyzed(imdbID) {
+			p.analyze(imdbID, poster)
+		}
+		return nil
+	})
+}
+
+// posterSharedKey is the key of a poster in the shared cache tier
+func posterSharedKey(imdbID string) string {
+	return "poster:" + imdbID
+}
+
+// share writes a poster to the shared tier as its fetch time in Unix milliseconds, its
+// content type and its data, separated by newlines
+func (p *PosterService) share(imdbID string, poster *Poster) {
+	header := fmt.Sprintf("%d\n%s\n", poster.FetchedAt.UnixMilli(), poster.ContentType)
+	value := append([]byte(header), poster.Data...)
+	p.shared.Set(context.Background(), posterSharedKey(imdbID), value, p.TTL)
+}
+
+// loadShared returns a poster another replica downloaded, or nil
+func (p *PosterService) loadShared(ctx context.Context, imdbID string) *Poster {
+	value := p.shared.Get(ctx, posterSharedKey(imdbID))
+	parts := bytes.SplitN(value, []byte("\n"), 3)
+	if len(parts) != 3 {
+		return nil
+	}
+	fetchedAt, err := strconv.ParseInt(string(parts[0]), 10, 64)
+	if err != nil {
+		return nil
+	}
+	poster := &Poster{Data: parts[2], ContentType: string(parts[1]), FetchedAt: time.UnixMilli(fetchedAt)}
+	if time.Since(poster.FetchedAt) >= p.TTL {
+		return nil
+	}
+	return poster
+}
+
+func (p *PosterService) download(ctx context.Context, posterURL string) (*Poster, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, posterURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download poster: %w", err)
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("failed to download poster: status %d, content type %q", resp.StatusCode, contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, p.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download poster: %w", err)
+	}
+	if int64(len(data)) > p.MaxBytes {
+		return nil, fmt.Errorf("poster exceeds %d bytes", p.MaxBytes)
+	}
+	return &Poster{Data: data, ContentType: contentType, FetchedAt: time.Now()}, nil
+}
+
+func (p *PosterService) cached(imdbID string) *Poster {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	poster, ok := p.entries[imdbID]
+	if !ok || time.Since(poster.FetchedAt) >= p.TTL {
+		return nil
+	}
+	return poster
+}
+
+// store keeps poster, evicting the oldest one when full
+func (p *PosterService) store(imdbID string, poster *Poster) {
+	if p.TTL <= 0 || p.MaxEntries <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.entries[imdbID]; !ok && len(p.entries) >= p.MaxEntries {
+		var oldestID string
+		var oldest time.Time
+		for id, entry := range p.entries {
+			if oldestID == "" || entry.FetchedAt.Before(oldest) {
+				oldestID, oldest = id, entry.FetchedAt
+			}
+		}
+		delete(p.entries, oldestID)
+	}
+	p.entries[imdbID] = poster
+}
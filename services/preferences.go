@@ -0,0 +1,255 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// ErrInvalidPreferences wraps the reason a preference profile was rejected
+var ErrInvalidPreferences = errors.New("invalid preferences")
+
+// contentRatingLevels orders US film and TV certifications from least to most
+// restrictive audience; a content-rating ceiling excludes titles above it
+var contentRatingLevels = map[string]int{
+	"G": 0, "TV-Y": 0, "TV-G": 0,
+	"PG": 1, "TV-Y7": 1, "TV-PG": 1,
+	"PG-13": 2, "TV-14": 2,
+	"R": 3, "TV-MA": 3,
+	"NC-17": 4, "X": 4,
+}
+
+// maxPreferredGenres bounds the genre lists of a profile
+const maxPreferredGenres = 20
+
+// PreferenceStore keeps each user's preference profile, persisted as a JSON file
+type PreferenceStore struct {
+	path string
+
+	mu          sync.Mutex
+	preferences map[string]models.Preferences
+}
+
+// NewPreferenceStore loads the profiles from PREFERENCES_PATH (default data/preferences.json)
+func NewPreferenceStore() (*PreferenceStore, error) {
+	path := os.Getenv("PREFERENCES_PATH")
+	if path == "" {
+		path = "data/preferences.json"
+	}
+
+	s := &PreferenceStore{path: path, preferences: make(map[string]models.Preferences)}
+	if err := store.LoadJSON(path, &s.preferences); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the profile of a user, or an empty one
+func (s *PreferenceStore) Get(userID string) models.Preferences {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs, ok := s.preferences[userID]
+	if !ok {
+		return models.Preferences{PreferredGenres: []string{}, DislikedGenres: []string{}}
+	}
+	return prefs
+}
+
+// Set validates and replaces the profile of a user
+func (s *PreferenceStore) Set(userID string, prefs models.Preferences) (models.Preferences, error) {
+	prefs, err := normalizePreferences(prefs)
+	if err != nil {
+		return models.Preferences{}, err
+	}
+	now := time.Now().UTC()
+	prefs.UpdatedAt = &now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.preferences[userID]
+	s.preferences[userID] = prefs
+	if err := store.SaveJSON(s.path, s.preferences); err != nil {
+		if existed {
+			s.preferences[userID] = previous
+		} else {
+			delete(s.preferences, userID)
+		}
+		return models.Preferences{}, err
+	}
+	return prefs, nil
+}
+
+func normalizePreferences(prefs models.Preferences) (models.Preferences, error) {
+	prefs.PreferredGenres = cleanList(prefs.PreferredGenres)
+	prefs.DislikedGenres = cleanList(prefs.DislikedGenres)
+	if len(prefs.PreferredGenres) > maxPreferredGenres || len(prefs.DislikedGenres) > maxPreferredGenres {
+		return prefs, fmt.Errorf("%w: at most %d preferred and %d disliked genres", ErrInvalidPreferences, maxPreferredGenres, maxPreferredGenres)
+	}
+	if prefs.MaxRuntime < 0 {
+		return prefs, fmt.Errorf("%w: max_runtime_minutes must not be negative", ErrInvalidPreferences)
+	}
+	prefs.Language = strings.TrimSpace(prefs.Language)
+	prefs.MaxContentRating = strings.ToUpper(strings.TrimSpace(prefs.MaxContentRating))
+	if _, ok := contentRatingLevels[prefs.MaxContentRating]; prefs.MaxContentRating != "" && !ok {
+		return prefs, fmt.Errorf("%w: max_content_rating must be a US certification such as PG-13 or TV-14", ErrInvalidPreferences)
+	}
+	return prefs, nil
+}
+
+// cleanList trims entries and drops empty ones and duplicates, ignoring case
+func cleanList(items []string) []string {
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item != "" && !seen[strings.ToLower(item)] {
+			seen[strings.ToLower(item)] = true
+			cleaned = append(cleaned, item)
+		}
+	}
+	return cleaned
+}
+
+// DiscoveryFilter narrows and orders genre lists and recommendations. It is built from
+// a user's preferences, with request parameters overriding individual settings.
+type DiscoveryFilter struct {
+	models.Preferences
+
+	// Tags are required of every movie (see WithTags)
+	Tags []string
+	// MinMetascore is the lowest Metascore kept; movies without one are removed
+	MinMetascore int
+	// MinCompleteness is the lowest completeness kept (see Completeness)
+	MinCompleteness int
+	// Weights are the score weights the movies' blended_score is computed with
+	Weights models.ScoreWeights
+
+	tags *TagStore
+}
+
+// NewDiscoveryFilter validates prefs for use as a filter
+func NewDiscoveryFilter(prefs models.Preferences) (DiscoveryFilter, error) {
+	prefs, err := normalizePreferences(prefs)
+	return DiscoveryFilter{Preferences: prefs}, err
+}
+
+// WithTags makes the filter tag movies from store and keep only those carrying all of
+// required, which must name tags of the taxonomy
+func (f DiscoveryFilter) WithTags(store *TagStore, required []string) (DiscoveryFilter, error) {
+	tags, err := store.Validate(required)
+	f.Tags, f.tags = tags, store
+	return f, err
+}
+
+// Active reports whether the filter changes anything
+func (f DiscoveryFilter) Active() bool {
+	return len(f.PreferredGenres) > 0 || len(f.DislikedGenres) > 0 || f.MaxRuntime > 0 ||
+		f.Language != "" || f.MaxContentRating != "" || len(f.Tags) > 0 || f.MinMetascore > 0 ||
+		f.MinCompleteness > 0
+}
+
+// Apply tags the movies, sets their blended score, removes those the filter excludes and moves those in a
+// preferred genre to the front, keeping the order otherwise. Titles whose runtime,
+// language or rating is unknown are kept, but not those without a Metascore when a
+// minimum is set.
+func (f DiscoveryFilter) Apply(movies []models.MovieBrief) []models.MovieBrief {
+	movies = f.tags.Annotate(movies)
+	for i := range movies {
+		movies[i].BlendedScore = BlendedScore(movies[i], f.Weights)
+	}
+	if !f.Active() {
+		return movies
+	}
+
+	kept := make([]models.MovieBrief, 0, len(movies))
+	for _, movie := range movies {
+		if f.allows(movie) {
+			kept = append(kept, movie)
+		}
+	}
+	f.Prefer(kept)
+	return kept
+}
+
+// Prefer moves the movies in a preferred genre to the front, keeping the order otherwise.
+// Callers that sort the movies Apply returned call it again so the preference stays the
+// primary order.
+func (f DiscoveryFilter) Prefer(movies []models.MovieBrief) {
+	if len(f.PreferredGenres) == 0 {
+		return
+	}
+	sort.SliceStable(movies, func(i, j int) bool {
+		return overlap(f.PreferredGenres, splitList(movies[i].Genre)) > 0 && overlap(f.PreferredGenres, splitList(movies[j].Genre)) == 0
+	})
+}
+
+func (f DiscoveryFilter) allows(movie models.MovieBrief) bool {
+	if len(f.Tags) > 0 && overlap(f.Tags, movie.Tags) < 1 {
+		return false
+	}
+	if movie.Metascore < f.MinMetascore || movie.Completeness < f.MinCompleteness {
+		return false
+	}
+	genres := splitList(movie.Genre)
+	for _, genre := range genres {
+		for _, disliked := range f.DislikedGenres {
+			if strings.EqualFold(genre, disliked) {
+				return false
+			}
+		}
+	}
+	if f.MaxRuntime > 0 {
+		if minutes, err := strconv.Atoi(strings.TrimSuffix(movie.Runtime, " min")); err == nil && minutes > f.MaxRuntime {
+			return false
+		}
+	}
+	if languages := splitList(movie.Language); f.Language != "" && len(languages) > 0 && overlap([]string{f.Language}, languages) == 0 {
+		return false
+	}
+	if f.MaxContentRating != "" {
+		if level, ok := contentRatingLevels[strings.ToUpper(movie.Rated)]; ok && level > contentRatingLevels[f.MaxContentRating] {
+			return false
+		}
+	}
+	return true
+}
+
+// Describe lists the filter's settings for explanations of discovery responses
+func (f DiscoveryFilter) Describe() []string {
+	var filters []string
+	if len(f.PreferredGenres) > 0 {
+		filters = append(filters, "prefer genres "+strings.Join(f.PreferredGenres, ", "))
+	}
+	if len(f.DislikedGenres) > 0 {
+		filters = append(filters, "exclude genres "+strings.Join(f.DislikedGenres, ", "))
+	}
+	if f.MaxRuntime > 0 {
+		filters = append(filters, "runtime <= "+strconv.Itoa(f.MaxRuntime))
+	}
+	if f.Language != "" {
+		filters = append(filters, "language = "+f.Language)
+	}
+	if f.MaxContentRating != "" {
+		filters = append(filters, "content rating <= "+f.MaxContentRating)
+	}
+	if len(f.Tags) > 0 {
+		filters = append(filters, "tags include "+strings.Join(f.Tags, ", "))
+	}
+	if f.MinMetascore > 0 {
+		filters = append(filters, "metascore >= "+strconv.Itoa(f.MinMetascore))
+	}
+	if f.MinCompleteness > 0 {
+		filters = append(filters, "completeness >= "+strconv.Itoa(f.MinCompleteness))
+	}
+	return filters
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// ErrInvalidPrivacy wraps the reason privacy settings were rejected
+var ErrInvalidPrivacy = errors.New("invalid privacy settings")
+
+// Privacy levels: who besides the user may see an area of their activity
+const (
+	PrivacyPublic    = "public"
+	PrivacyFollowers = "followers"
+	PrivacyPrivate   = "private"
+)
+
+// Privacy areas: a user's lists (their watchlist and the users they follow and are
+// followed by), their ratings, and their history, i.e. their activity as it appears in
+// other users' feeds
+const (
+	PrivacyLists   = "lists"
+	PrivacyRatings = "ratings"
+	PrivacyHistory = "history"
+)
+
+// defaultPrivacy applies to users who haven't changed their settings
+const defaultPrivacy = PrivacyFollowers
+
+// PrivacyStore keeps each user's privacy settings, persisted as a JSON file, and decides
+// who may see what. Every read of another user's activity goes through CanView.
+type PrivacyStore struct {
+	path   string
+	social *SocialGraph
+
+	mu       sync.Mutex
+	settings map[string]models.PrivacySettings
+}
+
+// NewPrivacyStore loads the settings from PRIVACY_PATH (default data/privacy.json)
+func NewPrivacyStore(social *SocialGraph) (*PrivacyStore, error) {
+	path := os.Getenv("PRIVACY_PATH")
+	if path == "" {
+		path = "data/privacy.json"
+	}
+
+	s := &PrivacyStore{path: path, social: social, settings: make(map[string]models.PrivacySettings)}
+	if err := store.LoadJSON(path, &s.settings); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the settings of a user, with the default level for areas never set
+func (s *PrivacyStore) Get(userID string) models.PrivacySettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return withDefaultPrivacy(s.settings[userID])
+}
+
+// Set validates and replaces the settings of a user. Areas left empty get the default
+// level.
+func (s *PrivacyStore) Set(userID string, settings models.PrivacySettings) (models.PrivacySettings, error) {
+	settings = withDefaultPrivacy(settings)
+	for area, level := range map[string]*string{PrivacyLists: &settings.Lists, PrivacyRatings: &settings.Ratings, PrivacyHistory: &settings.History} {
+		*level = strings.ToLower(strings.TrimSpace(*level))
+		if *level != PrivacyPublic && *level != PrivacyFollowers && *level != PrivacyPrivate {
+			return models.PrivacySettings{}, fmt.Errorf("%w: %s must be public, followers or private", ErrInvalidPrivacy, area)
+		}
+	}
+	now := time.Now().UTC()
+	settings.UpdatedAt = &now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.settings[userID]
+	s.settings[userID] = settings
+	if err := store.SaveJSON(s.path, s.settings); err != nil {
+		if existed {
+			s.settings[userID] = previous
+		} else {
+			delete(s.settings, userID)
+		}
+		return models.PrivacySettings{}, err
+	}
+	return settings, nil
+}
+
+// CanView reports whether viewer (empty when anonymous) may see an area of owner's
+// activity. Users always see their own.
+func (s *PrivacyStore) CanView(viewer, owner, area string) bool {
+	if viewer != "" && viewer == owner {
+		return true
+	}
+
+	settings := s.Get(owner)
+	var level string
+	switch area {
+	case PrivacyLists:
+		level = settings.Lists
+	case PrivacyRatings:
+		level = settings.Ratings
+	case PrivacyHistory:
+		level = settings.History
+	}
+	switch level {
+	case PrivacyPublic:
+		return true
+	case PrivacyFollowers:
+		return viewer != "" && s.social.IsFollowing(viewer, owner)
+	default:
+		return false
+	}
+}
+
+// RequiresApproval reports whether follows of a user must be approved: anyone whose
+// activity isn't all public, since a follower may see what is shared with followers
+func (s *PrivacyStore) RequiresApproval(userID string) bool {
+	settings := s.Get(userID)
+	return settings.Lists != PrivacyPublic || settings.Ratings != PrivacyPublic || settings.History != PrivacyPublic
+}
+
+func withDefaultPrivacy(settings models.PrivacySettings) models.PrivacySettings {
+	for _, level := range []*string{&settings.Lists, &settings.Ratings, &settings.History} {
+		if *level == "" {
+			*level = defaultPrivacy
+		}
+	}
+	return settings
+}
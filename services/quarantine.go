@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+var (
+	// ErrQuarantined is returned for a background lookup of a quarantined title
+	ErrQuarantined = errors.New("title is quarantined")
+
+	// ErrNotQuarantined is returned when releasing a title that isn't quarantined
+	ErrNotQuarantined = errors.New("title is not quarantined")
+)
+
+// Quarantine tracks titles that keep failing enrichment, such as records OMDb returns
+// malformed or lookups that time out every time. After Threshold failures in a row a title
+// is quarantined: background jobs (genre list refreshes, monitor checks, leaderboards,
+// cache refreshes) skip it instead of spending quota on it again, until an admin releases
+// it. Lookups clients wait on still go through. The list is persisted as a JSON file.
+type Quarantine struct {
+	// Threshold is the number of failures in a row that quarantines a title; 0 disables it
+	Threshold int
+
+	path string
+
+	mu      sync.Mutex
+	entries map[string]models.QuarantineEntry
+}
+
+// newQuarantine loads the list from QUARANTINE_PATH (default data/quarantine.json);
+// QUARANTINE_FAILURES (default 3) sets the threshold
+func newQuarantine() (*Quarantine, error) {
+	path := os.Getenv("QUARANTINE_PATH")
+	if path == "" {
+		path = "data/quarantine.json"
+	}
+
+	q := &Quarantine{
+		Threshold: envInt("QUARANTINE_FAILURES", 3),
+		path:      path,
+		entries:   make(map[string]models.QuarantineEntry),
+	}
+	if err := store.LoadJSON(path, &q.entries); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// countsTowardQuarantine reports whether an error says something about the title rather
+// than about the quota, the request or a title already skipped
+func countsTowardQuarantine(err error) bool {
+	return !errors.Is(err, ErrUpstreamQuota) && !errors.Is(err, ErrCallBudgetExceeded) &&
+		!errors.Is(err, ErrQuarantined) && !errors.Is(err, context.Canceled)
+}
+
+// Failed counts a failed enrichment of a title, quarantining it at the threshold
+func (q *Quarantine) Failed(imdbID, title string, err error) {
+	if q == nil || q.Threshold == 0 || !countsTowardQuarantine(err) {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now().UTC()
+	entry, ok := q.entries[imdbID]
+	if !ok {
+		entry = models.QuarantineEntry{ImdbID: imdbID, FirstFailedAt: now}
+	}
+	if title != "" {
+		entry.Title = title
+	}
+	entry.Failures++
+	entry.LastError = errorText(err)
+	entry.LastFailedAt = now
+	if entry.QuarantinedAt == nil && entry.Failures >= q.Threshold {
+		entry.QuarantinedAt = &now
+	}
+	q.entries[imdbID] = entry
+	q.saveLocked()
+}
+
+// Succeeded clears the failures of a title that isn't quarantined; a quarantined title
+// stays so until it is released
+func (q *Quarantine) Succeeded(imdbID string) {
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if entry, ok := q.entries[imdbID]; ok && entry.QuarantinedAt == nil {
+		delete(q.entries, imdbID)
+		q.saveLocked()
+	}
+}
+
+// Quarantined reports whether background jobs skip a title
+func (q *Quarantine) Quarantined(imdbID string) bool {
+	if q == nil {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.entries[imdbID].QuarantinedAt != nil
+}
+
+// skip returns ErrQuarantined for a background lookup of a quarantined title
+func (q *Quarantine) skip(ctx context.Context, imdbID string) error {
+	if priorityFrom(ctx) == PriorityBackground && q.Quarantined(imdbID) {
+		return fmt.Errorf("%w: %s is skipped by background jobs until released", ErrQuarantined, imdbID)
+	}
+	return nil
+}
+
+// List returns the quarantined titles, most recently quarantined first, followed by the
+// titles with failures below the threshold, most failures first
+func (q *Quarantine) List() models.QuarantineResponse {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	response := models.QuarantineResponse{
+		Threshold:   q.Threshold,
+		Quarantined: []models.QuarantineEntry{},
+		Suspects:    []models.QuarantineEntry{},
+	}
+	for _, entry := range q.entries {
+		if entry.QuarantinedAt != nil {
+			response.Quarantined = append(response.Quarantined, entry)
+		} else {
+			response.Suspects = append(response.Suspects, entry)
+		}
+	}
+	sort.Slice(response.Quarantined, func(i, j int) bool {
+		return response.Quarantined[i].QuarantinedAt.After(*response.Quarantined[j].QuarantinedAt)
+	})
+	sort.Slice(response.Suspects, func(i, j int) bool {
+		if response.Suspects[i].Failures != response.Suspects[j].Failures {
+			return response.Suspects[i].Failures > response.Suspects[j].Failures
+		}
+		return response.Suspects[i].ImdbID < response.Suspects[j].ImdbID
+	})
+	return response
+}
+
+// Release lets background jobs look a title up again, clearing its failures, and returns
+// the released entry
+func (q *Quarantine) Release(imdbID string) (models.QuarantineEntry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.entries[imdbID]
+	if !ok || entry.QuarantinedAt == nil {
+		return models.QuarantineEntry{}, ErrNotQuarantined
+	}
+	delete(q.entries, imdbID)
+	return entry, store.SaveJSON(q.path, q.entries)
+}
+
+// saveLocked persists the list; failing to save is logged rather than failing the lookup
+// that changed it
+func (q *Quarantine) saveLocked() {
+	if err := store.SaveJSON(q.path, q.entries); err != nil {
+		log.Printf("quarantine: failed to save: %v", err)
+	}
+}
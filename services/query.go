@@ -0,0 +1,421 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"movie-api-go/models"
+)
+
+// Query limits and defaults
+const (
+	DefaultQueryLimit = 20
+	MaxQueryLimit     = 50
+
+	// maxQueryTerms bounds the OMDb searches of a query's provider fallback
+	maxQueryTerms = 8
+)
+
+// querySortFields are the fields a query can be sorted by
+var querySortFields = map[string]bool{
+	"rating":     true,
+	"popularity": true,
+	"metascore":  true,
+	"blended":    true,
+	"year":       true,
+	"runtime":    true,
+	"title":      true,
+}
+
+// QueryService evaluates discovery queries, which combine filters on genre, year, rating,
+// runtime and people in one call. Queries are answered from the local corpus, the titles
+// already fetched from OMDb (see TitleHistory), and fall back to searching OMDb when it
+// has fewer matches than the query asks for.
+type QueryService struct {
+	omdb *OMDbService
+}
+
+func NewQueryService(omdb *OMDbService) *QueryService {
+	return &QueryService{omdb: omdb}
+}
+
+// Query is a validated discovery query, ready to be run
+type Query struct {
+	Type          string
+	Genres        []string
+	ExcludeGenres []string
+	Year          *models.QueryRange
+	Rating        *models.QueryRange
+	Metascore     *models.QueryRange
+	Runtime       *models.QueryRange
+	People        []string
+	SortField     string
+	Descending    bool
+	Limit         int
+	// Weights replace the caller's score weights when set
+	Weights *models.ScoreWeights
+}
+
+// QueryResult holds the matches of a query, sorted and limited
+type QueryResult struct {
+	Movies []models.MovieBrief
+	// ProviderFallback is set when OMDb was searched for more matches
+	ProviderFallback bool
+}
+
+// Plan validates a query request. Genres are resolved to the taxonomy's spelling and
+// people to the spelling of names seen in OMDb records, so typos match too.
+func (s *QueryService) Plan(req models.QueryRequest) (*Query, error) {
+	filters := req.Filters
+	query := &Query{
+		Type:      strings.ToLower(strings.TrimSpace(filters.Type)),
+		Year:      filters.Year,
+		Rating:    filters.Rating,
+		Metascore: filters.Metascore,
+		Runtime:   filters.Runtime,
+		Limit:     req.Limit,
+		Weights:   req.Weights,
+	}
+	if query.Type == "" {
+		query.Type = "movie"
+	}
+	switch query.Type {
+	case "movie", "series", "episode", "game":
+	default:
+		return nil, fmt.Errorf("filters.type must be one of movie, series, episode, game")
+	}
+
+	var err error
+	if query.Genres, err = s.resolveGenres(filters.Genres); err != nil {
+		return nil, err
+	}
+	if query.ExcludeGenres, err = s.resolveGenres(filters.ExcludeGenres); err != nil {
+		return nil, err
+	}
+	for name, bounds := range map[string]*models.QueryRange{"year": query.Year, "rating": query.Rating, "metascore": query.Metascore, "runtime": query.Runtime} {
+		if bounds != nil && bounds.Min != nil && bounds.Max != nil && *bounds.Min > *bounds.Max {
+			return nil, fmt.Errorf("filters.%s.min must not be greater than filters.%s.max", name, name)
+		}
+	}
+	for _, person := range filters.People {
+		person = strings.TrimSpace(person)
+		if person == "" {
+			continue
+		}
+		if match, ok := s.omdb.People.Resolve(person); ok {
+			person = match.Name
+		}
+		query.People = append(query.People, person)
+	}
+
+	sortBy := strings.TrimSpace(req.Sort)
+	if sortBy == "" {
+		sortBy = "-rating"
+	}
+	query.SortField = strings.TrimPrefix(sortBy, "-")
+	if !querySortFields[query.SortField] {
+		return nil, fmt.Errorf("sort must be one of rating, popularity, metascore, blended, year, runtime or title, optionally prefixed with - to sort descending")
+	}
+	query.Descending = strings.HasPrefix(sortBy, "-")
+	if query.Weights != nil {
+		if err := validateScoreWeights(*query.Weights); err != nil {
+			return nil, err
+		}
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultQueryLimit
+	}
+	if query.Limit < 1 || query.Limit > MaxQueryLimit {
+		return nil, fmt.Errorf("limit must be a number between 1 and %d", MaxQueryLimit)
+	}
+	return query, nil
+}
+
+// resolveGenres returns the canonical spelling of genres
+func (s *QueryService) resolveGenres(genres []string) ([]string, error) {
+	var resolved []string
+	for _, genre := range genres {
+		if strings.TrimSpace(genre) == "" {
+			continue
+		}
+		canonical, _, err := s.omdb.Genres.Resolve(genre, false)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, canonical)
+	}
+	return resolved, nil
+}
+
+// Run evaluates a query against the local corpus and, if that has fewer than Limit
+// matches, against OMDb searches derived from the query's genres or people. Running out
+// of the request's call budget ends the fallback with the matches found so far.
+func (s *QueryService) Run(ctx context.Context, query *Query) (*QueryResult, error) {
+	result := &QueryResult{}
+	seen := make(map[string]bool)
+	var matches []*models.OMDbResponse
+
+	explain := ExplainFrom(ctx)
+	step := explain.Begin(ctx)
+	corpus := s.omdb.History.Records()
+	for _, record := range corpus {
+		seen[record.ImdbID] = true
+		if query.Matches(record) {
+			matches = append(matches, record)
+		}
+	}
+	step.End(models.ExplainStage{Stage: "local_corpus", Provider: "local", Filters: query.describe(), Input: len(corpus), Candidates: len(matches)})
+
+	if len(matches) < query.Limit {
+		terms := query.searchTerms()
+		if limit := s.omdb.Limits.MaxSearchTerms; limit > 0 && len(terms) > limit {
+			ScopeFrom(ctx).truncate("max_search_terms", limit, fmt.Sprintf("searched %d of %d terms", limit, len(terms)))
+			terms = terms[:limit]
+		}
+		result.ProviderFallback = len(terms) > 0
+
+		found, err := s.search(ctx, query, terms, seen, query.Limit-len(matches))
+		matches = append(matches, found...)
+		if err != nil && len(matches) == 0 {
+			return nil, err
+		}
+	}
+
+	if query.Weights == nil {
+		weights := ScoreWeightsFrom(ctx)
+		query.Weights = &weights
+	}
+	query.sort(matches)
+	ranked := len(matches)
+	if len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+	}
+	explain.Add(models.ExplainStage{Stage: "rank", Strategy: fmt.Sprintf("%s, top %d", query.sortOrder(), query.Limit), Input: ranked, Candidates: len(matches)})
+	result.Movies = make([]models.MovieBrief, 0, len(matches))
+	for _, record := range matches {
+		movie := newMovieBrief(record)
+		movie.BlendedScore = BlendedScore(movie, *query.Weights)
+		result.Movies = append(result.Movies, movie)
+	}
+	return result, nil
+}
+
+// search looks up the results of OMDb searches for terms until wanted more matches are
+// found. The titles of seen are skipped; looked-up titles are added to it.
+func (s *QueryService) search(ctx context.Context, query *Query, terms []string, seen map[string]bool, wanted int) (matches []*models.OMDbResponse, err error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	year := ""
+	if query.Year != nil && query.Year.Min != nil && query.Year.Max != nil && *query.Year.Min == *query.Year.Max {
+		year = strconv.Itoa(int(*query.Year.Min))
+	}
+
+	var searched []string
+	evaluated := 0
+	step := ExplainFrom(ctx).Begin(ctx)
+	defer func() {
+		step.End(models.ExplainStage{Stage: "search", Strategy: "query_terms", Provider: "omdb", Terms: searched, Filters: query.describe(), Input: evaluated, Candidates: len(matches)})
+	}()
+
+	for _, term := range terms {
+		searched = append(searched, term)
+		searchResp, err := s.omdb.searchPage(ctx, term, query.Type, year, 1)
+		if errors.Is(err, ErrUpstreamQuota) || errors.Is(err, ErrCallBudgetExceeded) {
+			return matches, err
+		}
+		if err != nil || searchResp.Response == "False" {
+			continue
+		}
+
+		for _, result := range searchResp.Search {
+			if seen[result.ImdbID] {
+				continue
+			}
+			seen[result.ImdbID] = true
+
+			record, err := s.omdb.GetTitleByID(WithPriority(ctx, PriorityEnrichment), result.ImdbID)
+			if errors.Is(err, ErrUpstreamQuota) || errors.Is(err, ErrCallBudgetExceeded) {
+				return matches, err
+			}
+			if err != nil {
+				s.omdb.enrichmentFailed(ctx, result.ImdbID, result.Title, err)
+				continue
+			}
+			if record.Response == "False" {
+				s.omdb.enrichmentFailed(ctx, result.ImdbID, result.Title, ErrNotFound)
+				continue
+			}
+			evaluated++
+			if query.Matches(record) {
+				matches = append(matches, record)
+			}
+		}
+		if len(matches) >= wanted {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// searchTerms derives the OMDb searches of the provider fallback. OMDb only searches
+// titles, so like the genre listing, genres are searched by name, alone and with the
+// years of the query; without genres the people's names are searched.
+func (q *Query) searchTerms() []string {
+	var terms []string
+	for _, genre := range q.Genres {
+		terms = append(terms, genre, fmt.Sprintf("%s movie", genre), fmt.Sprintf("best %s", genre))
+		if q.Year != nil && q.Year.Max != nil {
+			for year := int(*q.Year.Max); year > int(*q.Year.Max)-3 && (q.Year.Min == nil || float64(year) >= *q.Year.Min); year-- {
+				terms = append(terms, fmt.Sprintf("%s %d", genre, year))
+			}
+		}
+	}
+	if len(q.Genres) == 0 {
+		terms = append(terms, q.People...)
+	}
+	if len(terms) > maxQueryTerms {
+		terms = terms[:maxQueryTerms]
+	}
+	return terms
+}
+
+// Matches reports whether a title passes every filter of the query. Titles whose year,
+// rating, Metascore or runtime is unknown don't match a filter on it.
+func (q *Query) Matches(record *models.OMDbResponse) bool {
+	if !strings.EqualFold(record.Type, q.Type) {
+		return false
+	}
+	genres := splitList(record.Genre)
+	if len(q.Genres) > 0 && overlap(q.Genres, genres) < 1 || overlap(q.ExcludeGenres, genres) > 0 {
+		return false
+	}
+	if !inRange(q.Year, queryYear(record)) || !inRange(q.Rating, parseFloat(record.ImdbRating)) ||
+		!inRange(q.Metascore, parseFloat(record.Metascore)) || !inRange(q.Runtime, queryRuntime(record)) {
+		return false
+	}
+	if len(q.People) > 0 {
+		credits := make(map[string]bool)
+		for _, field := range []string{record.Director, record.Writer, record.Actors} {
+			for _, name := range splitList(field) {
+				if i := strings.Index(name, "("); i >= 0 {
+					name = strings.TrimSpace(name[:i])
+				}
+				credits[strings.ToLower(foldDiacritics(name))] = true
+			}
+		}
+		for _, person := range q.People {
+			if !credits[strings.ToLower(foldDiacritics(person))] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// describe lists the query's filters for its explanation
+func (q *Query) describe() []string {
+	filters := []string{"type = " + q.Type}
+	for _, genre := range q.Genres {
+		filters = append(filters, "genre includes "+genre)
+	}
+	for _, genre := range q.ExcludeGenres {
+		filters = append(filters, "genre excludes "+genre)
+	}
+	for _, bounds := range []struct {
+		name  string
+		value *models.QueryRange
+	}{{"year", q.Year}, {"rating", q.Rating}, {"metascore", q.Metascore}, {"runtime", q.Runtime}} {
+		if bounds.value == nil {
+			continue
+		}
+		if bounds.value.Min != nil {
+			filters = append(filters, fmt.Sprintf("%s >= %g", bounds.name, *bounds.value.Min))
+		}
+		if bounds.value.Max != nil {
+			filters = append(filters, fmt.Sprintf("%s <= %g", bounds.name, *bounds.value.Max))
+		}
+	}
+	for _, person := range q.People {
+		filters = append(filters, "credits include "+person)
+	}
+	return filters
+}
+
+// sortOrder is the query's sort order as given, e.g. "-rating"
+func (q *Query) sortOrder() string {
+	if q.Descending {
+		return "-" + q.SortField
+	}
+	return q.SortField
+}
+
+// sort orders records by the query's sort field. Titles without a value for it go last.
+func (q *Query) sort(records []*models.OMDbResponse) {
+	key := func(record *models.OMDbResponse) *float64 {
+		switch q.SortField {
+		case "rating":
+			return parseFloat(record.ImdbRating)
+		case "popularity":
+			if votes := VoteCount(record.ImdbVotes); votes > 0 {
+				count := float64(votes)
+				return &count
+			}
+		case "metascore":
+			return parseFloat(record.Metascore)
+		case "blended":
+			return BlendedScore(newMovieBrief(record), *q.Weights)
+		case "year":
+			return queryYear(record)
+		case "runtime":
+			return queryRuntime(record)
+		}
+		return nil
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if q.SortField == "title" {
+			a, b := strings.ToLower(records[i].Title), strings.ToLower(records[j].Title)
+			if q.Descending {
+				return a > b
+			}
+			return a < b
+		}
+		a, b := key(records[i]), key(records[j])
+		switch {
+		case a == nil || b == nil:
+			return a != nil
+		case q.Descending:
+			return *a > *b
+		}
+		return *a < *b
+	})
+}
+
+// inRange reports whether value lies within bounds; an unknown value only lies within no bounds
+func inRange(bounds *models.QueryRange, value *float64) bool {
+	if bounds == nil {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	return (bounds.Min == nil || *value >= *bounds.Min) && (bounds.Max == nil || *value <= *bounds.Max)
+}
+
+// queryYear is the first year of a title; series years are ranges such as "2008–2013"
+func queryYear(record *models.OMDbResponse) *float64 {
+	if len(record.Year) < 4 {
+		return nil
+	}
+	return parseFloat(record.Year[:4])
+}
+
+// queryRuntime is the runtime of a title in minutes, from e.g. "136 min"
+func queryRuntime(record *models.OMDbResponse) *float64 {
+	return parseFloat(strings.TrimSuffix(record.Runtime, " min"))
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitScript counts a request in a fixed window unless the limit is reached. It
+// returns the window's count and whether the request was allowed.
+var rateLimitScript = NewRedisScript(`
+local count = tonumber(redis.call('GET', KEYS[1]) or '0')
+if count >= tonumber(ARGV[1]) then
+  return {count, 0}
+end
+count = redis.call('INCR', KEYS[1])
+if count == 1 then
+  redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return {count, 1}
+`)
+
+// counterScript adds to a counter that expires after ARGV[2] milliseconds and returns the total
+var counterScript = NewRedisScript(`
+local total = redis.call('INCRBY', KEYS[1], ARGV[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+  redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return total
+`)
+
+// usageRetention keeps daily usage counters in Redis until the day is well over everywhere
+const usageRetention = 48 * time.Hour
+
+// RateLimitStatus is a client's standing in its current rate limit window
+type RateLimitStatus struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	Allowed   bool
+}
+
+// RateLimiter allows each client a fixed number of requests per window. With Redis
+// configured the windows are counted there, so the limit holds across all replicas;
+// if Redis fails, each replica falls back to counting locally.
+type RateLimiter struct {
+	Limit  int
+	Window time.Duration
+
+	redis *RedisClient
+
+	mu      sync.Mutex
+	windows map[string]*clientWindow
+}
+
+type clientWindow struct {
+	start time.Time
+	count int
+}
+
+// NewRateLimiter reads RATE_LIMIT_PER_MINUTE (default 120) and uses Redis when REDIS_URL
+// is set. Zero disables limiting.
+func NewRateLimiter() *RateLimiter {
+	return newRateLimiter(envInt("RATE_LIMIT_PER_MINUTE", 120), time.Minute)
+}
+
+func newRateLimiter(limit int, window time.Duration) *RateLimiter {
+	// An invalid REDIS_URL is reported by NewOMDbService at startup
+	redis, _ := redisFromEnv()
+	return &RateLimiter{
+		Limit:   limit,
+		Window:  window,
+		redis:   redis,
+		windows: make(map[string]*clientWindow),
+	}
+}
+
+// Enabled reports whether requests are limited at all
+func (l *RateLimiter) Enabled() bool {
+	return l != nil && l.Limit > 0
+}
+
+// Allow counts a request from client and reports whether it is within the limit
+func (l *RateLimiter) Allow(client string) RateLimitStatus {
+	if l.redis != nil {
+		status, err := l.allowShared(client)
+		if err == nil {
+			return status
+		}
+		l.redis.logError("rate limit", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	window, ok := l.windows[client]
+	if !ok || now.Sub(window.start) >= l.Window {
+		l.pruneLocked(now)
+		window = &clientWindow{start: now.Truncate(l.Window)}
+		l.windows[client] = window
+	}
+
+	status := RateLimitStatus{
+		Limit: l.Limit,
+		Reset: window.start.Add(l.Window),
+	}
+	if window.count >= l.Limit {
+		return status
+	}
+
+	window.count++
+	status.Remaining = l.Limit - window.count
+	status.Allowed = true
+	return status
+}
+
+// allowShared counts the request in the client's window in Redis
+func (l *RateLimiter) allowShared(client string) (RateLimitStatus, error) {
+	start := time.Now().Truncate(l.Window)
+	status := RateLimitStatus{Limit: l.Limit, Reset: start.Add(l.Window)}
+
+	key := l.redis.Key("ratelimit", client, strconv.FormatInt(start.Unix(), 10))
+	reply, err := l.redis.Run(context.Background(), rateLimitScript, []string{key}, l.Limit, (l.Window + time.Second).Milliseconds())
+	if err != nil {
+		return status, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return status, fmt.Errorf("unexpected rate limit reply %v", reply)
+	}
+	count, _ := values[0].(int64)
+	allowed, _ := values[1].(int64)
+	if allowed == 1 {
+		status.Allowed = true
+		status.Remaining = max(l.Limit-int(count), 0)
+	}
+	return status, nil
+}
+
+// pruneLocked forgets clients whose window has ended, so idle clients don't accumulate
+func (l *RateLimiter) pruneLocked(now time.Time) {
+	for client, window := range l.windows {
+		if now.Sub(window.start) >= l.Window {
+			delete(l.windows, client)
+		}
+	}
+}
+
+// UsageTracker counts the upstream OMDb calls each client has caused during the current
+// UTC day. OMDb's quota is daily, so this is what clients need to pace themselves. With
+// Redis configured the counts are shared by all replicas.
+type UsageTracker struct {
+	redis *RedisClient
+
+	mu    sync.Mutex
+	day   string
+	calls map[string]int
+}
+
+func NewUsageTracker() *UsageTracker {
+	redis, _ := redisFromEnv()
+	return &UsageTracker{
+		redis: redis,
+		calls: make(map[string]int),
+	}
+}
+
+// Add records calls made on behalf of client
+func (u *UsageTracker) Add(client string, calls int) {
+	if calls == 0 {
+		return
+	}
+	if u.redis != nil {
+		_, err := u.redis.Run(context.Background(), counterScript, []string{u.key(client)}, calls, usageRetention.Milliseconds())
+		if err == nil {
+			return
+		}
+		u.redis.logError("usage count", err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.rolloverLocked()
+	u.calls[client] += calls
+}
+
+// Used returns the calls client has made today
+func (u *UsageTracker) Used(client string) int {
+	if u.redis != nil {
+		value, err := u.redis.Get(context.Background(), u.key(client))
+		if err == nil {
+			used, _ := strconv.Atoi(value)
+			return used
+		}
+		u.redis.logError("usage lookup", err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.rolloverLocked()
+	return u.calls[client]
+}
+
+// key is the Redis counter of client's usage today
+func (u *UsageTracker) key(client string) string {
+	return u.redis.Key("usage", time.Now().UTC().Format("2006-01-02"), client)
+}
+
+func (u *UsageTracker) rolloverLocked() {
+	if today := time.Now().UTC().Format("2006-01-02"); today != u.day {
+		u.day = today
+		u.calls = make(map[string]int)
+	}
+}
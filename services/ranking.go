@@ -0,0 +1,100 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+
+	"movie-api-go/models"
+)
+
+// GenreListSize is the number of titles the genre endpoint returns
+const GenreListSize = 15
+
+// Orders of genre lists
+const (
+	// SortRating puts the best rated titles first
+	SortRating = "rating"
+	// SortPopularity puts the titles with the most IMDb votes first
+	SortPopularity = "popularity"
+	// SortMetascore puts the titles critics rate best on Metacritic first
+	SortMetascore = "metascore"
+	// SortBlended puts the titles with the highest blended score first
+	SortBlended = "blended"
+)
+
+// ValidBriefSort reports whether order is one SortBriefs knows
+func ValidBriefSort(order string) bool {
+	return order == SortRating || order == SortPopularity || order == SortMetascore || order == SortBlended
+}
+
+// SortBriefs orders movies by IMDb rating, by popularity: the number of IMDb votes, by
+// Metascore or by blended score (see BlendedScore), which must have been set. Rating alone
+// ranks a well-reviewed title few have seen above the blockbusters users expect to find
+// first, and critics-focused clients prefer Metacritic. Ties are broken by IMDb rating,
+// then votes; titles without a Metascore or blended score come last by those.
+func SortBriefs(movies []models.MovieBrief, order string) {
+	rating := func(movie models.MovieBrief) float64 {
+		value, _ := strconv.ParseFloat(movie.ImdbRating, 64)
+		return value
+	}
+	blended := func(movie models.MovieBrief) float64 {
+		if movie.BlendedScore == nil {
+			return -1
+		}
+		return *movie.BlendedScore
+	}
+	sort.SliceStable(movies, func(i, j int) bool {
+		ratingI, ratingJ := rating(movies[i]), rating(movies[j])
+		votesI, votesJ := movies[i].VoteCount, movies[j].VoteCount
+		switch {
+		case order == SortPopularity && votesI != votesJ:
+			return votesI > votesJ
+		case order == SortMetascore && movies[i].Metascore != movies[j].Metascore:
+			return movies[i].Metascore > movies[j].Metascore
+		case order == SortBlended && blended(movies[i]) != blended(movies[j]):
+			return blended(movies[i]) > blended(movies[j])
+		case ratingI != ratingJ:
+			return ratingI > ratingJ
+		}
+		return votesI > votesJ
+	})
+}
+
+// levelReasons names what each recommendation level matched a movie on
+var levelReasons = map[int]string{1: "genre", 2: "director", 3: "actor"}
+
+// DedupeLevels keeps each movie only in the first level it appears in, the one with the
+// highest priority, so the same few titles don't fill every level. A movie that matched
+// several levels lists what each matched it on in Reasons, e.g. ["genre", "director"].
+// Levels left empty are dropped.
+func DedupeLevels(levels []models.MovieLevel) []models.MovieLevel {
+	reasons := make(map[string][]string)
+	for _, level := range levels {
+		for _, movie := range level.Movies {
+			key := briefKey(movie)
+			reasons[key] = append(reasons[key], levelReasons[level.Level])
+		}
+	}
+
+	seen := make(map[string]bool)
+	deduped := make([]models.MovieLevel, 0, len(levels))
+	for _, level := range levels {
+		movies := make([]models.MovieBrief, 0, len(level.Movies))
+		for _, movie := range level.Movies {
+			key := briefKey(movie)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if len(reasons[key]) > 1 {
+				movie.Reasons = reasons[key]
+			}
+			movies = append(movies, movie)
+		}
+		if len(movies) > 0 {
+			level.Movies = movies
+			deduped = append(deduped, level)
+		}
+	}
+	return deduped
+}
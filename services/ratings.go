@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// ErrInvalidRating wraps the reason a rating was rejected
+var ErrInvalidRating = errors.New("invalid rating")
+
+// imdbIDPattern matches IMDb title IDs such as tt0133093
+var imdbIDPattern = regexp.MustCompile(`^tt\d{7,}$`)
+
+// RatingStore keeps the ratings users give titles, persisted as a JSON file
+type RatingStore struct {
+	path string
+
+	mu      sync.Mutex
+	ratings map[string]map[string]models.UserRating
+}
+
+// NewRatingStore loads the ratings from RATINGS_PATH (default data/ratings.json)
+func NewRatingStore() (*RatingStore, error) {
+	path := os.Getenv("RATINGS_PATH")
+	if path == "" {
+		path = "data/ratings.json"
+	}
+
+	s := &RatingStore{path: path, ratings: make(map[string]map[string]models.UserRating)}
+	if err := store.LoadJSON(path, &s.ratings); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetMany validates and stores ratings for a user, replacing earlier ratings of the same
+// titles. Either all ratings are stored or none.
+func (s *RatingStore) SetMany(userID string, ratings []models.UserRating) ([]models.UserRating, error) {
+	now := time.Now().UTC()
+	stored := make([]models.UserRating, 0, len(ratings))
+	for _, rating := range ratings {
+		if !imdbIDPattern.MatchString(rating.ImdbID) {
+			return nil, fmt.Errorf("%w: %q is not an IMDb ID", ErrInvalidRating, rating.ImdbID)
+		}
+		if rating.Rating < 1 || rating.Rating > 10 {
+			return nil, fmt.Errorf("%w: rating of %s must be between 1 and 10", ErrInvalidRating, rating.ImdbID)
+		}
+		rating.RatedAt = now
+		stored = append(stored, rating)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.ratings[userID]
+	updated := make(map[string]models.UserRating, len(previous)+len(stored))
+	for imdbID, rating := range previous {
+		updated[imdbID] = rating
+	}
+	for _, rating := range stored {
+		updated[rating.ImdbID] = rating
+	}
+
+	s.ratings[userID] = updated
+	if err := store.SaveJSON(s.path, s.ratings); err != nil {
+		s.ratings[userID] = previous
+		return nil, err
+	}
+	return stored, nil
+}
+
+// List returns a user's ratings, most recent first
+func (s *RatingStore) List(userID string) []models.UserRating {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ratings := make([]models.UserRating, 0, len(s.ratings[userID]))
+	for _, rating := range s.ratings[userID] {
+		ratings = append(ratings, rating)
+	}
+	sort.Slice(ratings, func(i, j int) bool {
+		if !ratings[i].RatedAt.Equal(ratings[j].RatedAt) {
+			return ratings[i].RatedAt.After(ratings[j].RatedAt)
+		}
+		return ratings[i].ImdbID < ratings[j].ImdbID
+	})
+	return ratings
+}
+
+// all returns the ratings of every user
+func (s *RatingStore) all() []models.UserRating {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ratings []models.UserRating
+	for _, userRatings := range s.ratings {
+		for _, rating := range userRatings {
+			ratings = append(ratings, rating)
+		}
+	}
+	return ratings
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Roles a caller can have
+const (
+	RoleAdmin    = "admin"
+	RoleUser     = "user"
+	RoleReadonly = "readonly"
+	RoleService  = "service"
+)
+
+// Permissions required by route groups. Routes without one are public.
+const (
+	PermissionCatalogRead   = "catalog:read"
+	PermissionMonitorsRead  = "monitors:read"
+	PermissionMonitorsWrite = "monitors:write"
+	PermissionAccount       = "account"
+	PermissionRatingsWrite  = "ratings:write"
+	PermissionSocialWrite   = "social:write"
+	PermissionReviewsWrite  = "reviews:write"
+	PermissionAdmin         = "admin"
+)
+
+// rolePermissions is the permissions matrix. Service keys are for backend integrations:
+// they read the catalog and manage monitors but have no user account.
+var rolePermissions = map[string][]string{
+	RoleAdmin:    {PermissionCatalogRead, PermissionMonitorsRead, PermissionMonitorsWrite, PermissionAccount, PermissionRatingsWrite, PermissionSocialWrite, PermissionReviewsWrite, PermissionAdmin},
+	RoleUser:     {PermissionCatalogRead, PermissionMonitorsRead, PermissionMonitorsWrite, PermissionAccount, PermissionRatingsWrite, PermissionSocialWrite, PermissionReviewsWrite},
+	RoleReadonly: {PermissionCatalogRead, PermissionMonitorsRead, PermissionAccount},
+	RoleService:  {PermissionCatalogRead, PermissionMonitorsRead, PermissionMonitorsWrite},
+}
+
+// ValidRole reports whether role is one of the known roles
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// AccessPolicy decides the role of a caller and what the role may do
+type AccessPolicy struct {
+	// AdminToken is the shared admin token (ADMIN_API_KEY); it grants the admin role
+	AdminToken string
+	// AnonymousRole is the role of callers without credentials or with an unregistered API key
+	AnonymousRole string
+
+	keys    map[string]string
+	tenants map[string]string
+}
+
+// NewAccessPolicy reads API_KEY_ROLES, a comma-separated list of key:role pairs,
+// API_KEY_TENANTS, a list of key:tenant pairs naming the integration behind a key, and
+// ANONYMOUS_ROLE (default user, so that unauthenticated clients keep working)
+func NewAccessPolicy(adminToken string) (*AccessPolicy, error) {
+	p := &AccessPolicy{
+		AdminToken:    adminToken,
+		AnonymousRole: RoleUser,
+		keys:          make(map[string]string),
+		tenants:       make(map[string]string),
+	}
+	if role := os.Getenv("ANONYMOUS_ROLE"); role != "" {
+		if !ValidRole(role) {
+			return nil, fmt.Errorf("invalid ANONYMOUS_ROLE %q", role)
+		}
+		p.AnonymousRole = role
+	}
+
+	for _, entry := range strings.Split(os.Getenv("API_KEY_ROLES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, ":")
+		if i <= 0 || !ValidRole(entry[i+1:]) {
+			return nil, fmt.Errorf("invalid API_KEY_ROLES entry %q (expected key:role)", entry)
+		}
+		p.keys[entry[:i]] = entry[i+1:]
+	}
+
+	for _, entry := range strings.Split(os.Getenv("API_KEY_TENANTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, ":")
+		if i <= 0 || !tagSlugPattern.MatchString(entry[i+1:]) {
+			return nil, fmt.Errorf("invalid API_KEY_TENANTS entry %q (expected key:tenant, with a tenant such as acme-tv)", entry)
+		}
+		p.tenants[entry[:i]] = entry[i+1:]
+	}
+	return p, nil
+}
+
+// KeyTenant returns the tenant registered for an API key
+func (p *AccessPolicy) KeyTenant(key string) (string, bool) {
+	tenant, ok := p.tenants[key]
+	return tenant, ok
+}
+
+// KeyClientID identifies the client of an API key in rate limits, usage counters and
+// monitor ownership by a digest of the key, so the secret itself isn't stored
+func KeyClientID(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return "key:" + hex.EncodeToString(digest[:8])
+}
+
+// KeyRole returns the role registered for an API key
+func (p *AccessPolicy) KeyRole(key string) (string, bool) {
+	role, ok := p.keys[key]
+	return role, ok
+}
+
+// AdminEnabled reports whether any credential can reach the admin routes; users are only
+// promoted to admin through them
+func (p *AccessPolicy) AdminEnabled() bool {
+	if p.AdminToken != "" {
+		return true
+	}
+	for _, role := range p.keys {
+		if role == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed reports whether role has permission. The empty permission is public.
+func (p *AccessPolicy) Allowed(role, permission string) bool {
+	if permission == "" {
+		return true
+	}
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Matrix returns the permissions of every role
+func (p *AccessPolicy) Matrix() map[string][]string {
+	matrix := make(map[string][]string, len(rolePermissions))
+	for role, permissions := range rolePermissions {
+		matrix[role] = append([]string(nil), permissions...)
+	}
+	return matrix
+}
+
+// RolesWith lists the roles that have permission, sorted
+func (p *AccessPolicy) RolesWith(permission string) []string {
+	roles := []string{}
+	for role := range rolePermissions {
+		if p.Allowed(role, permission) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
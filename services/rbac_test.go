@@ -0,0 +1,101 @@
+package services
+
+import "testing"
+
+func TestAccessPolicyAllowed(t *testing.T) {
+	policy := &AccessPolicy{AnonymousRole: RoleUser}
+
+	tests := []struct {
+		role       string
+		permission string
+		want       bool
+	}{
+		{RoleAdmin, PermissionAdmin, true},
+		{RoleAdmin, PermissionReviewsWrite, true},
+		{RoleUser, PermissionAdmin, false},
+		{RoleUser, PermissionRatingsWrite, true},
+		{RoleUser, PermissionAccount, true},
+		{RoleReadonly, PermissionCatalogRead, true},
+		{RoleReadonly, PermissionMonitorsRead, true},
+		{RoleReadonly, PermissionMonitorsWrite, false},
+		{RoleReadonly, PermissionRatingsWrite, false},
+		{RoleReadonly, PermissionAdmin, false},
+		{RoleService, PermissionMonitorsWrite, true},
+		{RoleService, PermissionAccount, false},
+		{RoleService, PermissionSocialWrite, false},
+		{"superuser", PermissionCatalogRead, false},
+		{"", PermissionCatalogRead, false},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		if got := policy.Allowed(tt.role, tt.permission); got != tt.want {
+			t.Errorf("Allowed(%q, %q) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestNewAccessPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		anonymous string
+		keyRoles  string
+		tenants   string
+		wantErr   bool
+		wantRole  string
+		key       string
+		keyRole   string
+	}{
+		{name: "defaults", wantRole: RoleUser},
+		{name: "anonymous readonly", anonymous: RoleReadonly, wantRole: RoleReadonly},
+		{name: "unknown anonymous role", anonymous: "guest", wantErr: true},
+		{name: "key roles", keyRoles: "k1:service, k:2:admin", wantRole: RoleUser, key: "k:2", keyRole: RoleAdmin},
+		{name: "unknown key role", keyRoles: "k1:owner", wantErr: true},
+		{name: "key without role", keyRoles: "k1", wantErr: true},
+		{name: "tenants", tenants: "k1:acme-tv", wantRole: RoleUser},
+		{name: "invalid tenant", tenants: "k1:Acme TV", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ANONYMOUS_ROLE", tt.anonymous)
+			t.Setenv("API_KEY_ROLES", tt.keyRoles)
+			t.Setenv("API_KEY_TENANTS", tt.tenants)
+
+			policy, err := NewAccessPolicy("")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewAccessPolicy() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewAccessPolicy() = %v", err)
+			}
+			if policy.AnonymousRole != tt.wantRole {
+				t.Errorf("AnonymousRole = %q, want %q", policy.AnonymousRole, tt.wantRole)
+			}
+			if tt.key != "" {
+				if role, ok := policy.KeyRole(tt.key); !ok || role != tt.keyRole {
+					t.Errorf("KeyRole(%q) = %q, %v, want %q", tt.key, role, ok, tt.keyRole)
+				}
+			}
+		})
+	}
+}
+
+func TestAccessPolicyAdminEnabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *AccessPolicy
+		want   bool
+	}{
+		{name: "nothing", policy: &AccessPolicy{}, want: false},
+		{name: "admin token", policy: &AccessPolicy{AdminToken: "adm"}, want: true},
+		{name: "admin key", policy: &AccessPolicy{keys: map[string]string{"k1": RoleAdmin}}, want: true},
+		{name: "other keys", policy: &AccessPolicy{keys: map[string]string{"k1": RoleService}}, want: false},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.AdminEnabled(); got != tt.want {
+			t.Errorf("%s: AdminEnabled() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
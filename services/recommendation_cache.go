@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// RecommendationCache keeps complete recommendation responses by seed IMDb ID, engine
+// variant and algorithm version. Recommendations are the most expensive endpoint and the
+// same seeds are requested over and over, however the title is spelled. Raising
+// RECOMMENDATIONS_VERSION on deploy, or calling Invalidate after the algorithm changes,
+// retires every response computed by the previous algorithm.
+type RecommendationCache struct {
+	TTL        time.Duration
+	MaxEntries int
+	// Version identifies the recommendation algorithm the cached responses were computed with
+	Version string
+
+	mu         sync.Mutex
+	generation int
+	entries    map[string]recommendationEntry
+}
+
+type recommendationEntry struct {
+	body     []byte
+	storedAt time.Time
+}
+
+// NewRecommendationCache reads RECOMMENDATION_CACHE_TTL_SECONDS (default 86400, 0 disables),
+// RECOMMENDATION_CACHE_MAX_ENTRIES (default 1000) and RECOMMENDATIONS_VERSION (default "1")
+func NewRecommendationCache() *RecommendationCache {
+	version := os.Getenv("RECOMMENDATIONS_VERSION")
+	if version == "" {
+		version = "1"
+	}
+	return &RecommendationCache{
+		TTL:        time.Duration(envInt("RECOMMENDATION_CACHE_TTL_SECONDS", 86400)) * time.Second,
+		MaxEntries: envInt("RECOMMENDATION_CACHE_MAX_ENTRIES", 1000),
+		Version:    version,
+		entries:    make(map[string]recommendationEntry),
+	}
+}
+
+// CurrentVersion is the algorithm version new entries are stored under, including the
+// number of invalidations since startup (e.g. "1.0")
+func (rc *RecommendationCache) CurrentVersion() string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.versionLocked()
+}
+
+func (rc *RecommendationCache) versionLocked() string {
+	return fmt.Sprintf("%s.%d", rc.Version, rc.generation)
+}
+
+func (rc *RecommendationCache) keyLocked(imdbID, variant string) string {
+	return imdbID + "|" + variant + "|" + rc.versionLocked()
+}
+
+// Recommend serves the recommendations for a seed from the cache, computing and storing
+// them on a miss. Responses cut short by a request limit are not stored. The outcome is
+// recorded in the request scope like a detail cache lookup. Traced and explained requests
+// are always computed, so that the trace or explanation shows the lookups behind the
+// recommendations.
+func (rc *RecommendationCache) Recommend(ctx context.Context, imdbID, variant string, compute func(context.Context) (*models.RecommendationResponse, error)) (*models.RecommendationResponse, error) {
+	if TraceFrom(ctx) == nil && ExplainFrom(ctx) == nil {
+		if response, age, ok := rc.Get(imdbID, variant); ok {
+			ScopeFrom(ctx).recordCache(CacheHit, age)
+			return response, nil
+		}
+	}
+
+	response, err := compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if scope := ScopeFrom(ctx); scope.Meta() == nil && scope.FailedItems() == nil {
+		rc.Set(imdbID, variant, response)
+	}
+	return response, nil
+}
+
+// Get returns a copy of the cached response for a seed and its age
+func (rc *RecommendationCache) Get(imdbID, variant string) (*models.RecommendationResponse, time.Duration, bool) {
+	if rc.TTL == 0 {
+		return nil, 0, false
+	}
+
+	rc.mu.Lock()
+	key := rc.keyLocked(imdbID, variant)
+	entry, ok := rc.entries[key]
+	if ok && time.Since(entry.storedAt) >= rc.TTL {
+		delete(rc.entries, key)
+		ok = false
+	}
+	rc.mu.Unlock()
+	if !ok {
+		return nil, 0, false
+	}
+
+	// Responses are stored encoded so that callers can add links without sharing slices
+	var response models.RecommendationResponse
+	if err := json.Unmarshal(entry.body, &response); err != nil {
+		return nil, 0, false
+	}
+	return &response, time.Since(entry.storedAt), true
+}
+
+// Set stores the response computed for a seed under the current version
+func (rc *RecommendationCache) Set(imdbID, variant string, response *models.RecommendationResponse) {
+	if rc.TTL == 0 {
+		return
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	key := rc.keyLocked(imdbID, variant)
+	if _, ok := rc.entries[key]; !ok && rc.MaxEntries > 0 && len(rc.entries) >= rc.MaxEntries {
+		rc.evictLocked()
+	}
+	rc.entries[key] = recommendationEntry{body: body, storedAt: time.Now()}
+}
+
+// Invalidate retires every cached response by moving to a new version. It returns the
+// new version and the number of responses dropped.
+func (rc *RecommendationCache) Invalidate() (string, int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	purged := len(rc.entries)
+	rc.generation++
+	rc.entries = make(map[string]recommendationEntry)
+	return rc.versionLocked(), purged
+}
+
+// evictLocked drops expired entries, or the oldest entry if none have expired
+func (rc *RecommendationCache) evictLocked() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range rc.entries {
+		if time.Since(entry.storedAt) >= rc.TTL {
+			delete(rc.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.storedAt.Before(oldest) {
+			oldestKey, oldest = key, entry.storedAt
+		}
+	}
+	if len(rc.entries) >= rc.MaxEntries && oldestKey != "" {
+		delete(rc.entries, oldestKey)
+	}
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"movie-api-go/models"
+)
+
+// Recommendation engine variants
+const (
+	RecommendationsV1 = "v1"
+	RecommendationsV2 = "v2"
+)
+
+// Weights of the v2 similarity score; each signal is in [0, 1]
+const (
+	weightGenre    = 0.40
+	weightDirector = 0.25
+	weightActor    = 0.15
+	weightRating   = 0.20
+)
+
+// RecommendationCanary decides which engine serves a recommendation request and counts
+// the requests served by each. Assignment hashes the favorite movie, so a given query
+// always gets the same variant and cached responses stay consistent with it.
+type RecommendationCanary struct {
+	// Percent is the share of favorite movies routed to v2 (0-100)
+	Percent int
+
+	mu     sync.Mutex
+	served map[string]int
+}
+
+// NewRecommendationCanary reads RECOMMENDATIONS_V2_PERCENT (default 0, v1 only)
+func NewRecommendationCanary() *RecommendationCanary {
+	percent := envInt("RECOMMENDATIONS_V2_PERCENT", 0)
+	if percent > 100 {
+		percent = 100
+	}
+	return &RecommendationCanary{Percent: percent, served: make(map[string]int)}
+}
+
+// Variant returns the engine for a favorite movie title
+func (rc *RecommendationCanary) Variant(favorite string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(strings.ToLower(strings.TrimSpace(favorite))))
+	if int(hash.Sum32()%100) < rc.Percent {
+		return RecommendationsV2
+	}
+	return RecommendationsV1
+}
+
+// Record counts one response served by variant
+func (rc *RecommendationCanary) Record(variant string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.served[variant]++
+}
+
+// Report returns the configured split and the responses served by each variant
+func (rc *RecommendationCanary) Report() models.CanaryReport {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	report := models.CanaryReport{
+		Percent: rc.Percent,
+		Served:  map[string]int{RecommendationsV1: 0, RecommendationsV2: 0},
+	}
+	for variant, count := range rc.served {
+		report.Served[variant] = count
+	}
+	return report
+}
+
+// scoredBrief is a v2 candidate with the signals that matched it
+type scoredBrief struct {
+	movie    models.MovieBrief
+	viaActor bool
+	score    float64
+}
+
+// GetMovieRecommendationsV2 ranks candidates from the same genre, director and actor
+// searches as the legacy engine by one similarity score instead of returning them in
+// fixed levels. The score weighs genre overlap, a shared director, a shared lead actor
+// and the IMDb rating; the best 20 are returned as a single level.
+func (s *OMDbService) GetMovieRecommendationsV2(ctx context.Context, favoriteMovie *models.OMDbResponse) (*models.RecommendationResponse, error) {
+	response := &models.RecommendationResponse{
+		FavoriteMovie:   newMovieBrief(favoriteMovie),
+		Recommendations: []models.MovieLevel{},
+	}
+
+	explain := ExplainFrom(ctx)
+	candidates := make(map[string]*scoredBrief)
+	collect := func(term, signal string, viaActor bool) {
+		if term == "" {
+			return
+		}
+		step := explain.Begin(ctx)
+		movies, err := s.searchMoviesForRecommendation(ctx, term, favoriteMovie)
+		step.End(models.ExplainStage{Stage: "search", Strategy: signal, Provider: "omdb", Terms: []string{term}, Candidates: len(movies)})
+		if err != nil {
+			return
+		}
+		for _, movie := range movies {
+			key := titleYearKey(movie.Title, movie.Year)
+			candidate, ok := candidates[key]
+			if !ok {
+				candidate = &scoredBrief{movie: movie}
+				candidates[key] = candidate
+			}
+			candidate.viaActor = candidate.viaActor || viaActor
+		}
+	}
+
+	favoriteGenres := splitList(favoriteMovie.Genre)
+	favoriteDirectors := splitList(favoriteMovie.Director)
+	for _, genre := range favoriteGenres {
+		collect(genre, "genre", false)
+	}
+	for _, director := range favoriteDirectors {
+		collect(director, "director", false)
+	}
+	for i, actor := range splitList(favoriteMovie.Actors) {
+		if i >= 2 { // Only use first 2 main actors
+			break
+		}
+		collect(actor, "actor", true)
+	}
+
+	ranked := make([]*scoredBrief, 0, len(candidates))
+	for _, candidate := range candidates {
+		rating, err := strconv.ParseFloat(candidate.movie.ImdbRating, 64)
+		if err != nil || rating <= 0 {
+			continue
+		}
+		candidate.score = weightGenre*overlap(favoriteGenres, splitList(candidate.movie.Genre)) +
+			weightRating*rating/10
+		if overlap(favoriteDirectors, splitList(candidate.movie.Director)) > 0 {
+			candidate.score += weightDirector
+		}
+		if candidate.viaActor {
+			candidate.score += weightActor
+		}
+		ranked = append(ranked, candidate)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].movie.Title < ranked[j].movie.Title
+	})
+	if len(ranked) > 20 {
+		ranked = ranked[:20]
+	}
+	explain.Add(models.ExplainStage{
+		Stage:      "rank",
+		Strategy:   "similarity score, top 20",
+		Filters:    []string{"imdb_rating > 0"},
+		Input:      len(candidates),
+		Candidates: len(ranked),
+	})
+
+	if len(ranked) > 0 {
+		movies := make([]models.MovieBrief, len(ranked))
+		for i, candidate := range ranked {
+			movies[i] = candidate.movie
+		}
+		response.Recommendations = append(response.Recommendations, models.MovieLevel{
+			Level:       1,
+			Description: "Movies ranked by similarity",
+			Movies:      movies,
+		})
+	}
+
+	return response, nil
+}
+
+// splitList splits an OMDb comma-separated field, dropping N/A and empty entries
+func splitList(field string) []string {
+	var items []string
+	for _, item := range strings.Split(field, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// overlap returns the fraction of want that also appears in have, ignoring case
+func overlap(want, have []string) float64 {
+	if len(want) == 0 {
+		return 0
+	}
+	matched := 0
+	for _, w := range want {
+		for _, h := range have {
+			if strings.EqualFold(w, h) {
+				matched++
+				break
+			}
+		}
+	}
+	return float64(matched) / float64(len(want))
+}
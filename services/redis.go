@@ -0,0 +1,315 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errRedisNoScript is Redis's answer to EVALSHA for a script it hasn't cached
+var errRedisNoScript = errors.New("NOSCRIPT")
+
+// RedisClient is a minimal Redis client for the counters and cache shared between
+// replicas. It speaks RESP over a small pool of connections. Counters are Lua scripts,
+// which Redis executes atomically, so a read-modify-write needs a single round trip;
+// the cache only needs GET and SET.
+type RedisClient struct {
+	// KeyPrefix namespaces the keys of this deployment
+	KeyPrefix string
+	// Timeout bounds each command, including dialing
+	Timeout time.Duration
+
+	address  string
+	useTLS   bool
+	username string
+	password string
+	db       int
+
+	pool chan *redisConn
+
+	logMu    sync.Mutex
+	loggedAt time.Time
+}
+
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// RedisScript is a Lua script run by its SHA1, loaded on first use
+type RedisScript struct {
+	source string
+	sha    string
+}
+
+// NewRedisScript prepares a Lua script
+func NewRedisScript(source string) *RedisScript {
+	sum := sha1.Sum([]byte(source))
+	return &RedisScript{source: source, sha: hex.EncodeToString(sum[:])}
+}
+
+var (
+	sharedRedisOnce sync.Once
+	sharedRedisErr  error
+	sharedRedis     *RedisClient
+)
+
+// redisFromEnv returns the client configured by REDIS_URL (e.g. redis://:secret@host:6379/0,
+// or rediss:// for TLS), REDIS_KEY_PREFIX (default movie-api:), REDIS_TIMEOUT_MS (default
+// 200) and REDIS_POOL_SIZE (default 8). Every component shares one client. It returns
+// nil when REDIS_URL is unset.
+func redisFromEnv() (*RedisClient, error) {
+	sharedRedisOnce.Do(func() {
+		raw := strings.TrimSpace(os.Getenv("REDIS_URL"))
+		if raw == "" {
+			return
+		}
+		sharedRedis, sharedRedisErr = newRedisClient(raw)
+	})
+	return sharedRedis, sharedRedisErr
+}
+
+func newRedisClient(raw string) (*RedisClient, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "redis" && parsed.Scheme != "rediss") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid REDIS_URL %q: expected redis://[user:password@]host:port[/db]", raw)
+	}
+
+	client := &RedisClient{
+		KeyPrefix: os.Getenv("REDIS_KEY_PREFIX"),
+		Timeout:   time.Duration(envInt("REDIS_TIMEOUT_MS", 200)) * time.Millisecond,
+		address:   parsed.Host,
+		useTLS:    parsed.Scheme == "rediss",
+		pool:      make(chan *redisConn, max(envInt("REDIS_POOL_SIZE", 8), 1)),
+	}
+	if client.KeyPrefix == "" {
+		client.KeyPrefix = "movie-api:"
+	}
+	if parsed.Port() == "" {
+		client.address = net.JoinHostPort(parsed.Hostname(), "6379")
+	}
+	if parsed.User != nil {
+		client.username = parsed.User.Username()
+		client.password, _ = parsed.User.Password()
+		if client.password == "" {
+			// redis://secret@host is a password without a user
+			client.username, client.password = "", client.username
+		}
+	}
+	if db := strings.Trim(parsed.Path, "/"); db != "" {
+		if client.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid REDIS_URL %q: database must be a number", raw)
+		}
+	}
+	return client, nil
+}
+
+// Key prefixes a key with the deployment's namespace
+func (r *RedisClient) Key(parts ...string) string {
+	return r.KeyPrefix + strings.Join(parts, ":")
+}
+
+// Run executes script with keys and args and returns its reply: an int64, a string, nil
+// or a []interface{} of those
+func (r *RedisClient) Run(ctx context.Context, script *RedisScript, keys []string, args ...interface{}) (interface{}, error) {
+	command := make([]interface{}, 0, 3+len(keys)+len(args))
+	command = append(command, "EVALSHA", script.sha, len(keys))
+	for _, key := range keys {
+		command = append(command, key)
+	}
+	command = append(command, args...)
+
+	reply, err := r.do(ctx, command...)
+	if errors.Is(err, errRedisNoScript) {
+		command[0], command[1] = "EVAL", script.source
+		reply, err = r.do(ctx, command...)
+	}
+	return reply, err
+}
+
+// Get returns the value of key, or "" if it doesn't exist
+func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
+	reply, err := r.do(ctx, "GET", key)
+	value, _ := reply.(string)
+	return value, err
+}
+
+// Set stores value under key, expiring after ttl
+func (r *RedisClient) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := r.do(ctx, "SET", key, value, "PX", max(ttl.Milliseconds(), 1))
+	return err
+}
+
+// Ping checks that the server answers
+func (r *RedisClient) Ping(ctx context.Context) error {
+	_, err := r.do(ctx, "PING")
+	return err
+}
+
+// logError reports a failed command at most once a minute; callers fall back to local state
+func (r *RedisClient) logError(operation string, err error) {
+	r.logMu.Lock()
+	defer r.logMu.Unlock()
+
+	if time.Since(r.loggedAt) < time.Minute {
+		return
+	}
+	r.loggedAt = time.Now()
+	log.Printf("redis: %s on %s failed, using local state: %v", operation, r.address, err)
+}
+
+// do sends one command and reads its reply on a pooled connection
+func (r *RedisClient) do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(r.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	reply, err := conn.roundTrip(args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection is in an unknown state
+		conn.Close()
+		return nil, err
+	}
+
+	select {
+	case r.pool <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, err
+}
+
+// conn takes an idle connection from the pool or dials a new one
+func (r *RedisClient) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-r.pool:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: r.Timeout}
+	var raw net.Conn
+	var err error
+	if r.useTLS {
+		host, _, _ := net.SplitHostPort(r.address)
+		raw, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", r.address)
+	} else {
+		raw, err = dialer.DialContext(ctx, "tcp", r.address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &redisConn{Conn: raw, reader: bufio.NewReader(raw)}
+	conn.SetDeadline(time.Now().Add(r.Timeout))
+	if r.password != "" {
+		auth := []interface{}{"AUTH", r.password}
+		if r.username != "" {
+			auth = []interface{}{"AUTH", r.username, r.password}
+		}
+		if _, err := conn.roundTrip(auth...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis authentication failed: %w", err)
+		}
+	}
+	if r.db != 0 {
+		if _, err := conn.roundTrip("SELECT", r.db); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// redisError is an error reply; the connection stays usable
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+func (e redisError) Is(target error) bool {
+	return target == errRedisNoScript && strings.HasPrefix(string(e), "NOSCRIPT")
+}
+
+// roundTrip writes a command as an array of bulk strings and reads the reply
+func (c *redisConn) roundTrip(args ...interface{}) (interface{}, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		value, ok := arg.([]byte)
+		if !ok {
+			value = []byte(fmt.Sprint(arg))
+		}
+		fmt.Fprintf(&b, "$%d\r\n", len(value))
+		b.Write(value)
+		b.WriteString("\r\n")
+	}
+	if _, err := c.Write(b.Bytes()); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
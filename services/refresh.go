@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// Popularity tiers of cached lookups
+const (
+	TierHot  = "hot"
+	TierWarm = "warm"
+	TierCold = "cold"
+)
+
+// RefreshPolicy sets how long cached lookups stay fresh by how often they are read.
+// One TTL for the whole corpus either spends the upstream quota re-fetching titles nobody
+// asks for or serves the popular ones out of date; tiers keep the titles users see most
+// fresh and let the long tail age. An entry's popularity is the number of lookups it
+// served in the current and the previous AccessWindow.
+type RefreshPolicy struct {
+	// Interval between refresh passes
+	Interval time.Duration
+	// Batch is the number of entries refreshed per pass
+	Batch int
+
+	// AccessWindow is the period lookups are counted over
+	AccessWindow time.Duration
+	// HotAccesses and WarmAccesses are the popularity an entry needs for its tier
+	HotAccesses  int
+	WarmAccesses int
+	// HotTTL, WarmTTL and ColdTTL are how long the entries of each tier are fresh
+	HotTTL  time.Duration
+	WarmTTL time.Duration
+	ColdTTL time.Duration
+}
+
+// refreshPolicyFromEnv reads CACHE_REFRESH_MINUTES (default 15, 0 falls back to
+// DETAIL_CACHE_TTL_SECONDS for every entry), CACHE_REFRESH_BATCH (default 50),
+// CACHE_ACCESS_WINDOW_HOURS (default 24), CACHE_HOT_ACCESSES (default 10),
+// CACHE_WARM_ACCESSES (default 2), CACHE_HOT_TTL_HOURS (default 24),
+// CACHE_WARM_TTL_HOURS (default 168) and CACHE_COLD_TTL_HOURS (default 720)
+func refreshPolicyFromEnv() *RefreshPolicy {
+	interval := time.Duration(envInt("CACHE_REFRESH_MINUTES", 15)) * time.Minute
+	if interval == 0 {
+		return nil
+	}
+	return &RefreshPolicy{
+		Interval:     interval,
+		Batch:        envInt("CACHE_REFRESH_BATCH", 50),
+		AccessWindow: time.Duration(envInt("CACHE_ACCESS_WINDOW_HOURS", 24)) * time.Hour,
+		HotAccesses:  envInt("CACHE_HOT_ACCESSES", 10),
+		WarmAccesses: envInt("CACHE_WARM_ACCESSES", 2),
+		HotTTL:       time.Duration(envInt("CACHE_HOT_TTL_HOURS", 24)) * time.Hour,
+		WarmTTL:      time.Duration(envInt("CACHE_WARM_TTL_HOURS", 168)) * time.Hour,
+		ColdTTL:      time.Duration(envInt("CACHE_COLD_TTL_HOURS", 720)) * time.Hour,
+	}
+}
+
+// Tier returns the tier of an entry with the given popularity
+func (p *RefreshPolicy) Tier(popularity int) string {
+	switch {
+	case popularity >= p.HotAccesses:
+		return TierHot
+	case popularity >= p.WarmAccesses:
+		return TierWarm
+	}
+	return TierCold
+}
+
+// TTL returns how long the entries of a tier are fresh
+func (p *RefreshPolicy) TTL(tier string) time.Duration {
+	switch tier {
+	case TierHot:
+		return p.HotTTL
+	case TierWarm:
+		return p.WarmTTL
+	}
+	return p.ColdTTL
+}
+
+// dueRefresh is a cached lookup the refresh policy wants fetched again
+type dueRefresh struct {
+	key        string
+	popularity int
+}
+
+// due marks up to limit entries that were read in the current or previous access window
+// and expire within lead as refreshing, most popular first, and returns their keys.
+// Entries nobody reads aren't refreshed; they expire at the cold TTL.
+func (c *DetailCache) due(lead time.Duration, limit int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var due []dueRefresh
+	for key, entry := range c.entries {
+		if entry.negative || entry.refreshing {
+			continue
+		}
+		popularity := entry.popularity(c.Policy.AccessWindow)
+		if popularity == 0 || time.Since(entry.storedAt) < c.ttlLocked(entry)-lead {
+			continue
+		}
+		due = append(due, dueRefresh{key: key, popularity: popularity})
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].popularity != due[j].popularity {
+			return due[i].popularity > due[j].popularity
+		}
+		return due[i].key < due[j].key
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	keys := make([]string, 0, len(due))
+	for _, entry := range due {
+		c.entries[entry.key].refreshing = true
+		keys = append(keys, entry.key)
+	}
+	return keys
+}
+
+// CacheRefresh refreshes cached lookups on the schedule of the cache's refresh policy,
+// one pass ahead of their expiry, so that popular titles are replaced before anyone is
+// served them stale
+type CacheRefresh struct {
+	omdb *OMDbService
+}
+
+// NewCacheRefresh creates the refresh job of the service's detail cache
+func NewCacheRefresh(omdb *OMDbService) *CacheRefresh {
+	return &CacheRefresh{omdb: omdb}
+}
+
+// Run refreshes the entries due every policy Interval until ctx is done. It does nothing
+// without a cache or a refresh policy.
+func (r *CacheRefresh) Run(ctx context.Context) {
+	cache := r.omdb.Cache
+	if !cache.Enabled() || cache.Policy == nil || cache.Policy.Batch <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cache.Policy.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if refreshed := r.RefreshDue(ctx); refreshed > 0 {
+				log.Printf("cache: refreshed %d entries due under the refresh policy", refreshed)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RefreshDue refreshes up to the policy's Batch of due entries, most popular first, and
+// returns how many were refreshed. A pass stops early once the upstream quota is exhausted;
+// the entries it didn't get to are left to the next pass.
+func (r *CacheRefresh) RefreshDue(ctx context.Context) int {
+	cache := r.omdb.Cache
+	keys := cache.due(cache.Policy.Interval, cache.Policy.Batch)
+
+	refreshed := 0
+	for i, key := range keys {
+		params, err := url.ParseQuery(key)
+		if ctx.Err() != nil || err != nil {
+			cache.refreshFailed(key)
+			continue
+		}
+		params.Set("apikey", r.omdb.APIKey)
+		if err := isolate(func() error { return r.omdb.refresh(key, params) }); err != nil {
+			if errors.Is(err, ErrUpstreamQuota) {
+				for _, skipped := range keys[i+1:] {
+					cache.refreshFailed(skipped)
+				}
+				break
+			}
+			continue
+		}
+		refreshed++
+	}
+	return refreshed
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// Release statuses
+const (
+	ReleaseUpcoming    = "upcoming"
+	ReleaseInTheaters  = "in-theaters"
+	ReleaseHomeRelease = "home-release"
+	ReleaseClassic     = "classic"
+)
+
+// omdbDateLayout is the layout of OMDb's Released and DVD dates, e.g. "31 Mar 1999"
+const omdbDateLayout = "02 Jan 2006"
+
+// ReleaseWindows classifies titles by where they are in their release cycle, from their
+// Released and DVD dates
+type ReleaseWindows struct {
+	// Theatrical is how long after its release a title without a home release date is
+	// considered in theaters
+	Theatrical time.Duration
+	// Classic is the age after which a title is a classic
+	Classic time.Duration
+}
+
+// releaseWindowsFromEnv reads RELEASE_THEATRICAL_DAYS (default 90) and
+// RELEASE_CLASSIC_YEARS (default 25)
+func releaseWindowsFromEnv() ReleaseWindows {
+	return ReleaseWindows{
+		Theatrical: time.Duration(envInt("RELEASE_THEATRICAL_DAYS", 90)) * 24 * time.Hour,
+		Classic:    time.Duration(envInt("RELEASE_CLASSIC_YEARS", 25)) * 365 * 24 * time.Hour,
+	}
+}
+
+// Status returns the release status of a title as of now: upcoming before its release,
+// in-theaters until its home release or the end of the theatrical window, home-release
+// after that and classic once it is older than the classic window. Titles without a
+// release date are classified by year when possible, and "" otherwise.
+func (w ReleaseWindows) Status(record *models.OMDbResponse, now time.Time) string {
+	released, ok := ParseOMDbDate(record.Released)
+	if !ok {
+		year, err := strconv.Atoi(strings.TrimSpace(firstN(record.Year, 4)))
+		switch {
+		case err != nil:
+			return ""
+		case year > now.Year():
+			return ReleaseUpcoming
+		case now.Year()-year >= int(w.Classic/(365*24*time.Hour)):
+			return ReleaseClassic
+		}
+		return ""
+	}
+
+	if released.After(now) {
+		return ReleaseUpcoming
+	}
+	if now.Sub(released) >= w.Classic {
+		return ReleaseClassic
+	}
+	if dvd, ok := ParseOMDbDate(record.DVD); ok && !dvd.After(now) {
+		return ReleaseHomeRelease
+	}
+	if now.Sub(released) < w.Theatrical {
+		return ReleaseInTheaters
+	}
+	return ReleaseHomeRelease
+}
+
+// ParseOMDbDate parses an OMDb date such as "31 Mar 1999"; empty dates are not ok
+func ParseOMDbDate(value string) (time.Time, bool) {
+	date, err := time.Parse(omdbDateLayout, strings.TrimSpace(value))
+	return date, err == nil
+}
+
+func firstN(value string, n int) string {
+	if len(value) > n {
+		return value[:n]
+	}
+	return value
+}
+
+// Upcoming returns the movies released after now, of a genre unless it is "", soonest
+// first. OMDb can't be searched by release date, so they come from the local corpus of
+// titles looked up so far and only titles someone has asked for are listed.
+func (s *OMDbService) Upcoming(genre string, now time.Time) []models.UpcomingRelease {
+	releases := []models.UpcomingRelease{}
+	for _, record := range s.History.Records() {
+		if record.Type != "" && record.Type != "movie" {
+			continue
+		}
+		released, ok := ParseOMDbDate(record.Released)
+		if !ok || !released.After(now) {
+			continue
+		}
+		if genre != "" && !hasGenre(record, genre) {
+			continue
+		}
+		releases = append(releases, models.UpcomingRelease{
+			ReleaseDate: released.Format("2006-01-02"),
+			Movie:       newMovieBrief(record),
+		})
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		if releases[i].ReleaseDate != releases[j].ReleaseDate {
+			return releases[i].ReleaseDate < releases[j].ReleaseDate
+		}
+		return releases[i].Movie.Title < releases[j].Movie.Title
+	})
+	return releases
+}
+
+// hasGenre reports whether one of a record's genres is genre, ignoring case
+func hasGenre(record *models.OMDbResponse, genre string) bool {
+	for _, g := range splitList(record.Genre) {
+		if strings.EqualFold(g, genre) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+var (
+	// ErrInvalidReport wraps the reason a report or its resolution was rejected
+	ErrInvalidReport = errors.New("invalid report")
+
+	// ErrReportNotFound is returned for unknown report IDs
+	ErrReportNotFound = errors.New("report not found")
+)
+
+// Report statuses
+const (
+	ReportOpen     = "open"
+	ReportResolved = "resolved"
+)
+
+// Resolutions of reported content. Removing rejects a review and keeps a watchlist
+// hidden; banning also demotes the owner to the readonly role.
+const (
+	ResolveDismiss = "dismiss"
+	ResolveRemove  = "remove"
+	ResolveBan     = "ban"
+)
+
+// reportReasons are the reasons users can give for a report
+var reportReasons = map[string]bool{"spam": true, "abuse": true, "spoiler": true, "other": true}
+
+// maxReportComment bounds the characters of a report's comment
+const maxReportComment = 1000
+
+// ReportStore keeps users' reports of reviews and watchlists breaking the rules,
+// persisted as a JSON file. Content reported by enough users is hidden until a
+// moderator resolves the reports.
+type ReportStore struct {
+	// HideThreshold is the number of open reports hiding content; zero never hides it
+	HideThreshold int
+
+	path string
+
+	mu      sync.Mutex
+	reports map[string]models.Report
+}
+
+// NewReportStore loads the reports from REPORTS_PATH (default data/reports.json) and
+// reads REPORT_HIDE_THRESHOLD (default 3)
+func NewReportStore() (*ReportStore, error) {
+	path := os.Getenv("REPORTS_PATH")
+	if path == "" {
+		path = "data/reports.json"
+	}
+
+	s := &ReportStore{
+		HideThreshold: max(envInt("REPORT_HIDE_THRESHOLD", 3), 0),
+		path:          path,
+		reports:       make(map[string]models.Report),
+	}
+	var reports []models.Report
+	if err := store.LoadJSON(path, &reports); err != nil {
+		return nil, err
+	}
+	for _, report := range reports {
+		s.reports[report.ID] = report
+	}
+	return s, nil
+}
+
+// File records a user's report of content owned by owner. A user has one open report
+// per piece of content: reporting it again returns that report, and created is false.
+func (s *ReportStore) File(reporterID, owner string, req models.ReportRequest) (report models.Report, created bool, err error) {
+	reason := strings.ToLower(strings.TrimSpace(req.Reason))
+	comment := strings.TrimSpace(req.Comment)
+	switch {
+	case req.Kind != ContentReview && req.Kind != ContentList:
+		return report, false, fmt.Errorf("%w: kind must be %s or %s", ErrInvalidReport, ContentReview, ContentList)
+	case !reportReasons[reason]:
+		return report, false, fmt.Errorf("%w: reason must be spam, abuse, spoiler or other", ErrInvalidReport)
+	case len([]rune(comment)) > maxReportComment:
+		return report, false, fmt.Errorf("%w: comment must have at most %d characters", ErrInvalidReport, maxReportComment)
+	case reporterID == owner:
+		return report, false, fmt.Errorf("%w: you can't report your own %s", ErrInvalidReport, req.Kind)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.reports {
+		if existing.Status == ReportOpen && existing.ReporterID == reporterID && existing.Kind == req.Kind && existing.TargetID == req.ID {
+			return s.withHiddenLocked(existing), false, nil
+		}
+	}
+
+	id, err := newReportID()
+	if err != nil {
+		return report, false, err
+	}
+	report = models.Report{
+		ID:         id,
+		Kind:       req.Kind,
+		TargetID:   req.ID,
+		OwnerID:    owner,
+		ReporterID: reporterID,
+		Reason:     reason,
+		Comment:    comment,
+		Status:     ReportOpen,
+		CreatedAt:  time.Now().UTC(),
+	}
+	s.reports[id] = report
+	if err := s.saveLocked(); err != nil {
+		delete(s.reports, id)
+		return models.Report{}, false, err
+	}
+	return s.withHiddenLocked(report), true, nil
+}
+
+// Hidden reports whether content is hidden: reported by at least HideThreshold users
+// and not yet reviewed by a moderator, or removed by one
+func (s *ReportStore) Hidden(kind, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.hiddenLocked(kind, id)
+}
+
+// Queue returns the reports in a status, oldest first, for moderators
+func (s *ReportStore) Queue(status string) []models.Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reports := []models.Report{}
+	for _, report := range s.reports {
+		if report.Status == status {
+			reports = append(reports, s.withHiddenLocked(report))
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if !reports[i].CreatedAt.Equal(reports[j].CreatedAt) {
+			return reports[i].CreatedAt.Before(reports[j].CreatedAt)
+		}
+		return reports[i].ID < reports[j].ID
+	})
+	return reports
+}
+
+// Resolve closes a report along with the other open reports of the same content,
+// recording the moderator's action. It returns the resolved reports.
+func (s *ReportStore) Resolve(id, action string) ([]models.Report, error) {
+	if action != ResolveDismiss && action != ResolveRemove && action != ResolveBan {
+		return nil, fmt.Errorf("%w: action must be %s, %s or %s", ErrInvalidReport, ResolveDismiss, ResolveRemove, ResolveBan)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report, ok := s.reports[id]
+	if !ok {
+		return nil, ErrReportNotFound
+	}
+	if report.Status != ReportOpen {
+		return nil, fmt.Errorf("%w: the report was already resolved", ErrInvalidReport)
+	}
+
+	now := time.Now().UTC()
+	previous := make(map[string]models.Report)
+	for key, other := range s.reports {
+		if other.Status == ReportOpen && other.Kind == report.Kind && other.TargetID == report.TargetID {
+			previous[key] = other
+			other.Status, other.Resolution, other.ResolvedAt = ReportResolved, action, &now
+			s.reports[key] = other
+		}
+	}
+	if err := s.saveLocked(); err != nil {
+		for key, other := range previous {
+			s.reports[key] = other
+		}
+		return nil, err
+	}
+
+	resolved := make([]models.Report, 0, len(previous))
+	for key := range previous {
+		resolved = append(resolved, s.withHiddenLocked(s.reports[key]))
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].CreatedAt.Before(resolved[j].CreatedAt) })
+	return resolved, nil
+}
+
+func (s *ReportStore) hiddenLocked(kind, id string) bool {
+	open := 0
+	for _, report := range s.reports {
+		if report.Kind != kind || report.TargetID != id {
+			continue
+		}
+		if report.Status == ReportResolved && report.Resolution != ResolveDismiss {
+			return true
+		}
+		if report.Status == ReportOpen {
+			open++
+		}
+	}
+	return s.HideThreshold > 0 && open >= s.HideThreshold
+}
+
+// withHiddenLocked marks whether the reported content is hidden
+func (s *ReportStore) withHiddenLocked(report models.Report) models.Report {
+	report.Hidden = s.hiddenLocked(report.Kind, report.TargetID)
+	return report
+}
+
+func (s *ReportStore) saveLocked() error {
+	reports := make([]models.Report, 0, len(s.reports))
+	for _, report := range s.reports {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ID < reports[j].ID })
+	return store.SaveJSON(s.path, reports)
+}
+
+func newReportID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "rp_" + hex.EncodeToString(id), nil
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"movie-api-go/models"
+)
+
+// ErrNotFound is returned when a title reference can't be resolved to an OMDb record
+var ErrNotFound = errors.New("title not found")
+
+const (
+	// resolutionCacheTTL is how long a resolved reference is reused before resolving again
+	resolutionCacheTTL = 24 * time.Hour
+
+	// minFuzzyConfidence is the lowest confidence at which a fuzzy match is accepted
+	minFuzzyConfidence = 0.5
+)
+
+// ResolveQuery references a title in one of the supported ways. ImdbID takes precedence
+// over Title (optionally narrowed by Year), which takes precedence over the free-text Query.
+type ResolveQuery struct {
+	ImdbID string
+	Title  string
+	Year   string
+	Query  string
+	// Type restricts resolution to one OMDb type (movie, series, episode, game); empty allows any
+	Type string
+}
+
+func (q ResolveQuery) cacheKey() string {
+	return strings.ToLower(strings.Join([]string{q.Type, q.ImdbID, canonicalTitle(q.Title), q.Year, canonicalTitle(q.Query)}, "|"))
+}
+
+// Resolver turns any title reference into a canonical IMDb ID with a confidence score.
+// Every endpoint that accepts a title goes through it, so matching behaves the same everywhere.
+// Resolutions are cached, up to MaxEntries, and a cached one is served with the record the
+// detail cache holds for its IMDb ID.
+type Resolver struct {
+	// MaxEntries bounds the resolution cache
+	MaxEntries int
+
+	omdb    *OMDbService
+	aliases *AliasStore
+
+	mu    sync.RWMutex
+	cache map[string]resolverEntry
+}
+
+type resolverEntry struct {
+	resolution models.Resolution
+	// lookup is the OMDb lookup the record came from, whose detail cache entry serves hits
+	lookup    url.Values
+	expiresAt time.Time
+}
+
+// NewResolver reads RESOLUTION_CACHE_MAX_ENTRIES (default 10000)
+func NewResolver(omdb *OMDbService, aliases *AliasStore) *Resolver {
+	return &Resolver{
+		MaxEntries: envInt("RESOLUTION_CACHE_MAX_ENTRIES", 10000),
+		omdb:       omdb,
+		aliases:    aliases,
+		cache:      make(map[string]resolverEntry),
+	}
+}
+
+// Resolve returns the resolution of the query together with the full OMDb record.
+// It returns ErrNotFound when nothing matches with sufficient confidence.
+func (r *Resolver) Resolve(ctx context.Context, q ResolveQuery) (*models.Resolution, *models.OMDbResponse, error) {
+	// Aliases are checked before the cache so that admin edits take effect immediately
+	if q.ImdbID == "" {
+		if alias, ok := r.lookupAlias(q); ok {
+			record, err := r.fetchByID(ctx, alias.ImdbID, q.Type)
+			if err != nil {
+				return nil, nil, err
+			}
+			return newResolution(record, 1, "alias"), record, nil
+		}
+	}
+
+	key := q.cacheKey()
+
+	r.mu.RLock()
+	entry, ok := r.cache[key]
+	r.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		// The record is usually still in the detail cache; it is only looked up again once it isn't
+		record, cached := r.omdb.cachedTitle(ctx, entry.lookup)
+		if !cached {
+			var err error
+			if record, err = r.fetchByID(ctx, entry.resolution.ImdbID, q.Type); err != nil {
+				return nil, nil, err
+			}
+		}
+		if q.Type == "" || record.Type == q.Type {
+			resolution := entry.resolution
+			return &resolution, record, nil
+		}
+	}
+
+	resolution, record, lookup, err := r.resolve(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.Lock()
+	if _, ok := r.cache[key]; !ok && r.MaxEntries > 0 && len(r.cache) >= r.MaxEntries {
+		r.evictLocked()
+	}
+	r.cache[key] = resolverEntry{resolution: *resolution, lookup: lookup, expiresAt: time.Now().Add(resolutionCacheTTL)}
+	r.mu.Unlock()
+
+	return resolution, record, nil
+}
+
+// evictLocked drops expired resolutions, or the one closest to expiry if none have expired
+func (r *Resolver) evictLocked() {
+	now := time.Now()
+	var soonestKey string
+	var soonest time.Time
+	for key, entry := range r.cache {
+		if now.After(entry.expiresAt) {
+			delete(r.cache, key)
+			continue
+		}
+		if soonestKey == "" || entry.expiresAt.Before(soonest) {
+			soonestKey, soonest = key, entry.expiresAt
+		}
+	}
+	if len(r.cache) >= r.MaxEntries && soonestKey != "" {
+		delete(r.cache, soonestKey)
+	}
+}
+
+// resolve resolves a query without the cache. It also returns the OMDb lookup the record
+// came from.
+func (r *Resolver) resolve(ctx context.Context, q ResolveQuery) (*models.Resolution, *models.OMDbResponse, url.Values, error) {
+	switch {
+	case q.ImdbID != "":
+		record, err := r.fetchByID(ctx, q.ImdbID, q.Type)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return newResolution(record, 1, "imdb_id"), record, r.omdb.idParams(q.ImdbID), nil
+
+	case q.Title != "":
+		record, err := r.omdb.GetTitle(ctx, q.Title, q.Year, q.Type)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if record.Response != "False" {
+			return newResolution(record, titleConfidence(q.Title, record.Title), "title"), record, r.omdb.titleParams(q.Title, q.Year, q.Type), nil
+		}
+		// Try the other common spellings before falling back to a search
+		for _, variant := range titleVariants(q.Title) {
+			record, err := r.omdb.GetTitle(ctx, variant, q.Year, q.Type)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if record.Response != "False" {
+				return newResolution(record, titleConfidence(q.Title, record.Title), "title_variant"), record, r.omdb.titleParams(variant, q.Year, q.Type), nil
+			}
+		}
+		return r.resolveFuzzy(ctx, q.Title, q.Year, q.Type)
+
+	case q.Query != "":
+		return r.resolveFuzzy(ctx, q.Query, q.Year, q.Type)
+	}
+
+	return nil, nil, nil, fmt.Errorf("no title reference given")
+}
+
+// titleConfidence rates a title lookup. OMDb's lookup is itself somewhat lenient, so only
+// a match that is the same title up to spelling variants is certain.
+func titleConfidence(query, title string) float64 {
+	if canonicalTitle(query) == canonicalTitle(title) {
+		return 1
+	}
+	return 0.9
+}
+
+// resolveFuzzy searches OMDb and picks the result whose title is most similar to the query
+func (r *Resolver) resolveFuzzy(ctx context.Context, query, year, titleType string) (*models.Resolution, *models.OMDbResponse, url.Values, error) {
+	searchResp, err := r.omdb.searchPage(ctx, query, titleType, year, 1)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if searchResp.Response == "False" || len(searchResp.Search) == 0 {
+		return nil, nil, nil, ErrNotFound
+	}
+
+	var best models.SearchResult
+	bestScore := -1.0
+	for _, result := range searchResp.Search {
+		score := titleSimilarity(query, result.Title)
+		if score > bestScore {
+			best, bestScore = result, score
+		}
+	}
+
+	if bestScore < minFuzzyConfidence {
+		return nil, nil, nil, ErrNotFound
+	}
+
+	record, err := r.fetchByID(ctx, best.ImdbID, titleType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return newResolution(record, bestScore, "fuzzy"), record, r.omdb.idParams(best.ImdbID), nil
+}
+
+func (r *Resolver) lookupAlias(q ResolveQuery) (models.Alias, bool) {
+	if r.aliases == nil {
+		return models.Alias{}, false
+	}
+	if q.Title != "" {
+		return r.aliases.Lookup(q.Title)
+	}
+	return r.aliases.Lookup(q.Query)
+}
+
+func (r *Resolver) fetchByID(ctx context.Context, imdbID, titleType string) (*models.OMDbResponse, error) {
+	record, err := r.omdb.GetTitleByID(ctx, imdbID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Response == "False" || titleType != "" && record.Type != titleType {
+		return nil, ErrNotFound
+	}
+	return record, nil
+}
+
+func newResolution(record *models.OMDbResponse, confidence float64, method string) *models.Resolution {
+	return &models.Resolution{
+		ImdbID:     record.ImdbID,
+		Title:      record.Title,
+		Year:       record.Year,
+		Type:       record.Type,
+		Confidence: math.Round(confidence*100) / 100,
+		Method:     method,
+	}
+}
+
+// normalizeTitle lower-cases a title, folds diacritics (see foldDiacritics) and reduces
+// punctuation and whitespace runs to single spaces
+func normalizeTitle(title string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(foldDiacritics(title)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteRune(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// titleSimilarity returns a 0-1 similarity of two titles based on the edit distance of
+// their canonical forms
+func titleSimilarity(a, b string) float64 {
+	ra, rb := []rune(canonicalTitle(a)), []rune(canonicalTitle(b))
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+var (
+	// ErrInvalidReview wraps the reason a review or a moderation decision was rejected
+	ErrInvalidReview = errors.New("invalid review")
+
+	// ErrReviewNotFound is returned for unknown review IDs
+	ErrReviewNotFound = errors.New("review not found")
+)
+
+// Review statuses. Reviews raising a moderation flag wait as pending until a moderator
+// publishes or rejects them.
+const (
+	ReviewPublished = "published"
+	ReviewPending   = "pending"
+	ReviewRejected  = "rejected"
+)
+
+// Moderation flags raised by a review's text
+const (
+	FlagLink       = "link"
+	FlagShouting   = "shouting"
+	FlagRepetition = "repetition"
+)
+
+// linkPattern matches web links in a review
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// Orders of a title's reviews
+const (
+	ReviewSortNewest = "newest"
+	ReviewSortTop    = "top"
+)
+
+// maxRepeatedRunes is the longest run of one character a review may have unflagged
+const maxRepeatedRunes = 9
+
+// ReviewStore keeps the reviews users write of titles, one per user and title, persisted
+// as a JSON file
+type ReviewStore struct {
+	// MinLength and MaxLength bound the characters of a review
+	MinLength int
+	MaxLength int
+
+	path    string
+	users   *UserStore
+	likes   *LikeStore
+	reports *ReportStore
+	filter  *ContentFilter
+
+	mu      sync.Mutex
+	reviews map[string]models.Review
+}
+
+// NewReviewStore loads the reviews from REVIEWS_PATH (default data/reviews.json) and reads
+// REVIEW_MIN_LENGTH (default 20) and REVIEW_MAX_LENGTH (default 5000)
+func NewReviewStore(users *UserStore, likes *LikeStore, reports *ReportStore, filter *ContentFilter) (*ReviewStore, error) {
+	path := os.Getenv("REVIEWS_PATH")
+	if path == "" {
+		path = "data/reviews.json"
+	}
+
+	s := &ReviewStore{
+		MinLength: max(envInt("REVIEW_MIN_LENGTH", 20), 1),
+		MaxLength: max(envInt("REVIEW_MAX_LENGTH", 5000), 1),
+		path:      path,
+		users:     users,
+		likes:     likes,
+		reports:   reports,
+		filter:    filter,
+		reviews:   make(map[string]models.Review),
+	}
+	var reviews []models.Review
+	if err := store.LoadJSON(path, &reviews); err != nil {
+		return nil, err
+	}
+	for _, review := range reviews {
+		s.reviews[review.ID] = review
+	}
+	return s, nil
+}
+
+// Post stores a user's review of a title, replacing their earlier one. The review is
+// published unless its text raises a moderation flag.
+func (s *ReviewStore) Post(userID, imdbID string, req models.ReviewRequest) (models.Review, error) {
+	body := strings.TrimSpace(req.Body)
+	if !imdbIDPattern.MatchString(imdbID) {
+		return models.Review{}, fmt.Errorf("%w: %q is not an IMDb ID", ErrInvalidReview, imdbID)
+	}
+	if req.Rating < 1 || req.Rating > 10 {
+		return models.Review{}, fmt.Errorf("%w: rating must be between 1 and 10", ErrInvalidReview)
+	}
+	if length := len([]rune(body)); length < s.MinLength || length > s.MaxLength {
+		return models.Review{}, fmt.Errorf("%w: body must have %d to %d characters", ErrInvalidReview, s.MinLength, s.MaxLength)
+	}
+	if !validSpoilerMarkup(body) {
+		return models.Review{}, fmt.Errorf("%w: every [spoiler] must be closed with [/spoiler], without nesting", ErrInvalidReview)
+	}
+
+	// Profanity and personal data are redacted, or the review rejected, before anyone reads it
+	body, violations := s.filter.Check(body)
+	if len(violations) > 0 {
+		action := s.filter.Mode
+		if err := s.filter.Record(userID, ContentReview, imdbID, violations, action); err != nil {
+			log.Printf("content filter: failed to record violations by %s: %v", userID, err)
+		}
+		if action == FilterReject {
+			return models.Review{}, fmt.Errorf("%w: body must not contain %s", ErrInvalidReview, strings.Join(violationNames(violations), " or "))
+		}
+	}
+
+	now := time.Now().UTC()
+	review := models.Review{
+		ImdbID:     imdbID,
+		UserID:     userID,
+		Rating:     req.Rating,
+		Body:       body,
+		Spoiler:    req.Spoiler,
+		Status:     ReviewPublished,
+		Flags:      moderationFlags(body),
+		Redactions: violations,
+		CreatedAt:  now,
+	}
+	if len(review.Flags) > 0 {
+		review.Status = ReviewPending
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.byAuthorLocked(userID, imdbID)
+	if existed {
+		review.ID, review.CreatedAt, review.UpdatedAt = previous.ID, previous.CreatedAt, &now
+	} else {
+		id, err := newReviewID()
+		if err != nil {
+			return models.Review{}, err
+		}
+		review.ID = id
+	}
+	s.reviews[review.ID] = review
+	if err := s.saveLocked(); err != nil {
+		if existed {
+			s.reviews[review.ID] = previous
+		} else {
+			delete(s.reviews, review.ID)
+		}
+		return models.Review{}, err
+	}
+	return s.named(review), nil
+}
+
+// Get returns a review by ID
+func (s *ReviewStore) Get(id string) (models.Review, bool) {
+	s.mu.Lock()
+	review, ok := s.reviews[id]
+	s.mu.Unlock()
+
+	if !ok {
+		return review, false
+	}
+	return s.named(review), true
+}
+
+// ReviewPage is one page of a title's reviews along with the cursor for the next page
+type ReviewPage struct {
+	Reviews    []models.Review
+	Total      int
+	NextCursor string
+}
+
+// Page returns up to limit reviews of a title after cursor (nil for the first page),
+// newest first or, sorted top, most liked first. Readers see the visible reviews and
+// their own in any status; Total counts them all.
+func (s *ReviewStore) Page(imdbID, reader, order string, cursor *FeedCursor, limit int) ReviewPage {
+	s.mu.Lock()
+	var reviews []models.Review
+	for _, review := range s.reviews {
+		if review.ImdbID == imdbID && s.Visible(review, reader) {
+			reviews = append(reviews, review)
+		}
+	}
+	s.mu.Unlock()
+
+	for i := range reviews {
+		reviews[i] = s.named(reviews[i])
+		_, reviews[i].Liked = s.likes.Count(reader, ContentReview, reviews[i].ID)
+	}
+	// Newest first ranks every review the same
+	score := func(review models.Review) int {
+		if order == ReviewSortTop {
+			return review.Likes
+		}
+		return 0
+	}
+
+	sort.Slice(reviews, func(i, j int) bool {
+		if score(reviews[i]) != score(reviews[j]) {
+			return score(reviews[i]) > score(reviews[j])
+		}
+		if !reviews[i].CreatedAt.Equal(reviews[j].CreatedAt) {
+			return reviews[i].CreatedAt.After(reviews[j].CreatedAt)
+		}
+		return reviews[i].ID < reviews[j].ID
+	})
+
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(reviews), func(i int) bool {
+			at, score := reviews[i].CreatedAt.UnixNano(), score(reviews[i])
+			if score != cursor.Score {
+				return score < cursor.Score
+			}
+			return at < cursor.At || (at == cursor.At && reviews[i].ID > cursor.Key)
+		})
+	}
+	end := min(start+limit, len(reviews))
+	page := ReviewPage{Reviews: reviews[start:end], Total: len(reviews)}
+	if end < len(reviews) && end > start {
+		last := reviews[end-1]
+		page.NextCursor = EncodeFeedCursor(FeedCursor{At: last.CreatedAt.UnixNano(), Key: last.ID, Score: score(last)})
+	}
+	return page
+}
+
+// Queue returns the reviews in a status, oldest first, for moderators
+func (s *ReviewStore) Queue(status string) []models.Review {
+	s.mu.Lock()
+	reviews := []models.Review{}
+	for _, review := range s.reviews {
+		if review.Status == status {
+			reviews = append(reviews, review)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(reviews, func(i, j int) bool {
+		if !reviews[i].CreatedAt.Equal(reviews[j].CreatedAt) {
+			return reviews[i].CreatedAt.Before(reviews[j].CreatedAt)
+		}
+		return reviews[i].ID < reviews[j].ID
+	})
+	for i := range reviews {
+		reviews[i] = s.named(reviews[i])
+	}
+	return reviews
+}
+
+// Moderate publishes or rejects a review, returning it before and after. The flags are
+// kept for the record.
+func (s *ReviewStore) Moderate(id, status string) (before, after models.Review, err error) {
+	if status != ReviewPublished && status != ReviewRejected {
+		return before, after, fmt.Errorf("%w: status must be %s or %s", ErrInvalidReview, ReviewPublished, ReviewRejected)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, ok := s.reviews[id]
+	if !ok {
+		return before, after, ErrReviewNotFound
+	}
+	now := time.Now().UTC()
+	after = before
+	after.Status, after.ModeratedAt = status, &now
+	s.reviews[id] = after
+	if err := s.saveLocked(); err != nil {
+		s.reviews[id] = before
+		return before, models.Review{}, err
+	}
+	return s.named(before), s.named(after), nil
+}
+
+// Visible reports whether a reader (empty for anonymous callers) sees a review: authors
+// see their own, and others those published and not hidden by reports
+func (s *ReviewStore) Visible(review models.Review, reader string) bool {
+	if reader != "" && review.UserID == reader {
+		return true
+	}
+	return review.Status == ReviewPublished && !s.reports.Hidden(ContentReview, review.ID)
+}
+
+// HideSpoilers replaces the body of a review marked as a spoiler, or else the spans it
+// tags as spoilers
+func HideSpoilers(review models.Review) models.Review {
+	if review.Spoiler {
+		review.Body, review.SpoilersHidden = SpoilerPlaceholder, true
+		return review
+	}
+	review.Body, review.SpoilersHidden = redactSpoilerMarkup(review.Body)
+	return review
+}
+
+// ValidReviewStatus reports whether status is one of the review statuses
+func ValidReviewStatus(status string) bool {
+	return status == ReviewPublished || status == ReviewPending || status == ReviewRejected
+}
+
+func (s *ReviewStore) byAuthorLocked(userID, imdbID string) (models.Review, bool) {
+	for _, review := range s.reviews {
+		if review.UserID == userID && review.ImdbID == imdbID {
+			return review, true
+		}
+	}
+	return models.Review{}, false
+}
+
+// named adds the author's current name and the likes to a review
+func (s *ReviewStore) named(review models.Review) models.Review {
+	if user, ok := s.users.Get(review.UserID); ok {
+		review.Name = user.Name
+	}
+	review.Likes, _ = s.likes.Count("", ContentReview, review.ID)
+	return review
+}
+
+func (s *ReviewStore) saveLocked() error {
+	reviews := make([]models.Review, 0, len(s.reviews))
+	for _, review := range s.reviews {
+		reviews = append(reviews, review)
+	}
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].ID < reviews[j].ID })
+	return store.SaveJSON(s.path, reviews)
+}
+
+// moderationFlags returns the flags a review's text raises: links, mostly capital
+// letters, and a character repeated ten or more times in a row
+func moderationFlags(body string) []string {
+	var flags []string
+	if linkPattern.MatchString(body) {
+		flags = append(flags, FlagLink)
+	}
+	letters, upper := 0, 0
+	for _, r := range body {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters >= 20 && upper*10 > letters*7 {
+		flags = append(flags, FlagShouting)
+	}
+	run, last := 0, rune(0)
+	for _, r := range body {
+		if r == last {
+			run++
+		} else {
+			run, last = 1, r
+		}
+		if run > maxRepeatedRunes {
+			flags = append(flags, FlagRepetition)
+			break
+		}
+	}
+	return flags
+}
+
+func newReviewID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "r_" + hex.EncodeToString(id), nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// scalingWindowMinutes is the window the cache hit ratio is computed over
+const scalingWindowMinutes = 5
+
+// ScalingMetrics tracks the signals autoscalers should act on. Request counts alone are
+// a poor signal for this service: one genre listing or recommendation fans out to dozens
+// of OMDb calls, while a cached lookup costs nothing upstream. What saturates a replica
+// is the upstream work waiting and in progress, and how much of it the cache absorbs.
+type ScalingMetrics struct {
+	requests atomic.Int64
+	fanOuts  atomic.Int64
+
+	mu      sync.Mutex
+	buckets [scalingWindowMinutes]cacheBucket
+}
+
+// cacheBucket counts the detail cache lookups of one minute
+type cacheBucket struct {
+	minute int64
+	hits   int64
+	misses int64
+}
+
+// recordCacheLookup counts a detail cache lookup; stale entries served count as hits
+func (m *ScalingMetrics) recordCacheLookup(hit bool) {
+	minute := time.Now().Unix() / 60
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bucket := &m.buckets[minute%scalingWindowMinutes]
+	if bucket.minute != minute {
+		*bucket = cacheBucket{minute: minute}
+	}
+	if hit {
+		bucket.hits++
+	} else {
+		bucket.misses++
+	}
+}
+
+// cacheRatio returns the hit ratio and the number of lookups over the window
+func (m *ScalingMetrics) cacheRatio() (float64, int64) {
+	minute := time.Now().Unix() / 60
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var hits, lookups int64
+	for _, bucket := range m.buckets {
+		if minute-bucket.minute < scalingWindowMinutes {
+			hits += bucket.hits
+			lookups += bucket.hits + bucket.misses
+		}
+	}
+	if lookups == 0 {
+		return 0, 0
+	}
+	return float64(hits) / float64(lookups), lookups
+}
+
+// Scaling reports the autoscaling signals: the upstream calls queued by the scheduler, the
+// requests in flight and how many of them fan out to several OMDb calls, and the detail
+// cache hit ratio over the last minutes. The request of ctx isn't counted in flight.
+func (s *OMDbService) Scaling(ctx context.Context) models.ScalingMetrics {
+	report := models.ScalingMetrics{
+		InFlightRequests: s.scaling.requests.Load(),
+		InFlightFanOuts:  s.scaling.fanOuts.Load(),
+		WindowMinutes:    scalingWindowMinutes,
+		GeneratedAt:      time.Now().UTC(),
+	}
+	if scope := ScopeFrom(ctx); scope != nil && scope.scaling != nil {
+		report.InFlightRequests--
+	}
+	if queue := s.Scheduler.Status(); queue != nil {
+		report.QueueByPriority = queue.Waiting
+		for _, waiting := range queue.Waiting {
+			report.QueueDepth += waiting
+		}
+	}
+	if s.slots != nil {
+		inFlight := len(s.slots)
+		report.UpstreamInFlight = &inFlight
+		report.UpstreamConcurrency = cap(s.slots)
+	}
+	report.CacheHitRatio, report.CacheLookups = s.scaling.cacheRatio()
+	return report
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// Priority orders upstream calls waiting for the request rate ceiling
+type Priority int
+
+const (
+	// PriorityInteractive is a lookup a client is waiting on, e.g. a movie's details
+	PriorityInteractive Priority = iota
+	// PriorityEnrichment fills in the records of list entries: genre and recommendation
+	// candidates, and enriched search results
+	PriorityEnrichment
+	// PriorityBackground is work nobody is waiting on: cache refreshes and monitor checks
+	PriorityBackground
+
+	priorityClasses = 3
+)
+
+// priorityNames label the classes in the status report
+var priorityNames = [priorityClasses]string{"interactive", "enrichment", "background"}
+
+type priorityKey struct{}
+
+// WithPriority sets the priority of the upstream calls made with the context. A priority
+// is only ever lowered, so the enrichment done by a background job stays background work.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	if current, ok := ctx.Value(priorityKey{}).(Priority); ok && current > priority {
+		return ctx
+	}
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// priorityFrom returns the context's priority, interactive by default
+func priorityFrom(ctx context.Context) Priority {
+	priority, ok := ctx.Value(priorityKey{}).(Priority)
+	if !ok || priority < 0 || priority >= priorityClasses {
+		return PriorityInteractive
+	}
+	return priority
+}
+
+// RequestScheduler holds OMDb calls to a global requests-per-second ceiling, so that
+// bursts and background work don't use up the upstream quota. It is a token bucket: up
+// to Burst calls pass at once, then one per 1/MaxRPS seconds. Waiting calls are released
+// highest priority first, in arrival order within a class, so interactive lookups
+// overtake queued enrichment and background calls.
+type RequestScheduler struct {
+	MaxRPS float64
+	Burst  int
+
+	mu      sync.Mutex
+	tokens  float64
+	updated time.Time
+	queues  [priorityClasses][]chan struct{}
+	granted [priorityClasses]int64
+	timer   *time.Timer
+}
+
+// schedulerFromEnv reads OMDB_MAX_RPS (default 0, no ceiling) and OMDB_BURST (default
+// the ceiling rounded up). It returns nil without a ceiling.
+func schedulerFromEnv() *RequestScheduler {
+	rps := envInt("OMDB_MAX_RPS", 0)
+	if rps == 0 {
+		return nil
+	}
+	return newRequestScheduler(float64(rps), envInt("OMDB_BURST", rps))
+}
+
+func newRequestScheduler(rps float64, burst int) *RequestScheduler {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RequestScheduler{
+		MaxRPS:  rps,
+		Burst:   burst,
+		tokens:  float64(burst),
+		updated: time.Now(),
+	}
+}
+
+// Wait blocks until a call of the context's priority may be sent
+func (s *RequestScheduler) Wait(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	priority := priorityFrom(ctx)
+
+	s.mu.Lock()
+	s.refillLocked()
+	if s.tokens >= 1 && !s.queuedLocked(priority) {
+		s.tokens--
+		s.granted[priority]++
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	s.queues[priority] = append(s.queues[priority], ready)
+	s.scheduleLocked()
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, waiter := range s.queues[priority] {
+			if waiter == ready {
+				s.queues[priority] = append(s.queues[priority][:i], s.queues[priority][i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// Released while giving up; hand the token to the next waiter
+		s.tokens++
+		s.granted[priority]--
+		s.releaseLocked()
+		return ctx.Err()
+	}
+}
+
+// TryTake takes a token only if one is free and nobody is waiting, for optional calls
+// such as hedges
+func (s *RequestScheduler) TryTake() bool {
+	if s == nil {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refillLocked()
+	if s.tokens < 1 || s.queuedLocked(priorityClasses-1) {
+		return false
+	}
+	s.tokens--
+	s.granted[PriorityInteractive]++
+	return true
+}
+
+// queuedLocked reports whether calls of priority or higher are waiting
+func (s *RequestScheduler) queuedLocked(priority Priority) bool {
+	for p := PriorityInteractive; p <= priority; p++ {
+		if len(s.queues[p]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *RequestScheduler) refillLocked() {
+	now := time.Now()
+	s.tokens = min(float64(s.Burst), s.tokens+now.Sub(s.updated).Seconds()*s.MaxRPS)
+	s.updated = now
+}
+
+// releaseLocked hands available tokens to the waiters, highest priority first
+func (s *RequestScheduler) releaseLocked() {
+	for p := range s.queues {
+		for len(s.queues[p]) > 0 && s.tokens >= 1 {
+			s.tokens--
+			s.granted[p]++
+			close(s.queues[p][0])
+			s.queues[p] = s.queues[p][1:]
+		}
+	}
+}
+
+// scheduleLocked arms the timer for the next token while calls are waiting
+func (s *RequestScheduler) scheduleLocked() {
+	if s.timer != nil || !s.queuedLocked(priorityClasses-1) {
+		return
+	}
+	wait := time.Duration((1 - s.tokens) / s.MaxRPS * float64(time.Second))
+	s.timer = time.AfterFunc(max(wait, time.Millisecond), func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.timer = nil
+		s.refillLocked()
+		s.releaseLocked()
+		s.scheduleLocked()
+	})
+}
+
+// Status reports the ceiling, the calls waiting and the calls released per class
+func (s *RequestScheduler) Status() *models.QueueStatus {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := &models.QueueStatus{
+		MaxRPS:  s.MaxRPS,
+		Burst:   s.Burst,
+		Waiting: make(map[string]int, priorityClasses),
+		Granted: make(map[string]int64, priorityClasses),
+	}
+	for p, name := range priorityNames {
+		status.Waiting[name] = len(s.queues[p])
+		status.Granted[name] = s.granted[p]
+	}
+	return status
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"movie-api-go/models"
+)
+
+// omdbSearchPageSize is the fixed number of results OMDb returns per search page
+const omdbSearchPageSize = 10
+
+// maxSearchPagesPerRequest bounds how many upstream pages a single search call may consume
+const maxSearchPagesPerRequest = 5
+
+// SearchCursor marks a position in the upstream OMDb result stream
+type SearchCursor struct {
+	Page   int `json:"p"`
+	Offset int `json:"o"`
+	// Query is the query sent to OMDb, after spelling correction, so that later pages
+	// continue the same search even if the dictionary has learned words in between
+	Query string `json:"q,omitempty"`
+}
+
+// EncodeCursor serializes a cursor into an opaque, URL-safe token
+func EncodeCursor(cursor SearchCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token produced by EncodeCursor. An empty token is the start of the results.
+func DecodeCursor(token string) (SearchCursor, error) {
+	if token == "" {
+		return SearchCursor{Page: 1}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return SearchCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	var cursor SearchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Page < 1 || cursor.Offset < 0 || cursor.Offset >= omdbSearchPageSize {
+		return SearchCursor{}, fmt.Errorf("invalid cursor")
+	}
+	return cursor, nil
+}
+
+// SearchPage is one page of search results along with the cursor for the next page
+type SearchPage struct {
+	Results      []models.SearchResult
+	TotalResults int
+	NextCursor   string
+}
+
+// SearchTitles searches OMDb by title and returns up to limit results starting at cursor.
+// Results are read from consecutive upstream pages, so the page size seen by clients is
+// independent of OMDb's fixed page size and of any results dropped by filtering.
+func (s *OMDbService) SearchTitles(ctx context.Context, query, titleType, year string, cursor SearchCursor, limit int) (*SearchPage, error) {
+	page := &SearchPage{Results: []models.SearchResult{}}
+	seen := make(map[string]bool)
+
+	for fetched := 0; fetched < maxSearchPagesPerRequest; fetched++ {
+		searchResp, err := s.searchPage(ctx, query, titleType, year, cursor.Page)
+		if err != nil {
+			return nil, err
+		}
+
+		if searchResp.Response == "False" {
+			return page, nil
+		}
+
+		page.TotalResults, _ = strconv.Atoi(searchResp.TotalResults)
+
+		for i := cursor.Offset; i < len(searchResp.Search); i++ {
+			result := searchResp.Search[i]
+			if titleType != "" && result.Type != titleType || seen[result.ImdbID] {
+				continue
+			}
+			seen[result.ImdbID] = true
+			page.Results = append(page.Results, result)
+
+			if len(page.Results) == limit {
+				next := SearchCursor{Page: cursor.Page, Offset: i + 1, Query: query}
+				if next.Offset >= len(searchResp.Search) {
+					next = SearchCursor{Page: cursor.Page + 1, Query: query}
+				}
+				if hasMoreResults(next, page.TotalResults) {
+					page.NextCursor = EncodeCursor(next)
+				}
+				return page, nil
+			}
+		}
+
+		cursor = SearchCursor{Page: cursor.Page + 1, Query: query}
+		if !hasMoreResults(cursor, page.TotalResults) {
+			return page, nil
+		}
+	}
+
+	// Page budget exhausted: hand the remaining work to the next request
+	page.NextCursor = EncodeCursor(cursor)
+	return page, nil
+}
+
+func hasMoreResults(cursor SearchCursor, totalResults int) bool {
+	return (cursor.Page-1)*omdbSearchPageSize+cursor.Offset < totalResults
+}
+
+func (s *OMDbService) searchPage(ctx context.Context, searchTerm, titleType, year string, page int) (*models.SearchResponse, error) {
+	params := url.Values{}
+	params.Add("apikey", s.APIKey)
+	params.Add("s", searchTerm)
+	if titleType != "" {
+		params.Add("type", titleType)
+	}
+	if year != "" {
+		params.Add("y", year)
+	}
+	if page > 1 {
+		params.Add("page", strconv.Itoa(page))
+	}
+
+	body, err := s.fetchCached(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var searchResp models.SearchResponse
+	if err := json.Unmarshal(body, &searchResp); err != nil {
+		return nil, err
+	}
+
+	s.normalize(&searchResp)
+	for _, result := range searchResp.Search {
+		s.Dictionary.Learn(result.Title)
+	}
+
+	return &searchResp, nil
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+var (
+	// ErrInvalidSeed wraps the reason a list of titles to seed was rejected
+	ErrInvalidSeed = errors.New("invalid seed")
+
+	// ErrSeedJobNotFound is returned for an unknown seed job
+	ErrSeedJobNotFound = errors.New("seed job not found")
+)
+
+// Seed job statuses
+const (
+	SeedQueued    = "queued"
+	SeedRunning   = "running"
+	SeedCompleted = "completed"
+	SeedStopped   = "stopped"
+)
+
+// maxSeedJobErrors caps the failed titles a seed job reports
+const maxSeedJobErrors = 100
+
+// Seeder bootstraps the local corpus with titles an operator picks, such as the catalog
+// of their audience's region, instead of waiting for users to look them up. Each job
+// fetches its titles in the background at RatePerMinute, one at a time and behind user
+// requests, so a large seed doesn't eat the upstream quota of live traffic. Titles
+// already in the corpus or quarantined are skipped without a call. Jobs are kept in
+// memory: a restart loses their progress reports, not the titles already seeded.
+type Seeder struct {
+	// RatePerMinute is the number of upstream lookups a job makes per minute
+	RatePerMinute int
+	// MaxTitles is the number of titles a job may seed
+	MaxTitles int
+	// MaxJobs is the number of jobs kept for progress reports, oldest dropped first
+	MaxJobs int
+
+	omdb *OMDbService
+	ctx  context.Context
+
+	mu   sync.Mutex
+	jobs map[string]*models.SeedJob
+}
+
+// NewSeeder reads SEED_RATE_PER_MINUTE (default 30), SEED_MAX_TITLES (default 5000) and
+// SEED_MAX_JOBS (default 50). Jobs run until they finish or ctx is done.
+func NewSeeder(ctx context.Context, omdb *OMDbService) *Seeder {
+	return &Seeder{
+		RatePerMinute: envInt("SEED_RATE_PER_MINUTE", 30),
+		MaxTitles:     envInt("SEED_MAX_TITLES", 5000),
+		MaxJobs:       envInt("SEED_MAX_JOBS", 50),
+		omdb:          omdb,
+		ctx:           ctx,
+		jobs:          make(map[string]*models.SeedJob),
+	}
+}
+
+// Start validates a list of IMDb IDs, dropping duplicates, and starts a job seeding them
+func (s *Seeder) Start(actor string, imdbIDs []string) (models.SeedJob, error) {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, imdbID := range imdbIDs {
+		imdbID = strings.TrimSpace(imdbID)
+		if !imdbIDPattern.MatchString(imdbID) {
+			return models.SeedJob{}, fmt.Errorf("%w: %q is not an IMDb ID", ErrInvalidSeed, imdbID)
+		}
+		if !seen[imdbID] {
+			seen[imdbID] = true
+			ids = append(ids, imdbID)
+		}
+	}
+	if len(ids) == 0 {
+		return models.SeedJob{}, fmt.Errorf("%w: no IMDb IDs to seed", ErrInvalidSeed)
+	}
+	if len(ids) > s.MaxTitles {
+		return models.SeedJob{}, fmt.Errorf("%w: a job may seed at most %d titles", ErrInvalidSeed, s.MaxTitles)
+	}
+	if s.RatePerMinute <= 0 {
+		return models.SeedJob{}, fmt.Errorf("%w: seeding is disabled", ErrInvalidSeed)
+	}
+
+	id, err := newSeedJobID()
+	if err != nil {
+		return models.SeedJob{}, err
+	}
+	job := &models.SeedJob{
+		ID:            id,
+		Status:        SeedQueued,
+		Total:         len(ids),
+		RatePerMinute: s.RatePerMinute,
+		CreatedBy:     actor,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = job
+	s.prune()
+	snapshot := s.snapshot(job)
+	s.mu.Unlock()
+
+	go s.run(job, ids)
+	return snapshot, nil
+}
+
+// Get returns the progress of a job
+func (s *Seeder) Get(id string) (models.SeedJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return models.SeedJob{}, ErrSeedJobNotFound
+	}
+	return s.snapshot(job), nil
+}
+
+// List returns the progress of the jobs kept, newest first
+func (s *Seeder) List() []models.SeedJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]models.SeedJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, s.snapshot(job))
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+	return jobs
+}
+
+// run fetches a job's titles, at most RatePerMinute a minute, until they are all done,
+// the upstream quota is exhausted or the server shuts down
+func (s *Seeder) run(job *models.SeedJob, imdbIDs []string) {
+	s.update(func() { job.Status = SeedRunning })
+
+	// The job collects the titles that fail in a scope of its own, counting them toward quarantine
+	ctx := WithScope(WithPriority(s.ctx, PriorityBackground), newBackgroundScope())
+	ticker := time.NewTicker(time.Minute / time.Duration(s.RatePerMinute))
+	defer ticker.Stop()
+
+	stop, calls := "", 0
+	for _, imdbID := range imdbIDs {
+		if s.omdb.History.has(imdbID) || s.omdb.Quarantine.Quarantined(imdbID) {
+			s.update(func() { job.Processed++; job.Skipped++ })
+			continue
+		}
+		if calls > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			stop = "server shut down"
+			break
+		}
+
+		calls++
+		var record *models.OMDbResponse
+		err := isolate(func() (err error) {
+			record, err = s.omdb.GetTitleByID(ctx, imdbID)
+			return err
+		})
+		if errors.Is(err, ErrUpstreamQuota) {
+			stop = "upstream quota exhausted"
+			break
+		}
+		if err == nil && record.Response == "False" {
+			err = errors.New(record.Error)
+		}
+		if err != nil {
+			if !errors.Is(err, ErrQuarantined) {
+				s.omdb.enrichmentFailed(ctx, imdbID, "", err)
+			}
+			s.update(func() {
+				job.Processed++
+				job.Failed++
+				if len(job.Errors) < maxSeedJobErrors {
+					job.Errors = append(job.Errors, models.FailedItem{Item: imdbID, Reason: errorText(err), Panicked: errors.Is(err, ErrPanicked)})
+				}
+			})
+			continue
+		}
+		s.update(func() { job.Processed++; job.Seeded++ })
+	}
+
+	finished := time.Now().UTC()
+	s.update(func() {
+		job.Status = SeedCompleted
+		if stop != "" {
+			job.Status = SeedStopped
+			job.Error = stop
+		}
+		job.FinishedAt = &finished
+		log.Printf("seed: job %s %s: %d seeded, %d skipped, %d failed of %d titles", job.ID, job.Status, job.Seeded, job.Skipped, job.Failed, job.Total)
+	})
+}
+
+// update changes a job under the lock its progress is read with
+func (s *Seeder) update(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn()
+}
+
+// snapshot copies a job with its progress and, while it runs, the estimated time it
+// finishes at the job's rate. Callers hold s.mu.
+func (s *Seeder) snapshot(job *models.SeedJob) models.SeedJob {
+	snapshot := *job
+	snapshot.Errors = append([]models.FailedItem(nil), job.Errors...)
+	snapshot.Progress = float64(job.Processed*1000/job.Total) / 10
+	if job.FinishedAt == nil {
+		remaining := time.Duration(job.Total-job.Processed) * time.Minute / time.Duration(job.RatePerMinute)
+		estimate := time.Now().Add(remaining).UTC().Truncate(time.Second)
+		snapshot.EstimatedFinish = &estimate
+	}
+	return snapshot
+}
+
+// prune drops the oldest finished jobs beyond MaxJobs. Callers hold s.mu.
+func (s *Seeder) prune() {
+	var finished []*models.SeedJob
+	for _, job := range s.jobs {
+		if job.FinishedAt != nil {
+			finished = append(finished, job)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].CreatedAt.Before(finished[j].CreatedAt) })
+	for len(s.jobs) > s.MaxJobs && len(finished) > 0 {
+		delete(s.jobs, finished[0].ID)
+		finished = finished[1:]
+	}
+}
+
+func newSeedJobID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "sj_" + hex.EncodeToString(id), nil
+}
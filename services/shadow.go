@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+const (
+	// shadowTimeout bounds one replayed lookup
+	shadowTimeout = 10 * time.Second
+
+	// maxShadowInFlight caps concurrent replays; lookups beyond it are dropped, not queued
+	maxShadowInFlight = 4
+
+	// maxShadowSamples is how many recent mismatches the report keeps
+	maxShadowSamples = 20
+)
+
+// Shadow replays detail lookups against a secondary provider and compares the answers
+// with what the primary returned to the client. The secondary must speak the OMDb API
+// (e.g. a local index, or an adapter in front of TMDb), which lets a migration be
+// validated on production traffic before any client is served by it.
+type Shadow struct {
+	BaseURL string
+	// APIKey is sent to the secondary; without one no key is sent
+	APIKey string
+	// SamplePercent is the share of lookups replayed (0-100)
+	SamplePercent int
+	Client        *http.Client
+
+	inFlight chan struct{}
+
+	mu              sync.Mutex
+	compared        int
+	matched         int
+	failed          int
+	dropped         int
+	fieldMismatches map[string]int
+	samples         []models.ShadowDiff
+}
+
+// shadowFromEnv reads SHADOW_BASE_URL, SHADOW_API_KEY and SHADOW_SAMPLE_PERCENT (default
+// 100). It returns nil when no secondary is configured. The OMDb key is never sent to the
+// secondary, which is another provider.
+func shadowFromEnv() (*Shadow, error) {
+	baseURL := os.Getenv("SHADOW_BASE_URL")
+	if baseURL == "" {
+		return nil, nil
+	}
+
+	client, err := httpClientFromEnv("SHADOW")
+	if err != nil {
+		return nil, err
+	}
+
+	shadow := &Shadow{
+		BaseURL:         baseURL,
+		APIKey:          os.Getenv("SHADOW_API_KEY"),
+		SamplePercent:   envInt("SHADOW_SAMPLE_PERCENT", 100),
+		Client:          client,
+		inFlight:        make(chan struct{}, maxShadowInFlight),
+		fieldMismatches: make(map[string]int),
+	}
+	return shadow, nil
+}
+
+// replay compares primary, the normalized record served for params, with the secondary's
+// answer in the background
+func (sh *Shadow) replay(params url.Values, primary *models.OMDbResponse, normalize func(interface{})) {
+	if sh == nil || rand.Intn(100) >= sh.SamplePercent {
+		return
+	}
+
+	select {
+	case sh.inFlight <- struct{}{}:
+	default:
+		sh.mu.Lock()
+		sh.dropped++
+		sh.mu.Unlock()
+		return
+	}
+
+	go isolate(func() error {
+		defer func() { <-sh.inFlight }()
+
+		secondary, err := sh.lookup(params)
+		if err != nil {
+			log.Printf("shadow: %s failed: %s", cacheKey(params), errorText(err))
+			sh.mu.Lock()
+			sh.failed++
+			sh.mu.Unlock()
+			return nil
+		}
+		normalize(secondary)
+
+		sh.record(params, diffRecords(primary, secondary))
+		return nil
+	})
+}
+
+func (sh *Shadow) lookup(params url.Values) (*models.OMDbResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), shadowTimeout)
+	defer cancel()
+
+	replayed := url.Values{}
+	for name, values := range params {
+		replayed[name] = values
+	}
+	replayed.Del("apikey")
+	if sh.APIKey != "" {
+		replayed.Set("apikey", sh.APIKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?%s", sh.BaseURL, replayed.Encode()), nil)
+	if err != nil {
+		return nil, redactURLError(err)
+	}
+	resp, err := sh.Client.Do(req)
+	if err != nil {
+		return nil, redactURLError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var record models.OMDbResponse
+	if err := json.Unmarshal(body, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &record, nil
+}
+
+func (sh *Shadow) record(params url.Values, diffs []models.ShadowFieldDiff) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.compared++
+	if len(diffs) == 0 {
+		sh.matched++
+		return
+	}
+
+	fields := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		sh.fieldMismatches[diff.Field]++
+		fields = append(fields, diff.Field)
+	}
+	log.Printf("shadow: %s differs in %s", cacheKey(params), strings.Join(fields, ", "))
+
+	sh.samples = append(sh.samples, models.ShadowDiff{Lookup: cacheKey(params), Fields: diffs, At: time.Now().UTC()})
+	if len(sh.samples) > maxShadowSamples {
+		sh.samples = sh.samples[len(sh.samples)-maxShadowSamples:]
+	}
+}
+
+// Report summarizes the comparisons so far
+func (sh *Shadow) Report() models.ShadowReport {
+	if sh == nil {
+		return models.ShadowReport{}
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	report := models.ShadowReport{
+		Enabled:         true,
+		Secondary:       sh.BaseURL,
+		SamplePercent:   sh.SamplePercent,
+		Compared:        sh.compared,
+		Matched:         sh.matched,
+		Mismatched:      sh.compared - sh.matched,
+		Failed:          sh.failed,
+		Dropped:         sh.dropped,
+		FieldMismatches: make(map[string]int, len(sh.fieldMismatches)),
+		RecentDiffs:     append([]models.ShadowDiff{}, sh.samples...),
+	}
+	if sh.compared > 0 {
+		report.MatchRate = math.Round(float64(sh.matched)/float64(sh.compared)*1000) / 1000
+	}
+	for field, count := range sh.fieldMismatches {
+		report.FieldMismatches[field] = count
+	}
+	return report
+}
+
+// diffRecords lists the fields in which two records differ, by their OMDb JSON name
+func diffRecords(primary, secondary *models.OMDbResponse) []models.ShadowFieldDiff {
+	a, b := reflect.ValueOf(*primary), reflect.ValueOf(*secondary)
+	recordType := a.Type()
+
+	var diffs []models.ShadowFieldDiff
+	for i := 0; i < recordType.NumField(); i++ {
+		fieldA, fieldB := a.Field(i), b.Field(i)
+		if reflect.DeepEqual(fieldA.Interface(), fieldB.Interface()) ||
+			fieldA.Kind() == reflect.Slice && fieldA.Len() == 0 && fieldB.Len() == 0 {
+			continue
+		}
+		name := strings.Split(recordType.Field(i).Tag.Get("json"), ",")[0]
+		diffs = append(diffs, models.ShadowFieldDiff{
+			Field:     name,
+			Primary:   fmt.Sprint(fieldA.Interface()),
+			Secondary: fmt.Sprint(fieldB.Interface()),
+		})
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+	return diffs
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// shardVirtualNodes is the number of points each shard has on the hash ring; more points
+// spread keys more evenly
+const shardVirtualNodes = 160
+
+// ShardedCache is a second cache tier shared by all replicas and spread over several
+// Redis nodes. Keys are assigned to nodes by consistent hashing, so adding or removing a
+// node only moves the keys on its share of the ring. A node that fails a command or a
+// health check is skipped until it answers a check again; its keys go to the next node
+// on the ring in the meantime, and every other key stays where it was.
+type ShardedCache struct {
+	// CheckInterval is the time between health checks of every shard
+	CheckInterval time.Duration
+
+	shards []*cacheShard
+	ring   []ringPoint
+}
+
+type cacheShard struct {
+	client *RedisClient
+
+	mu        sync.Mutex
+	healthy   bool
+	failures  int64
+	lastError string
+	checkedAt time.Time
+}
+
+// ringPoint is one virtual node of a shard on the hash ring
+type ringPoint struct {
+	hash  uint32
+	shard int
+}
+
+var (
+	sharedCacheOnce sync.Once
+	sharedCacheErr  error
+	sharedCache     *ShardedCache
+)
+
+// shardedCacheFromEnv returns the cache configured by CACHE_REDIS_URLS, a comma-separated
+// list of Redis URLs in the REDIS_URL format, and CACHE_SHARD_CHECK_SECONDS (default 10).
+// The detail and poster caches share it. It returns nil when CACHE_REDIS_URLS is unset.
+func shardedCacheFromEnv() (*ShardedCache, error) {
+	sharedCacheOnce.Do(func() {
+		var urls []string
+		for _, raw := range strings.Split(os.Getenv("CACHE_REDIS_URLS"), ",") {
+			if raw = strings.TrimSpace(raw); raw != "" {
+				urls = append(urls, raw)
+			}
+		}
+		if len(urls) == 0 {
+			return
+		}
+		sharedCache, sharedCacheErr = newShardedCache(urls, time.Duration(envInt("CACHE_SHARD_CHECK_SECONDS", 10))*time.Second)
+	})
+	return sharedCache, sharedCacheErr
+}
+
+func newShardedCache(urls []string, checkInterval time.Duration) (*ShardedCache, error) {
+	c := &ShardedCache{CheckInterval: checkInterval}
+	seen := make(map[string]bool, len(urls))
+	for i, raw := range urls {
+		client, err := newRedisClient(raw)
+		if err != nil {
+			return nil, fmt.Errorf("CACHE_REDIS_URLS: %w", err)
+		}
+		if seen[client.address] {
+			return nil, fmt.Errorf("CACHE_REDIS_URLS: %s is listed twice", client.address)
+		}
+		seen[client.address] = true
+
+		c.shards = append(c.shards, &cacheShard{client: client, healthy: true})
+		for v := 0; v < shardVirtualNodes; v++ {
+			c.ring = append(c.ring, ringPoint{hash: crc32.ChecksumIEEE([]byte(client.address + "#" + strconv.Itoa(v))), shard: i})
+		}
+	}
+	sort.Slice(c.ring, func(i, j int) bool { return c.ring[i].hash < c.ring[j].hash })
+	return c, nil
+}
+
+// shardFor returns the healthy shard that owns key: the first one at or after the key's
+// hash on the ring. It returns nil when every shard is down.
+func (c *ShardedCache) shardFor(key string) *cacheShard {
+	hash := crc32.ChecksumIEEE([]byte(key))
+	start := sort.Search(len(c.ring), func(i int) bool { return c.ring[i].hash >= hash })
+	tried := make(map[int]bool, len(c.shards))
+	for i := 0; i < len(c.ring) && len(tried) < len(c.shards); i++ {
+		point := c.ring[(start+i)%len(c.ring)]
+		if tried[point.shard] {
+			continue
+		}
+		tried[point.shard] = true
+		if shard := c.shards[point.shard]; shard.isHealthy() {
+			return shard
+		}
+	}
+	return nil
+}
+
+// Get returns the value stored under key, or nil on a miss or when its shard is unreachable
+func (c *ShardedCache) Get(ctx context.Context, key string) []byte {
+	if c == nil {
+		return nil
+	}
+	shard := c.shardFor(key)
+	if shard == nil {
+		return nil
+	}
+	value, err := shard.client.Get(ctx, shard.client.Key("cache", key))
+	if err != nil {
+		shard.failed("cache get", err)
+		return nil
+	}
+	if value == "" {
+		return nil
+	}
+	return []byte(value)
+}
+
+// Set stores value under key for ttl. Failures only cost a later miss, so they are
+// logged rather than returned.
+func (c *ShardedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if c == nil || ttl <= 0 {
+		return
+	}
+	shard := c.shardFor(key)
+	if shard == nil {
+		return
+	}
+	if err := shard.client.Set(ctx, shard.client.Key("cache", key), value, ttl); err != nil {
+		shard.failed("cache set", err)
+	}
+}
+
+// Run checks every shard each CheckInterval until ctx is done, returning shards that
+// answer to the ring and taking out those that don't
+func (c *ShardedCache) Run(ctx context.Context) {
+	if c == nil || c.CheckInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, shard := range c.shards {
+				shard.check(ctx)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Status reports the health of every shard
+func (c *ShardedCache) Status() []models.CacheShardStatus {
+	if c == nil {
+		return nil
+	}
+
+	statuses := make([]models.CacheShardStatus, len(c.shards))
+	for i, shard := range c.shards {
+		shard.mu.Lock()
+		statuses[i] = models.CacheShardStatus{
+			Address:   shard.client.address,
+			Healthy:   shard.healthy,
+			Failures:  shard.failures,
+			LastError: shard.lastError,
+		}
+		if !shard.checkedAt.IsZero() {
+			checkedAt := shard.checkedAt.UTC()
+			statuses[i].CheckedAt = &checkedAt
+		}
+		shard.mu.Unlock()
+	}
+	return statuses
+}
+
+func (s *cacheShard) isHealthy() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.healthy
+}
+
+// failed takes the shard out of the ring until a health check succeeds
+func (s *cacheShard) failed(operation string, err error) {
+	s.mu.Lock()
+	wasHealthy := s.healthy
+	s.healthy = false
+	s.failures++
+	s.lastError = err.Error()
+	s.mu.Unlock()
+
+	if wasHealthy {
+		log.Printf("cache: shard %s is down after a failed %s: %v", s.client.address, operation, err)
+	}
+}
+
+// check pings the shard and records the outcome
+func (s *cacheShard) check(ctx context.Context) {
+	err := s.client.Ping(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkedAt = time.Now()
+	if err != nil {
+		if s.healthy {
+			log.Printf("cache: shard %s failed its health check: %v", s.client.address, err)
+		}
+		s.healthy = false
+		s.failures++
+		s.lastError = err.Error()
+		return
+	}
+	if !s.healthy {
+		log.Printf("cache: shard %s is back", s.client.address)
+	}
+	s.healthy = true
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+const (
+	// shedSamples is the number of recent latencies the p95 is computed from
+	shedSamples = 256
+
+	// shedMinSamples is the number of recent latencies below which the p95 isn't trusted
+	shedMinSamples = 20
+
+	// shedSampleAge is how long a latency counts towards the p95
+	shedSampleAge = 30 * time.Second
+)
+
+// LoadShedder protects the API from collapsing under a load spike. It tracks the requests
+// in flight and the p95 latency of cheap requests (detail lookups and the like), and when
+// either passes its threshold it rejects new expensive requests, such as genre listings
+// and recommendations that fan out to many OMDb calls, until the load has been below the
+// thresholds for a cooldown. Cheap requests are always admitted, so the API keeps serving
+// them while it sheds the work that would slow everything down.
+type LoadShedder struct {
+	// MaxInFlight is the number of requests in flight above which expensive requests are shed
+	MaxInFlight int
+	// TargetP95 is the p95 latency of cheap requests above which expensive requests are shed
+	TargetP95 time.Duration
+	// Cooldown is how long shedding continues after the load is back under the thresholds
+	Cooldown time.Duration
+
+	mu            sync.Mutex
+	inFlight      int
+	latencies     [shedSamples]time.Duration
+	recordedAt    [shedSamples]time.Time
+	next          int
+	sheddingUntil time.Time
+	reason        string
+	shed          int64
+}
+
+// NewLoadShedder reads SHED_MAX_IN_FLIGHT (default 100), SHED_P95_MS (default 3000) and
+// SHED_COOLDOWN_SECONDS (default 10). A threshold of 0 is not checked; it returns nil
+// when both are 0.
+func NewLoadShedder() *LoadShedder {
+	shedder := &LoadShedder{
+		MaxInFlight: envInt("SHED_MAX_IN_FLIGHT", 100),
+		TargetP95:   time.Duration(envInt("SHED_P95_MS", 3000)) * time.Millisecond,
+		Cooldown:    time.Duration(envInt("SHED_COOLDOWN_SECONDS", 10)) * time.Second,
+	}
+	if shedder.MaxInFlight <= 0 && shedder.TargetP95 <= 0 {
+		return nil
+	}
+	return shedder
+}
+
+// Admit counts a request in flight, unless it is expensive and the API is overloaded. A
+// rejected request should be retried after retryAfter. Admitted requests must call Done.
+func (l *LoadShedder) Admit(expensive bool) (admitted bool, retryAfter time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if expensive {
+		now := time.Now()
+		if reason := l.overloadedLocked(now); reason != "" {
+			l.sheddingUntil = now.Add(l.Cooldown)
+			l.reason = reason
+		}
+		if now.Before(l.sheddingUntil) {
+			l.shed++
+			return false, l.sheddingUntil.Sub(now)
+		}
+	}
+	l.inFlight++
+	return true, 0
+}
+
+// Done ends an admitted request, recording its latency if it was cheap
+func (l *LoadShedder) Done(expensive bool, latency time.Duration) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if !expensive {
+		l.latencies[l.next] = latency
+		l.recordedAt[l.next] = time.Now()
+		l.next = (l.next + 1) % shedSamples
+	}
+}
+
+// overloadedLocked returns why the API is overloaded, or "" if it isn't
+func (l *LoadShedder) overloadedLocked(now time.Time) string {
+	if l.MaxInFlight > 0 && l.inFlight >= l.MaxInFlight {
+		return fmt.Sprintf("%d requests in flight", l.inFlight)
+	}
+	if p95, ok := l.p95Locked(now); ok && l.TargetP95 > 0 && p95 > l.TargetP95 {
+		return fmt.Sprintf("p95 latency of %d ms", p95.Milliseconds())
+	}
+	return ""
+}
+
+// p95Locked computes the p95 of the recent latencies, if there are enough of them
+func (l *LoadShedder) p95Locked(now time.Time) (time.Duration, bool) {
+	recent := make([]time.Duration, 0, shedSamples)
+	for i, latency := range l.latencies {
+		if !l.recordedAt[i].IsZero() && now.Sub(l.recordedAt[i]) <= shedSampleAge {
+			recent = append(recent, latency)
+		}
+	}
+	if len(recent) < shedMinSamples {
+		return 0, false
+	}
+	sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+	return recent[(len(recent)*95+99)/100-1], true
+}
+
+// Report adds the shedding state to a status report and flags an incident while shedding
+func (l *LoadShedder) Report(report *models.StatusReport) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	status := &models.LoadSheddingStatus{
+		Shedding:    now.Before(l.sheddingUntil),
+		InFlight:    l.inFlight,
+		MaxInFlight: l.MaxInFlight,
+		TargetP95Ms: l.TargetP95.Milliseconds(),
+		Shed:        l.shed,
+	}
+	if p95, ok := l.p95Locked(now); ok {
+		status.P95Ms = p95.Milliseconds()
+	}
+	if status.Shedding {
+		status.Reason = l.reason
+	}
+	l.mu.Unlock()
+
+	report.Shedding = status
+	if status.Shedding {
+		report.Incidents = append(report.Incidents, models.Incident{
+			Flag:    "load_shedding",
+			Message: "The API is overloaded (" + status.Reason + ") and is rejecting genre listings and recommendations to keep lookups fast",
+		})
+		if report.Status == "operational" {
+			report.Status = "degraded"
+		}
+	}
+}
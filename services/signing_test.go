@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestURLSignerVerify(t *testing.T) {
+	signer := &URLSigner{TTL: time.Hour, MaxTTL: 24 * time.Hour, secret: []byte("s3cret")}
+	signed, _ := signer.Sign("/api/poster/tt0133093", url.Values{"w": {"300"}}, time.Hour)
+	link, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("Sign returned an invalid URL %q: %v", signed, err)
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		query func(url.Values)
+		valid bool
+	}{
+		{name: "as signed", path: link.Path, valid: true},
+		{name: "other path", path: "/api/poster/tt0234215"},
+		{name: "changed parameter", path: link.Path, query: func(q url.Values) { q.Set("w", "2000") }},
+		{name: "added parameter", path: link.Path, query: func(q url.Values) { q.Set("format", "png") }},
+		{name: "removed parameter", path: link.Path, query: func(q url.Values) { q.Del("w") }},
+		{name: "extended expiry", path: link.Path, query: func(q url.Values) {
+			q.Set("expires", strconv.FormatInt(time.Now().Add(48*time.Hour).Unix(), 10))
+		}},
+		{name: "forged signature", path: link.Path, query: func(q url.Values) { q.Set("signature", "AAAA") }},
+		{name: "missing signature", path: link.Path, query: func(q url.Values) { q.Del("signature") }},
+		{name: "missing expiry", path: link.Path, query: func(q url.Values) { q.Del("expires") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := link.Query()
+			if tt.query != nil {
+				tt.query(query)
+			}
+			err := signer.Verify(tt.path, query)
+			if tt.valid && err != nil {
+				t.Errorf("Verify() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Verify() = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestURLSignerVerifyExpired(t *testing.T) {
+	signer := &URLSigner{secret: []byte("s3cret")}
+	query := url.Values{"expires": {strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)}}
+	query.Set("signature", signer.signature("/api/poster/tt0133093", query))
+
+	if err := signer.Verify("/api/poster/tt0133093", query); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestURLSignerVerifyOtherSecret(t *testing.T) {
+	signed, _ := (&URLSigner{TTL: time.Hour, secret: []byte("s3cret")}).Sign("/api/poster/tt0133093", nil, 0)
+	link, _ := url.Parse(signed)
+
+	other := &URLSigner{secret: []byte("other")}
+	if err := other.Verify(link.Path, link.Query()); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() = %v, want ErrInvalidSignature", err)
+	}
+	var disabled *URLSigner
+	if err := disabled.Verify(link.Path, link.Query()); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() on a nil signer = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestURLSignerSignTTL(t *testing.T) {
+	signer := &URLSigner{TTL: time.Hour, MaxTTL: 24 * time.Hour, secret: []byte("s3cret")}
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{name: "default", ttl: 0, want: time.Hour},
+		{name: "requested", ttl: 10 * time.Minute, want: 10 * time.Minute},
+		{name: "capped", ttl: 30 * 24 * time.Hour, want: 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, expires := signer.Sign("/api/poster/tt0133093", nil, tt.ttl)
+			if got := time.Until(expires); got > tt.want || got < tt.want-2*time.Second {
+				t.Errorf("expires in %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"movie-api-go/store"
+)
+
+// CacheSnapshotter persists the detail cache to disk and restores it at startup, so a
+// restart doesn't drop the service back to cold-cache latency and upstream usage.
+type CacheSnapshotter struct {
+	Path string
+	// Interval between periodic snapshots; zero saves only on shutdown
+	Interval time.Duration
+
+	cache *DetailCache
+}
+
+// cacheSnapshotEntry is one detail cache entry as stored on disk
+type cacheSnapshotEntry struct {
+	Key      string        `json:"key"`
+	Body     []byte        `json:"body"`
+	StoredAt time.Time     `json:"stored_at"`
+	TTL      time.Duration `json:"ttl"`
+	Stale    time.Duration `json:"stale"`
+}
+
+// NewCacheSnapshotter reads CACHE_SNAPSHOT_PATH and CACHE_SNAPSHOT_INTERVAL_SECONDS
+// (default 300). It returns nil when no path is set or the cache is disabled.
+func NewCacheSnapshotter(cache *DetailCache) *CacheSnapshotter {
+	path := os.Getenv("CACHE_SNAPSHOT_PATH")
+	if path == "" || !cache.Enabled() {
+		return nil
+	}
+	return &CacheSnapshotter{
+		Path:     path,
+		Interval: time.Duration(envInt("CACHE_SNAPSHOT_INTERVAL_SECONDS", 300)) * time.Second,
+		cache:    cache,
+	}
+}
+
+// Load restores the entries of the last snapshot that haven't expired, returning how many
+// were restored. A missing snapshot restores nothing.
+func (s *CacheSnapshotter) Load() (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	var entries []cacheSnapshotEntry
+	if err := store.LoadJSON(s.Path, &entries); err != nil {
+		return 0, err
+	}
+	return s.cache.restore(entries), nil
+}
+
+// Save writes the current cache contents to the snapshot file
+func (s *CacheSnapshotter) Save() error {
+	if s == nil {
+		return nil
+	}
+	return store.SaveJSON(s.Path, s.cache.snapshot())
+}
+
+// Run saves a snapshot every Interval until ctx is done
+func (s *CacheSnapshotter) Run(ctx context.Context) {
+	if s == nil || s.Interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Save(); err != nil {
+				log.Printf("cache: snapshot failed: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// snapshot copies the entries that can still be served
+func (c *DetailCache) snapshot() []cacheSnapshotEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make([]cacheSnapshotEntry, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if time.Since(entry.storedAt) >= c.ttlLocked(entry)+entry.stale {
+			continue
+		}
+		entries = append(entries, cacheSnapshotEntry{
+			Key:      key,
+			Body:     entry.body,
+			StoredAt: entry.storedAt,
+			TTL:      entry.ttl,
+			Stale:    entry.stale,
+		})
+	}
+	return entries
+}
+
+// restore adds snapshot entries that haven't expired, keeping their original age so they
+// go stale and expire on schedule. Entries already in the cache are newer and are kept.
+func (c *DetailCache) restore(entries []cacheSnapshotEntry) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	restored := 0
+	for _, snapshotted := range entries {
+		if time.Since(snapshotted.StoredAt) >= snapshotted.TTL+snapshotted.Stale {
+			continue
+		}
+		if _, ok := c.entries[snapshotted.Key]; ok {
+			continue
+		}
+		if c.MaxEntries > 0 && len(c.entries) >= c.MaxEntries {
+			break
+		}
+		_, negative := cacheable(snapshotted.Body)
+		c.entries[snapshotted.Key] = &cacheEntry{
+			body:        snapshotted.Body,
+			storedAt:    snapshotted.StoredAt,
+			ttl:         snapshotted.TTL,
+			stale:       snapshotted.Stale,
+			negative:    negative,
+			windowStart: time.Now(),
+		}
+		restored++
+	}
+	return restored
+}
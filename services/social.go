@@ -0,0 +1,275 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+var (
+	// ErrInvalidFollow wraps the reason a follow was rejected
+	ErrInvalidFollow = errors.New("invalid follow")
+
+	// ErrFollowLimit is returned when a user already follows MAX_FOLLOWING users
+	ErrFollowLimit = errors.New("follow limit reached")
+
+	// ErrFollowRequestNotFound is returned for a follow request that wasn't sent
+	ErrFollowRequestNotFound = errors.New("follow request not found")
+)
+
+// SocialGraph keeps which users follow which, and the follow requests waiting for
+// approval, each persisted as a JSON file
+type SocialGraph struct {
+	// MaxFollowing bounds the users one user may follow; zero means unlimited
+	MaxFollowing int
+
+	path         string
+	requestsPath string
+	users        *UserStore
+
+	mu sync.Mutex
+	// follows maps follower to followee to the time of the follow
+	follows map[string]map[string]time.Time
+	// requests maps followee to follower to the time of the request
+	requests map[string]map[string]time.Time
+}
+
+// NewSocialGraph loads the follows from FOLLOWS_PATH (default data/follows.json) and the
+// follow requests from FOLLOW_REQUESTS_PATH (default data/follow_requests.json), and reads
+// MAX_FOLLOWING (default 1000)
+func NewSocialGraph(users *UserStore) (*SocialGraph, error) {
+	path := os.Getenv("FOLLOWS_PATH")
+	if path == "" {
+		path = "data/follows.json"
+	}
+	requestsPath := os.Getenv("FOLLOW_REQUESTS_PATH")
+	if requestsPath == "" {
+		requestsPath = "data/follow_requests.json"
+	}
+
+	g := &SocialGraph{
+		MaxFollowing: envInt("MAX_FOLLOWING", 1000),
+		path:         path,
+		requestsPath: requestsPath,
+		users:        users,
+		follows:      make(map[string]map[string]time.Time),
+		requests:     make(map[string]map[string]time.Time),
+	}
+	if err := store.LoadJSON(path, &g.follows); err != nil {
+		return nil, err
+	}
+	if err := store.LoadJSON(requestsPath, &g.requests); err != nil {
+		return nil, err
+	}
+	if g.follows == nil {
+		g.follows = make(map[string]map[string]time.Time)
+	}
+	if g.requests == nil {
+		g.requests = make(map[string]map[string]time.Time)
+	}
+	return g, nil
+}
+
+// Follow makes follower follow followee. With approval set, as for users who don't share
+// everything publicly, the follow is only requested: it is returned pending and grants
+// nothing until followee approves it. Following a user again keeps the original time.
+func (g *SocialGraph) Follow(follower, followee string, approval bool) (models.Follow, error) {
+	if follower == followee {
+		return models.Follow{}, fmt.Errorf("%w: users can't follow themselves", ErrInvalidFollow)
+	}
+	user, ok := g.users.Get(followee)
+	if !ok {
+		return models.Follow{}, ErrUserNotFound
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	following := g.follows[follower]
+	if at, ok := following[followee]; ok {
+		return models.Follow{UserID: followee, Name: user.Name, FollowedAt: at}, nil
+	}
+	if g.MaxFollowing > 0 && len(following) >= g.MaxFollowing {
+		return models.Follow{}, ErrFollowLimit
+	}
+
+	now := time.Now().UTC()
+	if approval {
+		if at, ok := g.requests[followee][follower]; ok {
+			return models.Follow{UserID: followee, Name: user.Name, FollowedAt: at, Pending: true}, nil
+		}
+		if g.requests[followee] == nil {
+			g.requests[followee] = make(map[string]time.Time)
+		}
+		g.requests[followee][follower] = now
+		if err := store.SaveJSON(g.requestsPath, g.requests); err != nil {
+			delete(g.requests[followee], follower)
+			return models.Follow{}, err
+		}
+		return models.Follow{UserID: followee, Name: user.Name, FollowedAt: now, Pending: true}, nil
+	}
+
+	if following == nil {
+		following = make(map[string]time.Time)
+		g.follows[follower] = following
+	}
+	following[followee] = now
+	if err := store.SaveJSON(g.path, g.follows); err != nil {
+		delete(following, followee)
+		return models.Follow{}, err
+	}
+	return models.Follow{UserID: followee, Name: user.Name, FollowedAt: now}, nil
+}
+
+// Unfollow stops follower following followee, or withdraws the request to. It reports
+// whether follower did either.
+func (g *SocialGraph) Unfollow(follower, followee string) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if requested, ok := g.requests[followee][follower]; ok {
+		delete(g.requests[followee], follower)
+		if err := store.SaveJSON(g.requestsPath, g.requests); err != nil {
+			g.requests[followee][follower] = requested
+			return false, err
+		}
+		return true, nil
+	}
+
+	at, ok := g.follows[follower][followee]
+	if !ok {
+		return false, nil
+	}
+	delete(g.follows[follower], followee)
+	if err := store.SaveJSON(g.path, g.follows); err != nil {
+		g.follows[follower][followee] = at
+		return false, err
+	}
+	return true, nil
+}
+
+// Requests returns the follow requests waiting for user's approval, most recent first
+func (g *SocialGraph) Requests(user string) []models.Follow {
+	g.mu.Lock()
+	requests := make([]models.Follow, 0, len(g.requests[user]))
+	for follower, at := range g.requests[user] {
+		requests = append(requests, models.Follow{UserID: follower, FollowedAt: at, Pending: true})
+	}
+	g.mu.Unlock()
+
+	return g.named(requests)
+}
+
+// Approve turns follower's request to follow followee into a follow
+func (g *SocialGraph) Approve(followee, follower string) (models.Follow, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	requested, ok := g.requests[followee][follower]
+	if !ok {
+		return models.Follow{}, ErrFollowRequestNotFound
+	}
+	following := g.follows[follower]
+	if g.MaxFollowing > 0 && len(following) >= g.MaxFollowing {
+		return models.Follow{}, ErrFollowLimit
+	}
+
+	now := time.Now().UTC()
+	if following == nil {
+		following = make(map[string]time.Time)
+		g.follows[follower] = following
+	}
+	following[followee] = now
+	if err := store.SaveJSON(g.path, g.follows); err != nil {
+		delete(following, followee)
+		return models.Follow{}, err
+	}
+	delete(g.requests[followee], follower)
+	if err := store.SaveJSON(g.requestsPath, g.requests); err != nil {
+		g.requests[followee][follower] = requested
+		return models.Follow{}, err
+	}
+
+	follow := models.Follow{UserID: follower, FollowedAt: now}
+	if user, ok := g.users.Get(follower); ok {
+		follow.Name = user.Name
+	}
+	return follow, nil
+}
+
+// Reject declines follower's request to follow followee
+func (g *SocialGraph) Reject(followee, follower string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	requested, ok := g.requests[followee][follower]
+	if !ok {
+		return ErrFollowRequestNotFound
+	}
+	delete(g.requests[followee], follower)
+	if err := store.SaveJSON(g.requestsPath, g.requests); err != nil {
+		g.requests[followee][follower] = requested
+		return err
+	}
+	return nil
+}
+
+// IsFollowing reports whether follower follows followee; pending requests don't count
+func (g *SocialGraph) IsFollowing(follower, followee string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	_, ok := g.follows[follower][followee]
+	return ok
+}
+
+// Following returns the users user follows, most recent first
+func (g *SocialGraph) Following(user string) []models.Follow {
+	g.mu.Lock()
+	follows := make([]models.Follow, 0, len(g.follows[user]))
+	for followee, at := range g.follows[user] {
+		follows = append(follows, models.Follow{UserID: followee, FollowedAt: at})
+	}
+	g.mu.Unlock()
+
+	return g.named(follows)
+}
+
+// Followers returns the users following user, most recent first
+func (g *SocialGraph) Followers(user string) []models.Follow {
+	g.mu.Lock()
+	var follows []models.Follow
+	for follower, following := range g.follows {
+		if at, ok := following[user]; ok {
+			follows = append(follows, models.Follow{UserID: follower, FollowedAt: at})
+		}
+	}
+	g.mu.Unlock()
+
+	return g.named(follows)
+}
+
+// named adds the users' names and sorts follows newest first
+func (g *SocialGraph) named(follows []models.Follow) []models.Follow {
+	if follows == nil {
+		follows = []models.Follow{}
+	}
+	for i := range follows {
+		if user, ok := g.users.Get(follows[i].UserID); ok {
+			follows[i].Name = user.Name
+		}
+	}
+	sort.Slice(follows, func(i, j int) bool {
+		if !follows[i].FollowedAt.Equal(follows[j].FollowedAt) {
+			return follows[i].FollowedAt.After(follows[j].FollowedAt)
+		}
+		return follows[i].UserID < follows[j].UserID
+	})
+	return follows
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxSpellingDistance is the largest edit distance the dictionary precomputes deletes for
+const maxSpellingDistance = 2
+
+// titleWordPattern matches the words of a title or query
+var titleWordPattern = regexp.MustCompile(`[\p{L}\p{N}']+`)
+
+// TitleDictionary corrects misspelled words in search queries against the words of titles
+// the service has already seen (search results, lookups and aliases), because OMDb search
+// has no typo tolerance. It uses the symmetric delete approach (SymSpell): every word is
+// stored under all its variants with up to two characters deleted, so candidates for a
+// query word are found by generating the query word's deletes instead of scanning the
+// whole dictionary.
+type TitleDictionary struct {
+	// MaxWords bounds the number of distinct words, and of the distinct titles counted
+	// towards their frequencies; new ones are ignored once it is reached
+	MaxWords int
+
+	mu      sync.RWMutex
+	words   map[string]int
+	deletes map[string][]string
+	titles  map[string]bool
+}
+
+// titleDictionaryFromEnv reads SPELL_CORRECTION (default true) and SPELL_DICTIONARY_MAX_WORDS
+// (default 50000). It returns nil when correction is disabled.
+func titleDictionaryFromEnv() *TitleDictionary {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("SPELL_CORRECTION")), "false") {
+		return nil
+	}
+	return &TitleDictionary{
+		MaxWords: envInt("SPELL_DICTIONARY_MAX_WORDS", 50000),
+		words:    make(map[string]int),
+		deletes:  make(map[string][]string),
+		titles:   make(map[string]bool),
+	}
+}
+
+// Learn adds the words of a title. Each distinct title counts once towards word frequencies.
+func (d *TitleDictionary) Learn(title string) {
+	if d == nil || title == "" {
+		return
+	}
+	key := strings.ToLower(title)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.titles[key] || d.MaxWords > 0 && len(d.titles) >= d.MaxWords {
+		return
+	}
+	d.titles[key] = true
+
+	for _, word := range titleWordPattern.FindAllString(key, -1) {
+		if _, ok := d.words[word]; !ok {
+			if d.MaxWords > 0 && len(d.words) >= d.MaxWords {
+				continue
+			}
+			for variant := range deleteVariants(word, maxSpellingDistance) {
+				d.deletes[variant] = append(d.deletes[variant], word)
+			}
+		}
+		d.words[word]++
+	}
+}
+
+// Correct returns query with misspelled words replaced by their closest known words, and
+// whether anything changed. Words already in the dictionary, numbers and words shorter
+// than four characters are left alone; up to one edit is allowed for words shorter than
+// eight characters and two for longer ones.
+func (d *TitleDictionary) Correct(query string) (string, bool) {
+	if d == nil {
+		return query, false
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	changed := false
+	corrected := titleWordPattern.ReplaceAllStringFunc(query, func(word string) string {
+		if suggestion, ok := d.suggestLocked(strings.ToLower(word)); ok {
+			changed = true
+			return suggestion
+		}
+		return word
+	})
+	return corrected, changed
+}
+
+// suggestLocked returns the best correction for a lowercase word, if it needs one
+func (d *TitleDictionary) suggestLocked(word string) (string, bool) {
+	length := utf8.RuneCountInString(word)
+	if length < 4 || d.words[word] > 0 || strings.IndexFunc(word, isLetter) < 0 {
+		return "", false
+	}
+	maxDistance := 1
+	if length >= 8 {
+		maxDistance = maxSpellingDistance
+	}
+
+	best, bestDistance, bestCount := "", maxDistance+1, 0
+	for variant := range deleteVariants(word, maxDistance) {
+		for _, candidate := range d.deletes[variant] {
+			distance := editDistance(word, candidate)
+			count := d.words[candidate]
+			if distance < bestDistance || distance == bestDistance && (count > bestCount || count == bestCount && candidate < best) {
+				best, bestDistance, bestCount = candidate, distance, count
+			}
+		}
+	}
+	return best, best != ""
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r > utf8.RuneSelf
+}
+
+// deleteVariants returns word and every string obtained by deleting up to distance runes
+func deleteVariants(word string, distance int) map[string]bool {
+	variants := map[string]bool{word: true}
+	frontier := []string{word}
+	for step := 0; step < distance; step++ {
+		var next []string
+		for _, current := range frontier {
+			runes := []rune(current)
+			if len(runes) <= 1 {
+				continue
+			}
+			for i := range runes {
+				variant := string(runes[:i]) + string(runes[i+1:])
+				if !variants[variant] {
+					variants[variant] = true
+					next = append(next, variant)
+				}
+			}
+		}
+		frontier = next
+	}
+	return variants
+}
+
+// editDistance is the optimal string alignment distance: insertions, deletions,
+// substitutions and transpositions of adjacent runes each cost one
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
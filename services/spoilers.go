@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// ErrInvalidSpoilers wraps the reason spoiler spans were rejected
+var ErrInvalidSpoilers = errors.New("invalid spoilers")
+
+// SpoilerPlaceholder replaces spoilers hidden from a response
+const SpoilerPlaceholder = "[spoiler]"
+
+// maxSpoilerSpans bounds the spans tagged in one title's plot
+const maxSpoilerSpans = 20
+
+var (
+	// spoilerMarkup matches a span tagged as a spoiler in user-written text
+	spoilerMarkup = regexp.MustCompile(`(?is)\[spoiler\](.*?)\[/spoiler\]`)
+
+	// spoilerTag matches an opening or closing tag left over from unbalanced markup
+	spoilerTag = regexp.MustCompile(`(?i)\[/?spoiler\]`)
+)
+
+// validSpoilerMarkup reports whether every [spoiler] tag in text is closed, without nesting
+func validSpoilerMarkup(text string) bool {
+	if spoilerTag.MatchString(spoilerMarkup.ReplaceAllString(text, "")) {
+		return false
+	}
+	for _, match := range spoilerMarkup.FindAllStringSubmatch(text, -1) {
+		if spoilerTag.MatchString(match[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// redactSpoilerMarkup replaces the spans tagged [spoiler]...[/spoiler] in text. It
+// reports whether there were any.
+func redactSpoilerMarkup(text string) (string, bool) {
+	redacted := spoilerMarkup.ReplaceAllString(text, SpoilerPlaceholder)
+	return redacted, redacted != text
+}
+
+// SpoilerStore keeps the spans of titles' plots that moderators tagged as spoilers,
+// persisted as a JSON file. OMDb plots can't carry markup, so spans are exact passages of
+// the plot.
+type SpoilerStore struct {
+	path string
+
+	mu    sync.Mutex
+	spans map[string][]string
+}
+
+// NewSpoilerStore loads the spans from SPOILERS_PATH (default data/spoilers.json)
+func NewSpoilerStore() (*SpoilerStore, error) {
+	path := os.Getenv("SPOILERS_PATH")
+	if path == "" {
+		path = "data/spoilers.json"
+	}
+
+	s := &SpoilerStore{path: path, spans: make(map[string][]string)}
+	if err := store.LoadJSON(path, &s.spans); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the spans tagged in a title's plot
+func (s *SpoilerStore) Get(imdbID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.spans[imdbID]...)
+}
+
+// Set replaces the spans tagged in a title's plot, returning them before and after. An
+// empty list removes them.
+func (s *SpoilerStore) Set(imdbID string, spans []string) (before, after models.TitleSpoilers, err error) {
+	if !imdbIDPattern.MatchString(imdbID) {
+		return before, after, fmt.Errorf("%w: %q is not an IMDb ID", ErrInvalidSpoilers, imdbID)
+	}
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, span := range spans {
+		span = strings.TrimSpace(span)
+		if span != "" && !seen[span] {
+			seen[span] = true
+			cleaned = append(cleaned, span)
+		}
+	}
+	if len(cleaned) > maxSpoilerSpans {
+		return before, after, fmt.Errorf("%w: at most %d spans per title", ErrInvalidSpoilers, maxSpoilerSpans)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.spans[imdbID]
+	if len(cleaned) == 0 {
+		delete(s.spans, imdbID)
+	} else {
+		s.spans[imdbID] = cleaned
+	}
+	if err := store.SaveJSON(s.path, s.spans); err != nil {
+		if existed {
+			s.spans[imdbID] = previous
+		} else {
+			delete(s.spans, imdbID)
+		}
+		return before, after, err
+	}
+	before = models.TitleSpoilers{ImdbID: imdbID, Spans: append([]string{}, previous...)}
+	after = models.TitleSpoilers{ImdbID: imdbID, Spans: cleaned}
+	return before, after, nil
+}
+
+// RedactPlot replaces the spans tagged in a title's plot. It reports whether there were any.
+func (s *SpoilerStore) RedactPlot(imdbID, plot string) (string, bool) {
+	redacted := plot
+	for _, span := range s.Get(imdbID) {
+		redacted = strings.ReplaceAll(redacted, span, SpoilerPlaceholder)
+	}
+	return redacted, redacted != plot
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+var (
+	// ErrInvalidStaffPick wraps the reason a staff pick was rejected
+	ErrInvalidStaffPick = errors.New("invalid staff pick")
+
+	// ErrStaffPickNotFound is returned for a title that isn't a staff pick
+	ErrStaffPickNotFound = errors.New("staff pick not found")
+)
+
+// maxStaffPickNote bounds the length of a pick's note
+const maxStaffPickNote = 500
+
+// StaffPicks is the editorial list of titles admins curate, in their order, persisted as
+// a JSON file. Only the IMDb IDs and notes are stored; the titles' details come from the
+// detail cache when the list is served, so they stay current.
+type StaffPicks struct {
+	path string
+	omdb *OMDbService
+
+	mu    sync.RWMutex
+	picks []models.StaffPick
+}
+
+// NewStaffPicks loads the list from STAFF_PICKS_PATH (default data/staff_picks.json)
+func NewStaffPicks(omdb *OMDbService) (*StaffPicks, error) {
+	path := os.Getenv("STAFF_PICKS_PATH")
+	if path == "" {
+		path = "data/staff_picks.json"
+	}
+
+	s := &StaffPicks{path: path, omdb: omdb, picks: []models.StaffPick{}}
+	if err := store.LoadJSON(path, &s.picks); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// List returns the picks in editorial order
+func (s *StaffPicks) List() []models.StaffPick {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.StaffPick{}, s.picks...)
+}
+
+// Put adds a title to the list or updates its note and position, persists the list and
+// returns the pick with the one it replaced, if any. Positions past the end of the list
+// move the pick to the end.
+func (s *StaffPicks) Put(imdbID, actor string, req models.StaffPickRequest) (models.StaffPick, *models.StaffPick, error) {
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxStaffPickNote {
+		return models.StaffPick{}, nil, fmt.Errorf("%w: note must be at most %d characters", ErrInvalidStaffPick, maxStaffPickNote)
+	}
+	if req.Position < 0 {
+		return models.StaffPick{}, nil, fmt.Errorf("%w: position must be positive", ErrInvalidStaffPick)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pick := models.StaffPick{ImdbID: imdbID, Note: note, AddedBy: actor, AddedAt: time.Now().UTC()}
+	var previous *models.StaffPick
+	picks := make([]models.StaffPick, 0, len(s.picks)+1)
+	for _, existing := range s.picks {
+		if existing.ImdbID == imdbID {
+			replaced := existing
+			previous = &replaced
+			continue
+		}
+		picks = append(picks, existing)
+	}
+
+	at := len(picks)
+	if previous != nil {
+		pick.AddedBy, pick.AddedAt = previous.AddedBy, previous.AddedAt
+		if req.Position == 0 {
+			at = previous.Position - 1
+		}
+	}
+	if req.Position > 0 {
+		at = req.Position - 1
+	}
+	at = min(at, len(picks))
+	picks = append(picks[:at], append([]models.StaffPick{pick}, picks[at:]...)...)
+
+	s.picks = renumberPicks(picks)
+	return s.picks[at], previous, store.SaveJSON(s.path, s.picks)
+}
+
+// Delete removes a title from the list, persists it and returns the removed pick
+func (s *StaffPicks) Delete(imdbID string) (models.StaffPick, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, pick := range s.picks {
+		if pick.ImdbID == imdbID {
+			s.picks = renumberPicks(append(s.picks[:i:i], s.picks[i+1:]...))
+			return pick, store.SaveJSON(s.path, s.picks)
+		}
+	}
+	return models.StaffPick{}, ErrStaffPickNotFound
+}
+
+// Movies returns the picks with their titles' details, looked up through the detail cache,
+// and the IDs of the picks whose title couldn't be fetched
+func (s *StaffPicks) Movies(ctx context.Context) ([]models.StaffPickMovie, []string) {
+	picks := s.List()
+	imdbIDs := make([]string, len(picks))
+	for i, pick := range picks {
+		imdbIDs[i] = pick.ImdbID
+	}
+	records := s.omdb.GetTitlesByID(ctx, imdbIDs)
+
+	movies := make([]models.StaffPickMovie, 0, len(picks))
+	var missing []string
+	for _, pick := range picks {
+		record, ok := records[pick.ImdbID]
+		if !ok {
+			missing = append(missing, pick.ImdbID)
+			continue
+		}
+		movies = append(movies, models.StaffPickMovie{
+			ImdbID:   pick.ImdbID,
+			Position: pick.Position,
+			Note:     pick.Note,
+			Movie:    newMovieBrief(record),
+		})
+	}
+	return movies, missing
+}
+
+// renumberPicks sets the positions of picks to their order, starting at 1
+func renumberPicks(picks []models.StaffPick) []models.StaffPick {
+	for i := range picks {
+		picks[i].Position = i + 1
+	}
+	return picks
+}
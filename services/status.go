@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+const (
+	// statusWindow is how far back the status page looks
+	statusWindow = 15 * time.Minute
+
+	// statusMinCalls is the number of calls in the window below which error rates aren't flagged
+	statusMinCalls = 5
+
+	// Thresholds at which the status page flags an incident
+	statusErrorRateDegraded = 0.25
+	statusErrorRateOutage   = 0.5
+	statusSlowLatency       = 2 * time.Second
+)
+
+// quotaStateScript reads the day's upstream call count and when the quota ran out
+var quotaStateScript = NewRedisScript(`return {redis.call('GET', KEYS[1]), redis.call('GET', KEYS[2])}`)
+
+// quotaHitScript records when the quota ran out, for the rest of the day
+var quotaHitScript = NewRedisScript(`redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2]) return 1`)
+
+// StatusRecorder keeps per-minute counts of upstream calls and of the API's own requests
+// for the public status page, so clients can tell whether slowness is ours or OMDb's.
+// With Redis configured the daily quota figures are shared by all replicas, since they
+// all draw on the same OMDb key.
+type StatusRecorder struct {
+	// DailyLimit is the OMDb plan's daily request limit, used to report the remaining quota
+	DailyLimit int
+
+	redis *RedisClient
+
+	mu       sync.Mutex
+	upstream [statusBuckets]statusBucket
+	api      [statusBuckets]statusBucket
+
+	day        string
+	callsToday int
+	quotaHitAt time.Time
+}
+
+const statusBuckets = int(statusWindow / time.Minute)
+
+// statusBucket holds the counts of one minute
+type statusBucket struct {
+	minute  int64
+	count   int
+	errors  int
+	latency time.Duration
+}
+
+// newStatusRecorder reads OMDB_DAILY_LIMIT (default 1000, the free plan's limit)
+func newStatusRecorder(redis *RedisClient) *StatusRecorder {
+	return &StatusRecorder{DailyLimit: envInt("OMDB_DAILY_LIMIT", 1000), redis: redis}
+}
+
+// recordUpstream counts one upstream call. Cancelled calls say nothing about the upstream.
+func (r *StatusRecorder) recordUpstream(latency time.Duration, err error) {
+	if r == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	addToBucket(&r.upstream, latency, err != nil)
+	r.rolloverLocked()
+	r.callsToday++
+	quotaHit := errors.Is(err, ErrUpstreamQuota)
+	if quotaHit {
+		r.quotaHitAt = time.Now()
+	}
+	if r.redis != nil {
+		// Shared counts are updated off the request path
+		go r.recordShared(r.day, quotaHit)
+	}
+}
+
+// recordShared adds the call to the replicas' shared daily count
+func (r *StatusRecorder) recordShared(day string, quotaHit bool) {
+	ctx := context.Background()
+	_, err := r.redis.Run(ctx, counterScript, []string{r.redis.Key("upstream", day, "calls")}, 1, usageRetention.Milliseconds())
+	if err == nil && quotaHit {
+		_, err = r.redis.Run(ctx, quotaHitScript, []string{r.redis.Key("upstream", day, "quota_hit")}, time.Now().UnixMilli(), usageRetention.Milliseconds())
+	}
+	if err != nil {
+		r.redis.logError("upstream call count", err)
+	}
+}
+
+// sharedQuota reads the day's shared call count and quota exhaustion
+func (r *StatusRecorder) sharedQuota(day string) (calls int, exhausted bool, err error) {
+	keys := []string{r.redis.Key("upstream", day, "calls"), r.redis.Key("upstream", day, "quota_hit")}
+	reply, err := r.redis.Run(context.Background(), quotaStateScript, keys)
+	if err != nil {
+		return 0, false, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, false, fmt.Errorf("unexpected quota reply %v", reply)
+	}
+	count, _ := values[0].(string)
+	calls, _ = strconv.Atoi(count)
+	return calls, values[1] != nil, nil
+}
+
+// RecordRequest counts one request served by the API; failed is set for 5xx responses
+func (r *StatusRecorder) RecordRequest(latency time.Duration, failed bool) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	addToBucket(&r.api, latency, failed)
+}
+
+func addToBucket(buckets *[statusBuckets]statusBucket, latency time.Duration, failed bool) {
+	minute := time.Now().Unix() / 60
+	bucket := &buckets[minute%int64(statusBuckets)]
+	if bucket.minute != minute {
+		*bucket = statusBucket{minute: minute}
+	}
+	bucket.count++
+	bucket.latency += latency
+	if failed {
+		bucket.errors++
+	}
+}
+
+func (r *StatusRecorder) rolloverLocked() {
+	if today := time.Now().UTC().Format("2006-01-02"); today != r.day {
+		r.day = today
+		r.callsToday = 0
+	}
+}
+
+// summarize adds up the buckets within the window
+func summarize(buckets *[statusBuckets]statusBucket) models.TrafficStatus {
+	oldest := time.Now().Unix()/60 - int64(statusBuckets) + 1
+	var count, failed int
+	var latency time.Duration
+	for _, bucket := range buckets {
+		if bucket.minute >= oldest {
+			count += bucket.count
+			failed += bucket.errors
+			latency += bucket.latency
+		}
+	}
+
+	traffic := models.TrafficStatus{Requests: count, Errors: failed}
+	if count > 0 {
+		traffic.ErrorRate = math.Round(float64(failed)/float64(count)*1000) / 1000
+		traffic.AvgLatencyMs = (latency / time.Duration(count)).Milliseconds()
+	}
+	return traffic
+}
+
+// fill adds the traffic and quota figures to report
+func (r *StatusRecorder) fill(report *models.StatusReport) {
+	if r == nil {
+		return
+	}
+
+	// The shared figures are read before locking, so a slow Redis doesn't hold up calls
+	var sharedCalls int
+	var shared, sharedExhausted bool
+	if r.redis != nil {
+		calls, exhausted, err := r.sharedQuota(time.Now().UTC().Format("2006-01-02"))
+		if err == nil {
+			sharedCalls, sharedExhausted, shared = calls, exhausted, true
+		} else {
+			r.redis.logError("quota lookup", err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report.API = summarize(&r.api)
+	report.Upstream = summarize(&r.upstream)
+	r.rolloverLocked()
+	report.Quota = models.QuotaStatus{
+		CallsToday: r.callsToday,
+		DailyLimit: r.DailyLimit,
+		Exhausted:  !r.quotaHitAt.IsZero() && r.quotaHitAt.UTC().Format("2006-01-02") == r.day,
+	}
+	if shared {
+		report.Quota.CallsToday = sharedCalls
+		report.Quota.Exhausted = report.Quota.Exhausted || sharedExhausted
+		report.Quota.Shared = true
+	}
+	if r.DailyLimit > 0 {
+		report.Quota.Remaining = max(r.DailyLimit-report.Quota.CallsToday, 0)
+	}
+}
+
+// Status reports the OMDb service's recent upstream behaviour and quota use together with
+// the API's own, and flags incidents
+func (s *OMDbService) Status() models.StatusReport {
+	report := models.StatusReport{
+		Status:        "operational",
+		GeneratedAt:   time.Now().UTC(),
+		WindowMinutes: statusBuckets,
+		Incidents:     []models.Incident{},
+	}
+	s.Stats.fill(&report)
+	report.BaseURLs = s.health.report(s.baseURLs())
+	report.Queue = s.Scheduler.Status()
+	report.CacheShards = s.Cache.Shared.Status()
+	if s.Keys.Len() > 1 {
+		// One key running out doesn't exhaust the pool
+		report.Quota.Keys = s.Keys.Usage()
+		report.Quota.Exhausted = s.Keys.Exhausted()
+	}
+
+	flag := func(name, message string, outage bool) {
+		report.Incidents = append(report.Incidents, models.Incident{Flag: name, Message: message})
+		if outage {
+			report.Status = "outage"
+		} else if report.Status == "operational" {
+			report.Status = "degraded"
+		}
+	}
+	upstream := report.Upstream
+	if report.Quota.Exhausted {
+		flag("quota_exhausted", "The OMDb daily request limit has been reached; lookups not in the cache fail until midnight UTC", true)
+	}
+	if upstream.Requests >= statusMinCalls && upstream.ErrorRate >= statusErrorRateDegraded {
+		flag("upstream_errors", "OMDb is returning errors for a large share of requests", upstream.ErrorRate >= statusErrorRateOutage)
+	}
+	if upstream.Requests > 0 && time.Duration(upstream.AvgLatencyMs)*time.Millisecond >= statusSlowLatency {
+		flag("upstream_slow", "OMDb is responding slowly", false)
+	}
+	for _, baseURL := range report.BaseURLs {
+		if baseURL.Benched {
+			flag("base_url_benched", "An OMDb base URL is failing and temporarily skipped: "+baseURL.Host, false)
+		}
+	}
+	if report.API.Requests >= statusMinCalls && report.API.ErrorRate >= statusErrorRateDegraded {
+		flag("api_errors", "The API is failing a large share of requests", false)
+	}
+	return report
+}
+
+// report lists the health of each base URL, identified by host only
+func (h *upstreamHealth) report(baseURLs []string) []models.BaseURLStatus {
+	statuses := make([]models.BaseURLStatus, 0, len(baseURLs))
+	if h == nil {
+		return statuses
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for _, baseURL := range baseURLs {
+		host := baseURL
+		if parsed, err := url.Parse(baseURL); err == nil && parsed.Host != "" {
+			host = parsed.Host
+		}
+		stats := h.statsLocked(baseURL)
+		statuses = append(statuses, models.BaseURLStatus{
+			Host:    host,
+			Score:   math.Round(stats.score*1000) / 1000,
+			Benched: now.Before(stats.benchedTill),
+		})
+	}
+	return statuses
+}
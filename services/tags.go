@@ -0,0 +1,263 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+//go:embed data/tags.json
+var defaultTagData []byte
+
+var (
+	// ErrInvalidTag wraps the reason a tag or a title's tags were rejected
+	ErrInvalidTag = errors.New("invalid tag")
+
+	// ErrTagNotFound is returned for a tag that isn't in the taxonomy
+	ErrTagNotFound = errors.New("tag not found")
+)
+
+// tagSlugPattern matches tag slugs such as heist or time-travel
+var tagSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// maxTitleTags bounds the tags of one title
+const maxTitleTags = 20
+
+// TagStore is a taxonomy of subgenres and themes finer than OMDb's genres, and the tags
+// admins assigned to titles. With extraction enabled, titles are also tagged when their
+// plot mentions one of a tag's keywords. The taxonomy starts from a built-in list the
+// first time and is persisted as a JSON file with the assignments.
+type TagStore struct {
+	// Extraction enables tagging titles by the keywords found in their plot
+	Extraction bool
+
+	path string
+
+	mu       sync.RWMutex
+	tags     map[string]models.Tag
+	titles   map[string]models.TitleTags
+	keywords map[string]*regexp.Regexp
+}
+
+// tagFile is the persisted form of the store
+type tagFile struct {
+	Tags   map[string]models.Tag       `json:"tags"`
+	Titles map[string]models.TitleTags `json:"titles"`
+}
+
+// NewTagStore loads the taxonomy and assignments from TAGS_PATH (default data/tags.json)
+// and reads TAG_EXTRACTION (default false)
+func NewTagStore() (*TagStore, error) {
+	path := os.Getenv("TAGS_PATH")
+	if path == "" {
+		path = "data/tags.json"
+	}
+
+	var file tagFile
+	if err := store.LoadJSON(path, &file); err != nil {
+		return nil, err
+	}
+	if file.Tags == nil {
+		var defaults []models.Tag
+		if err := json.Unmarshal(defaultTagData, &defaults); err != nil {
+			return nil, fmt.Errorf("invalid default tags: %w", err)
+		}
+		file.Tags = make(map[string]models.Tag, len(defaults))
+		for _, tag := range defaults {
+			file.Tags[tag.Slug] = tag
+		}
+	}
+	if file.Titles == nil {
+		file.Titles = make(map[string]models.TitleTags)
+	}
+
+	s := &TagStore{
+		Extraction: strings.EqualFold(strings.TrimSpace(os.Getenv("TAG_EXTRACTION")), "true"),
+		path:       path,
+		tags:       file.Tags,
+		titles:     file.Titles,
+		keywords:   make(map[string]*regexp.Regexp, len(file.Tags)),
+	}
+	for slug, tag := range s.tags {
+		s.keywords[slug] = keywordPattern(tag.Keywords)
+	}
+	return s, nil
+}
+
+// keywordPattern matches any of keywords as whole words, ignoring case; nil if there are none
+func keywordPattern(keywords []string) *regexp.Regexp {
+	if len(keywords) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		quoted[i] = regexp.QuoteMeta(keyword)
+	}
+	return regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// List returns the taxonomy sorted by slug, with the number of titles assigned each tag
+func (s *TagStore) List() []models.Tag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, title := range s.titles {
+		for _, slug := range title.Tags {
+			counts[slug]++
+		}
+	}
+
+	tags := make([]models.Tag, 0, len(s.tags))
+	for _, tag := range s.tags {
+		tag.Titles = counts[tag.Slug]
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Slug < tags[j].Slug })
+	return tags
+}
+
+// Put creates or replaces a tag and persists the store. It also returns the tag it
+// replaced, if any.
+func (s *TagStore) Put(slug string, req models.TagRequest) (models.Tag, *models.Tag, error) {
+	if !tagSlugPattern.MatchString(slug) {
+		return models.Tag{}, nil, fmt.Errorf("%w: slug must be lowercase words joined by hyphens, e.g. time-travel", ErrInvalidTag)
+	}
+	tag := models.Tag{
+		Slug:        slug,
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Keywords:    cleanList(req.Keywords),
+	}
+	if tag.Name == "" {
+		return models.Tag{}, nil, fmt.Errorf("%w: name is required", ErrInvalidTag)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var previous *models.Tag
+	if existing, ok := s.tags[slug]; ok {
+		previous = &existing
+	}
+	s.tags[slug] = tag
+	s.keywords[slug] = keywordPattern(tag.Keywords)
+	return tag, previous, s.saveLocked()
+}
+
+// Delete removes a tag and its assignments, persists the store and returns the removed tag
+func (s *TagStore) Delete(slug string) (models.Tag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag, ok := s.tags[slug]
+	if !ok {
+		return models.Tag{}, ErrTagNotFound
+	}
+	delete(s.tags, slug)
+	delete(s.keywords, slug)
+	for imdbID, title := range s.titles {
+		kept := title.Tags[:0:0]
+		for _, assigned := range title.Tags {
+			if assigned != slug {
+				kept = append(kept, assigned)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.titles, imdbID)
+		} else if len(kept) != len(title.Tags) {
+			title.Tags = kept
+			s.titles[imdbID] = title
+		}
+	}
+	return tag, s.saveLocked()
+}
+
+// SetTitle replaces the tags assigned to a title and persists the store; an empty list
+// removes them. It also returns the previous assignment, if any.
+func (s *TagStore) SetTitle(imdbID string, slugs []string) (models.TitleTags, *models.TitleTags, error) {
+	slugs = cleanList(slugs)
+	if len(slugs) > maxTitleTags {
+		return models.TitleTags{}, nil, fmt.Errorf("%w: at most %d tags per title", ErrInvalidTag, maxTitleTags)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, slug := range slugs {
+		slugs[i] = strings.ToLower(slug)
+		if _, ok := s.tags[slugs[i]]; !ok {
+			return models.TitleTags{}, nil, fmt.Errorf("%w: unknown tag %q", ErrInvalidTag, slug)
+		}
+	}
+	sort.Strings(slugs)
+
+	var previous *models.TitleTags
+	if existing, ok := s.titles[imdbID]; ok {
+		previous = &existing
+	}
+	title := models.TitleTags{ImdbID: imdbID, Tags: slugs, UpdatedAt: time.Now().UTC()}
+	if len(slugs) == 0 {
+		delete(s.titles, imdbID)
+	} else {
+		s.titles[imdbID] = title
+	}
+	return title, previous, s.saveLocked()
+}
+
+// Validate checks that every slug names a tag, returning them lowercased
+func (s *TagStore) Validate(slugs []string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	valid := make([]string, 0, len(slugs))
+	for _, slug := range cleanList(slugs) {
+		slug = strings.ToLower(slug)
+		if _, ok := s.tags[slug]; !ok {
+			return nil, fmt.Errorf("%w: unknown tag %q; see GET /api/tags for the supported tags", ErrInvalidTag, slug)
+		}
+		valid = append(valid, slug)
+	}
+	return valid, nil
+}
+
+// Annotate returns a copy of movies with each movie's tags set: those assigned by admins
+// and, with extraction enabled, those whose keywords appear in the plot
+func (s *TagStore) Annotate(movies []models.MovieBrief) []models.MovieBrief {
+	if s == nil {
+		return movies
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	annotated := make([]models.MovieBrief, len(movies))
+	for i, movie := range movies {
+		tags := append([]string(nil), s.titles[movie.ImdbID].Tags...)
+		if s.Extraction && movie.Plot != "" {
+			for slug, pattern := range s.keywords {
+				if pattern != nil && overlap([]string{slug}, tags) == 0 && pattern.MatchString(movie.Plot) {
+					tags = append(tags, slug)
+				}
+			}
+			sort.Strings(tags)
+		}
+		movie.Tags = tags
+		annotated[i] = movie
+	}
+	return annotated
+}
+
+func (s *TagStore) saveLocked() error {
+	return store.SaveJSON(s.path, tagFile{Tags: s.tags, Titles: s.titles})
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxTitleVariants bounds the alternative spellings tried for a title OMDb doesn't know,
+// since each one costs an upstream call
+const maxTitleVariants = 3
+
+// titleArticles are the leading articles that don't distinguish one title from another
+var titleArticles = map[string]bool{"the": true, "a": true, "an": true}
+
+var (
+	// romanNumeralPattern matches the roman numerals up to XXXIX, as used in sequel titles
+	romanNumeralPattern = regexp.MustCompile(`^x{0,3}(ix|iv|v?i{0,3})$`)
+
+	// romanWordPattern and sequelNumberPattern find the words of a title that may be
+	// written either way: "Rocky II" and "Rocky 2"
+	romanWordPattern    = regexp.MustCompile(`\b[IVXivx]+\b`)
+	sequelNumberPattern = regexp.MustCompile(`\b[1-9]\d?\b`)
+
+	// trailingArticlePattern matches catalogue-style titles such as "Matrix, The"
+	trailingArticlePattern = regexp.MustCompile(`(?i)^(.+),\s*(the|a|an)$`)
+
+	// andPattern matches "and" as a word
+	andPattern = regexp.MustCompile(`(?i)\band\b`)
+)
+
+// foldedLetters spells the letters that don't decompose into a base letter and marks
+var foldedLetters = strings.NewReplacer(
+	"ß", "ss", "Æ", "AE", "æ", "ae", "Œ", "OE", "œ", "oe", "Ø", "O", "ø", "o",
+	"Ł", "L", "ł", "l", "Đ", "D", "đ", "d", "Þ", "Th", "þ", "th", "ı", "i",
+)
+
+// foldDiacritics removes accents and other marks, so that "Amélie" and "Amelie" compare
+// equal. The text is decomposed with NFKD, which also turns compatibility forms such as
+// ligatures and full-width letters into plain ones, and the combining marks are dropped.
+func foldDiacritics(text string) string {
+	decomposed := norm.NFKD.String(foldedLetters.Replace(text))
+	var b strings.Builder
+	b.Grow(len(decomposed))
+	for _, r := range decomposed {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// titleYearKey identifies a title and year for deduplication when there is no IMDb ID,
+// ignoring case, punctuation and diacritics
+func titleYearKey(title, year string) string {
+	return normalizeTitle(title) + "|" + year
+}
+
+// romanValue returns the value of a roman numeral from II to XXXIX. I is left out, since
+// as a word it is far more often the pronoun.
+func romanValue(word string) (int, bool) {
+	word = strings.ToLower(word)
+	if word == "" || word == "i" || !romanNumeralPattern.MatchString(word) {
+		return 0, false
+	}
+	values := map[byte]int{'i': 1, 'v': 5, 'x': 10}
+	total := 0
+	for i := 0; i < len(word); i++ {
+		value := values[word[i]]
+		if i+1 < len(word) && values[word[i+1]] > value {
+			total -= value
+		} else {
+			total += value
+		}
+	}
+	return total, true
+}
+
+// romanNumeral writes 2 to 39 as a roman numeral
+func romanNumeral(n int) string {
+	var b strings.Builder
+	for _, step := range []struct {
+		value   int
+		numeral string
+	}{{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"}} {
+		for n >= step.value {
+			b.WriteString(step.numeral)
+			n -= step.value
+		}
+	}
+	return b.String()
+}
+
+// canonicalTitle reduces a title to the form in which common spelling variants compare
+// equal: normalized (see normalizeTitle), without a leading article, with roman numerals
+// as digits and "&" as "and". "The Lord of the Rings: The Two Towers" and "Lord of the
+// Rings - The Two Towers" share a form, as do "Rocky II" and "Rocky 2".
+func canonicalTitle(title string) string {
+	if match := trailingArticlePattern.FindStringSubmatch(strings.TrimSpace(title)); match != nil {
+		title = match[2] + " " + match[1]
+	}
+	words := strings.Fields(normalizeTitle(strings.ReplaceAll(title, "&", " and ")))
+	if len(words) > 1 && titleArticles[words[0]] {
+		words = words[1:]
+	}
+	for i, word := range words {
+		if value, ok := romanValue(word); ok {
+			words[i] = strconv.Itoa(value)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// titleVariants returns up to maxTitleVariants other spellings of a title to look up when
+// OMDb doesn't know the one given: numbers and roman numerals swapped, "&" and "and"
+// swapped, and the leading article dropped or added
+func titleVariants(title string) []string {
+	title = strings.TrimSpace(title)
+	original := title
+	var variants []string
+	add := func(variant string) {
+		variant = strings.Join(strings.Fields(variant), " ")
+		if len(variants) == maxTitleVariants || variant == "" || strings.EqualFold(variant, original) {
+			return
+		}
+		for _, existing := range variants {
+			if strings.EqualFold(existing, variant) {
+				return
+			}
+		}
+		variants = append(variants, variant)
+	}
+
+	if match := trailingArticlePattern.FindStringSubmatch(title); match != nil {
+		// The other variants start from the usual word order
+		title = match[2] + " " + match[1]
+		add(title)
+	}
+	add(romanWordPattern.ReplaceAllStringFunc(title, func(word string) string {
+		if value, ok := romanValue(word); ok {
+			return strconv.Itoa(value)
+		}
+		return word
+	}))
+	add(sequelNumberPattern.ReplaceAllStringFunc(title, func(word string) string {
+		if value, _ := strconv.Atoi(word); value >= 2 && value <= 39 {
+			return romanNumeral(value)
+		}
+		return word
+	}))
+	if strings.Contains(title, "&") {
+		add(strings.ReplaceAll(title, "&", " and "))
+	} else {
+		add(andPattern.ReplaceAllString(title, "&"))
+	}
+	if words := strings.Fields(title); len(words) > 1 && titleArticles[strings.ToLower(words[0])] {
+		add(strings.Join(words[1:], " "))
+	} else {
+		add("The " + title)
+	}
+	return variants
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, forged or expired
+var ErrInvalidToken = errors.New("invalid token")
+
+// TokenIssuer issues and verifies the API's own JWTs (HS256). Every login method ends in
+// the same kind of token, so the rest of the API doesn't care how a user signed in.
+type TokenIssuer struct {
+	// TTL is how long an issued token is valid
+	TTL time.Duration
+	// Issuer is the iss claim of issued tokens
+	Issuer string
+
+	secret []byte
+}
+
+// Claims are the claims of an API token
+type Claims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	Name      string `json:"name,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Role      string `json:"role"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// NewTokenIssuer reads JWT_SECRET and JWT_TTL_SECONDS (default 86400). The issuer is
+// PUBLIC_BASE_URL, or "movie-api". Without a secret no tokens are issued and it returns nil.
+func NewTokenIssuer() *TokenIssuer {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil
+	}
+
+	issuer := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	if issuer == "" {
+		issuer = "movie-api"
+	}
+	return &TokenIssuer{
+		TTL:    time.Duration(envInt("JWT_TTL_SECONDS", 86400)) * time.Second,
+		Issuer: issuer,
+		secret: []byte(secret),
+	}
+}
+
+// Issue signs a token for user
+func (t *TokenIssuer) Issue(user models.User) (string, time.Time, error) {
+	now := time.Now()
+	expires := now.Add(t.TTL)
+	claims := Claims{
+		Issuer:    t.Issuer,
+		Subject:   user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expires.Unix(),
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + t.sign(unsigned), expires, nil
+}
+
+// jwtHeader is the encoded {"alg":"HS256","typ":"JWT"} header of every issued token
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Verify checks the signature, issuer and expiry of token and returns its claims
+func (t *TokenIssuer) Verify(token string) (Claims, error) {
+	var claims Claims
+	if t == nil {
+		return claims, ErrInvalidToken
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return claims, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(t.sign(parts[0]+"."+parts[1]))) {
+		return claims, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(payload, &claims) != nil {
+		return claims, ErrInvalidToken
+	}
+	if claims.Issuer != t.Issuer || claims.Subject == "" || time.Now().Unix() >= claims.ExpiresAt {
+		return claims, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (t *TokenIssuer) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
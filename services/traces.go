@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// maxTraceCalls bounds the calls kept in one trace; the genre fan-out stays well below it
+const maxTraceCalls = 500
+
+// TraceStore keeps debug traces of single requests: every OMDb call made on the request's
+// behalf, with its URL (without the API key), timing, outcome and payload, and every
+// lookup served from the detail cache with the cached payload. A trace shows exactly
+// which data produced an odd response, e.g. a surprising recommendation, so it can be
+// reproduced. Traces are kept in memory, newest first, up to MaxTraces.
+type TraceStore struct {
+	// MaxTraces bounds the number of traces kept; the oldest is dropped first
+	MaxTraces int
+	// MaxPayloadBytes bounds the payload kept per call
+	MaxPayloadBytes int
+
+	mu     sync.Mutex
+	traces map[string]*RequestTrace
+	order  []string
+}
+
+// RequestTrace collects the trace of one request while it runs
+type RequestTrace struct {
+	maxPayload int
+
+	mu    sync.Mutex
+	trace models.DebugTrace
+	start time.Time
+}
+
+// NewTraceStore reads DEBUG_TRACE_MAX (default 100) and DEBUG_TRACE_PAYLOAD_BYTES
+// (default 65536)
+func NewTraceStore() *TraceStore {
+	return &TraceStore{
+		MaxTraces:       max(envInt("DEBUG_TRACE_MAX", 100), 1),
+		MaxPayloadBytes: envInt("DEBUG_TRACE_PAYLOAD_BYTES", 65536),
+		traces:          make(map[string]*RequestTrace),
+	}
+}
+
+// Start begins the trace of a request and keeps it, dropping the oldest trace when full
+func (s *TraceStore) Start(method, path, actor string) (*RequestTrace, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	trace := &RequestTrace{
+		maxPayload: s.MaxPayloadBytes,
+		start:      now,
+		trace: models.DebugTrace{
+			ID:        "tr_" + hex.EncodeToString(raw),
+			Method:    method,
+			Path:      path,
+			Actor:     actor,
+			StartedAt: now.UTC(),
+			Calls:     []models.TraceCall{},
+		},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.traces[trace.trace.ID] = trace
+	s.order = append(s.order, trace.trace.ID)
+	for len(s.order) > s.MaxTraces {
+		delete(s.traces, s.order[0])
+		s.order = s.order[1:]
+	}
+	return trace, nil
+}
+
+// Get returns a trace by ID
+func (s *TraceStore) Get(id string) (models.DebugTrace, bool) {
+	s.mu.Lock()
+	trace, ok := s.traces[id]
+	s.mu.Unlock()
+	if !ok {
+		return models.DebugTrace{}, false
+	}
+	return trace.Snapshot(), true
+}
+
+// List returns summaries of the kept traces, newest first, without their calls
+func (s *TraceStore) List() []models.DebugTrace {
+	s.mu.Lock()
+	traces := make([]*RequestTrace, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		traces = append(traces, s.traces[s.order[i]])
+	}
+	s.mu.Unlock()
+
+	summaries := make([]models.DebugTrace, len(traces))
+	for i, trace := range traces {
+		summaries[i] = trace.Snapshot()
+		summaries[i].Calls = nil
+	}
+	return summaries
+}
+
+type traceKey struct{}
+
+// WithTrace attaches a trace to the context passed to the service methods
+func WithTrace(ctx context.Context, trace *RequestTrace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// TraceFrom returns the trace of the context, or nil if the request isn't traced
+func TraceFrom(ctx context.Context) *RequestTrace {
+	trace, _ := ctx.Value(traceKey{}).(*RequestTrace)
+	return trace
+}
+
+// ID returns the trace's ID
+func (t *RequestTrace) ID() string {
+	return t.trace.ID
+}
+
+// Finish records the response status and the request's duration
+func (t *RequestTrace) Finish(status int) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.trace.Status = status
+	t.trace.DurationMs = time.Since(t.start).Milliseconds()
+	t.trace.Complete = true
+}
+
+// Snapshot returns a copy of the trace as recorded so far
+func (t *RequestTrace) Snapshot() models.DebugTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	trace := t.trace
+	trace.Calls = append([]models.TraceCall(nil), t.trace.Calls...)
+	return trace
+}
+
+// recordUpstream adds an OMDb call. The API key is left out of the URL.
+func (t *RequestTrace) recordUpstream(reqURL string, start time.Time, body []byte, err error) {
+	if t == nil {
+		return
+	}
+	call := models.TraceCall{
+		Source:     "upstream",
+		URL:        redactURL(reqURL),
+		OffsetMs:   start.Sub(t.start).Milliseconds(),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		call.Error = errorText(err)
+	}
+	t.add(call, body)
+}
+
+// recordCache adds a lookup served from the detail cache
+func (t *RequestTrace) recordCache(key, status string, age time.Duration, body []byte) {
+	if t == nil {
+		return
+	}
+	t.add(models.TraceCall{
+		Source:      "cache",
+		CacheKey:    key,
+		CacheStatus: status,
+		CacheAgeMs:  age.Milliseconds(),
+		OffsetMs:    time.Since(t.start).Milliseconds(),
+	}, body)
+}
+
+func (t *RequestTrace) add(call models.TraceCall, body []byte) {
+	switch {
+	case len(body) == 0:
+	case t.maxPayload > 0 && len(body) > t.maxPayload:
+		call.Payload = string(body[:t.maxPayload])
+		call.PayloadTruncated = true
+	case json.Valid(body):
+		call.Payload = json.RawMessage(body)
+	default:
+		call.Payload = string(body)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.trace.Calls) >= maxTraceCalls {
+		t.trace.CallsDropped++
+		return
+	}
+	t.trace.Calls = append(t.trace.Calls, call)
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// httpClientFromEnv builds the HTTP client for one upstream provider (e.g. "OMDB",
+// "WIKIPEDIA"). <PROVIDER>_PROXY_URL and <PROVIDER>_CA_BUNDLE configure that provider;
+// OUTBOUND_PROXY_URL and OUTBOUND_CA_BUNDLE apply to every provider without its own
+// setting. Without a proxy setting the standard HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+// variables are honoured. A CA bundle is a PEM file trusted in addition to the system
+// roots, as needed behind TLS-intercepting corporate proxies.
+func httpClientFromEnv(provider string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy := providerSetting(provider, "PROXY_URL"); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid %s proxy URL %q", provider, proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if bundle := providerSetting(provider, "CA_BUNDLE"); bundle != "" {
+		pem, err := os.ReadFile(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s CA bundle: %w", provider, err)
+		}
+
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s CA bundle %s contains no PEM certificates", provider, bundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// providerSetting reads <PROVIDER>_<NAME>, falling back to OUTBOUND_<NAME>
+func providerSetting(provider, name string) string {
+	if value := os.Getenv(provider + "_" + name); value != "" {
+		return value
+	}
+	return os.Getenv("OUTBOUND_" + name)
+}
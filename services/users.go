@@ -0,0 +1,174 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"movie-api-go/models"
+	"movie-api-go/store"
+)
+
+// ErrUserNotFound is returned for unknown user IDs
+var ErrUserNotFound = errors.New("user not found")
+
+// UserStore keeps the local users and the external identities mapped to them. It is
+// persisted as a JSON file.
+type UserStore struct {
+	path string
+
+	mu    sync.Mutex
+	users map[string]*models.User
+}
+
+// NewUserStore loads the users from USERS_PATH (default data/users.json)
+func NewUserStore() (*UserStore, error) {
+	path := os.Getenv("USERS_PATH")
+	if path == "" {
+		path = "data/users.json"
+	}
+
+	var users []*models.User
+	if err := store.LoadJSON(path, &users); err != nil {
+		return nil, err
+	}
+
+	s := &UserStore{path: path, users: make(map[string]*models.User)}
+	for _, user := range users {
+		s.users[user.ID] = user
+	}
+	return s, nil
+}
+
+// Get returns the user with the given ID
+func (s *UserStore) Get(id string) (models.User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, false
+	}
+	return *user, true
+}
+
+// List returns all users, oldest first
+func (s *UserStore) List() []models.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := make([]models.User, 0, len(s.users))
+	for _, user := range s.sortedLocked() {
+		users = append(users, *user)
+	}
+	return users
+}
+
+// SetRole changes a user's role, returning the user before and after the change. The
+// new role applies to the user's next request, since Authenticate looks it up here.
+func (s *UserStore) SetRole(id, role string) (models.User, models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, models.User{}, ErrUserNotFound
+	}
+	before := *user
+	user.Role = role
+	if err := s.saveLocked(); err != nil {
+		user.Role = before.Role
+		return models.User{}, models.User{}, err
+	}
+	return before, *user, nil
+}
+
+// RevokeTokens invalidates every token issued to a user so far, returning the user before
+// and after. Tokens issued from the user's next login are accepted.
+func (s *UserStore) RevokeTokens(id string) (models.User, models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, models.User{}, ErrUserNotFound
+	}
+	before := *user
+	now := time.Now().UTC()
+	user.TokensRevokedAt = &now
+	if err := s.saveLocked(); err != nil {
+		user.TokensRevokedAt = before.TokensRevokedAt
+		return models.User{}, models.User{}, err
+	}
+	return before, *user, nil
+}
+
+// LoginExternal returns the user mapped to identity, creating one on the first login.
+// The name and email reported by the provider are refreshed on every login.
+func (s *UserStore) LoginExternal(identity models.Identity, name, email string) (models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.findLocked(identity)
+	if user == nil {
+		id, err := newUserID()
+		if err != nil {
+			return models.User{}, err
+		}
+		user = &models.User{
+			ID:         id,
+			Role:       RoleUser,
+			Identities: []models.Identity{identity},
+			CreatedAt:  time.Now().UTC(),
+		}
+		s.users[id] = user
+	}
+	if name != "" {
+		user.Name = name
+	}
+	if email != "" {
+		user.Email = email
+	}
+	user.LastLoginAt = time.Now().UTC()
+
+	if err := s.saveLocked(); err != nil {
+		return models.User{}, err
+	}
+	return *user, nil
+}
+
+func (s *UserStore) findLocked(identity models.Identity) *models.User {
+	for _, user := range s.users {
+		for _, known := range user.Identities {
+			if known == identity {
+				return user
+			}
+		}
+	}
+	return nil
+}
+
+func (s *UserStore) sortedLocked() []*models.User {
+	users := make([]*models.User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.Before(users[j].CreatedAt) })
+	return users
+}
+
+func (s *UserStore) saveLocked() error {
+	return store.SaveJSON(s.path, s.sortedLocked())
+}
+
+func newUserID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "u_" + hex.EncodeToString(id), nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"movie-api-go/models"
+)
+
+// editionPattern matches the edition suffix of a title, e.g. "Blade Runner: The Final Cut"
+// or "Apocalypse Now Redux"
+var editionPattern = regexp.MustCompile(`(?i)[\s:(–-]*(the\s+)?(director'?s cut|extended (edition|cut|version)|final cut|redux|remastered|special edition|ultimate (cut|edition)|theatrical (cut|version)|unrated( cut| version)?|re-?release|anniversary edition|3d|imax)\)?\s*$`)
+
+// versionKey identifies a work across remakes and editions: the normalized title without
+// its edition suffix. The second result reports whether there was one.
+func versionKey(title string) (string, bool) {
+	base := editionPattern.ReplaceAllString(title, "")
+	return normalizeTitle(base), base != title
+}
+
+// startYear parses the first year of a Year field such as "1999" or "2008–2013"
+func startYear(year string) int {
+	if len(year) < 4 {
+		return 0
+	}
+	parsed, _ := strconv.Atoi(year[:4])
+	return parsed
+}
+
+// OtherVersions finds the remakes, earlier versions and special editions of a movie: the
+// movies OMDb returns for its title whose title is the same once edition suffixes are
+// removed. They are sorted by year. Each is labelled relative to movie: "original" for
+// earlier releases, "remake" for later ones, "edition" for a cut or re-release and
+// "version" for another title of the same year.
+func (s *OMDbService) OtherVersions(ctx context.Context, movie *models.OMDbResponse) ([]models.TitleVersion, error) {
+	key, _ := versionKey(movie.Title)
+	if key == "" {
+		return nil, nil
+	}
+
+	searchResp, err := s.searchPage(ctx, key, "movie", "", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	year := startYear(movie.Year)
+	versions := []models.TitleVersion{}
+	for _, result := range searchResp.Search {
+		if result.ImdbID == movie.ImdbID {
+			continue
+		}
+		resultKey, edition := versionKey(result.Title)
+		if resultKey != key {
+			continue
+		}
+
+		version := models.TitleVersion{ImdbID: result.ImdbID, Title: result.Title, Year: result.Year}
+		switch resultYear := startYear(result.Year); {
+		case edition:
+			version.Relation = "edition"
+		case resultYear != 0 && year != 0 && resultYear < year:
+			version.Relation = "original"
+		case resultYear > year && year != 0:
+			version.Relation = "remake"
+		default:
+			version.Relation = "version"
+		}
+		versions = append(versions, version)
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return startYear(versions[i].Year) < startYear(versions[j].Year)
+	})
+	return versions, nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"movie-api-go/models"
+)
+
+// WikipediaEnricher looks up the Wikipedia summary of a movie
+type WikipediaEnricher struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewWikipediaEnricher() (*WikipediaEnricher, error) {
+	baseURL := os.Getenv("WIKIPEDIA_API_URL")
+	if baseURL == "" {
+		baseURL = "https://en.wikipedia.org/api/rest_v1"
+	}
+
+	client, err := httpClientFromEnv("WIKIPEDIA")
+	if err != nil {
+		return nil, err
+	}
+
+	return &WikipediaEnricher{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client:  client,
+	}, nil
+}
+
+func (e *WikipediaEnricher) Name() string           { return "wikipedia" }
+func (e *WikipediaEnricher) Timeout() time.Duration { return 3 * time.Second }
+
+// Enrich tries the most specific page titles first ("Heat (1995 film)", "Heat (film)")
+// so that films sharing a name with other topics resolve to the right article
+func (e *WikipediaEnricher) Enrich(ctx context.Context, movie *models.OMDbResponse) (interface{}, error) {
+	candidates := []string{
+		fmt.Sprintf("%s (%s film)", movie.Title, movie.Year),
+		fmt.Sprintf("%s (film)", movie.Title),
+		movie.Title,
+	}
+
+	for _, candidate := range candidates {
+		summary, err := e.fetchSummary(ctx, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if summary != nil {
+			return summary, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no Wikipedia article found")
+}
+
+func (e *WikipediaEnricher) fetchSummary(ctx context.Context, title string) (*models.WikipediaSummary, error) {
+	reqURL := fmt.Sprintf("%s/page/summary/%s", e.BaseURL, url.PathEscape(strings.ReplaceAll(title, " ", "_")))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wikipedia returned status %d", resp.StatusCode)
+	}
+
+	var page struct {
+		Type        string `json:"type"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Extract     string `json:"extract"`
+		Thumbnail   struct {
+			Source string `json:"source"`
+		} `json:"thumbnail"`
+		ContentURLs struct {
+			Desktop struct {
+				Page string `json:"page"`
+			} `json:"desktop"`
+		} `json:"content_urls"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if page.Type == "disambiguation" || page.Title == "" {
+		return nil, nil
+	}
+
+	return &models.WikipediaSummary{
+		Title:       page.Title,
+		Description: page.Description,
+		Extract:     page.Extract,
+		URL:         page.ContentURLs.Desktop.Page,
+		Thumbnail:   page.Thumbnail.Source,
+	}, nil
+}
@@ -0,0 +1,159 @@
+// Minimal client for the Movie API. Everything is rendered with textContent, so
+// upstream data is never interpreted as HTML.
+(function () {
+  const results = document.getElementById("results");
+  const status = document.getElementById("status");
+
+  function el(tag, className, text) {
+    const node = document.createElement(tag);
+    if (className) node.className = className;
+    if (text) node.textContent = text;
+    return node;
+  }
+
+  function setStatus(message, isError) {
+    status.textContent = message || "";
+    status.className = isError ? "error" : "";
+  }
+
+  async function request(path, params, headers) {
+    const query = new URLSearchParams();
+    for (const [name, value] of Object.entries(params || {})) {
+      if (value) query.set(name, value);
+    }
+    setStatus("Loading…");
+    results.replaceChildren();
+
+    const response = await fetch(path + (query.toString() ? "?" + query : ""), { headers: headers || {} });
+    const body = response.status === 204 ? null : await response.json();
+    if (!response.ok) {
+      throw new Error((body && body.message) || response.statusText);
+    }
+    setStatus("");
+    return body;
+  }
+
+  function card(item) {
+    const node = el("div", "card");
+    const poster = item.poster || (item._links && item._links.poster && item._links.poster.href);
+    if (poster) {
+      const img = el("img");
+      img.src = poster;
+      img.alt = "";
+      node.appendChild(img);
+    }
+
+    const body = el("div");
+    const title = el("h3");
+    const link = el("a", "", item.title + (item.year ? " (" + item.year + ")" : ""));
+    link.href = "#";
+    link.addEventListener("click", (event) => {
+      event.preventDefault();
+      showDetails({ imdb_id: item.imdb_id, title: item.imdb_id ? "" : item.title, year: item.imdb_id ? "" : item.year });
+    });
+    title.appendChild(link);
+    body.appendChild(title);
+
+    const facts = [item.type, item.genre, item.director, item.imdb_rating && "IMDb " + item.imdb_rating].filter(Boolean);
+    if (facts.length) body.appendChild(el("div", "meta", facts.join(" · ")));
+    if (item.plot) body.appendChild(el("p", "", item.plot));
+    node.appendChild(body);
+    return node;
+  }
+
+  async function showDetails(params) {
+    activate("details");
+    try {
+      const movie = await request("/api/movie", params);
+      results.appendChild(card(movie));
+      for (const [name, value] of Object.entries(movie.expansions || {})) {
+        results.appendChild(el("h2", "", name));
+        results.appendChild(el("pre", "", JSON.stringify(value, null, 2)));
+      }
+    } catch (err) {
+      setStatus(err.message, true);
+    }
+  }
+
+  async function showWatchlist(userID) {
+    try {
+      const body = await request("/api/users/" + encodeURIComponent(userID) + "/watchlist");
+      results.appendChild(el("h2", "", userID + "'s watchlist"));
+      body.watchlist.forEach((item) => results.appendChild(card(item)));
+      if (!body.total) setStatus("This watchlist is empty");
+    } catch (err) {
+      setStatus(err.message, true);
+    }
+  }
+
+  const handlers = {
+    async search(data) {
+      const body = await request("/api/search", data);
+      body.results.forEach((item) => results.appendChild(card(item)));
+      setStatus(body.total_results + " results");
+    },
+    async details(data) {
+      await showDetails(data);
+    },
+    async genre(data) {
+      const body = await request("/api/movies/genre", data);
+      body.movies.forEach((item) => results.appendChild(card(item)));
+    },
+    async recommendations(data) {
+      const body = await request("/api/recommendations", data);
+      for (const level of body.recommendations) {
+        results.appendChild(el("h2", "", level.description));
+        level.movies.forEach((item) => results.appendChild(card(item)));
+      }
+    },
+    async admin(data) {
+      const body = await request("/admin/aliases", {}, { "X-Admin-Token": data.token });
+      if (!body.aliases.length) setStatus("No aliases defined");
+      body.aliases.forEach((alias) => results.appendChild(el("div", "card", alias.alias + " → " + alias.imdb_id)));
+    },
+  };
+
+  function activate(view) {
+    document.querySelectorAll("nav button").forEach((button) => button.classList.toggle("active", button.dataset.view === view));
+    document.querySelectorAll(".view").forEach((section) => section.classList.toggle("active", section.id === view));
+  }
+
+  document.querySelectorAll("nav button").forEach((button) => {
+    button.addEventListener("click", () => {
+      activate(button.dataset.view);
+      results.replaceChildren();
+      setStatus("");
+    });
+  });
+
+  document.querySelectorAll("form[data-form]").forEach((form) => {
+    form.addEventListener("submit", async (event) => {
+      event.preventDefault();
+      const data = Object.fromEntries(new FormData(form));
+      try {
+        await handlers[form.dataset.form](data);
+      } catch (err) {
+        setStatus(err.message, true);
+      }
+    });
+  });
+
+  // Suggest the supported genres; the form still works without them
+  fetch("/api/genres")
+    .then((response) => (response.ok ? response.json() : { genres: [] }))
+    .then((body) => {
+      const options = body.genres.map((genre) => {
+        const option = el("option");
+        option.value = genre.name;
+        return option;
+      });
+      document.getElementById("genres").replaceChildren(...options);
+    })
+    .catch(() => {});
+
+  // Detail pages are linkable as /?imdb_id=tt0133093 (these are the URLs in the sitemap),
+  // and public watchlists as /?watchlist=<user ID> (the URLs in their QR codes)
+  const params = new URLSearchParams(window.location.search);
+  if (params.get("imdb_id")) showDetails({ imdb_id: params.get("imdb_id") });
+  else if (params.get("watchlist")) showWatchlist(params.get("watchlist"));
+})();
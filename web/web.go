@@ -0,0 +1,23 @@
+// Package web embeds the single-page demo UI served at /
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed static
+var files embed.FS
+
+// Register serves the demo UI at / and its assets under /static
+func Register(router *gin.Engine) {
+	static, _ := fs.Sub(files, "static")
+
+	router.GET("/", func(c *gin.Context) {
+		c.FileFromFS("/", http.FS(static))
+	})
+	router.StaticFS("/static", http.FS(static))
+}